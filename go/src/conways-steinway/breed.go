@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/config"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// breed runs the "breed" subcommand: it crosses two saved boards or patterns
+// into a child board and writes it as JSON, which --pattern-file can play
+func breed(cfg *config.Config, args []string) error {
+	fset := flag.NewFlagSet("breed", flag.ContinueOnError)
+	var mix life.Crossover
+	fset.Var(&mix, "mix", "how the parents are combined: halves, rows or columns")
+	out := fset.String("out", "-", "file the child board is written to, or - for standard output")
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "usage: conways-steinway breed [-mix halves|rows|columns] [-out file] first second")
+		fset.PrintDefaults()
+	}
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() != 2 {
+		fset.Usage()
+		return fmt.Errorf("breed: want two parent files, got %d", fset.NArg())
+	}
+
+	var parents [2]*life.Grid
+	for i, path := range fset.Args() {
+		pattern, err := readPattern(cfg, path)
+		if err != nil {
+			return err
+		}
+		grid := life.NewEmptyGrid(life.BoardWidth, cfg.Height)
+		grid.Rule, grid.Edge, grid.Neighbourhood = cfg.Rule, cfg.Edge, cfg.Neighbourhood
+		if pattern.Rule != nil {
+			grid.Rule = *pattern.Rule
+		}
+		pattern.Place(grid, (grid.Width-pattern.Width)/2, (grid.Height-pattern.Height)/2)
+		parents[i] = grid
+	}
+	data, err := json.Marshal(life.Cross(parents[0], parents[1], mix))
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *out == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the %v child of %s and %s to %s\n", mix, fset.Arg(0), fset.Arg(1), *out)
+	return nil
+}
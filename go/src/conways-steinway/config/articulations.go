@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// Articulation sets how long the notes of a MIDI channel sound for
+type Articulation struct {
+	Channel int // MIDI channel 1 to 16, or 0 for every channel without one of its own
+	Length  music.Articulation
+}
+
+// String formats the articulation as Set reads it
+func (a Articulation) String() string {
+	if a.Channel == 0 {
+		return a.Length.String()
+	}
+	return strconv.Itoa(a.Channel) + "=" + a.Length.String()
+}
+
+// parseArticulation reads an articulation, e.g. "staccato" or "60%",
+// optionally after its channel, as in "2=tenuto"
+func parseArticulation(s string) (Articulation, error) {
+	var a Articulation
+	spec := s
+	if channel, length, ok := strings.Cut(s, "="); ok {
+		if err := (*intValue)(&a.Channel).Set(channel); err != nil || a.Channel < 1 || a.Channel > 16 {
+			return a, fmt.Errorf("articulation %q: channel %q is not between 1 and 16", s, strings.TrimSpace(channel))
+		}
+		spec = length
+	}
+	var err error
+	if a.Length, err = music.ParseArticulation(spec); err != nil {
+		return a, fmt.Errorf("articulation %q: %w", s, err)
+	}
+	return a, nil
+}
+
+// Articulations is a flag.Value for a list of articulations separated by
+// semicolons. Repeating the flag adds one each time. No channel may have two.
+type Articulations []Articulation
+
+func (as *Articulations) String() string {
+	specs := make([]string, len(*as))
+	for i, a := range *as {
+		specs[i] = a.String()
+	}
+	return strings.Join(specs, ";")
+}
+
+func (as *Articulations) Set(s string) error {
+	var articulations Articulations
+	used := make(map[int]bool)
+	for _, spec := range strings.Split(s, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		a, err := parseArticulation(spec)
+		if err != nil {
+			return err
+		}
+		if used[a.Channel] {
+			if a.Channel == 0 {
+				return fmt.Errorf("more than one articulation is given to every channel")
+			}
+			return fmt.Errorf("channel %d is given more than one articulation", a.Channel)
+		}
+		used[a.Channel] = true
+		articulations = append(articulations, a)
+	}
+	*as = articulations
+	return nil
+}
+
+// IsListFlag tells Parse to collect repeated flags rather than keep the last
+func (as *Articulations) IsListFlag() bool { return true }
+
+// Map returns the articulations by channel, as the sequencer takes them
+func (as Articulations) Map() map[int]music.Articulation {
+	m := make(map[int]music.Articulation, len(as))
+	for _, a := range as {
+		m[a.Channel] = a.Length
+	}
+	return m
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Channel plays one board row on a MIDI channel of its own, so that one board
+// can play several instruments
+type Channel struct {
+	Row     int // board row whose keys are played; negative rows count from the bottom
+	Channel int // MIDI channel 1 to 16
+	Program int // General MIDI program 1 to 128 selected on the channel; zero leaves the instrument alone
+}
+
+// String formats the channel as Set reads it
+func (c Channel) String() string {
+	s := "row=" + strconv.Itoa(c.Row) + ",channel=" + strconv.Itoa(c.Channel)
+	if c.Program != 0 {
+		s += ",program=" + strconv.Itoa(c.Program)
+	}
+	return s
+}
+
+// parseChannel reads "key=value" fields separated by commas, e.g.
+// "row=5,channel=2,program=49"
+func parseChannel(s string) (Channel, error) {
+	var c Channel
+	hasRow := false
+	for _, field := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok {
+			return c, fmt.Errorf("channel field %q is not key=value", field)
+		}
+		var err error
+		switch key {
+		case "row":
+			err = (*intValue)(&c.Row).Set(value)
+			hasRow = true
+		case "channel":
+			if err = (*intValue)(&c.Channel).Set(value); err == nil && (c.Channel < 1 || c.Channel > 16) {
+				err = fmt.Errorf("channel %d is not between 1 and 16", c.Channel)
+			}
+		case "program":
+			if err = (*intValue)(&c.Program).Set(value); err == nil && (c.Program < 1 || c.Program > 128) {
+				err = fmt.Errorf("program %d is not between 1 and 128", c.Program)
+			}
+		default:
+			err = fmt.Errorf("unknown channel field %q (want row, channel or program)", key)
+		}
+		if err != nil {
+			return c, fmt.Errorf("channel %q: %w", s, err)
+		}
+	}
+	if !hasRow || c.Channel == 0 {
+		return c, fmt.Errorf("channel %q: both row and channel are needed", s)
+	}
+	return c, nil
+}
+
+// Channels is a flag.Value for a list of channels separated by semicolons.
+// Repeating the flag adds a channel each time. No two rows may share a
+// channel.
+type Channels []Channel
+
+func (cs *Channels) String() string {
+	specs := make([]string, len(*cs))
+	for i, c := range *cs {
+		specs[i] = c.String()
+	}
+	return strings.Join(specs, ";")
+}
+
+func (cs *Channels) Set(s string) error {
+	var channels Channels
+	used := make(map[int]bool)
+	for _, spec := range strings.Split(s, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		c, err := parseChannel(spec)
+		if err != nil {
+			return err
+		}
+		if used[c.Channel] {
+			return fmt.Errorf("channel %d is given to more than one row", c.Channel)
+		}
+		used[c.Channel] = true
+		channels = append(channels, c)
+	}
+	*cs = channels
+	return nil
+}
+
+// IsListFlag tells Parse to collect repeated flags rather than keep the last
+func (cs *Channels) IsListFlag() bool { return true }
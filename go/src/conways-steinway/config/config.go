@@ -0,0 +1,1105 @@
+// Package config loads the Go implementation's settings from the shared
+// properties file, CONWAYS_STEINWAY_* environment variables and command-line
+// flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/synth"
+)
+
+// DefaultFile is the configuration file read when --config is not given,
+// found by FindFile
+const DefaultFile = "config/conways_steinway.properties"
+
+// FindFile returns the DefaultFile under the working directory or the
+// nearest directory above it that has one, or else under the executable's
+// directory or one above it, so that the file shared with the Python and
+// Rust players at the root of the project is found from go/src as they find
+// it. It returns "" when there is none.
+func FindFile() string {
+	var dirs []string
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, wd)
+	}
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exe))
+	}
+	for _, dir := range dirs {
+		for {
+			path := filepath.Join(dir, DefaultFile)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	return ""
+}
+
+// EnvPrefix is prepended to every environment variable name
+const EnvPrefix = "CONWAYS_STEINWAY_"
+
+// Config holds the settings for one run
+type Config struct {
+	Edge     life.EdgeMode // board edge behaviour
+	Rule     life.Rule     // birth/survival rulestring
+	RuleFile string        // Golly .rule file run instead of Rule
+	Circuit  string        // Wireworld circuit file run instead of Rule
+
+	MutateEvery int // generations between random changes to the rule; 0 keeps it fixed
+
+	Neighbourhood life.Neighbourhood // cells counted as neighbours
+
+	Engine   Engine     // board representation
+	Width    int        // columns of the board, which must be one to each piano key
+	Height   int        // rows of the board, which is always a column to each piano key wide
+	Ants     int        // ants walking the board under the ant engine
+	Viewport life.Coord // top-left corner of the window an unbounded board is viewed through
+	Skip     int        // generations to fast-forward before the first one is shown
+	Workers  int        // goroutines stepping each generation; 0 uses GOMAXPROCS
+	History  int        // generations kept for rewinding
+
+	OnCycle     CyclePolicy // what to do when the board enters a cycle
+	CycleWindow int         // generations remembered when looking for cycles
+
+	ReseedThreshold int            // generations an empty or static board is tolerated; negative disables reseeding
+	ReseedStrategy  ReseedStrategy // how an empty or static board is revived
+
+	Seed     int64         // random seed; 0 picks one from the clock
+	Density  float64       // probability that a randomly initialised cell is alive
+	Symmetry life.Symmetry // symmetry imposed on random boards
+
+	Noise      float64 // fraction of cells flipped at random between generations
+	NoiseEvery int     // generations between flips of noise
+
+	Layers       Layers   // boards played together on a shared clock; empty plays one board
+	CoupleLayers bool     // stack the layers so each cell also counts the cells above and below it
+	Bass         int      // generations between steps of a board playing a bass line below the others; 0 plays none
+	Channels     Channels // board rows played on MIDI channels of their own instead of the note row
+	Drums        bool     // play DrumRow as a General MIDI drum kit on channel 10
+	DrumRow      int      // board row the drums are played from; negative rows count from the bottom
+	Gates        Gates    // Euclidean rhythms the notes of each channel are let through on
+
+	PatternFile string      // pattern file placed on an empty board instead of random cells
+	PatternRows int         // rows of the board to each quarter note of a MIDI pattern file
+	Pattern     PatternSpec // built-in pattern placed on an empty board instead of random cells
+
+	NoteRow int              // board row whose live cells strike piano keys; negative rows count from the bottom
+	Mapper  music.MapperName // how the board's cells become the keys struck
+
+	BPM                float64             // beats a minute
+	StepDelay          int                 // milliseconds between generations, setting the tempo when BPM is not given; 0 for none
+	GenerationsPerBeat int                 // generations played in each beat
+	Swing              float64             // percent, 0 to 100, that off-beat generations are delayed
+	HumanizeTiming     int                 // most milliseconds a note is struck early or late
+	HumanizeVelocity   int                 // most a note's velocity is raised or lowered at random
+	TimeSignature      music.TimeSignature // beats to the bar and the note each beat is
+
+	Scale    music.Scale      // notes the keys struck are kept to
+	Root     music.PitchClass // note the scale is built on
+	ScaleFit ScaleFit         // whether notes outside the scale are moved into it or left out
+
+	Retrigger     bool          // strike held notes again every generation instead of holding them while their cell lives
+	Articulations Articulations // how long the notes of each channel sound for, as a fraction of the step
+	Quantize      music.Grid    // grid the notes' times are moved onto, before humanizing
+
+	VelocityMin   int               // velocity of a lonely newborn cell's note
+	VelocityMax   int               // velocity of an old, crowded cell's note
+	VelocityCurve float64           // exponent shaping velocities between the two; 1 is linear
+	VelocityMap   music.VelocityMap // curve or breakpoints the velocities are reshaped by for the instrument
+	Volume        float64           // fraction, 0 to 1, of their velocities the notes are played at
+
+	Key           MusicalKey // key the output is moved into from the scale's root; empty stays in the root's
+	Transpose     int        // further semitones the output is moved up, or down when negative
+	ModulateEvery int        // generations between moves of the key up a fifth; 0 never modulates
+
+	PitchShift bool // move clusters of notes into a comfortable register
+
+	DetectChords bool          // recognise chords among the keys struck together
+	Voicing      music.Voicing // how recognised chords are rearranged before they are played
+
+	AvoidSemitones bool // leave out keys a semitone above another when a chord is recognised
+
+	ArpeggiateAbove int                 // most keys struck together before they are arpeggiated; 0 never arpeggiates
+	ArpeggioOrder   music.ArpeggioOrder // order arpeggiated keys are struck in
+
+	Rest RestPolicy // what is played when a generation strikes no keys
+
+	Smooth  float64 // how much the keys struck follow a Markov model of stepwise motion from the last, 0 to 1; 0 never smooths
+	MaxLeap int     // widest interval, in semitones, smoothing lets the keys leap from the last
+
+	NoteProbability  float64 // chance, 0 to 1, that each key struck afresh is played
+	ProbabilityByAge bool    // raise the chance with the age of the key's cell, to always for a grown one
+
+	ArcDepth  float64 // most the population's trend raises or lowers velocities by, as a fraction; 0 keeps them
+	ArcAttack float64 // how fast the dynamics follow a growing population, 0 to 1
+	ArcDecay  float64 // how fast the dynamics follow a shrinking population, 0 to 1
+
+	Polyphony int         // most notes sounding at once; 0 is unlimited
+	Steal     music.Steal // which notes give way to keep within Polyphony
+
+	PedalPress   float64 // board density at which the sustain pedal goes down; 0 leaves it up
+	PedalRelease float64 // density below which the pedal comes up again; 0 is PedalPress
+	PedalChords  bool    // hold the sustain pedal down while chords are struck
+
+	Modulations Modulations // MIDI controllers moved each generation by measures of the board
+	Sections    Sections    // instruments the channels move on to at milestones and events of the board
+
+	PhraseDip    float64 // fraction of its recent level a dip in the population ending a phrase falls by
+	PhraseLength int     // fewest generations in a phrase
+	Cadence      int     // bars of the cadence closing each phrase on the tonic chord; 0 for none
+
+	Generations  int        // generations played; 0 plays until the run is stopped
+	Output       Output     // where the performance goes
+	MIDIPath     string     // file the midi-file output writes
+	MusicXMLPath string     // file the musicxml output writes
+	LilyPondPath string     // file the lilypond output writes
+	ABCPath      string     // file the abc output writes
+	OSCAddr      string     // host and UDP port the osc output sends to
+	WAVPath      string     // file the wav output writes
+	JSONLPath    string     // file the jsonl output writes, or "-" for standard output
+	SoundFont    string     // SoundFont 2 file the wav output plays the notes on
+	MIDIPort     string     // MIDI output port the notes are streamed to, by index or name
+	VirtualPort  bool       // create a virtual MIDI output port and stream the notes to it
+	MIDIClock    bool       // send MIDI clock, Start and Stop on the MIDI output ports
+	MMC          bool       // send MIDI Machine Control and Song Position Pointers on the MIDI output ports
+	Lookahead    int        // milliseconds before their time the ticks are played, for the live outputs to queue
+	MPE          bool       // play MIDI Polyphonic Expression on the MIDI output ports
+	MPEBend      float64    // semitones the loneliest and most crowded cells bend their notes with MPE
+	Scala        string     // Scala .scl file of the tuning the notes are played in
+	KeyboardMap  string     // Scala .kbm file mapping the tuning onto the keys
+	TuningMode   TuningMode // how the tuning is sent to the MIDI output ports
+	Link         bool       // follow the tempo and beat of an Ableton Link session
+	MIDIIn       string     // MIDI input port whose notes plant cells, by index or name
+	InjectRow    InjectRow  // row the notes played on the MIDI input plant cells on
+	Transport    bool       // start and stop the performance with the transport heard on the MIDI input
+	Loop         int        // bars of the notes played the looper keeps; 0 for no looper
+	LoopRecord   int        // controller on the MIDI input that captures the loop
+	LoopOverdub  int        // controller on the MIDI input that adds to the loop
+	LoopClear    int        // controller on the MIDI input that empties the loop
+
+	Audio        bool           // play the notes on the built-in synth through the sound output when playing live
+	Silent       bool           // play no sound: neither the built-in synth nor the MIDI output ports
+	AudioWave    synth.Waveform // wave the built-in synth plays
+	AudioAttack  int            // milliseconds the synth's notes take to sound fully
+	AudioDecay   int            // milliseconds they take to fall to AudioSustain
+	AudioSustain float64        // level, 0 to 1, held notes fall to
+	AudioRelease int            // milliseconds notes take to fade once let go
+
+	Args        []string // positional arguments left after flag parsing
+	File        string   // configuration file the settings were read from, or "" when there was none
+	ShowSources bool     // print each setting and where it came from instead of playing, with --show-config-sources
+
+	sources map[string]Source // where the settings the file, environment or flags set came from, by key
+}
+
+// Default returns the configuration used when nothing overrides it
+func Default() *Config {
+	return &Config{
+		Edge: life.EdgeDead,
+		Rule: life.Conway,
+
+		Neighbourhood: life.Moore,
+
+		Engine: EngineGrid,
+		Width:  life.BoardWidth,
+		Height: life.BoardHeight,
+		Ants:   1,
+
+		OnCycle:     CycleIgnore,
+		CycleWindow: 64,
+
+		ReseedThreshold: 8,
+		ReseedStrategy:  ReseedRandom,
+
+		Density:     0.5,
+		PatternRows: 4,
+
+		NoteRow: -1,
+		Mapper:  music.DefaultMapper,
+
+		BPM:                120,
+		GenerationsPerBeat: 1,
+		TimeSignature:      music.TimeSignature{Beats: 4, Unit: 4},
+
+		Scale:    music.Chromatic,
+		ScaleFit: FitSnap,
+
+		VelocityMin:   32,
+		VelocityMax:   112,
+		VelocityCurve: 1,
+		Volume:        1,
+
+		MaxLeap: 12,
+
+		NoteProbability: 1,
+
+		PhraseDip:    0.3,
+		PhraseLength: 16,
+
+		PitchShift:   true,
+		DetectChords: true,
+
+		Rest:      RestSilence,
+		ArcAttack: 0.3,
+		ArcDecay:  0.1,
+
+		Generations:  10,
+		Output:       OutputTerminal,
+		MIDIPath:     "out.mid",
+		MusicXMLPath: "out.musicxml",
+		LilyPondPath: "out.ly",
+		ABCPath:      "out.abc",
+		OSCAddr:      "127.0.0.1:57120",
+		WAVPath:      "out.wav",
+		JSONLPath:    "-",
+		Lookahead:    50,
+		InjectRow:    InjectPlayed,
+		LoopRecord:   80,
+		LoopOverdub:  81,
+		LoopClear:    82,
+		MPEBend:      0.5,
+		TuningMode:   TuningMTS,
+
+		Audio:        true,
+		AudioAttack:  5,
+		AudioDecay:   400,
+		AudioSustain: 0.4,
+		AudioRelease: 300,
+
+		NoiseEvery: 1,
+	}
+}
+
+// Clock returns the clock the configured tempo and time signature keep
+func (c *Config) Clock() music.Clock {
+	return music.Clock{BPM: c.BPM, GenerationsPerBeat: c.GenerationsPerBeat, Meter: c.TimeSignature, Swing: c.Swing}
+}
+
+// option binds a properties key and a command-line flag to one Config field
+type option struct {
+	key   string
+	flag  string
+	usage string
+	value func(c *Config) flag.Value
+	env   []string // extra environment variable names, checked after EnvName(key)
+
+	aliases []string // properties keys the Python and Rust implementations give it, read when key is absent
+	ahead   []string // properties keys the Python implementation reads before key, so that they win over it here too
+	flags   []string // other flags for it, as the Python and Rust implementations spell them
+
+	negate string // flag that turns a bool option off, e.g. no-detect-chords
+}
+
+var options = []option{
+	{
+		key: "board.edge", flag: "edge",
+		usage: "board edge behaviour: dead, wrap, alive or mirror",
+		value: func(c *Config) flag.Value { return &c.Edge },
+	},
+	{
+		key: "rule", flag: "rule",
+		usage: "birth/survival rulestring, e.g. B3/S23 or B36/S23",
+		value: func(c *Config) flag.Value { return &c.Rule },
+	},
+	{
+		key: "rule.mutate", flag: "mutate-rule-every",
+		usage: "add or remove one birth or survival count every this many generations (0 never changes the rule)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.MutateEvery) },
+	},
+	{
+		key: "rule.file", flag: "rule-file",
+		usage: "Golly .rule file (@TABLE or @TREE) to run instead of --rule",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.RuleFile) },
+	},
+	{
+		key: "circuit.file", flag: "circuit",
+		usage: "Wireworld circuit file to run instead of --rule",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.Circuit) },
+	},
+	{
+		key: "board.neighbourhood", flag: "neighbourhood",
+		usage: "neighbourhood: moore, von-neumann, moore2, circular or hexagonal",
+		value: func(c *Config) flag.Value { return &c.Neighbourhood },
+	},
+	{
+		key: "board.width", flag: "width",
+		usage: "columns of the board, which is always 88, one to each piano key; only checked",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.Width) },
+	},
+	{
+		key: "board.height", flag: "height",
+		usage: "rows of the board; it is always 88 columns wide, one to each piano key",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.Height) },
+	},
+	{
+		key: "board.engine", flag: "engine",
+		usage: "board representation: grid, bitpacked, incremental, the unbounded sparse or hashlife, or Langton's ant",
+		value: func(c *Config) flag.Value { return &c.Engine },
+	},
+	{
+		key: "ant.count", flag: "ants",
+		usage: "ants walking the board under --engine ant",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.Ants) },
+	},
+	{
+		key: "board.viewport", flag: "viewport",
+		usage: "x,y of the top-left corner of the unbounded board's window",
+		value: func(c *Config) flag.Value { return &c.Viewport },
+	},
+	{
+		key: "board.skip", flag: "skip",
+		usage: "generations to fast-forward before the first one is shown",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.Skip) },
+	},
+	{
+		key: "board.workers", flag: "workers",
+		usage: "goroutines stepping each generation (0 uses GOMAXPROCS)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.Workers) },
+	},
+	{
+		key: "board.history", flag: "history",
+		usage: "generations kept so playback can be rewound",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.History) },
+	},
+	{
+		key: "cycle.policy", flag: "on-cycle",
+		usage: "what to do when the board enters a cycle: ignore, stop or reseed",
+		value: func(c *Config) flag.Value { return &c.OnCycle },
+	},
+	{
+		key: "cycle.window", flag: "cycle-window",
+		usage: "generations remembered when looking for cycles",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.CycleWindow) },
+	},
+	{
+		key: "reseed.threshold", flag: "reseed-threshold",
+		usage: "generations an empty or static board is tolerated before reseeding (negative disables)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.ReseedThreshold) },
+	},
+	{
+		key: "reseed.strategy", flag: "reseed-strategy",
+		usage: "how an empty or static board is revived: random or inject",
+		value: func(c *Config) flag.Value { return &c.ReseedStrategy },
+	},
+	{
+		key: "seed", flag: "seed",
+		usage: "random seed, so runs can be reproduced (0 picks one from the clock)",
+		value: func(c *Config) flag.Value { return (*int64Value)(&c.Seed) },
+		env:   []string{"LIFE_SEED"},
+	},
+	{
+		key: "density", flag: "density",
+		usage:   "probability that a randomly initialised cell is alive, 0 to 1",
+		value:   func(c *Config) flag.Value { return (*probabilityValue)(&c.Density) },
+		aliases: []string{"random.alive.probability"},
+		flags:   []string{"alive-probability"},
+		env:     []string{"CONWAYS_STEINWAY_ALIVE_PROBABILITY"},
+	},
+	{
+		key: "symmetry", flag: "symmetry",
+		usage: "symmetry of random boards: none, horizontal, vertical, four-fold or rotational",
+		value: func(c *Config) flag.Value { return &c.Symmetry },
+	},
+	{
+		key: "noise", flag: "noise",
+		usage: "probability that each cell is flipped between generations, e.g. 0.001",
+		value: func(c *Config) flag.Value { return (*probabilityValue)(&c.Noise) },
+	},
+	{
+		key: "noise.every", flag: "noise-every",
+		usage: "generations between flips of --noise",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.NoiseEvery) },
+	},
+	{
+		key: "layers", flag: "layer",
+		usage: "add a board played alongside the others, e.g. rule=B36/S23,seed=7,channel=2,every=4 (repeatable)",
+		value: func(c *Config) flag.Value { return &c.Layers },
+	},
+	{
+		key: "layers.coupled", flag: "couple-layers",
+		usage: "stack the layers so each cell also counts the cells at its position in the layers above and below",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.CoupleLayers) },
+	},
+	{
+		key: "bass", flag: "bass",
+		usage: "add a board stepping once every this many generations that plays a bass line in the bottom two octaves on a channel of its own, against the others (0 adds none)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.Bass) },
+	},
+	{
+		key: "channels", flag: "channel",
+		usage: "board row played on a MIDI channel of its own, as row=…,channel=…,program=… with a General MIDI program 1 to 128; repeat for an ensemble",
+		value: func(c *Config) flag.Value { return &c.Channels },
+	},
+	{
+		key: "drums", flag: "drums",
+		usage: "play --drum-row as a General MIDI drum kit on channel 10, kick on the left to ride on the right",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.Drums) },
+	},
+	{
+		key: "drums.row", flag: "drum-row",
+		usage: "board row the drums are played from; negative rows count from the bottom",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.DrumRow) },
+	},
+	{
+		key: "gates", flag: "gate",
+		usage: "Euclidean rhythm, E(pulses,steps) or E(pulses,steps,rotation), whose onsets alone let notes through, for every channel or for one as channel=E(…); repeat for several channels",
+		value: func(c *Config) flag.Value { return &c.Gates },
+	},
+	{
+		key: "pattern.file", flag: "pattern-file",
+		usage: "pattern file (RLE, Life 1.05, Life 1.06, plaintext .cells or a Standard MIDI File whose notes plant cells) to start from instead of a random board",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.PatternFile) },
+	},
+	{
+		key: "pattern.midi.rows", flag: "pattern-midi-rows",
+		usage: "rows of the board to each quarter note of a MIDI --pattern-file, each note planting a cell in its key's column on the row of its onset",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.PatternRows) },
+	},
+	{
+		key: "pattern", flag: "pattern",
+		usage: "built-in pattern to start from, as name or name@x,y (see \"patterns list\")",
+		value: func(c *Config) flag.Value { return &c.Pattern },
+	},
+	{
+		key: "music.row", flag: "note-row",
+		usage: "board row played as piano keys, A0 to C8 from left to right; negative rows count from the bottom (-1)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.NoteRow) },
+	},
+	{
+		key: "music.mapper", flag: "mapper",
+		usage: "how the board's cells become keys: row (the live cells of the --note-row), column-sum (the columns fullest of live cells), piano-roll (the next row down each generation) or centre-weighted (column-sum counting the cells nearer the middle row more)",
+		value: func(c *Config) flag.Value { return &c.Mapper },
+	},
+	{
+		key: "tempo.bpm", flag: "bpm",
+		usage: "tempo in beats a minute",
+		value: func(c *Config) flag.Value { return (*float64Value)(&c.BPM) },
+		flags: []string{"tempo"},
+		env:   []string{"CONWAYS_STEINWAY_TEMPO"},
+	},
+	{
+		key: "step.delay.ms", flag: "step-delay",
+		usage: "milliseconds between generations, setting the tempo when --bpm is not given (0 for none)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.StepDelay) },
+		env:   []string{"CONWAYS_STEINWAY_STEP_DELAY", "CONWAYS_STEINWAY_DELAY"},
+	},
+	{
+		key: "tempo.generations-per-beat", flag: "generations-per-beat",
+		usage: "generations played in each beat, e.g. 4 for sixteenth notes in 4/4",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.GenerationsPerBeat) },
+	},
+	{
+		key: "tempo.time-signature", flag: "time-signature",
+		usage: "beats to the bar and the note each beat is, e.g. 4/4, 3/4 or 6/8",
+		value: func(c *Config) flag.Value { return &c.TimeSignature },
+	},
+	{
+		key: "tempo.swing", flag: "swing",
+		usage: "percent, 0 to 100, that every second generation is delayed towards the next; 100 plays pairs as a triplet shuffle",
+		value: func(c *Config) flag.Value { return (*float64Value)(&c.Swing) },
+	},
+	{
+		key: "humanize.timing", flag: "humanize-timing",
+		usage: "most milliseconds each note is struck early or late at random (0 keeps strict time)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.HumanizeTiming) },
+	},
+	{
+		key: "humanize.velocity", flag: "humanize-velocity",
+		usage: "most each note's velocity is raised or lowered at random (0 keeps it as the cells give it)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.HumanizeVelocity) },
+	},
+	{
+		key: "scale", flag: "scale",
+		usage: "scale notes are kept to: chromatic, major, minor, pentatonic, minor-pentatonic, dorian, whole-tone or semitones such as 0,2,3,7,9",
+		value: func(c *Config) flag.Value { return &c.Scale },
+	},
+	{
+		key: "root", flag: "root",
+		usage: "note the scale is built on, e.g. C, F# or Bb",
+		value: func(c *Config) flag.Value { return &c.Root },
+	},
+	{
+		key: "scale.fit", flag: "scale-fit",
+		usage: "what becomes of notes outside the scale: snap (to the nearest in it) or drop",
+		value: func(c *Config) flag.Value { return &c.ScaleFit },
+	},
+	{
+		key: "music.retrigger", flag: "retrigger",
+		usage: "strike every note again each generation instead of holding it while its cell stays alive",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.Retrigger) },
+	},
+	{
+		key: "music.articulation", flag: "articulation",
+		usage: "how long notes sound for: legato (held while their cell lives), tenuto (the whole step), staccato (under a third of it) or a percentage of the step such as 60%, for every channel or for one as channel=…; repeat for several channels",
+		value: func(c *Config) flag.Value { return &c.Articulations },
+	},
+	{
+		key: "music.quantize", flag: "quantize",
+		usage: "grid the times of the notes and controls are moved onto, whatever the generations' timing, before humanizing: a note value such as 1/8 or 1/16, a triplet such as 1/8t, or off",
+		value: func(c *Config) flag.Value { return &c.Quantize },
+	},
+	{
+		key: "velocity.min", flag: "velocity-min",
+		usage: "velocity (1-127) of a note struck by a newborn cell with no neighbours",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.VelocityMin) },
+	},
+	{
+		key: "velocity.max", flag: "velocity-max",
+		usage: "velocity (1-127) of a note struck by an old cell with eight neighbours",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.VelocityMax) },
+	},
+	{
+		key: "velocity.curve", flag: "velocity-curve",
+		usage: "exponent shaping velocities between --velocity-min and --velocity-max (1 is linear, above 1 quieter)",
+		value: func(c *Config) flag.Value { return (*float64Value)(&c.VelocityCurve) },
+	},
+	{
+		key: "velocity.map", flag: "velocity-map",
+		usage: "how the velocities are reshaped for the instrument once worked out: linear, soft (louder), hard (quieter), s-curve, or breakpoints such as 1:20,64:80,127:120",
+		value: func(c *Config) flag.Value { return &c.VelocityMap },
+	},
+	{
+		key: "audio.volume", flag: "volume",
+		usage:   "fraction, 0 to 1, of their velocities the notes are played at",
+		value:   func(c *Config) flag.Value { return (*probabilityValue)(&c.Volume) },
+		aliases: []string{"volume"},
+		env:     []string{"CONWAYS_STEINWAY_VOLUME"},
+	},
+	{
+		key: "key", flag: "key",
+		usage: "key to move the output into from the scale's --root, e.g. G or Eb, by the nearer way up or down",
+		value: func(c *Config) flag.Value { return &c.Key },
+	},
+	{
+		key: "transpose", flag: "transpose",
+		usage: "semitones to move every note up, or down when negative, after fitting it to the scale",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.Transpose) },
+	},
+	{
+		key: "key.modulate", flag: "modulate-every",
+		usage: "move the key up a fifth every this many generations (0 never modulates)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.ModulateEvery) },
+	},
+	{
+		key: "pitch.shift", flag: "pitch-shift", negate: "no-pitch-shift",
+		usage: "move clusters of notes below C2 or above C7 by octaves into that register",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.PitchShift) },
+		ahead: []string{"audio.pitch.shift"},
+	},
+	{
+		key: "music.chords", flag: "detect-chords", negate: "no-detect-chords",
+		usage:   "recognise triads, sevenths and clusters among the keys struck together",
+		value:   func(c *Config) flag.Value { return (*boolValue)(&c.DetectChords) },
+		aliases: []string{"audio.detect.chords"},
+		env:     []string{"CONWAYS_STEINWAY_DETECT_CHORDS"},
+	},
+	{
+		key: "music.voicing", flag: "chord-voicing",
+		usage: "how recognised chords are played: none (as struck), close, open or drop-2",
+		value: func(c *Config) flag.Value { return &c.Voicing },
+	},
+	{
+		key: "music.voicing.avoid-semitones", flag: "avoid-semitones",
+		usage: "when a chord is recognised, leave out each key a semitone above the one below it, so clusters of cells sound as chords",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.AvoidSemitones) },
+	},
+	{
+		key: "arpeggio.above", flag: "arpeggiate-above",
+		usage: "arpeggiate the keys struck in a generation across it when there are more than this many (0 strikes them together)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.ArpeggiateAbove) },
+	},
+	{
+		key: "arpeggio.order", flag: "arpeggio-order",
+		usage: "order arpeggiated keys are struck in: up, down or random",
+		value: func(c *Config) flag.Value { return &c.ArpeggioOrder },
+	},
+	{
+		key: "music.rest-policy", flag: "rest-policy",
+		usage: "what a generation striking no keys plays: silence, repeat (the last keys, softly), pedal-tone (the key's root in the bass) or skip (on to the next generation striking keys)",
+		value: func(c *Config) flag.Value { return &c.Rest },
+	},
+	{
+		key: "music.smooth", flag: "smooth",
+		usage: "how strongly, 0 to 1, each generation's keys are conditioned on the last ones struck, preferring steps to leaps (0 plays them as the board strikes them)",
+		value: func(c *Config) flag.Value { return (*probabilityValue)(&c.Smooth) },
+	},
+	{
+		key: "music.smooth.leap", flag: "max-leap",
+		usage: "widest interval, in semitones, --smooth lets a key leap from the last ones struck",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.MaxLeap) },
+	},
+	{
+		key: "music.note-probability", flag: "note-probability",
+		usage: "chance, 0 to 1, that each key struck afresh is played, thinning out dense passages (notes already held play on)",
+		value: func(c *Config) flag.Value { return (*probabilityValue)(&c.NoteProbability) },
+	},
+	{
+		key: "music.note-probability.age", flag: "probability-by-age",
+		usage: "raise --note-probability with the age of each key's cell, so that a cell alive for 16 generations is always heard",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.ProbabilityByAge) },
+	},
+	{
+		key: "dynamics.depth", flag: "dynamics-depth",
+		usage: "most a growing or dying population raises or lowers velocities by, as a fraction, e.g. 0.4 (0 keeps them)",
+		value: func(c *Config) flag.Value { return (*probabilityValue)(&c.ArcDepth) },
+	},
+	{
+		key: "dynamics.attack", flag: "dynamics-attack",
+		usage: "how fast the dynamics follow a growing population, from 0 to 1 (at once)",
+		value: func(c *Config) flag.Value { return (*probabilityValue)(&c.ArcAttack) },
+	},
+	{
+		key: "dynamics.decay", flag: "dynamics-decay",
+		usage: "how fast the dynamics follow a shrinking population, from 0 to 1 (at once)",
+		value: func(c *Config) flag.Value { return (*probabilityValue)(&c.ArcDecay) },
+	},
+	{
+		key: "polyphony", flag: "polyphony",
+		usage: "most notes sounding at once, for instruments that choke beyond a limit (0 is unlimited)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.Polyphony) },
+	},
+	{
+		key: "polyphony.steal", flag: "voice-stealing",
+		usage: "which notes give way beyond --polyphony: quietest, oldest or scale (those outside the scale, then the quietest)",
+		value: func(c *Config) flag.Value { return &c.Steal },
+	},
+	{
+		key: "pedal.density", flag: "pedal-density",
+		usage: "fraction of the board alive at which the sustain pedal goes down, e.g. 0.3 (0 never presses it for density)",
+		value: func(c *Config) flag.Value { return (*probabilityValue)(&c.PedalPress) },
+	},
+	{
+		key: "pedal.release", flag: "pedal-release",
+		usage: "fraction of the board alive below which the pedal comes up again (0 is --pedal-density)",
+		value: func(c *Config) flag.Value { return (*probabilityValue)(&c.PedalRelease) },
+	},
+	{
+		key: "pedal.chords", flag: "pedal-chords",
+		usage: "hold the sustain pedal down while chords are struck",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.PedalChords) },
+	},
+	{
+		key: "cc", flag: "cc",
+		usage: "MIDI controller moved each generation by a measure of the board, as metric->ccN with metric density, birth-rate, death-rate, centre-x or centre-y, optionally scaled as density*4->cc1; repeat for several",
+		value: func(c *Config) flag.Value { return &c.Modulations },
+	},
+	{
+		key: "sections", flag: "section",
+		usage: "instrument to move on to, as at=…, every=… or on=cycle|collapse|reseed with program=… (several as 12/49/89, taken in turn), and optionally bank=MSB or MSB:LSB and channel=…; repeat for several",
+		value: func(c *Config) flag.Value { return &c.Sections },
+	},
+	{
+		key: "phrase.dip", flag: "phrase-dip",
+		usage: "fraction (0-1) of its recent level the population must dip by to end a phrase, as the board entering a cycle does",
+		value: func(c *Config) flag.Value { return (*probabilityValue)(&c.PhraseDip) },
+	},
+	{
+		key: "phrase.length", flag: "phrase-length",
+		usage: "fewest generations in a phrase",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.PhraseLength) },
+	},
+	{
+		key: "phrase.cadence", flag: "cadence",
+		usage: "bars of the cadence closing each phrase, and the performance, slowing down to resolve to the tonic chord (0 for none)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.Cadence) },
+	},
+	{
+		key: "generations", flag: "generations",
+		usage: "generations to play (0 or unlimited plays until stopped)",
+		value: func(c *Config) flag.Value { return (*generationsValue)(&c.Generations) },
+	},
+	{
+		key: "output", flag: "output",
+		usage: "where the performance goes: terminal, midi-file, musicxml, lilypond, abc, osc, wav or jsonl (JSON Lines)",
+		value: func(c *Config) flag.Value { return &c.Output },
+	},
+	{
+		key: "midi.path", flag: "midi-path",
+		usage: "Standard MIDI File written by --output midi-file",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.MIDIPath) },
+	},
+	{
+		key: "musicxml.path", flag: "musicxml-path",
+		usage: "MusicXML score written by --output musicxml",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.MusicXMLPath) },
+	},
+	{
+		key: "lilypond.path", flag: "lilypond-path",
+		usage: "LilyPond score written by --output lilypond",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.LilyPondPath) },
+	},
+	{
+		key: "abc.path", flag: "abc-path",
+		usage: "ABC tune written by --output abc",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.ABCPath) },
+	},
+	{
+		key: "osc.addr", flag: "osc-addr",
+		usage: "host:port of the Open Sound Control server --output osc sends to",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.OSCAddr) },
+	},
+	{
+		key: "wav.path", flag: "wav-path",
+		usage: "WAV file written by --output wav",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.WAVPath) },
+	},
+	{
+		key: "jsonl.path", flag: "jsonl-path",
+		usage: "file the jsonl output writes, or - for standard output",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.JSONLPath) },
+	},
+	{
+		key: "soundfont", flag: "soundfont",
+		usage: "SoundFont 2 (.sf2) file --output wav plays the notes on",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.SoundFont) },
+	},
+	{
+		key: "midi.port", flag: "midi-port",
+		usage: "MIDI output port to play the notes on as they happen, by index or name (see \"ports\"); needs a -tags rtmidi build",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.MIDIPort) },
+	},
+	{
+		key: "midi.virtual", flag: "virtual-port",
+		usage: "create a virtual MIDI output port named \"Conways Steinway\" and play the notes on it; needs a -tags rtmidi build",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.VirtualPort) },
+	},
+	{
+		key: "midi.clock", flag: "midi-clock",
+		usage: "send MIDI clock, Start and Stop on the MIDI output ports so sequencers and drum machines follow the tempo",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.MIDIClock) },
+	},
+	{
+		key: "midi.mmc", flag: "mmc",
+		usage: "send MIDI Machine Control Play and Stop, with Song Position Pointers, on the MIDI output ports so a DAW can locate and chase the performance",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.MMC) },
+	},
+	{
+		key: "lookahead", flag: "lookahead",
+		usage: "milliseconds ahead of their time the ticks are played, so that the live outputs have their notes queued to send on time even when a generation is slow to work out",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.Lookahead) },
+	},
+	{
+		key: "midi.mpe", flag: "mpe",
+		usage: "play MIDI Polyphonic Expression on the MIDI output ports, each note on its own channel bent by its cell's neighbours and pressed by its age",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.MPE) },
+	},
+	{
+		key: "midi.mpe.bend", flag: "mpe-bend",
+		usage: "semitones --mpe bends the notes of the loneliest cells down and the most crowded up",
+		value: func(c *Config) flag.Value { return (*float64Value)(&c.MPEBend) },
+	},
+	{
+		key: "tuning.scl", flag: "scl",
+		usage: "Scala .scl file of a tuning to play the notes in on the MIDI output ports and the built-in synth",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.Scala) },
+	},
+	{
+		key: "tuning.kbm", flag: "kbm",
+		usage: "Scala .kbm file mapping the --scl tuning onto the keys; without one its degrees run up from middle C, which keeps its pitch",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.KeyboardMap) },
+	},
+	{
+		key: "tuning.mode", flag: "tuning-mode",
+		usage: "how the --scl tuning reaches the MIDI output ports: mts (MIDI Tuning Standard messages) or bend (each note bent to its pitch on a channel of its own, as --mpe plays)",
+		value: func(c *Config) flag.Value { return &c.TuningMode },
+	},
+	{
+		key: "link", flag: "link",
+		usage: "follow the tempo and beat of an Ableton Link session on the network, starting on its next bar",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.Link) },
+	},
+	{
+		key: "midi.in", flag: "midi-in",
+		usage: "MIDI input port, by index or name (see \"ports\"), whose notes bring the cells of their keys' columns to life; needs a -tags rtmidi build",
+		value: func(c *Config) flag.Value { return (*stringValue)(&c.MIDIIn) },
+	},
+	{
+		key: "midi.in.row", flag: "inject-row",
+		usage: "row the notes played on --midi-in plant cells on: played (the row the notes are played from), top, velocity (higher the harder the key is struck), random or column (the whole column)",
+		value: func(c *Config) flag.Value { return &c.InjectRow },
+	},
+	{
+		key: "midi.in.transport", flag: "midi-transport",
+		usage: "wait for a Start, Continue or MMC Play on --midi-in before playing, and hold the performance while a Stop or MMC Stop has it stopped, so starting a DAW starts the boards",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.Transport) },
+	},
+	{
+		key: "loop", flag: "loop",
+		usage: "bars of the notes played that the looper keeps, to capture and play again under the boards with controllers on --midi-in (0 for no looper)",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.Loop) },
+	},
+	{
+		key: "loop.record", flag: "loop-record",
+		usage: "controller (0-127) on --midi-in that captures the last --loop bars as the loop, replacing it",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.LoopRecord) },
+	},
+	{
+		key: "loop.overdub", flag: "loop-overdub",
+		usage: "controller (0-127) on --midi-in that adds the notes played since to the loop",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.LoopOverdub) },
+	},
+	{
+		key: "loop.clear", flag: "loop-clear",
+		usage: "controller (0-127) on --midi-in that empties the loop",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.LoopClear) },
+	},
+	{
+		key: "audio", flag: "audio",
+		usage: "play the notes on the built-in synth through the computer's sound output when the terminal plays live (default true; --silent turns it off)",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.Audio) },
+	},
+	{
+		key: "silent", flag: "silent",
+		usage: "play no sound, on the built-in synth or the MIDI output ports, as in the Python and Rust players",
+		value: func(c *Config) flag.Value { return (*boolValue)(&c.Silent) },
+	},
+	{
+		key: "audio.wave", flag: "audio-wave",
+		usage: "wave the built-in synth plays: sine or triangle",
+		value: func(c *Config) flag.Value { return &c.AudioWave },
+	},
+	{
+		key: "audio.attack", flag: "audio-attack",
+		usage: "milliseconds the built-in synth's notes take to sound fully",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.AudioAttack) },
+	},
+	{
+		key: "audio.decay", flag: "audio-decay",
+		usage: "milliseconds the built-in synth's notes take to fall to the sustain level",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.AudioDecay) },
+	},
+	{
+		key: "audio.sustain", flag: "audio-sustain",
+		usage: "level, 0 to 1, the built-in synth's held notes fall to",
+		value: func(c *Config) flag.Value { return (*probabilityValue)(&c.AudioSustain) },
+	},
+	{
+		key: "audio.release", flag: "audio-release",
+		usage: "milliseconds the built-in synth's notes take to fade once let go",
+		value: func(c *Config) flag.Value { return (*intValue)(&c.AudioRelease) },
+	},
+}
+
+// EnvName returns the environment variable that overrides a properties key,
+// e.g. "board.edge" becomes CONWAYS_STEINWAY_BOARD_EDGE
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// Parse builds the configuration from defaults, the configuration file, the
+// environment and args, each overriding the one before it: a flag wins over
+// an environment variable, which wins over the file, which wins over the
+// default, whichever order they are written in. A setting is taken whole
+// from the layer that wins, so lists such as --channel replace the file's
+// rather than adding to them. A step delay sets
+// the tempo only when no tempo is given, as in the Python and Rust players.
+// A bad value does not stop it: it goes on to the rest and checks the merged
+// configuration with Validate, then returns every problem found as Problems
+// along with the configuration, so that callers can add their own checks and
+// report them all at once.
+func Parse(name string, args []string) (*Config, error) {
+	fset := flag.NewFlagSet(name, flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "usage: %s [flags] [ports | patterns list | search | breed | render]\n\n", name)
+		fmt.Fprintln(fset.Output(), "The MIDI ports (--midi-port, --virtual-port, --midi-in and ports) need the RtMidi")
+		fmt.Fprintln(fset.Output(), "driver, built in with go build -tags rtmidi; the default build plays on the synth.")
+		fmt.Fprintln(fset.Output())
+		fset.PrintDefaults()
+	}
+	path := fset.String("config", "", "path to configuration file (default "+DefaultFile+" in the working directory, the executable's or one above them)")
+	show := fset.Bool("show-config-sources", false, "print each setting's value and where it came from, then exit")
+	set := make(map[string]flagSetting)
+	for _, o := range options {
+		_, isBool := o.value(Default()).(interface{ IsBoolFlag() bool })
+		_, isList := o.value(Default()).(interface{ IsListFlag() bool })
+		fset.Var(&rawValue{name: o.key, flag: o.flag, set: set, isBool: isBool, isList: isList}, o.flag, o.usage)
+		if o.negate != "" {
+			fset.Var(&rawValue{name: o.key, flag: o.negate, set: set, isBool: true, negate: true}, o.negate, "turn off --"+o.flag)
+		}
+		for _, f := range o.flags {
+			fset.Var(&rawValue{name: o.key, flag: f, set: set, isBool: isBool, isList: isList}, f, "the same as --"+o.flag)
+		}
+	}
+	for _, f := range foreignFlags {
+		fset.Var(ignoredValue{isBool: f.isBool}, f.name, "accepted, as the Python and Rust players take it, and ignored")
+	}
+	if err := fset.Parse(args); err != nil {
+		return nil, err
+	}
+
+	c := Default()
+	var problems Problems
+	file := *path
+	if file == "" {
+		file = FindFile()
+	}
+	if file != "" {
+		if err := c.LoadFile(file); err != nil && !errors.As(err, &problems) {
+			return nil, err
+		}
+		c.File = file
+	}
+	if err := c.LoadEnv(os.LookupEnv); err != nil {
+		problems = append(problems, err.(Problems)...)
+	}
+	for _, o := range options {
+		if v, ok := set[o.key]; ok {
+			src := Source{Kind: FromFlag, Name: v.flag}
+			if err := o.value(c).Set(v.value); err != nil {
+				problems = append(problems, Problem{Key: o.key, Source: src, Err: err})
+				continue
+			}
+			c.from(o.key, src)
+		}
+	}
+	if c.StepDelay > 0 && c.Source("tempo.bpm").Kind == FromDefault {
+		c.BPM = 60000 / float64(c.StepDelay*max(c.GenerationsPerBeat, 1))
+		c.from("tempo.bpm", c.Source("step.delay.ms"))
+	}
+	c.Args = fset.Args()
+	c.ShowSources = *show
+	if err := c.Validate(); err != nil {
+		problems = append(problems, err.(Problems)...)
+	}
+	if len(problems) > 0 {
+		return c, problems
+	}
+	return c, nil
+}
+
+// LoadFile applies the settings found in a properties file. Keys that the Go
+// implementation does not know are ignored, since the file is shared. Values
+// that cannot be used are left out and returned together as Problems.
+func (c *Config) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	props, err := readProperties(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	var problems Problems
+	for _, o := range options {
+		for _, key := range slices.Concat(o.ahead, []string{o.key}, o.aliases) {
+			if p, ok := props[key]; ok {
+				src := Source{Kind: FromFile, Name: path, Line: p.line}
+				if err := o.value(c).Set(p.value); err != nil {
+					problems = append(problems, Problem{Key: key, Source: src, Err: err})
+				} else {
+					c.from(o.key, src)
+				}
+				break
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// LoadEnv applies the settings found in the environment through lookup.
+// Values that cannot be used are left out and returned together as Problems.
+func (c *Config) LoadEnv(lookup func(string) (string, bool)) error {
+	var problems Problems
+	for _, o := range options {
+		for _, name := range append([]string{EnvName(o.key)}, o.env...) {
+			if v, ok := lookup(name); ok {
+				src := Source{Kind: FromEnv, Name: name}
+				if err := o.value(c).Set(v); err != nil {
+					problems = append(problems, Problem{Key: o.key, Source: src, Err: err})
+				} else {
+					c.from(o.key, src)
+				}
+				break
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// foreignFlags are the flags of the Python and Rust players' settings that
+// have no counterpart here. They are accepted, so that the same launcher
+// scripts run every implementation, and ignored.
+var foreignFlags = []struct {
+	name   string
+	isBool bool
+}{
+	{name: "board-type"},
+	{name: "note-duration"},
+	{name: "gap"},
+	{name: "chord-duration"},
+	{name: "initial-delay"},
+	{name: "log-level"},
+	{name: "log-to-file", isBool: true},
+	{name: "log-file-path"},
+	{name: "log-file-level"},
+	{name: "log-console-level"},
+	{name: "no-log-file-rotation", isBool: true},
+	{name: "log-file-size-limit"},
+	{name: "log-file-count"},
+}
+
+// ignoredValue is a flag.Value that takes anything and keeps none of it
+type ignoredValue struct{ isBool bool }
+
+func (v ignoredValue) String() string   { return "" }
+func (v ignoredValue) Set(string) error { return nil }
+func (v ignoredValue) IsBoolFlag() bool { return v.isBool }
+
+// flagSetting is the text given to an option on the command line, and the
+// flag that gave it
+type flagSetting struct {
+	value, flag string
+}
+
+// rawValue records a flag's text so it can be applied after the file and
+// environment have been loaded
+type rawValue struct {
+	name   string
+	flag   string
+	set    map[string]flagSetting
+	isBool bool
+	isList bool // repeated flags are joined with ';' instead of replaced
+	negate bool // the flag sets the opposite of its value
+}
+
+func (v *rawValue) String() string { return "" }
+
+func (v *rawValue) IsBoolFlag() bool { return v.isBool }
+
+func (v *rawValue) Set(s string) error {
+	if v.negate {
+		var b boolValue
+		if err := b.Set(s); err != nil {
+			return err
+		}
+		s = strconv.FormatBool(!bool(b))
+	}
+	if prev, ok := v.set[v.name]; ok && v.isList {
+		s = prev.value + ";" + s
+	}
+	v.set[v.name] = flagSetting{value: s, flag: v.flag}
+	return nil
+}
@@ -0,0 +1,546 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+func writeFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "conways_steinway.properties")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadProperties(t *testing.T) {
+	props, err := ReadProperties(strings.NewReader("# comment\n! also a comment\n\nboard.edge = wrap\nboard.type: random\nsilent\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"board.edge": "wrap", "board.type": "random", "silent": ""}
+	if len(props) != len(want) {
+		t.Fatalf("got %v, want %v", props, want)
+	}
+	for k, v := range want {
+		if props[k] != v {
+			t.Fatalf("props[%q] = %q, want %q", k, props[k], v)
+		}
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if got := EnvName("board.edge"); got != "CONWAYS_STEINWAY_BOARD_EDGE" {
+		t.Fatalf("EnvName = %q", got)
+	}
+}
+
+func TestParseEdgeFromEachSource(t *testing.T) {
+	path := writeFile(t, "board.edge=mirror\n")
+
+	c, err := Parse("test", []string{"-config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Edge != life.EdgeMirror {
+		t.Fatalf("file: Edge = %v, want mirror", c.Edge)
+	}
+
+	t.Setenv("CONWAYS_STEINWAY_BOARD_EDGE", "alive")
+	if c, err = Parse("test", []string{"-config", path}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Edge != life.EdgeAlive {
+		t.Fatalf("env: Edge = %v, want alive", c.Edge)
+	}
+
+	if c, err = Parse("test", []string{"-config", path, "-edge", "wrap"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Edge != life.EdgeWrap {
+		t.Fatalf("flag: Edge = %v, want wrap", c.Edge)
+	}
+}
+
+func TestSharedProperties(t *testing.T) {
+	// The keys the Python and Rust players write
+	path := writeFile(t, "board.type=random\nstep.delay.ms=200\naudio.detect.chords=false\naudio.pitch.shift=false\naudio.volume=0.6\naudio.gap.ms=50\nrandom.alive.probability=0.2\nboard.height=30\n")
+	c, err := Parse("test", []string{"-config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DetectChords || c.PitchShift || c.Volume != 0.6 || c.Density != 0.2 || c.Height != 30 {
+		t.Errorf("chords %v, pitch shift %v, volume %v, density %v, height %d, want off, off, 0.6, 0.2 and 30", c.DetectChords, c.PitchShift, c.Volume, c.Density, c.Height)
+	}
+	if c.BPM != 300 {
+		t.Errorf("BPM = %v, want 300 from a step every 200ms", c.BPM)
+	}
+
+	// The Go keys win over the other players' names for them, and a tempo
+	// over a step delay
+	path = writeFile(t, "density=0.4\nrandom.alive.probability=0.2\nstep.delay.ms=200\ntempo.bpm=90\n")
+	if c, err = Parse("test", []string{"-config", path}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Density != 0.4 || c.BPM != 90 {
+		t.Errorf("density %v, BPM %v, want 0.4 and 90", c.Density, c.BPM)
+	}
+}
+
+func TestSharedPropertiesOrder(t *testing.T) {
+	// The Python player reads audio.volume before volume and
+	// audio.pitch.shift before pitch.shift, so the same file plays alike
+	path := writeFile(t, "volume=0.2\naudio.volume=0.6\npitch.shift=true\naudio.pitch.shift=false\n")
+	c, err := Parse("test", []string{"-config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Volume != 0.6 || c.PitchShift {
+		t.Errorf("volume %v, pitch shift %v, want 0.6 and off", c.Volume, c.PitchShift)
+	}
+	if got := c.Source("pitch.shift").String(); got != path+":4" {
+		t.Errorf("pitch.shift from %s, want %s:4", got, path)
+	}
+}
+
+func TestFindFile(t *testing.T) {
+	// Run from go/src, the file at the root of the project is found
+	root := t.TempDir()
+	path := filepath.Join(root, DefaultFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("board.height=30\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(root, "go", "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(src)
+	c, err := Parse("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Height != 30 || c.File != path {
+		t.Errorf("height %d from %q, want 30 from %s", c.Height, c.File, path)
+	}
+
+	// The working directory's own file comes first
+	own := filepath.Join(src, DefaultFile)
+	if err := os.MkdirAll(filepath.Dir(own), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(own, []byte("board.height=20\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindFile(); got != own {
+		t.Errorf("FindFile() = %q, want %q", got, own)
+	}
+}
+
+func TestSharedFlags(t *testing.T) {
+	// A command line written for the Python and Rust players
+	args := []string{"--board-type", "random", "--generations", "unlimited", "--step-delay", "100", "--tempo", "90",
+		"--note-duration", "200", "--gap", "50", "--no-detect-chords", "--volume", "0.6", "--no-pitch-shift",
+		"--alive-probability", "0.3", "--height", "30", "--log-level", "debug", "--log-to-file", "song"}
+	c, err := Parse("test", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Generations != 0 || c.BPM != 90 || c.DetectChords || c.Volume != 0.6 || c.PitchShift || c.Density != 0.3 || c.Height != 30 {
+		t.Errorf("got generations %d, BPM %v, chords %v, volume %v, pitch shift %v, density %v, height %d", c.Generations, c.BPM, c.DetectChords, c.Volume, c.PitchShift, c.Density, c.Height)
+	}
+	if len(c.Args) != 1 || c.Args[0] != "song" {
+		t.Errorf("Args = %q, want the ignored flags to leave just song", c.Args)
+	}
+}
+
+func TestSharedEnv(t *testing.T) {
+	// The variables the Python and Rust players read
+	for name, v := range map[string]string{"TEMPO": "90", "ALIVE_PROBABILITY": "0.3", "DETECT_CHORDS": "false", "VOLUME": "0.5", "PITCH_SHIFT": "no", "BOARD_HEIGHT": "30", "GENERATIONS": "unlimited"} {
+		t.Setenv(EnvPrefix+name, v)
+	}
+	c, err := Parse("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.BPM != 90 || c.Density != 0.3 || c.DetectChords || c.Volume != 0.5 || c.PitchShift || c.Height != 30 || c.Generations != 0 {
+		t.Errorf("got BPM %v, density %v, chords %v, volume %v, pitch shift %v, height %d, generations %d", c.BPM, c.Density, c.DetectChords, c.Volume, c.PitchShift, c.Height, c.Generations)
+	}
+
+	// The variable named for the key wins, and a flag over both
+	t.Setenv("CONWAYS_STEINWAY_DENSITY", "0.4")
+	if c, err = Parse("test", nil); err != nil {
+		t.Fatal(err)
+	}
+	if c.Density != 0.4 {
+		t.Errorf("density %v, want 0.4 from CONWAYS_STEINWAY_DENSITY", c.Density)
+	}
+	if c, err = Parse("test", []string{"--tempo", "60"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.BPM != 60 {
+		t.Errorf("BPM %v, want 60 from the flag", c.BPM)
+	}
+}
+
+func TestParseRejectsBadEdge(t *testing.T) {
+	if _, err := Parse("test", []string{"-edge", "sideways"}); err == nil {
+		t.Fatal("expected an error for an unknown edge mode")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	path := writeFile(t, "# shared with the other players\nboard.width=80\ndensity=1.5\n")
+	t.Setenv("CONWAYS_STEINWAY_TEMPO", "fast")
+	c, err := Parse("test", []string{"--config", path, "--velocity-min", "0"})
+	var problems Problems
+	if !errors.As(err, &problems) {
+		t.Fatalf("got %v, want Problems", err)
+	}
+	if c == nil {
+		t.Fatal("no configuration returned with the problems")
+	}
+	want := map[string]string{
+		"board.width":  path + ":2",
+		"density":      path + ":3",
+		"tempo.bpm":    "environment CONWAYS_STEINWAY_TEMPO",
+		"velocity.min": "flag --velocity-min",
+	}
+	for _, p := range problems {
+		if src, ok := want[p.Key]; !ok || p.Source.String() != src {
+			t.Errorf("problem %v, want one of %v", p, want)
+		}
+		delete(want, p.Key)
+	}
+	if len(want) > 0 {
+		t.Errorf("not reported: %v", want)
+	}
+	if !strings.HasPrefix(err.Error(), "4 problems") {
+		t.Errorf("error %q", err)
+	}
+
+	if _, err := Parse("test", []string{"--bpm", "100000"}); err == nil {
+		t.Error("a tempo leaving less than a millisecond to a generation was accepted")
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	path := writeFile(t, "tempo.bpm=100\ndensity=0.2\nrule=B36/S23\nchannels=row=10,channel=2\n")
+	t.Setenv("CONWAYS_STEINWAY_TEMPO_BPM", "110")
+	t.Setenv("CONWAYS_STEINWAY_DENSITY", "0.3")
+	c, err := Parse("test", []string{"--bpm", "120", "--config", path, "--channel", "row=20,channel=3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"tempo.bpm":  "flag --bpm",
+		"density":    "environment CONWAYS_STEINWAY_DENSITY",
+		"rule":       path + ":3",
+		"channels":   "flag --channel",
+		"board.edge": "default",
+	}
+	for _, s := range c.Settings() {
+		if src, ok := want[s.Key]; ok && s.Source.String() != src {
+			t.Errorf("%s from %v, want %s", s.Key, s.Source, src)
+		}
+	}
+	if c.BPM != 120 || c.Density != 0.3 || c.Rule.String() != "B36/S23" {
+		t.Errorf("got BPM %v, density %v, rule %v", c.BPM, c.Density, c.Rule)
+	}
+	if len(c.Channels) != 1 || c.Channels[0].Row != 20 {
+		t.Errorf("channels %v, want the flag's alone", c.Channels)
+	}
+
+	var out strings.Builder
+	if err := c.WriteSources(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "# configuration file: "+path) || !strings.Contains(out.String(), "environment CONWAYS_STEINWAY_DENSITY") {
+		t.Errorf("sources:\n%s", out.String())
+	}
+}
+
+func TestSilent(t *testing.T) {
+	c, err := Parse("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Audio || c.Silent {
+		t.Errorf("audio %v, silent %v; want audio on by default", c.Audio, c.Silent)
+	}
+
+	// As in the Python and Rust players, the key alone in the file is enough
+	path := writeFile(t, "silent\n")
+	if c, err = Parse("test", []string{"--config", path}); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Silent {
+		t.Error("a bare silent key in the file did not silence the performance")
+	}
+	t.Setenv("CONWAYS_STEINWAY_SILENT", "false")
+	if c, err = Parse("test", []string{"--config", path}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Silent {
+		t.Error("CONWAYS_STEINWAY_SILENT=false did not win over the file")
+	}
+	if c, err = Parse("test", []string{"--config", path, "--silent"}); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Silent {
+		t.Error("--silent did not win over the environment")
+	}
+}
+
+func TestSeedFromLegacyEnv(t *testing.T) {
+	t.Setenv("LIFE_SEED", "42")
+	c, err := Parse("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Seed != 42 {
+		t.Fatalf("Seed = %d, want 42", c.Seed)
+	}
+
+	t.Setenv("CONWAYS_STEINWAY_SEED", "7")
+	if c, err = Parse("test", nil); err != nil {
+		t.Fatal(err)
+	}
+	if c.Seed != 7 {
+		t.Fatalf("Seed = %d, want the prefixed variable to win", c.Seed)
+	}
+}
+
+func TestDensityOutOfRange(t *testing.T) {
+	if _, err := Parse("test", []string{"--density", "1.5"}); err == nil {
+		t.Fatal("Parse accepted a density above 1")
+	}
+	c, err := Parse("test", []string{"--density", "0.15"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Density != 0.15 {
+		t.Fatalf("Density = %v, want 0.15", c.Density)
+	}
+}
+
+func TestPatternSpec(t *testing.T) {
+	c, err := Parse("test", []string{"--pattern", "gosper-gun@20,5", "patterns", "list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PatternSpec{Name: "gosper-gun", At: life.Coord{X: 20, Y: 5}, Placed: true}
+	if c.Pattern != want {
+		t.Fatalf("Pattern = %+v, want %+v", c.Pattern, want)
+	}
+	if len(c.Args) != 2 || c.Args[0] != "patterns" {
+		t.Fatalf("Args = %v, want the subcommand", c.Args)
+	}
+	if _, err := Parse("test", []string{"--pattern", "glider@5"}); err == nil {
+		t.Fatal("Parse accepted a position without a y")
+	}
+}
+
+func TestLayersRepeat(t *testing.T) {
+	file := writeFile(t, "layers = rule=B36/S23\n")
+	c, err := Parse("test", []string{"--config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Layers) != 1 || c.Layers[0].Rule.String() != "B36/S23" {
+		t.Fatalf("Layers = %v, want the file's layer", c.Layers)
+	}
+
+	c, err = Parse("test", []string{"--config", file,
+		"--layer", "rule=B5-7/S4,6,8-10,seed=7,channel=2",
+		"--layer", "every=4,density=0.2,bass=yes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Layers{
+		{Rule: life.Rule{Birth: 0xe0, Survive: 0x750}, Seed: 7, Channel: 2},
+		{Every: 4, Density: 0.2, Bass: true},
+	}
+	if len(c.Layers) != len(want) || c.Layers[0] != want[0] || c.Layers[1] != want[1] {
+		t.Fatalf("Layers = %v, want the flags' layers replacing the file's", c.Layers.String())
+	}
+}
+
+func TestBass(t *testing.T) {
+	c, err := Parse("test", []string{"--bass", "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Bass != 4 {
+		t.Fatalf("Bass = %d, want 4", c.Bass)
+	}
+	if c, _ = Parse("test", nil); c.Bass != 0 {
+		t.Fatalf("Bass = %d by default, want 0", c.Bass)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	c, err := Parse("test", []string{"--loop", "4", "--loop-clear", "90"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Loop != 4 || c.LoopRecord != 80 || c.LoopOverdub != 81 || c.LoopClear != 90 {
+		t.Fatalf("Loop = %d on controllers %d, %d, %d, want 4 bars on 80, 81, 90", c.Loop, c.LoopRecord, c.LoopOverdub, c.LoopClear)
+	}
+}
+
+func TestLayerErrors(t *testing.T) {
+	for _, spec := range []string{"channel=17", "every=0", "tempo=3", "seed", "bass=maybe"} {
+		if _, err := Parse("test", []string{"--layer", spec}); err == nil {
+			t.Errorf("Parse accepted layer %q", spec)
+		}
+	}
+}
+
+func TestChannels(t *testing.T) {
+	file := writeFile(t, "channels = row=0,channel=1,program=1; row=5,channel=2,program=49\n")
+	c, err := Parse("test", []string{"--config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Channels{{Row: 0, Channel: 1, Program: 1}, {Row: 5, Channel: 2, Program: 49}}
+	if len(c.Channels) != len(want) || c.Channels[0] != want[0] || c.Channels[1] != want[1] {
+		t.Fatalf("Channels = %v, want the file's table", c.Channels.String())
+	}
+	c, err = Parse("test", []string{"--config", file, "--channel", "row=10,channel=3,program=12", "--channel", "row=-1,channel=4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Channels.String(); got != "row=10,channel=3,program=12;row=-1,channel=4" {
+		t.Fatalf("Channels = %s, want the flags' channels replacing the file's", got)
+	}
+	for _, spec := range []string{"row=1", "channel=2", "row=1,channel=17", "row=1,channel=2,program=0", "row=1,channel=2;row=3,channel=2", "row=1,channel=2,voice=3"} {
+		if _, err := Parse("test", []string{"--channel", spec}); err == nil {
+			t.Errorf("Parse accepted channels %q", spec)
+		}
+	}
+}
+
+func TestArticulations(t *testing.T) {
+	c, err := Parse("test", []string{"--articulation", "staccato", "--articulation", "2=75%"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Articulations.String(); got != "staccato;2=75%" {
+		t.Fatalf("Articulations = %s, want both flags' articulations", got)
+	}
+	if m := c.Articulations.Map(); m[0] != 0.3 || m[2] != 0.75 {
+		t.Errorf("Map() = %v", m)
+	}
+	for _, spec := range []string{"17=staccato", "legato;tenuto", "3=slurred"} {
+		if _, err := Parse("test", []string{"--articulation", spec}); err == nil {
+			t.Errorf("Parse accepted articulations %q", spec)
+		}
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	c, err := Parse("test", []string{"--quantize", "1/8t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Quantize.Unit != 8 || !c.Quantize.Triplet {
+		t.Fatalf("Quantize = %v, want 1/8t", c.Quantize)
+	}
+	if _, err := Parse("test", []string{"--quantize", "1/12"}); err == nil {
+		t.Error("Parse accepted a grid of twelfths")
+	}
+}
+
+func TestGates(t *testing.T) {
+	c, err := Parse("test", []string{"--gate", "E(5,8)", "--gate", "10=E(3,8,2)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Gates.String(); got != "E(5,8);10=E(3,8,2)" {
+		t.Fatalf("Gates = %s, want both flags' gates", got)
+	}
+	for _, tc := range []struct {
+		channel, step int
+		want          bool
+	}{
+		{1, 0, true}, {1, 1, false}, {1, 2, true},
+		{10, 0, true}, {10, 1, false}, {10, 2, true}, {10, 3, false},
+	} {
+		if got := c.Gates.Open(tc.channel, tc.step); got != tc.want {
+			t.Errorf("Open(%d, %d) = %v, want %v", tc.channel, tc.step, got, tc.want)
+		}
+	}
+	if !(Gates{}).Open(1, 1) {
+		t.Error("no gates closed a step")
+	}
+	for _, spec := range []string{"E(9,8)", "17=E(3,8)", "E(3,8);E(5,8)", "2=E(3,8);2=E(1,4)"} {
+		if _, err := Parse("test", []string{"--gate", spec}); err == nil {
+			t.Errorf("Parse accepted gates %q", spec)
+		}
+	}
+}
+
+func TestModulations(t *testing.T) {
+	file := writeFile(t, "cc = density->cc1; birth-rate*2->cc11\n")
+	c, err := Parse("test", []string{"--config", file, "--cc", "centre-x->cc10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Modulations.String(); got != "centre-x->cc10" {
+		t.Fatalf("Modulations = %s, want the flag's replacing the file's", got)
+	}
+	if _, err := Parse("test", []string{"--cc", "density->cc1;centre-y->cc1"}); err == nil {
+		t.Error("Parse accepted two metrics moving cc1")
+	}
+}
+
+func TestSections(t *testing.T) {
+	c, err := Parse("test", []string{"--section", "at=500,program=49", "--section", "on=Cycle,program=12/89,bank=121:1,channel=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Sections.String(); got != "at=500,program=49;on=cycle,program=12/89,bank=121:1,channel=2" {
+		t.Fatalf("Sections = %s, want both flags' sections", got)
+	}
+	if s := c.Sections[1]; s.Bank != 121*128+1 || len(s.Programs) != 2 {
+		t.Errorf("section %+v, want bank 121:1 and two programs", s)
+	}
+	if c.Sections[0].Bank >= 0 {
+		t.Errorf("section without a bank selects bank %d", c.Sections[0].Bank)
+	}
+	for _, spec := range []string{"at=5", "program=3", "at=5,every=4,program=3", "on=rain,program=3", "at=0,program=3", "every=4,program=129", "on=cycle,program=3,bank=128", "on=cycle,program=3,channel=17", "on=cycle,program=3,voice=2"} {
+		if _, err := Parse("test", []string{"--section", spec}); err == nil {
+			t.Errorf("Parse accepted section %q", spec)
+		}
+	}
+}
+
+func TestNoDetectChords(t *testing.T) {
+	file := writeFile(t, "music.chords = yes\n")
+	for _, tc := range []struct {
+		args []string
+		want bool
+	}{
+		{nil, true},
+		{[]string{"--no-detect-chords"}, false},
+		{[]string{"--no-detect-chords=false"}, true},
+		{[]string{"--detect-chords=off"}, false},
+	} {
+		c, err := Parse("test", append([]string{"--config", file}, tc.args...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.DetectChords != tc.want {
+			t.Errorf("%v: DetectChords = %v, want %v", tc.args, c.DetectChords, tc.want)
+		}
+	}
+}
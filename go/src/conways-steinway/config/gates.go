@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// Gate lets the notes of a MIDI channel through only on the onsets of a
+// Euclidean rhythm
+type Gate struct {
+	Channel int // MIDI channel 1 to 16, or 0 for every channel without a gate of its own
+	Rhythm  music.Euclid
+}
+
+// String formats the gate as Set reads it
+func (g Gate) String() string {
+	if g.Channel == 0 {
+		return g.Rhythm.String()
+	}
+	return strconv.Itoa(g.Channel) + "=" + g.Rhythm.String()
+}
+
+// parseGate reads a rhythm, e.g. "E(5,8)", optionally after its channel, as
+// in "10=E(3,8)"
+func parseGate(s string) (Gate, error) {
+	var g Gate
+	spec := s
+	if channel, rhythm, ok := strings.Cut(s, "="); ok {
+		if err := (*intValue)(&g.Channel).Set(channel); err != nil || g.Channel < 1 || g.Channel > 16 {
+			return g, fmt.Errorf("gate %q: channel %q is not between 1 and 16", s, strings.TrimSpace(channel))
+		}
+		spec = rhythm
+	}
+	var err error
+	if g.Rhythm, err = music.ParseEuclid(spec); err != nil {
+		return g, fmt.Errorf("gate %q: %w", s, err)
+	}
+	return g, nil
+}
+
+// Gates is a flag.Value for a list of gates separated by semicolons.
+// Repeating the flag adds a gate each time. No channel may have two.
+type Gates []Gate
+
+func (gs *Gates) String() string {
+	specs := make([]string, len(*gs))
+	for i, g := range *gs {
+		specs[i] = g.String()
+	}
+	return strings.Join(specs, ";")
+}
+
+func (gs *Gates) Set(s string) error {
+	var gates Gates
+	used := make(map[int]bool)
+	for _, spec := range strings.Split(s, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		g, err := parseGate(spec)
+		if err != nil {
+			return err
+		}
+		if used[g.Channel] {
+			if g.Channel == 0 {
+				return fmt.Errorf("more than one gate is given to every channel")
+			}
+			return fmt.Errorf("channel %d is given more than one gate", g.Channel)
+		}
+		used[g.Channel] = true
+		gates = append(gates, g)
+	}
+	*gs = gates
+	return nil
+}
+
+// IsListFlag tells Parse to collect repeated flags rather than keep the last
+func (gs *Gates) IsListFlag() bool { return true }
+
+// Open reports whether the gates let channel's notes through on step: the
+// channel's own gate says, failing that the gate of every channel, and
+// without either the notes always pass
+func (gs Gates) Open(channel, step int) bool {
+	open := true
+	for _, g := range gs {
+		if g.Channel == channel {
+			return g.Rhythm.Open(step)
+		}
+		if g.Channel == 0 {
+			open = g.Rhythm.Open(step)
+		}
+	}
+	return open
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// Layer describes one of several boards played together. Zero fields take
+// their value from the main configuration or from the layer's position.
+type Layer struct {
+	Rule    life.Rule // rule; zero uses the configured rule
+	Seed    int64     // random seed; zero uses the run's seed plus the layer's index
+	Channel int       // MIDI channel 1 to 16; zero uses the layer's index plus one
+	Every   int       // shared clock ticks per generation, so higher is slower; zero means 1
+	Density float64   // probability a cell starts alive; zero uses the configured density
+	Bass    bool      // play a bass line in the bottom two octaves instead of the keys struck
+}
+
+// String formats the layer as Set reads it, omitting zero fields
+func (l Layer) String() string {
+	var fields []string
+	if l.Rule != (life.Rule{}) {
+		fields = append(fields, "rule="+l.Rule.String())
+	}
+	if l.Seed != 0 {
+		fields = append(fields, "seed="+strconv.FormatInt(l.Seed, 10))
+	}
+	if l.Channel != 0 {
+		fields = append(fields, "channel="+strconv.Itoa(l.Channel))
+	}
+	if l.Every != 0 {
+		fields = append(fields, "every="+strconv.Itoa(l.Every))
+	}
+	if l.Density != 0 {
+		fields = append(fields, "density="+strconv.FormatFloat(l.Density, 'g', -1, 64))
+	}
+	if l.Bass {
+		fields = append(fields, "bass=yes")
+	}
+	return strings.Join(fields, ",")
+}
+
+// parseLayer reads "key=value" fields separated by commas, e.g.
+// "rule=B36/S23,seed=7,channel=2,every=4". A comma not followed by a key
+// belongs to the value before it, as in "rule=B5-7/S4,6,8".
+func parseLayer(s string) (Layer, error) {
+	var l Layer
+	var fields []string
+	for _, part := range strings.Split(s, ",") {
+		if !strings.Contains(part, "=") && len(fields) > 0 {
+			fields[len(fields)-1] += "," + part
+			continue
+		}
+		fields = append(fields, part)
+	}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok {
+			return l, fmt.Errorf("layer field %q is not key=value", field)
+		}
+		var err error
+		switch key {
+		case "rule":
+			err = l.Rule.Set(value)
+		case "seed":
+			err = (*int64Value)(&l.Seed).Set(value)
+		case "channel":
+			if err = (*intValue)(&l.Channel).Set(value); err == nil && (l.Channel < 1 || l.Channel > 16) {
+				err = fmt.Errorf("channel %d is not between 1 and 16", l.Channel)
+			}
+		case "every":
+			if err = (*intValue)(&l.Every).Set(value); err == nil && l.Every < 1 {
+				err = fmt.Errorf("every %d is not positive", l.Every)
+			}
+		case "density":
+			err = (*probabilityValue)(&l.Density).Set(value)
+		case "bass":
+			err = (*boolValue)(&l.Bass).Set(value)
+		default:
+			err = fmt.Errorf("unknown layer field %q (want rule, seed, channel, every, density or bass)", key)
+		}
+		if err != nil {
+			return l, fmt.Errorf("layer %q: %w", s, err)
+		}
+	}
+	return l, nil
+}
+
+// Layers is a flag.Value for a list of layers separated by semicolons.
+// Repeating the flag adds a layer each time.
+type Layers []Layer
+
+func (ls *Layers) String() string {
+	specs := make([]string, len(*ls))
+	for i, l := range *ls {
+		specs[i] = l.String()
+	}
+	return strings.Join(specs, ";")
+}
+
+func (ls *Layers) Set(s string) error {
+	var layers Layers
+	for _, spec := range strings.Split(s, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		l, err := parseLayer(spec)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, l)
+	}
+	*ls = layers
+	return nil
+}
+
+// IsListFlag tells Parse to collect repeated flags rather than keep the last
+func (ls *Layers) IsListFlag() bool { return true }
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// Modulations is a flag.Value for a list of modulations separated by
+// semicolons, e.g. "density->cc1;birth-rate*4->cc11;centre-x->cc10".
+// Repeating the flag adds a modulation each time. No two may move the same
+// controller.
+type Modulations []music.Modulation
+
+func (ms *Modulations) String() string {
+	specs := make([]string, len(*ms))
+	for i, m := range *ms {
+		specs[i] = m.String()
+	}
+	return strings.Join(specs, ";")
+}
+
+func (ms *Modulations) Set(s string) error {
+	var modulations Modulations
+	used := make(map[int]bool)
+	for _, spec := range strings.Split(s, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		m, err := music.ParseModulation(spec)
+		if err != nil {
+			return err
+		}
+		if used[m.Controller] {
+			return fmt.Errorf("cc%d is moved by more than one metric", m.Controller)
+		}
+		used[m.Controller] = true
+		modulations = append(modulations, m)
+	}
+	*ms = modulations
+	return nil
+}
+
+// IsListFlag tells Parse to collect repeated flags rather than keep the last
+func (ms *Modulations) IsListFlag() bool { return true }
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadProperties parses a Java properties style file: one key/value pair per
+// line separated by '=' or ':', with '#' and '!' introducing comment lines.
+func ReadProperties(r io.Reader) (map[string]string, error) {
+	lines, err := readProperties(r)
+	if err != nil {
+		return nil, err
+	}
+	props := make(map[string]string, len(lines))
+	for key, p := range lines {
+		props[key] = p.value
+	}
+	return props, nil
+}
+
+// property is a value read from a properties file, with the line it is on
+type property struct {
+	value string
+	line  int
+}
+
+// readProperties parses a properties file as ReadProperties does, keeping
+// the line each value is on
+func readProperties(r io.Reader) (map[string]property, error) {
+	props := make(map[string]property)
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			// A bare key is a flag such as "silent"
+			props[line] = property{line: lineNo}
+			continue
+		}
+		key := strings.TrimSpace(line[:sep])
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", lineNo)
+		}
+		props[key] = property{value: strings.TrimSpace(line[sep+1:]), line: lineNo}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SectionTrigger is an event of the board that starts a new section
+type SectionTrigger string
+
+const (
+	// OnCycle starts a section when the board enters a cycle
+	OnCycle SectionTrigger = "cycle"
+	// OnCollapse starts a section when the population falls below a quarter
+	// of its peak since the last collapse
+	OnCollapse SectionTrigger = "collapse"
+	// OnReseed starts a section when the board is reseeded
+	OnReseed SectionTrigger = "reseed"
+)
+
+var sectionTriggers = []SectionTrigger{OnCycle, OnCollapse, OnReseed}
+
+// Section moves a layer's channels on to another instrument at a generation,
+// every so many generations or whenever an event of the board happens,
+// stepping through Programs in turn each time
+type Section struct {
+	At       int            // generation the section starts on; 0 for none
+	Every    int            // generations between sections; 0 for none
+	On       SectionTrigger // event starting a section; empty for none
+	Programs []int          // General MIDI programs 1 to 128, selected in turn
+	Bank     int            // bank selected first, as MSB*128+LSB; negative keeps the bank
+	Channel  int            // MIDI channel 1 to 16; 0 for each of the layer's channels
+}
+
+// String formats the section as Set reads it
+func (s Section) String() string {
+	var fields []string
+	if s.At > 0 {
+		fields = append(fields, "at="+strconv.Itoa(s.At))
+	}
+	if s.Every > 0 {
+		fields = append(fields, "every="+strconv.Itoa(s.Every))
+	}
+	if s.On != "" {
+		fields = append(fields, "on="+string(s.On))
+	}
+	programs := make([]string, len(s.Programs))
+	for i, p := range s.Programs {
+		programs[i] = strconv.Itoa(p)
+	}
+	fields = append(fields, "program="+strings.Join(programs, "/"))
+	if s.Bank >= 0 {
+		bank := strconv.Itoa(s.Bank >> 7)
+		if s.Bank&0x7f != 0 {
+			bank += ":" + strconv.Itoa(s.Bank&0x7f)
+		}
+		fields = append(fields, "bank="+bank)
+	}
+	if s.Channel != 0 {
+		fields = append(fields, "channel="+strconv.Itoa(s.Channel))
+	}
+	return strings.Join(fields, ",")
+}
+
+// parseSection reads "key=value" fields separated by commas, e.g.
+// "on=cycle,program=12/49/89" or "at=500,program=1,bank=121:1,channel=2"
+func parseSection(s string) (Section, error) {
+	sec := Section{Bank: -1}
+	for _, field := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok {
+			return sec, fmt.Errorf("section field %q is not key=value", field)
+		}
+		var err error
+		switch key {
+		case "at":
+			if err = (*intValue)(&sec.At).Set(value); err == nil && sec.At < 1 {
+				err = fmt.Errorf("generation %d is before the first", sec.At)
+			}
+		case "every":
+			if err = (*intValue)(&sec.Every).Set(value); err == nil && sec.Every < 1 {
+				err = fmt.Errorf("every %d is not a positive number of generations", sec.Every)
+			}
+		case "on":
+			err = choose(&sec.On, value, sectionTriggers, "section trigger")
+		case "program":
+			for _, p := range strings.Split(value, "/") {
+				var n int
+				if err = (*intValue)(&n).Set(p); err == nil && (n < 1 || n > 128) {
+					err = fmt.Errorf("program %d is not between 1 and 128", n)
+				}
+				if err != nil {
+					break
+				}
+				sec.Programs = append(sec.Programs, n)
+			}
+		case "bank":
+			msb, lsb, _ := strings.Cut(value, ":")
+			var hi, lo int
+			if err = (*intValue)(&hi).Set(msb); err == nil && lsb != "" {
+				err = (*intValue)(&lo).Set(lsb)
+			}
+			if err == nil && (hi < 0 || hi > 127 || lo < 0 || lo > 127) {
+				err = fmt.Errorf("bank %q is not MSB or MSB:LSB, each 0 to 127", value)
+			}
+			sec.Bank = hi<<7 | lo
+		case "channel":
+			if err = (*intValue)(&sec.Channel).Set(value); err == nil && (sec.Channel < 1 || sec.Channel > 16) {
+				err = fmt.Errorf("channel %d is not between 1 and 16", sec.Channel)
+			}
+		default:
+			err = fmt.Errorf("unknown section field %q (want at, every, on, program, bank or channel)", key)
+		}
+		if err != nil {
+			return sec, fmt.Errorf("section %q: %w", s, err)
+		}
+	}
+	if len(sec.Programs) == 0 {
+		return sec, fmt.Errorf("section %q: a program is needed", s)
+	}
+	triggers := 0
+	for _, given := range []bool{sec.At > 0, sec.Every > 0, sec.On != ""} {
+		if given {
+			triggers++
+		}
+	}
+	if triggers != 1 {
+		return sec, fmt.Errorf("section %q: give one of at, every and on", s)
+	}
+	return sec, nil
+}
+
+// Sections is a flag.Value for a list of sections separated by semicolons.
+// Repeating the flag adds a section each time.
+type Sections []Section
+
+func (ss *Sections) String() string {
+	specs := make([]string, len(*ss))
+	for i, s := range *ss {
+		specs[i] = s.String()
+	}
+	return strings.Join(specs, ";")
+}
+
+func (ss *Sections) Set(s string) error {
+	var sections Sections
+	for _, spec := range strings.Split(s, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		sec, err := parseSection(spec)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, sec)
+	}
+	*ss = sections
+	return nil
+}
+
+// IsListFlag tells Parse to collect repeated flags rather than keep the last
+func (ss *Sections) IsListFlag() bool { return true }
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// SourceKind is the kind of place a setting's value comes from
+type SourceKind int
+
+const (
+	FromDefault SourceKind = iota
+	FromFile
+	FromEnv
+	FromFlag
+)
+
+// Source is where a setting's value came from
+type Source struct {
+	Kind SourceKind
+	Name string // the file, environment variable or flag
+	Line int    // line of the file
+}
+
+// String names the source, e.g. "conways_steinway.properties:12",
+// "environment CONWAYS_STEINWAY_TEMPO" or "flag --bpm"
+func (s Source) String() string {
+	switch s.Kind {
+	case FromFile:
+		return fmt.Sprintf("%s:%d", s.Name, s.Line)
+	case FromEnv:
+		return "environment " + s.Name
+	case FromFlag:
+		return "flag --" + s.Name
+	}
+	return "default"
+}
+
+// Source returns where the setting with the properties key came from
+func (c *Config) Source(key string) Source {
+	return c.sources[key]
+}
+
+// from records that the setting with key came from s
+func (c *Config) from(key string, s Source) {
+	if c.sources == nil {
+		c.sources = make(map[string]Source)
+	}
+	c.sources[key] = s
+}
+
+// Setting is a setting's effective value and where it came from
+type Setting struct {
+	Key    string // properties key
+	Value  string
+	Source Source
+}
+
+// Settings returns every setting, in the order the options are declared
+func (c *Config) Settings() []Setting {
+	settings := make([]Setting, len(options))
+	for i, o := range options {
+		settings[i] = Setting{Key: o.key, Value: o.value(c).String(), Source: c.Source(o.key)}
+	}
+	return settings
+}
+
+// WriteSources writes each setting's value and where it came from to w, a
+// line to a setting in aligned columns, for --show-config-sources
+func (c *Config) WriteSources(w io.Writer) error {
+	file := c.File
+	if file == "" {
+		file = "none; no " + DefaultFile + " in the working directory, the executable's or any above them"
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "# configuration file: %s\n", file)
+	fmt.Fprintln(tw, "# flags win over environment variables, which win over the file, which wins over the defaults")
+	for _, s := range c.Settings() {
+		fmt.Fprintf(tw, "%s\t%s\t%v\n", s.Key, s.Value, s.Source)
+	}
+	return tw.Flush()
+}
+
+// Problem is a setting that cannot be used, with where it came from
+type Problem struct {
+	Key    string // properties key of the setting
+	Source Source
+	Err    error
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("%v: %s: %v", p.Source, p.Key, p.Err)
+}
+
+func (p Problem) Unwrap() error { return p.Err }
+
+// Problems are all the problems found with a configuration, reported
+// together so they can be fixed at once
+type Problems []Problem
+
+func (ps Problems) Error() string {
+	if len(ps) == 1 {
+		return ps[0].Error()
+	}
+	lines := []string{fmt.Sprintf("%d problems with the configuration:", len(ps))}
+	for _, p := range ps {
+		lines = append(lines, "  "+p.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Problem returns a Problem with the setting with key, naming where its
+// value came from
+func (c *Config) Problem(key string, format string, args ...any) Problem {
+	return Problem{Key: key, Source: c.Source(key), Err: fmt.Errorf(format, args...)}
+}
+
+// Validate checks that the settings can be played together, returning every
+// problem found as Problems, or nil
+func (c *Config) Validate() error {
+	var ps Problems
+	check := func(ok bool, key string, format string, args ...any) {
+		if !ok {
+			ps = append(ps, c.Problem(key, format, args...))
+		}
+	}
+	check(c.Width == life.BoardWidth, "board.width", "the board is always %d columns wide, one to each piano key, not %d; leave it out", life.BoardWidth, c.Width)
+	check(c.Height >= 1, "board.height", "the board needs at least 1 row, not %d", c.Height)
+	check(c.Density >= 0 && c.Density <= 1, "density", "%g is not a probability between 0 and 1", c.Density)
+	check(c.Generations >= 0, "generations", "%d generations cannot be played; give 0 or unlimited to play until stopped", c.Generations)
+	check(c.BPM > 0, "tempo.bpm", "the tempo must be above 0 beats a minute, not %g", c.BPM)
+	check(c.BPM <= 0 || 60000/(c.BPM*float64(max(c.GenerationsPerBeat, 1))) >= 1, "tempo.bpm", "%g bpm at %d generations a beat leaves less than a millisecond to a generation", c.BPM, c.GenerationsPerBeat)
+	check(c.GenerationsPerBeat >= 1, "tempo.generations-per-beat", "at least 1 generation must be played in a beat, not %d", c.GenerationsPerBeat)
+	check(c.StepDelay >= 0, "step.delay.ms", "a step cannot take %dms; give 0 to take the tempo from --bpm", c.StepDelay)
+	check(c.Swing >= 0 && c.Swing <= 100, "tempo.swing", "swing is a percentage between 0 and 100, not %g", c.Swing)
+	check(c.VelocityMin >= 1 && c.VelocityMin <= 127, "velocity.min", "velocity %d is not between 1 and 127", c.VelocityMin)
+	check(c.VelocityMax >= 1 && c.VelocityMax <= 127, "velocity.max", "velocity %d is not between 1 and 127", c.VelocityMax)
+	check(c.VelocityMin <= c.VelocityMax, "velocity.min", "velocity %d is above velocity.max, %d", c.VelocityMin, c.VelocityMax)
+	check(c.Lookahead >= 0, "lookahead", "the ticks cannot be played %dms after their time; give 0 or more", c.Lookahead)
+	if len(ps) == 0 {
+		return nil
+	}
+	return ps
+}
@@ -0,0 +1,365 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// Engine names a board representation
+type Engine string
+
+const (
+	// EngineGrid is the fixed-size dense grid
+	EngineGrid Engine = "grid"
+	// EngineBitPacked is the fixed-size grid stored one bit per cell
+	EngineBitPacked Engine = "bitpacked"
+	// EngineSparse is the unbounded set-backed board
+	EngineSparse Engine = "sparse"
+	// EngineHashLife is the unbounded memoised quadtree
+	EngineHashLife Engine = "hashlife"
+	// EngineIncremental is the fixed-size grid that keeps neighbour counts
+	// between generations and revisits only the cells near a change
+	EngineIncremental Engine = "incremental"
+	// EngineAnt runs Langton's Ant instead of a Life rule
+	EngineAnt Engine = "ant"
+)
+
+// Engines lists every engine name accepted by Engine.Set
+var Engines = []Engine{EngineGrid, EngineBitPacked, EngineSparse, EngineHashLife, EngineIncremental, EngineAnt}
+
+func (e *Engine) String() string { return string(*e) }
+
+func (e *Engine) Set(s string) error { return choose(e, s, Engines, "engine") }
+
+// Output says where a run's performance goes
+type Output string
+
+const (
+	// OutputTerminal draws each generation in the terminal as it is played
+	OutputTerminal Output = "terminal"
+	// OutputMIDIFile renders the generations to a Standard MIDI File without
+	// pausing between them
+	OutputMIDIFile Output = "midi-file"
+	// OutputMusicXML renders the generations to a MusicXML score, likewise
+	OutputMusicXML Output = "musicxml"
+	// OutputLilyPond renders the generations to a LilyPond score, likewise
+	OutputLilyPond Output = "lilypond"
+	// OutputABC renders a melody reduction of the generations to a tune in
+	// ABC notation, likewise
+	OutputABC Output = "abc"
+	// OutputOSC sends the notes, chords and statistics of each generation as
+	// it is played to an Open Sound Control server
+	OutputOSC Output = "osc"
+	// OutputWAV renders the generations to a WAV file, playing them on a
+	// SoundFont without pausing between them
+	OutputWAV Output = "wav"
+	// OutputJSONL writes the notes, chords and statistics of each
+	// generation as it is played as JSON Lines, one object to a line
+	OutputJSONL Output = "jsonl"
+)
+
+// Outputs lists every output accepted by Output.Set
+var Outputs = []Output{OutputTerminal, OutputMIDIFile, OutputMusicXML, OutputLilyPond, OutputABC, OutputOSC, OutputWAV, OutputJSONL}
+
+func (o *Output) String() string { return string(*o) }
+
+func (o *Output) Set(s string) error { return choose(o, s, Outputs, "output") }
+
+// ScaleFit says what happens to notes outside the configured scale
+type ScaleFit string
+
+const (
+	// FitSnap moves a note to the nearest one in the scale
+	FitSnap ScaleFit = "snap"
+	// FitDrop leaves a note out
+	FitDrop ScaleFit = "drop"
+)
+
+// ScaleFits lists every choice accepted by ScaleFit.Set
+var ScaleFits = []ScaleFit{FitSnap, FitDrop}
+
+func (f *ScaleFit) String() string { return string(*f) }
+
+func (f *ScaleFit) Set(s string) error { return choose(f, s, ScaleFits, "scale fit") }
+
+// MusicalKey names the key the performance is moved into, such as "G" or
+// "Eb", or is empty to play in the key of the scale's root
+type MusicalKey string
+
+func (k *MusicalKey) String() string { return string(*k) }
+
+func (k *MusicalKey) Set(s string) error {
+	s = strings.TrimSpace(s)
+	if s != "" {
+		if _, err := music.ParsePitchClass(s); err != nil {
+			return err
+		}
+	}
+	*k = MusicalKey(s)
+	return nil
+}
+
+// PitchClass returns the key's tonic, or false when no key is set
+func (k MusicalKey) PitchClass() (music.PitchClass, bool) {
+	if k == "" {
+		return 0, false
+	}
+	pc, err := music.ParsePitchClass(string(k))
+	return pc, err == nil
+}
+
+// CyclePolicy says what the runner does when the board enters a cycle
+type CyclePolicy string
+
+const (
+	// CycleIgnore keeps playing
+	CycleIgnore CyclePolicy = "ignore"
+	// CycleStop ends the run
+	CycleStop CyclePolicy = "stop"
+	// CycleReseed replaces the board with fresh random cells
+	CycleReseed CyclePolicy = "reseed"
+)
+
+// CyclePolicies lists every policy accepted by CyclePolicy.Set
+var CyclePolicies = []CyclePolicy{CycleIgnore, CycleStop, CycleReseed}
+
+func (p *CyclePolicy) String() string { return string(*p) }
+
+func (p *CyclePolicy) Set(s string) error { return choose(p, s, CyclePolicies, "cycle policy") }
+
+// ReseedStrategy says how the runner revives an empty or static board
+type ReseedStrategy string
+
+const (
+	// ReseedRandom replaces the whole board with random cells
+	ReseedRandom ReseedStrategy = "random"
+	// ReseedInject adds a patch of random cells, keeping the rest of the board
+	ReseedInject ReseedStrategy = "inject"
+)
+
+// ReseedStrategies lists every strategy accepted by ReseedStrategy.Set
+var ReseedStrategies = []ReseedStrategy{ReseedRandom, ReseedInject}
+
+func (r *ReseedStrategy) String() string { return string(*r) }
+
+func (r *ReseedStrategy) Set(s string) error {
+	return choose(r, s, ReseedStrategies, "reseed strategy")
+}
+
+// RestPolicy says what is played for a generation whose row strikes no keys
+type RestPolicy string
+
+const (
+	// RestSilence plays nothing
+	RestSilence RestPolicy = "silence"
+	// RestRepeat plays the last keys struck again, softly
+	RestRepeat RestPolicy = "repeat"
+	// RestPedalTone plays the root of the playing key low in the bass
+	RestPedalTone RestPolicy = "pedal-tone"
+	// RestSkip steps the board on, unheard, to the next generation that
+	// strikes keys
+	RestSkip RestPolicy = "skip"
+)
+
+// RestPolicies lists every policy accepted by RestPolicy.Set
+var RestPolicies = []RestPolicy{RestSilence, RestRepeat, RestPedalTone, RestSkip}
+
+func (p *RestPolicy) String() string { return string(*p) }
+
+func (p *RestPolicy) Set(s string) error { return choose(p, s, RestPolicies, "rest policy") }
+
+// InjectRow says which row of the board each note played on the MIDI input
+// brings to life the cell of its key's column on
+type InjectRow string
+
+const (
+	// InjectPlayed plants the cell on the row the notes are played from, so
+	// the piano answers at once
+	InjectPlayed InjectRow = "played"
+	// InjectTop plants it on the top row
+	InjectTop InjectRow = "top"
+	// InjectVelocity plants it higher up the board the harder the key is struck
+	InjectVelocity InjectRow = "velocity"
+	// InjectRandom plants it on a row chosen at random
+	InjectRandom InjectRow = "random"
+	// InjectColumn brings the key's whole column to life
+	InjectColumn InjectRow = "column"
+)
+
+// InjectRows lists every policy accepted by InjectRow.Set
+var InjectRows = []InjectRow{InjectPlayed, InjectTop, InjectVelocity, InjectRandom, InjectColumn}
+
+func (r *InjectRow) String() string { return string(*r) }
+
+func (r *InjectRow) Set(s string) error { return choose(r, s, InjectRows, "inject row policy") }
+
+// TuningMode says how a --scl tuning is sent to the MIDI output ports
+type TuningMode string
+
+const (
+	// TuningMTS retunes the synth's keys with MIDI Tuning Standard messages
+	TuningMTS TuningMode = "mts"
+	// TuningBend bends each note to its pitch on a channel of its own, as MPE
+	// plays them
+	TuningBend TuningMode = "bend"
+)
+
+// TuningModes lists every mode accepted by TuningMode.Set
+var TuningModes = []TuningMode{TuningMTS, TuningBend}
+
+func (m *TuningMode) String() string { return string(*m) }
+
+func (m *TuningMode) Set(s string) error { return choose(m, s, TuningModes, "tuning mode") }
+
+// choose sets *dst to the choice matching s, ignoring case
+func choose[T ~string](dst *T, s string, choices []T, what string) error {
+	name := T(strings.ToLower(strings.TrimSpace(s)))
+	for _, c := range choices {
+		if name == c {
+			*dst = c
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %s %q (want one of %v)", what, s, choices)
+}
+
+// boolValue is a flag.Value for a bool field. Besides Go's own spellings it
+// accepts yes/no and on/off, as the other implementations do.
+type boolValue bool
+
+func (b *boolValue) String() string { return strconv.FormatBool(bool(*b)) }
+
+func (b *boolValue) Set(s string) error {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "on", "":
+		*b = true
+		return nil
+	case "no", "off":
+		*b = false
+		return nil
+	}
+	v, err := strconv.ParseBool(strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+	*b = boolValue(v)
+	return nil
+}
+
+func (b *boolValue) IsBoolFlag() bool { return true }
+
+// intValue is a flag.Value for an int field
+type intValue int
+
+func (i *intValue) String() string { return strconv.Itoa(int(*i)) }
+
+func (i *intValue) Set(s string) error {
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+	*i = intValue(v)
+	return nil
+}
+
+// generationsValue is a flag.Value for a count of generations, which like
+// the Python and Rust players takes "unlimited" for 0, playing until stopped
+type generationsValue int
+
+func (g *generationsValue) String() string { return strconv.Itoa(int(*g)) }
+
+func (g *generationsValue) Set(s string) error {
+	if strings.EqualFold(strings.TrimSpace(s), "unlimited") {
+		*g = 0
+		return nil
+	}
+	return (*intValue)(g).Set(s)
+}
+
+// int64Value is a flag.Value for an int64 field
+type int64Value int64
+
+func (i *int64Value) String() string { return strconv.FormatInt(int64(*i), 10) }
+
+func (i *int64Value) Set(s string) error {
+	v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = int64Value(v)
+	return nil
+}
+
+// probabilityValue is a flag.Value for a float64 field between 0 and 1
+type probabilityValue float64
+
+func (p *probabilityValue) String() string {
+	return strconv.FormatFloat(float64(*p), 'g', -1, 64)
+}
+
+func (p *probabilityValue) Set(s string) error {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return err
+	}
+	if v < 0 || v > 1 {
+		return fmt.Errorf("probability %v is not between 0 and 1", v)
+	}
+	*p = probabilityValue(v)
+	return nil
+}
+
+// float64Value is a flag.Value for a float64 field
+type float64Value float64
+
+func (f *float64Value) String() string { return strconv.FormatFloat(float64(*f), 'g', -1, 64) }
+
+func (f *float64Value) Set(s string) error {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return err
+	}
+	*f = float64Value(v)
+	return nil
+}
+
+// stringValue is a flag.Value for a string field
+type stringValue string
+
+func (v *stringValue) String() string { return string(*v) }
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(strings.TrimSpace(s))
+	return nil
+}
+
+// PatternSpec names a built-in pattern and, optionally, where its top-left
+// corner goes on the visible board, written "name" or "name@x,y"
+type PatternSpec struct {
+	Name   string
+	At     life.Coord
+	Placed bool // At was given; otherwise the pattern is centred
+}
+
+func (p *PatternSpec) String() string {
+	if p.Placed {
+		return p.Name + "@" + p.At.String()
+	}
+	return p.Name
+}
+
+func (p *PatternSpec) Set(s string) error {
+	name, at, placed := strings.Cut(strings.TrimSpace(s), "@")
+	spec := PatternSpec{Name: strings.TrimSpace(name), Placed: placed}
+	if placed {
+		if err := spec.At.Set(at); err != nil {
+			return err
+		}
+	}
+	*p = spec
+	return nil
+}
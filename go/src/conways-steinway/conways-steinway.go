@@ -1 +1,283 @@
+// Command conways-steinway runs Conway's Game of Life on a board one column
+// per piano key wide.
+package main
 
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/config"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/rle"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/ruletable"
+)
+
+func main() {
+	cfg, err := config.Parse(os.Args[0], os.Args[1:])
+	var problems config.Problems
+	if err != nil && !errors.As(err, &problems) {
+		if err == flag.ErrHelp {
+			return
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if cfg.ShowSources {
+		if err := cfg.WriteSources(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if firstArg(cfg.Args) != "ports" {
+		problems = append(problems, checkPorts(cfg)...)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintln(os.Stderr, problems)
+		os.Exit(2)
+	}
+	if cfg.ShowSources {
+		return
+	}
+
+	if len(cfg.Args) == 2 && cfg.Args[0] == "patterns" && cfg.Args[1] == "list" {
+		for _, name := range rle.Builtins() {
+			fmt.Println(name)
+		}
+		return
+	}
+	if command, ok := commands[firstArg(cfg.Args)]; ok {
+		if err := command(cfg, cfg.Args[1:]); err != nil {
+			if err == flag.ErrHelp {
+				return
+			}
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := perform(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// perform plays the configured layers from the configured seed, or from the
+// clock if none is set, printing the seed so the run can be repeated; to
+// standard error when the performance streams to standard output
+func perform(cfg *config.Config) error {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	if cfg.Output == config.OutputJSONL && cfg.JSONLPath == "-" {
+		status = os.Stderr
+	}
+	fmt.Fprintf(status, "Seed %d\n", seed)
+
+	layers, err := newLayers(cfg, seed)
+	if err != nil {
+		return err
+	}
+	return run(cfg, layers, seed)
+}
+
+// commands are the subcommands, named by the first positional argument
+var commands = map[string]func(cfg *config.Config, args []string) error{
+	"search": search,
+	"breed":  breed,
+	"ports":  ports,
+	"render": render,
+}
+
+// firstArg returns the first positional argument, or "" when there is none
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// randomPopulate returns a populate function for newBoard that fills the
+// window with random cells at the configured density and symmetry
+func randomPopulate(cfg *config.Config, rng *rand.Rand) func(b life.Setter, x, y int) {
+	return func(b life.Setter, x, y int) {
+		life.RandomizeSymmetric(rng, b, x, y, life.BoardWidth, cfg.Height, cfg.Density, cfg.Symmetry)
+	}
+}
+
+// loadPattern returns the pattern the configuration starts from, read from
+// --pattern-file as readPattern does or taken from the built-in library by
+// --pattern, or nil for a random board
+func loadPattern(cfg *config.Config) (*life.Pattern, error) {
+	path := cfg.PatternFile
+	switch {
+	case path != "" && cfg.Pattern.Name != "":
+		return nil, fmt.Errorf("--pattern and --pattern-file cannot be used together")
+	case cfg.Pattern.Name != "":
+		return rle.Builtin(cfg.Pattern.Name)
+	case path == "":
+		return nil, nil
+	}
+	return readPattern(cfg, path)
+}
+
+// readPattern reads the pattern file at path, in any format rle.Read detects,
+// or from the notes of a Standard MIDI File at --pattern-midi-rows rows to the
+// quarter note, as many as fit on the board
+func readPattern(cfg *config.Config, path string) (*life.Pattern, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	if magic, _ := r.Peek(4); string(magic) == "MThd" {
+		notes, perQuarter, err := music.ReadSMF(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		perRow := int64(perQuarter / max(cfg.PatternRows, 1))
+		return music.NotePattern(notes, perRow, cfg.Height), nil
+	}
+	pattern, err := rle.Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return pattern, nil
+}
+
+// newBoard builds the board described by the configuration; populate sets its
+// starting cells in the 88-column window whose top-left corner is (x, y).
+// Langton's ants always start on an empty board, where their highway forms.
+func newBoard(cfg *config.Config, populate func(b life.Setter, x, y int)) (life.Board, error) {
+	if cfg.Height < 1 {
+		return nil, fmt.Errorf("--height must be at least 1 row, not %d", cfg.Height)
+	}
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	var plane interface {
+		life.Plane
+		life.Setter
+	}
+	if cfg.History > 0 {
+		switch {
+		case cfg.RuleFile != "":
+			return nil, fmt.Errorf("rule file: --history can only rewind the grid engine")
+		case cfg.Circuit != "":
+			return nil, fmt.Errorf("circuit: --history can only rewind the grid engine")
+		case cfg.Engine != config.EngineGrid:
+			return nil, fmt.Errorf("%s engine: --history can only rewind the grid engine", cfg.Engine)
+		}
+	}
+	if cfg.RuleFile != "" {
+		return newTableBoard(cfg, populate)
+	}
+	if cfg.Circuit != "" {
+		return newCircuitBoard(cfg)
+	}
+	switch cfg.Engine {
+	case config.EngineBitPacked:
+		if cfg.Neighbourhood != life.Moore {
+			return nil, fmt.Errorf("bitpacked engine: %w: %v neighbourhood", life.ErrUnsupportedRule, cfg.Neighbourhood)
+		}
+		if cfg.Rule.States > 2 || cfg.Rule.Colours > 1 {
+			return nil, fmt.Errorf("bitpacked engine: %w: %v", life.ErrUnsupportedRule, cfg.Rule)
+		}
+		bits := life.NewBitGrid(life.BoardWidth, cfg.Height)
+		bits.Edge = cfg.Edge
+		bits.Rule = cfg.Rule
+		bits.Workers = workers
+		populate(bits, 0, 0)
+		return bits, nil
+	case config.EngineIncremental:
+		if cfg.Rule.States > 2 || cfg.Rule.Colours > 1 {
+			return nil, fmt.Errorf("incremental engine: %w: %v", life.ErrUnsupportedRule, cfg.Rule)
+		}
+		counts := life.NewCountGrid(life.BoardWidth, cfg.Height)
+		counts.Edge = cfg.Edge
+		counts.Rule = cfg.Rule
+		counts.Neighbourhood = cfg.Neighbourhood
+		populate(counts, 0, 0)
+		return counts, nil
+	case config.EngineSparse:
+		if cfg.Rule.Colours > 1 {
+			return nil, fmt.Errorf("sparse engine: %w: %v", life.ErrUnsupportedRule, cfg.Rule)
+		}
+		sparse := life.NewSparseGrid()
+		sparse.Rule = cfg.Rule
+		sparse.Neighbourhood = cfg.Neighbourhood
+		plane = sparse
+	case config.EngineAnt:
+		if cfg.Ants < 1 {
+			return nil, fmt.Errorf("ant engine: --ants must be at least 1")
+		}
+		return life.NewAntGrid(life.BoardWidth, cfg.Height, cfg.Ants), nil
+	case config.EngineHashLife:
+		if cfg.Neighbourhood != life.Moore {
+			return nil, fmt.Errorf("hashlife engine: %w: %v neighbourhood", life.ErrUnsupportedRule, cfg.Neighbourhood)
+		}
+		hash, err := life.NewHashLife(cfg.Rule)
+		if err != nil {
+			return nil, fmt.Errorf("hashlife engine: %w: %v", err, cfg.Rule)
+		}
+		plane = hash
+	default:
+		grid := life.NewEmptyGrid(life.BoardWidth, cfg.Height)
+		grid.Edge = cfg.Edge
+		grid.Rule = cfg.Rule
+		grid.Neighbourhood = cfg.Neighbourhood
+		grid.Workers = workers
+		grid.SetHistory(cfg.History)
+		populate(grid, 0, 0)
+		return grid, nil
+	}
+	populate(plane, cfg.Viewport.X, cfg.Viewport.Y)
+	return &life.View{Plane: plane, Origin: cfg.Viewport, Width: life.BoardWidth, Height: cfg.Height}, nil
+}
+
+// newTableBoard builds a grid run by the Golly rule file named in the
+// configuration
+func newTableBoard(cfg *config.Config, populate func(b life.Setter, x, y int)) (life.Board, error) {
+	if cfg.Edge != life.EdgeDead && cfg.Edge != life.EdgeWrap {
+		return nil, fmt.Errorf("rule file: %v edges are not supported", cfg.Edge)
+	}
+	rule, err := ruletable.Load(cfg.RuleFile)
+	if err != nil {
+		return nil, err
+	}
+	grid := ruletable.NewGrid(rule, life.BoardWidth, cfg.Height)
+	grid.Wrap = cfg.Edge == life.EdgeWrap
+	populate(grid, 0, 0)
+	return grid, nil
+}
+
+// newCircuitBoard builds a Wireworld board from the circuit file named in the
+// configuration. The circuit is placed at the top-left corner so that its
+// columns line up with the piano keys they are drawn over.
+func newCircuitBoard(cfg *config.Config) (life.Board, error) {
+	f, err := os.Open(cfg.Circuit)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	circuit, err := rle.ParseWireworld(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cfg.Circuit, err)
+	}
+	if circuit.Width > life.BoardWidth || circuit.Height > cfg.Height {
+		return nil, fmt.Errorf("%s: circuit is %dx%d, larger than the %dx%d board", cfg.Circuit, circuit.Width, circuit.Height, life.BoardWidth, cfg.Height)
+	}
+	board := life.NewWireworld(life.BoardWidth, cfg.Height)
+	board.Place(circuit, 0, 0)
+	return board, nil
+}
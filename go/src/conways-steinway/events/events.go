@@ -0,0 +1,229 @@
+// Package events carries what happens during a run, such as per-generation
+// statistics and cycle detection, from the simulation to the music layer and
+// user interface.
+package events
+
+import (
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// Event is one of the event types below. Layer numbers the board an event
+// belongs to when several are played together, starting from 0.
+type Event interface {
+	// Gen returns the generation the event belongs to, or the tick of the
+	// shared clock for the note events, which are not tied to one layer
+	Gen() int
+}
+
+// Board is published before each generation is played, so subscribers can
+// draw it or turn it into notes
+type Board struct {
+	Layer      int
+	Generation int
+	Channel    int // MIDI channel of the layer
+	Board      life.Board
+}
+
+// Notes is published with each Board, carrying the piano keys its note row
+// strikes in that generation
+type Notes struct {
+	Layer      int
+	Generation int
+	Tick       int // tick of the shared clock the layer was played on
+	Channel    int
+	Keys       []music.Key // lowest first
+	Velocities []int       // velocity of each key, or nil for the sequencer's own
+	Restrike   bool        // strike the keys afresh even where they are held, as for a rest
+
+	// Expressions holds the bend and pressure of each key, for MPE, or nil
+	Expressions []music.Expression
+}
+
+// Chord is published after Notes when the keys struck form a chord, with
+// Chord.Keys as struck; the Notes keys are already voiced
+type Chord struct {
+	Layer      int
+	Generation int
+	Tick       int
+	Channel    int
+	Chord      music.Chord
+}
+
+// NoteOn is published when a note starts sounding. Its Duration is not known
+// until the matching NoteOff.
+type NoteOn struct {
+	Tick int
+	Note music.NoteEvent
+}
+
+// NoteOff is published when a note stops sounding, with its full duration
+type NoteOff struct {
+	Tick int
+	Note music.NoteEvent
+}
+
+// Pedal is published when a layer's sustain pedal goes down or comes up
+type Pedal struct {
+	Layer      int
+	Generation int
+	Tick       int
+	Channel    int
+	Down       bool
+}
+
+// Modulation is published when a measure of a layer's board moves a MIDI
+// controller to a new value
+type Modulation struct {
+	Layer      int
+	Generation int
+	Tick       int
+	Channel    int
+	Controller int
+	Value      int
+}
+
+// Phrase is published when a layer's phrase ends, on a dip in the
+// population or as the board enters a cycle, or as the performance nears its
+// end. With a cadence the layer closes the phrase with a ritardando, which
+// the sequencer plays, resolving to the tonic chord.
+type Phrase struct {
+	Layer      int
+	Generation int
+	Tick       int
+	Reason     string
+	Cadence    int // ticks of the shared clock the cadence lasts; 0 for none
+}
+
+// Control is published for each MIDI control change, such as the sustain
+// pedal's, on the sequencer's clock
+type Control struct {
+	Tick    int
+	Control music.Control
+}
+
+// Expression is published after the NoteOn and NoteOff events of a Notes
+// carrying expressions, for each key it strikes, on the sequencer's clock
+type Expression struct {
+	Tick       int
+	Expression music.Expression
+}
+
+// Program is published when the run starts for each channel given an
+// instrument, so that outputs select it before the first note, and when a
+// section of the performance changes the instrument
+type Program struct {
+	Tick    int
+	Channel int
+	Program int   // General MIDI program, 1 to 128
+	Start   int64 // tick of the sequencer's clock the program is selected on; 0 at the start
+}
+
+// Section is published when a layer's performance reaches a milestone or an
+// event, such as finding a cycle, that --section moves to a new instrument
+type Section struct {
+	Layer      int
+	Generation int
+	Tick       int // tick of the shared clock the new instrument plays from
+	Channel    int
+	Program    int    // General MIDI program, 1 to 128
+	Bank       int    // bank selected first, as MSB*128+LSB; negative keeps the bank
+	Reason     string // what started the section
+}
+
+// Loop is published when the looper captures, overdubs or clears the loop
+// of the notes played, on a controller of the MIDI input
+type Loop struct {
+	Tick   int
+	Action string // "record", "overdub" or "clear"
+	Notes  int    // notes in the loop afterwards
+}
+
+// End is published once when the run stops, so that sounding notes can be
+// ended and outputs closed
+type End struct {
+	Tick int
+}
+
+// Generation is published once for every generation played
+type Generation struct {
+	Layer      int
+	Generation int
+	Stats      life.Stats
+}
+
+// Cycle is published when the board enters a cycle
+type Cycle struct {
+	Layer      int
+	Generation int
+	Period     int
+}
+
+// Reseed is published when the runner puts fresh cells on the board
+type Reseed struct {
+	Layer      int
+	Generation int
+	Reason     string
+}
+
+// Rewind is published when a layer's board is stepped back over the
+// generations it played, to play them again
+type Rewind struct {
+	Layer      int
+	Generation int // generation played next
+	Back       int // generations stepped back
+}
+
+// RuleChange is published when the runner mutates a layer's rule, marking
+// the start of a new section of the performance
+type RuleChange struct {
+	Layer      int
+	Generation int
+	From, To   life.Rule
+}
+
+// KeyChange is published when a layer's music modulates into a new key
+type KeyChange struct {
+	Layer      int
+	Generation int
+	From, To   music.PitchClass
+}
+
+func (e Board) Gen() int      { return e.Generation }
+func (e Notes) Gen() int      { return e.Generation }
+func (e Chord) Gen() int      { return e.Generation }
+func (e NoteOn) Gen() int     { return e.Tick }
+func (e NoteOff) Gen() int    { return e.Tick }
+func (e Pedal) Gen() int      { return e.Generation }
+func (e Modulation) Gen() int { return e.Generation }
+func (e Section) Gen() int    { return e.Generation }
+func (e Phrase) Gen() int     { return e.Generation }
+func (e Control) Gen() int    { return e.Tick }
+func (e Expression) Gen() int { return e.Tick }
+func (e Program) Gen() int    { return e.Tick }
+func (e Loop) Gen() int       { return e.Tick }
+func (e End) Gen() int        { return e.Tick }
+func (e Generation) Gen() int { return e.Generation }
+func (e Cycle) Gen() int      { return e.Generation }
+func (e Reseed) Gen() int     { return e.Generation }
+func (e Rewind) Gen() int     { return e.Generation }
+func (e RuleChange) Gen() int { return e.Generation }
+func (e KeyChange) Gen() int  { return e.Generation }
+
+// Bus delivers every published event to each subscriber, in the order they
+// subscribed. It is not safe for concurrent use.
+type Bus struct {
+	handlers []func(Event)
+}
+
+// Subscribe registers fn to receive every later event
+func (b *Bus) Subscribe(fn func(Event)) {
+	b.handlers = append(b.handlers, fn)
+}
+
+// Publish delivers e to every subscriber
+func (b *Bus) Publish(e Event) {
+	for _, fn := range b.handlers {
+		fn(e)
+	}
+}
@@ -0,0 +1,18 @@
+package events
+
+import "testing"
+
+func TestBusDeliversInSubscriptionOrder(t *testing.T) {
+	var bus Bus
+	var got []string
+	bus.Subscribe(func(e Event) { got = append(got, "first") })
+	bus.Subscribe(func(e Event) {
+		if c, ok := e.(Cycle); ok && c.Period == 2 {
+			got = append(got, "second")
+		}
+	})
+	bus.Publish(Cycle{Generation: 10, Period: 2})
+	if len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Fatalf("got %v", got)
+	}
+}
@@ -0,0 +1,172 @@
+// Package jsonl writes a performance as JSON Lines, one JSON object to a
+// line for each note, chord and generation, so that visualisers, analytics
+// and programs in other languages can follow it without speaking MIDI.
+package jsonl
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// Output writes the events of a performance as they are published, each
+// object naming its type first:
+//
+//	{"type":"note_on","tick":480,"time":0.5,"channel":1,"pitch":60,"key":"C4","velocity":96}
+//	{"type":"note_off","tick":480,"time":0.5,"channel":1,"pitch":60,"key":"C4","velocity":96,"duration":120}
+//	{"type":"chord","layer":0,"generation":3,"channel":1,"root":"C","quality":"major","inversion":0,"pitches":[60,64,67]}
+//	{"type":"stats","layer":0,"generation":3,"population":412,"births":37,"deaths":41,"density":0.117}
+//	{"type":"cycle","layer":0,"generation":90,"period":2}
+//
+// Ticks are the sequencer's, TicksPerQuarter to the quarter note, and a
+// note_off's tick is the one its note started on. With KeepTime each note
+// also has the time in seconds from the start that its tick falls.
+type Output struct {
+	w     *bufio.Writer
+	c     io.Closer // closes the file written to; nil for standard output
+	name  string
+	clock *music.Clock
+	err   error // first failed write
+}
+
+// Create returns an output writing to the file at path, or to standard output
+// when path is "-"
+func Create(path string) (*Output, error) {
+	if path == "-" {
+		return &Output{w: bufio.NewWriter(os.Stdout), name: "standard output"}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Output{w: bufio.NewWriter(f), c: f, name: path}, nil
+}
+
+// NewOutput returns an output writing to w
+func NewOutput(w io.Writer) *Output {
+	return &Output{w: bufio.NewWriter(w), name: "output"}
+}
+
+// Name returns where the output writes, its path or "standard output"
+func (o *Output) Name() string { return o.name }
+
+// KeepTime makes the output give each note the time its tick falls on clock.
+// The start is not needed, as times count from it.
+func (o *Output) KeepTime(clock music.Clock, _ time.Time) {
+	o.clock = &clock
+}
+
+type note struct {
+	Type     string   `json:"type"`
+	Tick     int64    `json:"tick"`
+	Time     *float64 `json:"time,omitempty"`
+	Channel  int      `json:"channel"`
+	Pitch    int      `json:"pitch"`
+	Key      string   `json:"key"`
+	Velocity int      `json:"velocity"`
+	Duration *int64   `json:"duration,omitempty"`
+}
+
+type chord struct {
+	Type       string `json:"type"`
+	Layer      int    `json:"layer"`
+	Generation int    `json:"generation"`
+	Channel    int    `json:"channel"`
+	Root       string `json:"root"`
+	Quality    string `json:"quality"`
+	Inversion  int    `json:"inversion"`
+	Pitches    []int  `json:"pitches"`
+}
+
+type stats struct {
+	Type       string  `json:"type"`
+	Layer      int     `json:"layer"`
+	Generation int     `json:"generation"`
+	Population int     `json:"population"`
+	Births     int     `json:"births"`
+	Deaths     int     `json:"deaths"`
+	Density    float64 `json:"density"`
+}
+
+type cycle struct {
+	Type       string `json:"type"`
+	Layer      int    `json:"layer"`
+	Generation int    `json:"generation"`
+	Period     int    `json:"period"`
+}
+
+// Handle writes NoteOn, NoteOff, Chord, Generation and Cycle events, and
+// flushes what it has written after each Generation so that a reader keeps
+// up. A failed write is kept for Close to return and stops any more.
+func (o *Output) Handle(e events.Event) {
+	switch e := e.(type) {
+	case events.NoteOn:
+		o.write(o.note("note_on", e.Note))
+	case events.NoteOff:
+		n := o.note("note_off", e.Note)
+		n.Duration = &e.Note.Duration
+		o.write(n)
+	case events.Chord:
+		c := chord{Type: "chord", Layer: e.Layer, Generation: e.Generation, Channel: e.Channel,
+			Root: music.PitchClass(e.Chord.Root).String(), Quality: e.Chord.Quality.String(), Inversion: e.Chord.Inversion, Pitches: []int{}}
+		for _, k := range e.Chord.Keys {
+			c.Pitches = append(c.Pitches, k.Note())
+		}
+		o.write(c)
+	case events.Generation:
+		s := e.Stats
+		o.write(stats{Type: "stats", Layer: e.Layer, Generation: e.Generation, Population: s.Population, Births: s.Births, Deaths: s.Deaths, Density: s.Density})
+		o.flush()
+	case events.Cycle:
+		o.write(cycle{Type: "cycle", Layer: e.Layer, Generation: e.Generation, Period: e.Period})
+	}
+}
+
+// note returns the object of a note of the given type
+func (o *Output) note(kind string, n music.NoteEvent) note {
+	obj := note{Type: kind, Tick: n.Start, Channel: n.Channel, Pitch: n.Pitch, Key: music.Key(n.Pitch - music.LowestNote).String(), Velocity: n.Velocity}
+	if o.clock != nil {
+		t := o.clock.Time(n.Start).Seconds()
+		obj.Time = &t
+	}
+	return obj
+}
+
+func (o *Output) write(v any) {
+	if o.err != nil {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err == nil {
+		_, err = o.w.Write(append(b, '\n'))
+	}
+	if err != nil {
+		o.err = fmt.Errorf("%s: %w", o.name, err)
+	}
+}
+
+func (o *Output) flush() {
+	if o.err == nil {
+		if err := o.w.Flush(); err != nil {
+			o.err = fmt.Errorf("%s: %w", o.name, err)
+		}
+	}
+}
+
+// Close flushes what is left to write and closes the file, returning the
+// first error writing to it
+func (o *Output) Close() error {
+	o.flush()
+	if o.c != nil {
+		if err := o.c.Close(); err != nil && o.err == nil {
+			o.err = fmt.Errorf("%s: %w", o.name, err)
+		}
+	}
+	return o.err
+}
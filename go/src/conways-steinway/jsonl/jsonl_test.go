@@ -0,0 +1,59 @@
+package jsonl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+func TestOutput(t *testing.T) {
+	var buf bytes.Buffer
+	o := NewOutput(&buf)
+	o.KeepTime(music.NewClock(), time.Now())
+	c4 := music.NoteEvent{Pitch: 60, Velocity: 96, Start: 480, Duration: 120, Channel: 1}
+	o.Handle(events.NoteOn{Note: c4})
+	o.Handle(events.NoteOff{Note: c4})
+	o.Handle(events.Chord{Layer: 1, Generation: 3, Channel: 2, Chord: music.Chord{Root: 0, Quality: music.Major, Keys: []music.Key{39, 43, 46}}})
+	o.Handle(events.Generation{Generation: 3, Stats: life.Stats{Population: 10, Births: 2, Deaths: 1, Density: 0.25}})
+	o.Handle(events.Cycle{Generation: 4, Period: 2})
+	o.Handle(events.Pedal{Down: true})
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`{"type":"note_on","tick":480,"time":0.5,"channel":1,"pitch":60,"key":"C4","velocity":96}`,
+		`{"type":"note_off","tick":480,"time":0.5,"channel":1,"pitch":60,"key":"C4","velocity":96,"duration":120}`,
+		`{"type":"chord","layer":1,"generation":3,"channel":2,"root":"C","quality":"major","inversion":0,"pitches":[60,64,67]}`,
+		`{"type":"stats","layer":0,"generation":3,"population":10,"births":2,"deaths":1,"density":0.25}`,
+		`{"type":"cycle","layer":0,"generation":4,"period":2}`,
+	}
+	if got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("wrote\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	o, err := Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Handle(events.NoteOn{Note: music.NoteEvent{Pitch: 21, Velocity: 1, Channel: 1}})
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"type":"note_on","tick":0,"channel":1,"pitch":21,"key":"A0","velocity":1}` + "\n"; string(data) != want {
+		t.Errorf("wrote %q, want %q without a time", data, want)
+	}
+}
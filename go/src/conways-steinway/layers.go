@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/config"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// layer is one of the boards played together, with the state the runner
+// keeps for it
+type layer struct {
+	index   int
+	cfg     *config.Config // the run's configuration with the layer's overrides applied
+	board   life.Board
+	rng     *rand.Rand
+	channel int    // MIDI channel the layer's notes go to
+	parts   []part // rows of the board played and their channels
+	every   int    // shared clock ticks per generation
+
+	generation int
+	cycles     *life.CycleDetector
+	watchdog   *life.Watchdog
+	pedal      *music.Pedal
+	arc        *music.Arc
+	last       map[int]struck  // last keys struck on each channel, for the repeat rest policy
+	smoother   *music.Smoother // conditions the keys struck on the last, if --smooth is set
+	thinner    *music.Thinner  // leaves out keys at random, if --note-probability is below 1
+	modulated  map[int]int     // value last sent to each controller --cc moves
+	sections   []int           // sections each --section has started
+	peak       int             // most live cells since the last collapse
+	bass       bool            // plays a bass line rather than the keys struck
+	phrases    *music.Phrases  // finds where the layer's phrases end
+	cadence    int             // generations left of the cadence closing a phrase; 0 outside one
+
+	ahead           []voicing // what each part strikes in aheadGeneration, worked out by silent
+	aheadGeneration int
+}
+
+// newLayers builds the boards described by cfg.Layers, or a single board when
+// there are none. A pattern from --pattern or --pattern-file seeds the first
+// board; the others start random. With --couple-layers the boards are stacked
+// so that each one's births and deaths also depend on its neighbours.
+func newLayers(cfg *config.Config, seed int64) ([]*layer, error) {
+	specs := cfg.Layers
+	if len(specs) == 0 {
+		specs = config.Layers{{}}
+	}
+	if len(cfg.Channels) > 0 && len(specs) > 1 {
+		return nil, fmt.Errorf("--channel plays rows of a single board, so it cannot be combined with --layer")
+	}
+	if cfg.Bass > 0 {
+		if len(cfg.Channels) > 0 {
+			return nil, fmt.Errorf("--bass adds a board, so it cannot be combined with --channel")
+		}
+		specs = append(slices.Clone(specs), config.Layer{Every: cfg.Bass, Bass: true, Channel: freeChannel(specs)})
+	}
+	pattern, err := loadPattern(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make([]*layer, len(specs))
+	for i, spec := range specs {
+		lc := *cfg
+		if spec.Rule != (life.Rule{}) {
+			lc.Rule = spec.Rule
+		}
+		if spec.Density != 0 {
+			lc.Density = spec.Density
+		}
+		s := spec.Seed
+		if s == 0 {
+			s = seed + int64(i)
+		}
+		rng := rand.New(rand.NewSource(s))
+
+		populate := randomPopulate(&lc, rng)
+		if i == 0 && pattern != nil {
+			if pattern.Rule != nil && spec.Rule == (life.Rule{}) {
+				lc.Rule = *pattern.Rule
+			}
+			populate = placePattern(&lc, pattern)
+		}
+		board, err := newBoard(&lc, populate)
+		if err != nil {
+			return nil, err
+		}
+		if grid, ok := board.(*life.Grid); ok {
+			grid.RandomColours(rng)
+		}
+
+		l := &layer{
+			index:     i,
+			cfg:       &lc,
+			board:     board,
+			rng:       rng,
+			channel:   spec.Channel,
+			every:     max(spec.Every, 1),
+			bass:      spec.Bass,
+			cycles:    life.NewCycleDetector(lc.CycleWindow),
+			watchdog:  &life.Watchdog{Patience: lc.ReseedThreshold},
+			pedal:     &music.Pedal{Press: lc.PedalPress, Release: lc.PedalRelease, Chords: lc.PedalChords},
+			arc:       music.NewArc(lc.ArcDepth, lc.ArcAttack, lc.ArcDecay),
+			last:      make(map[int]struck),
+			modulated: make(map[int]int),
+			sections:  make([]int, len(lc.Sections)),
+			phrases:   music.NewPhrases(lc.PhraseDip, lc.PhraseLength),
+		}
+		if lc.Smooth > 0 {
+			l.smoother = music.NewSmoother(rng, lc.Smooth, lc.MaxLeap)
+		}
+		if lc.NoteProbability < 1 {
+			l.thinner = music.NewThinner(rng, lc.NoteProbability, lc.ProbabilityByAge)
+		}
+		if l.channel == 0 {
+			l.channel = i%16 + 1
+		}
+		l.parts = []part{{row: lc.NoteRow, channel: l.channel}}
+		if len(cfg.Channels) > 0 {
+			l.parts = l.parts[:0]
+			for _, c := range cfg.Channels {
+				l.parts = append(l.parts, part{row: c.Row, channel: c.Channel})
+			}
+		}
+		if cfg.Drums && i == 0 {
+			l.parts = append(l.parts, part{row: cfg.DrumRow, channel: music.DrumChannel, drums: true})
+		}
+		layers[i] = l
+	}
+	if err := checkDrums(layers); err != nil {
+		return nil, err
+	}
+	if cfg.CoupleLayers {
+		if err := stackLayers(layers); err != nil {
+			return nil, err
+		}
+	}
+	for _, l := range layers {
+		life.Advance(l.board, l.cfg.Skip)
+	}
+	return layers, nil
+}
+
+// freeChannel returns the lowest MIDI channel none of specs plays on, as
+// newLayers numbers them, passing over the drums' channel
+func freeChannel(specs config.Layers) int {
+	used := make(map[int]bool)
+	for i, spec := range specs {
+		if spec.Channel == 0 {
+			spec.Channel = i%16 + 1
+		}
+		used[spec.Channel] = true
+	}
+	for c := 1; c <= 16; c++ {
+		if !used[c] && c != music.DrumChannel {
+			return c
+		}
+	}
+	return len(specs)%16 + 1
+}
+
+// part is a board row a layer plays on a MIDI channel of its own
+type part struct {
+	row     int // board row, as config.Config.NoteRow numbers them
+	channel int
+	drums   bool // the row plays music.DrumKit rather than piano keys
+}
+
+// checkDrums reports an error when the drums' channel is also given to
+// piano notes, which would be played as drums
+func checkDrums(layers []*layer) error {
+	drums := false
+	for _, l := range layers {
+		for _, p := range l.parts {
+			drums = drums || p.drums
+		}
+	}
+	for _, l := range layers {
+		for _, p := range l.parts {
+			if drums && !p.drums && p.channel == music.DrumChannel {
+				return fmt.Errorf("--drums plays on channel %d, so no layer or row may also use it", music.DrumChannel)
+			}
+		}
+	}
+	return nil
+}
+
+// stackLayers couples the layers' grids into a life.Stack, bottom layer
+// first, so that each one's cells also count the cells beside them in the
+// layers above and below
+func stackLayers(layers []*layer) error {
+	grids := make([]*life.Grid, len(layers))
+	for i, l := range layers {
+		grid, ok := l.board.(*life.Grid)
+		if !ok {
+			return fmt.Errorf("--couple-layers needs the grid engine")
+		}
+		if l.cfg.History > 0 {
+			return fmt.Errorf("--couple-layers steps the layers together, so they cannot be rewound with --history")
+		}
+		grids[i] = grid
+	}
+	stack, err := life.NewStack(grids...)
+	if err != nil {
+		return fmt.Errorf("--couple-layers: %w", err)
+	}
+	for i, l := range layers {
+		l.board = stack.Layer(i)
+	}
+	return nil
+}
+
+// placePattern returns a populate function for newBoard that stamps pattern
+// where --pattern put it, or in the middle of the window
+func placePattern(cfg *config.Config, pattern *life.Pattern) func(b life.Setter, x, y int) {
+	at := life.Coord{X: (life.BoardWidth - pattern.Width) / 2, Y: (cfg.Height - pattern.Height) / 2}
+	if cfg.Pattern.Placed {
+		at = cfg.Pattern.At
+	}
+	return func(b life.Setter, x, y int) {
+		pattern.Place(b, x+at.X, y+at.Y)
+	}
+}
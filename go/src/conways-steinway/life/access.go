@@ -0,0 +1,46 @@
+package life
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOutOfBounds is returned by the checked accessors for coordinates off the grid
+var ErrOutOfBounds = errors.New("coordinates out of bounds")
+
+// Get reports whether the cell at (x, y) is alive, or returns an error
+// wrapping ErrOutOfBounds if (x, y) is off the grid
+func (g *Grid) Get(x, y int) (bool, error) {
+	if err := g.check(x, y); err != nil {
+		return false, err
+	}
+	return g.Cells[g.Index(x, y)].Alive, nil
+}
+
+// Set brings the cell at (x, y) to life or kills it, as SetAlive does, or
+// returns an error wrapping ErrOutOfBounds if (x, y) is off the grid
+func (g *Grid) Set(x, y int, alive bool) error {
+	if err := g.check(x, y); err != nil {
+		return err
+	}
+	g.SetAlive(x, y, alive)
+	return nil
+}
+
+// Toggle flips the cell at (x, y) between alive and dead, or returns an error
+// wrapping ErrOutOfBounds if (x, y) is off the grid
+func (g *Grid) Toggle(x, y int) error {
+	if err := g.check(x, y); err != nil {
+		return err
+	}
+	g.SetAlive(x, y, !g.Cells[g.Index(x, y)].Alive)
+	return nil
+}
+
+// check returns an error wrapping ErrOutOfBounds if (x, y) is off the grid
+func (g *Grid) check(x, y int) error {
+	if !g.InBounds(x, y) {
+		return fmt.Errorf("%w: (%d, %d) on a %dx%d grid", ErrOutOfBounds, x, y, g.Width, g.Height)
+	}
+	return nil
+}
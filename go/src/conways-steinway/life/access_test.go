@@ -0,0 +1,44 @@
+package life
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckedAccessors(t *testing.T) {
+	g := NewEmptyGrid(3, 2)
+	if err := g.Set(2, 1, true); err != nil {
+		t.Fatal(err)
+	}
+	if alive, err := g.Get(2, 1); err != nil || !alive {
+		t.Fatalf("Get(2, 1) = %v, %v, want alive", alive, err)
+	}
+	if err := g.Toggle(2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Toggle(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, g,
+		"#..",
+		"...",
+	)
+}
+
+func TestCheckedAccessorsRejectOffGrid(t *testing.T) {
+	g := NewEmptyGrid(3, 2)
+	for _, p := range []Coord{{-1, 0}, {0, -1}, {3, 0}, {0, 2}} {
+		if _, err := g.Get(p.X, p.Y); !errors.Is(err, ErrOutOfBounds) {
+			t.Fatalf("Get(%v) error = %v, want ErrOutOfBounds", p, err)
+		}
+		if err := g.Set(p.X, p.Y, true); !errors.Is(err, ErrOutOfBounds) {
+			t.Fatalf("Set(%v) error = %v, want ErrOutOfBounds", p, err)
+		}
+		if err := g.Toggle(p.X, p.Y); !errors.Is(err, ErrOutOfBounds) {
+			t.Fatalf("Toggle(%v) error = %v, want ErrOutOfBounds", p, err)
+		}
+	}
+	if g.Stats().Population != 0 {
+		t.Fatal("rejected writes should not change the grid")
+	}
+}
@@ -0,0 +1,76 @@
+package life
+
+// span is the range of columns of one row holding live or dying cells; lo >
+// hi when the row has none
+type span struct{ lo, hi int }
+
+// radius returns how far, in rows or columns, a cell's neighbours reach
+func (n Neighbourhood) radius() int {
+	r := 0
+	for _, o := range n.offsets() {
+		r = max(r, o.dx, -o.dx, o.dy, -o.dy)
+	}
+	if n == Hexagonal {
+		r = max(r, 1)
+	}
+	return r
+}
+
+// findActive records, for each row, the columns its live and dying cells
+// span, so Step can skip the dead area around them. It reports false when
+// every cell has to be visited anyway: under a B0 rule dead cells with no
+// live neighbours are born, and under EdgeAlive the border always has some.
+func (g *Grid) findActive() bool {
+	if g.Rule.Birth&1 != 0 || g.Edge == EdgeAlive {
+		return false
+	}
+	if len(g.spans) != g.Height {
+		g.spans = make([]span, g.Height)
+	}
+	for y := range g.spans {
+		row := g.Cells[y*g.Width : (y+1)*g.Width]
+		s := span{lo: g.Width, hi: -1}
+		for x, c := range row {
+			if c.Alive || c.State > 0 {
+				s.lo = x
+				break
+			}
+		}
+		for x := len(row) - 1; x >= s.lo; x-- {
+			if c := row[x]; c.Alive || c.State > 0 {
+				s.hi = x
+				break
+			}
+		}
+		g.spans[y] = s
+	}
+	return true
+}
+
+// activeColumns returns the columns of row y whose next state can differ
+// from dead: those within the neighbourhood's reach of a live or dying cell
+func (g *Grid) activeColumns(y int) (lo, hi int) {
+	r := g.Neighbourhood.radius()
+	lo, hi = g.Width, -1
+	for dy := -r; dy <= r; dy++ {
+		ny := y + dy
+		if ny < 0 || ny >= g.Height {
+			if g.Edge != EdgeWrap {
+				continue
+			}
+			ny = wrap(ny, g.Height)
+		}
+		if s := g.spans[ny]; s.lo <= s.hi {
+			lo, hi = min(lo, s.lo), max(hi, s.hi)
+		}
+	}
+	if lo > hi {
+		return lo, hi
+	}
+	lo, hi = lo-r, hi+r
+	if g.Edge == EdgeWrap && (lo < 0 || hi >= g.Width) {
+		// Cells near one border influence the other
+		return 0, g.Width - 1
+	}
+	return max(lo, 0), min(hi, g.Width-1)
+}
@@ -0,0 +1,64 @@
+package life
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// fullStep computes the next generation of g visiting every cell, as Step did
+// before dead areas were skipped
+func fullStep(g *Grid) []Cell {
+	next := make([]Cell, len(g.Cells))
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			i := g.Index(x, y)
+			next[i] = g.Rule.Advance(g.Cells[i], g.neighboursCount(x, y))
+		}
+	}
+	return next
+}
+
+func TestStepSkipsOnlyDeadAreas(t *testing.T) {
+	rules := []string{"B3/S23", "B36/S23", "B2/S/C3", "B5-7/S4,6,8-10"}
+	rng := rand.New(rand.NewSource(11))
+	for _, rule := range rules {
+		for n := range neighbourhoods {
+			for edge := range edgeModeNames {
+				g := NewEmptyGrid(30, 24)
+				g.Rule, _ = ParseRule(rule)
+				g.Neighbourhood = Neighbourhood(n)
+				g.Edge = EdgeMode(edge)
+				// A few small clumps, some touching the borders
+				for i := 0; i < 6; i++ {
+					Randomize(rng, g, rng.Intn(g.Width-3), rng.Intn(g.Height-3), 4, 4, 0.5)
+				}
+				Randomize(rng, g, g.Width-3, 0, 3, 3, 0.6)
+				for gen := 0; gen < 12; gen++ {
+					want := fullStep(g)
+					g.Step()
+					for i := range want {
+						if g.Cells[i] != want[i] {
+							t.Fatalf("%s %v %v generation %d: cell %d,%d = %+v, want %+v",
+								rule, g.Neighbourhood, g.Edge, gen, i%g.Width, i/g.Width, g.Cells[i], want[i])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkGridStepSparse steps a late-game board: a handful of blocks and
+// blinkers on an otherwise dead 88x512 grid
+func BenchmarkGridStepSparse(b *testing.B) {
+	g := NewEmptyGrid(BoardWidth, 512)
+	block := NewPattern([]Coord{{0, 0}, {1, 0}, {0, 1}, {1, 1}})
+	blinker := NewPattern([]Coord{{0, 0}, {1, 0}, {2, 0}})
+	for i := 0; i < 8; i++ {
+		g.Place(block, 10*i+3, 60*i+10)
+		g.Place(blinker, 10*i+6, 60*i+40)
+	}
+	for b.Loop() {
+		g.Step()
+	}
+}
@@ -0,0 +1,136 @@
+package life
+
+import (
+	"fmt"
+	"io"
+)
+
+// Direction is the way an ant faces
+type Direction int
+
+// The directions an ant can face, clockwise from north
+const (
+	North Direction = iota
+	East
+	South
+	West
+)
+
+// step returns the offset of the cell in front of an ant facing d
+func (d Direction) step() (dx, dy int) {
+	switch d {
+	case North:
+		return 0, -1
+	case East:
+		return 1, 0
+	case South:
+		return 0, 1
+	default:
+		return -1, 0
+	}
+}
+
+// Ant is one of the walkers on an AntGrid
+type Ant struct {
+	X, Y   int
+	Facing Direction
+}
+
+// AntGrid runs Langton's Ant on a toroidal board. Each Step every ant, in
+// turn, turns right on a dead cell or left on a live one, flips that cell and
+// moves forward one cell. From an empty board a single ant wanders chaotically
+// for about ten thousand steps before building its diagonal highway.
+type AntGrid struct {
+	Width  int
+	Height int
+	Cells  []bool
+	Ants   []Ant
+
+	Generation int // number of generations stepped
+
+	births, deaths int // changes made by the last Step
+}
+
+// NewAntGrid returns an empty board with n ants spaced evenly along its middle
+// row, all facing north
+func NewAntGrid(width, height, n int) *AntGrid {
+	g := &AntGrid{Width: width, Height: height, Cells: make([]bool, width*height)}
+	for i := 0; i < n; i++ {
+		g.Ants = append(g.Ants, Ant{X: (i + 1) * width / (n + 1), Y: height / 2, Facing: North})
+	}
+	return g
+}
+
+// Size returns the width and height of the board
+func (g *AntGrid) Size() (width, height int) { return g.Width, g.Height }
+
+// Alive reports whether the cell at (x, y) is alive; cells off the board are dead
+func (g *AntGrid) Alive(x, y int) bool {
+	return x >= 0 && x < g.Width && y >= 0 && y < g.Height && g.Cells[x+y*g.Width]
+}
+
+// SetAlive sets the state of the cell at (x, y); coordinates off the board are ignored
+func (g *AntGrid) SetAlive(x, y int, alive bool) {
+	if x >= 0 && x < g.Width && y >= 0 && y < g.Height {
+		g.Cells[x+y*g.Width] = alive
+	}
+}
+
+// Step moves every ant once. Ants sharing a cell each flip it, in the order
+// they appear in Ants.
+func (g *AntGrid) Step() {
+	g.births, g.deaths = 0, 0
+	for i := range g.Ants {
+		a := &g.Ants[i]
+		cell := &g.Cells[a.X+a.Y*g.Width]
+		if *cell {
+			a.Facing = (a.Facing + 3) % 4
+			g.deaths++
+		} else {
+			a.Facing = (a.Facing + 1) % 4
+			g.births++
+		}
+		*cell = !*cell
+		dx, dy := a.Facing.step()
+		a.X, a.Y = wrap(a.X+dx, g.Width), wrap(a.Y+dy, g.Height)
+	}
+	g.Generation++
+}
+
+// Stats returns births and deaths for the last generation together with the
+// current population and density
+func (g *AntGrid) Stats() Stats {
+	n := 0
+	for _, alive := range g.Cells {
+		if alive {
+			n++
+		}
+	}
+	return newStats(g.births, g.deaths, n, len(g.Cells))
+}
+
+// antChars draws an ant by the way it faces
+var antChars = [...]byte{North: '^', East: '>', South: 'v', West: '<'}
+
+// Print writes the board to w, drawing each ant as an arrow over its cell
+func (g *AntGrid) Print(w io.Writer) {
+	ants := make(map[int]Direction, len(g.Ants))
+	for _, a := range g.Ants {
+		ants[a.X+a.Y*g.Width] = a.Facing
+	}
+	row := make([]byte, g.Width)
+	for y := 0; y < g.Height; y++ {
+		for x := range row {
+			i := x + y*g.Width
+			switch d, ok := ants[i]; {
+			case ok:
+				row[x] = antChars[d]
+			case g.Cells[i]:
+				row[x] = '#'
+			default:
+				row[x] = '.'
+			}
+		}
+		fmt.Fprintf(w, "%s\n", row)
+	}
+}
@@ -0,0 +1,65 @@
+package life
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAntFirstSteps(t *testing.T) {
+	g := NewAntGrid(5, 5, 1)
+	// Four dead cells in a row turn the ant right each time, leaving a block
+	Advance(g, 4)
+	if a := g.Ants[0]; a != (Ant{X: 2, Y: 2, Facing: North}) {
+		t.Fatalf("after 4 steps the ant is at %+v, want back at the start facing north", a)
+	}
+	var b strings.Builder
+	g.Print(&b)
+	want := ".....\n.....\n..^#.\n..##.\n.....\n"
+	if b.String() != want {
+		t.Fatalf("unexpected board:\n%swant:\n%s", b.String(), want)
+	}
+	// The block's corner is live, so the ant turns left and clears it
+	g.Step()
+	if a := g.Ants[0]; a != (Ant{X: 1, Y: 2, Facing: West}) || g.Alive(2, 2) {
+		t.Fatalf("after 5 steps the ant is at %+v, alive(2,2) = %v", a, g.Alive(2, 2))
+	}
+	if s := g.Stats(); s.Population != 3 || s.Deaths != 1 {
+		t.Fatalf("stats = %+v, want 3 live cells after one death", s)
+	}
+}
+
+func TestAntWrapsAround(t *testing.T) {
+	g := NewAntGrid(3, 3, 0)
+	g.Ants = []Ant{{X: 2, Y: 0, Facing: North}}
+	g.Step()
+	if a := g.Ants[0]; a.X != 0 || a.Y != 0 {
+		t.Fatalf("ant at %+v, want wrapped to (0, 0)", a)
+	}
+}
+
+func TestAntHighway(t *testing.T) {
+	g := NewAntGrid(200, 200, 1)
+	Advance(g, 11000)
+	before, pop := g.Ants[0], g.Stats().Population
+	// The highway repeats every 104 steps, two cells further along a diagonal
+	Advance(g, 104)
+	after := g.Ants[0]
+	dx, dy := after.X-before.X, after.Y-before.Y
+	if (dx != 2 && dx != -2) || (dy != 2 && dy != -2) || after.Facing != before.Facing {
+		t.Fatalf("ant moved from %+v to %+v, want two cells diagonally", before, after)
+	}
+	if grown := g.Stats().Population - pop; grown != 12 {
+		t.Fatalf("highway period added %d cells, want 12", grown)
+	}
+}
+
+func TestAntsShareTheBoard(t *testing.T) {
+	g := NewAntGrid(9, 3, 2)
+	if len(g.Ants) != 2 || g.Ants[0].X != 3 || g.Ants[1].X != 6 {
+		t.Fatalf("ants = %+v, want two spread along the middle row", g.Ants)
+	}
+	g.Step()
+	if !g.Alive(3, 1) || !g.Alive(6, 1) {
+		t.Fatal("each ant should flip the cell it started on")
+	}
+}
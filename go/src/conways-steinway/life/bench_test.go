@@ -0,0 +1,129 @@
+package life
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// stepper is a board the engine benchmarks can seed and step
+type stepper interface {
+	Setter
+	Step()
+}
+
+// engines builds an empty board of each representation
+var engines = []struct {
+	name string
+	new  func(width, height int) stepper
+}{
+	{"grid", func(w, h int) stepper { return NewEmptyGrid(w, h) }},
+	{"bitpacked", func(w, h int) stepper { return NewBitGrid(w, h) }},
+	{"incremental", func(w, h int) stepper { return NewCountGrid(w, h) }},
+	{"sparse", func(w, h int) stepper { return NewSparseGrid() }},
+	{"hashlife", func(w, h int) stepper {
+		hl, _ := NewHashLife(Conway)
+		return hl
+	}},
+}
+
+var benchSizes = []struct {
+	name          string
+	width, height int
+}{
+	{"small", BoardWidth, 20},
+	{"medium", 256, 256},
+	{"large", 1024, 1024},
+}
+
+// benchSeeds return the live cells a benchmark board starts with
+var benchSeeds = []struct {
+	name  string
+	cells func(width, height int) []Coord
+}{
+	{"random", func(w, h int) []Coord {
+		rng := rand.New(rand.NewSource(1))
+		var cells []Coord
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				if rng.Float64() < 0.35 {
+					cells = append(cells, Coord{x, y})
+				}
+			}
+		}
+		return cells
+	}},
+	{"structured", func(w, h int) []Coord {
+		// A lattice of 16x16 tiles, each holding a glider, a blinker and a block
+		tile := []Coord{
+			{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2},
+			{8, 4}, {9, 4}, {10, 4},
+			{4, 10}, {5, 10}, {4, 11}, {5, 11},
+		}
+		var cells []Coord
+		for ty := 0; ty+16 <= h; ty += 16 {
+			for tx := 0; tx+16 <= w; tx += 16 {
+				for _, c := range tile {
+					cells = append(cells, Coord{tx + c.X, ty + c.Y})
+				}
+			}
+		}
+		return cells
+	}},
+}
+
+// benchRestart is how many generations a benchmark board is stepped before
+// it is rebuilt, so the timings are not of a board long since settled
+const benchRestart = 100
+
+// BenchmarkEngines steps every representation on small, medium and large
+// boards seeded with random soup and with a lattice of simple patterns, e.g.
+//
+//	go test -run '^$' -bench 'Engines/medium' ./life
+func BenchmarkEngines(b *testing.B) {
+	for _, size := range benchSizes {
+		for _, seed := range benchSeeds {
+			cells := seed.cells(size.width, size.height)
+			for _, engine := range engines {
+				build := func() stepper {
+					s := engine.new(size.width, size.height)
+					for _, c := range cells {
+						s.SetAlive(c.X, c.Y, true)
+					}
+					return s
+				}
+				b.Run(fmt.Sprintf("%s/%s/%s", size.name, seed.name, engine.name), func(b *testing.B) {
+					s := build()
+					gen := 0
+					for b.Loop() {
+						if gen == benchRestart {
+							b.StopTimer()
+							s, gen = build(), 0
+							b.StartTimer()
+						}
+						s.Step()
+						gen++
+					}
+				})
+			}
+		}
+	}
+}
+
+// BenchmarkNeighboursCount counts the neighbours of every cell of a random
+// 88x20 grid in each neighbourhood
+func BenchmarkNeighboursCount(b *testing.B) {
+	for n := range neighbourhoods {
+		b.Run(Neighbourhood(n).String(), func(b *testing.B) {
+			g := NewGrid(BoardWidth, 20, rand.New(rand.NewSource(1)), 0.35)
+			g.Neighbourhood = Neighbourhood(n)
+			for b.Loop() {
+				for y := 0; y < g.Height; y++ {
+					for x := 0; x < g.Width; x++ {
+						g.neighboursCount(x, y)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package life
+
+import (
+	"io"
+	"math/bits"
+)
+
+// BitGrid is a fixed-size board storing one bit per cell, 64 cells to a
+// word, and counting neighbours for a whole word at a time with bit-sliced
+// adders. It runs two-state B/S rules on the Moore neighbourhood; the dying
+// states of Generations rules are not tracked.
+type BitGrid struct {
+	Width  int
+	Height int
+	Edge   EdgeMode
+	Rule   Rule
+
+	Workers int // goroutines Step splits the rows between; 0 or 1 steps serially
+
+	stride int      // words per row
+	words  []uint64 // row-major, bit i of word k in a row is column 64k+i
+	next   []uint64
+	last   uint64 // mask of the columns in use in a row's final word
+
+	births, deaths int // changes made by the last Step
+}
+
+// NewBitGrid returns an empty bit-packed board running Conway's rule
+func NewBitGrid(width, height int) *BitGrid {
+	stride := (width + 63) / 64
+	last := ^uint64(0)
+	if r := width % 64; r != 0 {
+		last = 1<<r - 1
+	}
+	return &BitGrid{
+		Width:  width,
+		Height: height,
+		Rule:   Conway,
+		stride: stride,
+		words:  make([]uint64, stride*height),
+		next:   make([]uint64, stride*height),
+		last:   last,
+	}
+}
+
+// Size returns the width and height of the grid
+func (b *BitGrid) Size() (width, height int) { return b.Width, b.Height }
+
+// Alive reports whether the cell at (x, y) is alive; cells off the grid are dead
+func (b *BitGrid) Alive(x, y int) bool {
+	if x < 0 || x >= b.Width || y < 0 || y >= b.Height {
+		return false
+	}
+	return b.words[y*b.stride+x/64]>>(x%64)&1 == 1
+}
+
+// SetAlive sets the state of the cell at (x, y); coordinates off the grid are ignored
+func (b *BitGrid) SetAlive(x, y int, alive bool) {
+	if x < 0 || x >= b.Width || y < 0 || y >= b.Height {
+		return
+	}
+	w := &b.words[y*b.stride+x/64]
+	if alive {
+		*w |= 1 << (x % 64)
+	} else {
+		*w &^= 1 << (x % 64)
+	}
+}
+
+// SetRule changes the rule the following generations are stepped with
+func (b *BitGrid) SetRule(r Rule) { b.Rule = r }
+
+// Population returns the number of live cells
+func (b *BitGrid) Population() int {
+	n := 0
+	for _, w := range b.words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// row returns the words of row y, resolving rows beyond the top and bottom
+// borders according to the edge mode; ones is an all-alive row
+func (b *BitGrid) row(y int, ones []uint64) []uint64 {
+	if y >= 0 && y < b.Height {
+		return b.words[y*b.stride : (y+1)*b.stride]
+	}
+	switch b.Edge {
+	case EdgeWrap:
+		y = wrap(y, b.Height)
+	case EdgeMirror:
+		y = mirror(y, b.Height)
+	case EdgeAlive:
+		return ones
+	default:
+		return nil
+	}
+	return b.words[y*b.stride : (y+1)*b.stride]
+}
+
+// outside returns the cells just beyond the left and right ends of a row
+func (b *BitGrid) outside(r []uint64) (left, right uint64) {
+	if r == nil {
+		return 0, 0
+	}
+	first := r[0] & 1
+	end := r[len(r)-1] >> ((b.Width - 1) % 64) & 1
+	switch b.Edge {
+	case EdgeWrap:
+		return end, first
+	case EdgeMirror:
+		return first, end
+	case EdgeAlive:
+		return 1, 1
+	default:
+		return 0, 0
+	}
+}
+
+// Step simulates one generation using the grid's Rule
+func (b *BitGrid) Step() {
+	var ones []uint64
+	if b.Edge == EdgeAlive {
+		ones = make([]uint64, b.stride)
+		for k := range ones {
+			ones[k] = ^uint64(0)
+		}
+		ones[b.stride-1] = b.last
+	}
+
+	var birth, survive [9]bool
+	for n := 0; n <= 8; n++ {
+		birth[n] = b.Rule.Birth&(1<<n) != 0
+		survive[n] = b.Rule.Survive&(1<<n) != 0
+	}
+
+	parallelRows(b.Height, b.Workers, func(y0, y1 int) {
+		b.stepRows(y0, y1, ones, &birth, &survive)
+	})
+	b.births, b.deaths = 0, 0
+	for i, w := range b.words {
+		b.births += bits.OnesCount64(b.next[i] &^ w)
+		b.deaths += bits.OnesCount64(w &^ b.next[i])
+	}
+	b.words, b.next = b.next, b.words
+}
+
+// stepRows computes rows [y0, y1) of the next generation
+func (b *BitGrid) stepRows(y0, y1 int, ones []uint64, birth, survive *[9]bool) {
+	for y := y0; y < y1; y++ {
+		rows := [3][]uint64{b.row(y-1, ones), b.row(y, ones), b.row(y+1, ones)}
+		var edges [3][2]uint64
+		for i, r := range rows {
+			edges[i][0], edges[i][1] = b.outside(r)
+		}
+		out := b.next[y*b.stride : (y+1)*b.stride]
+		for k := 0; k < b.stride; k++ {
+			// Four bit planes of a per-cell counter, incremented once for
+			// each of the eight neighbour words
+			var s0, s1, s2, s3 uint64
+			add := func(x uint64) {
+				c0 := s0 & x
+				s0 ^= x
+				c1 := s1 & c0
+				s1 ^= c0
+				c2 := s2 & c1
+				s2 ^= c1
+				s3 |= c2
+			}
+			for i, r := range rows {
+				var mid, west, east uint64
+				if r != nil {
+					mid = r[k]
+					west = mid << 1
+					east = mid >> 1
+					if k > 0 {
+						west |= r[k-1] >> 63
+					} else {
+						west |= edges[i][0]
+					}
+					if k < b.stride-1 {
+						east |= r[k+1] << 63
+					} else {
+						east |= edges[i][1] << ((b.Width - 1) % 64)
+					}
+				}
+				add(west)
+				add(east)
+				if i != 1 {
+					add(mid)
+				}
+			}
+
+			alive := rows[1][k]
+			var next uint64
+			for n := 0; n <= 8; n++ {
+				if !birth[n] && !survive[n] {
+					continue
+				}
+				eq := ^uint64(0)
+				for bit, plane := range [4]uint64{s0, s1, s2, s3} {
+					if n>>bit&1 == 1 {
+						eq &= plane
+					} else {
+						eq &^= plane
+					}
+				}
+				if survive[n] {
+					next |= alive & eq
+				}
+				if birth[n] {
+					next |= ^alive & eq
+				}
+			}
+			if k == b.stride-1 {
+				next &= b.last
+			}
+			out[k] = next
+		}
+	}
+}
+
+// Print writes the current state of the game board to w
+func (b *BitGrid) Print(w io.Writer) {
+	printCells(w, b.Width, b.Height, false, func(x, y int) Cell {
+		return Cell{Alive: b.Alive(x, y)}
+	})
+}
@@ -0,0 +1,72 @@
+package life
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// randomPair returns a dense grid and a bit-packed grid with the same cells
+func randomPair(width, height int, seed int64) (*Grid, *BitGrid) {
+	rng := rand.New(rand.NewSource(seed))
+	g := NewEmptyGrid(width, height)
+	b := NewBitGrid(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			alive := rng.Intn(3) == 0
+			g.SetAlive(x, y, alive)
+			b.SetAlive(x, y, alive)
+		}
+	}
+	return g, b
+}
+
+func TestBitGridMatchesGrid(t *testing.T) {
+	highLife, _ := ParseRule("B36/S23")
+	for _, edge := range []EdgeMode{EdgeDead, EdgeWrap, EdgeAlive, EdgeMirror} {
+		for _, rule := range []Rule{Conway, highLife} {
+			// 88 leaves a partial final word; 128 fills both exactly
+			for _, width := range []int{88, 128, 5} {
+				g, b := randomPair(width, 30, int64(width))
+				g.Edge, b.Edge = edge, edge
+				g.Rule, b.Rule = rule, rule
+				for gen := 1; gen <= 20; gen++ {
+					g.Step()
+					b.Step()
+					for y := 0; y < g.Height; y++ {
+						for x := 0; x < g.Width; x++ {
+							if g.Alive(x, y) != b.Alive(x, y) {
+								t.Fatalf("%v %v width %d generation %d: cell %d,%d differs", edge, rule, width, gen, x, y)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBitGridPopulation(t *testing.T) {
+	b := NewBitGrid(88, 3)
+	b.SetAlive(0, 1, true)
+	b.SetAlive(87, 1, true)
+	b.SetAlive(200, 1, true) // ignored
+	if b.Population() != 2 {
+		t.Fatalf("Population = %d, want 2", b.Population())
+	}
+}
+
+func BenchmarkGridStep88x512(b *testing.B) {
+	g, _ := randomPair(88, 512, 1)
+	b.ReportAllocs()
+	for b.Loop() {
+		g.Step()
+	}
+}
+
+func BenchmarkBitGridStep88x512(b *testing.B) {
+	_, bg := randomPair(88, 512, 1)
+	b.ReportAllocs()
+	for b.Loop() {
+		bg.Step()
+	}
+}
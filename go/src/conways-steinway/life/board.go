@@ -0,0 +1,106 @@
+package life
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Board is implemented by every board representation so the runner and the
+// music layer can drive them interchangeably
+type Board interface {
+	// Size returns the dimensions of the visible board
+	Size() (width, height int)
+	// Alive reports whether the cell at (x, y) is alive
+	Alive(x, y int) bool
+	// Step advances the board by one generation
+	Step()
+	// Print writes the visible board to w
+	Print(w io.Writer)
+}
+
+// Advancer is implemented by boards that can move many generations ahead
+// faster than stepping one at a time
+type Advancer interface {
+	Advance(n int)
+}
+
+// Advance moves b forward n generations, using its Advancer if it has one
+func Advance(b interface{ Step() }, n int) {
+	if a, ok := b.(Advancer); ok {
+		a.Advance(n)
+		return
+	}
+	for i := 0; i < n; i++ {
+		b.Step()
+	}
+}
+
+// Ager is implemented by boards that track how long each cell has lived
+type Ager interface {
+	// Age returns how many generations the cell at (x, y) has survived
+	Age(x, y int) int
+}
+
+// Setter is implemented by boards whose cells can be set directly
+type Setter interface {
+	SetAlive(x, y int, alive bool)
+}
+
+// Randomize brings each cell of the given rectangle of b to life with
+// probability density, drawing from rng
+func Randomize(rng *rand.Rand, b Setter, x, y, width, height int, density float64) {
+	RandomizeSymmetric(rng, b, x, y, width, height, density, SymmetryNone)
+}
+
+// Coord is a cell position
+type Coord struct {
+	X, Y int
+}
+
+func (c Coord) String() string { return fmt.Sprintf("%d,%d", c.X, c.Y) }
+
+// Set implements flag.Value, parsing "x,y"
+func (c *Coord) Set(s string) error {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid coordinate %q (want x,y)", s)
+	}
+	x, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return fmt.Errorf("invalid coordinate %q: %w", s, err)
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return fmt.Errorf("invalid coordinate %q: %w", s, err)
+	}
+	c.X, c.Y = x, y
+	return nil
+}
+
+// printCells renders a width by height block of cells, one row per line
+func printCells(w io.Writer, width, height int, hex bool, cell func(x, y int) Cell) {
+	for y := 0; y < height; y++ {
+		if hex && y&1 == 1 {
+			fmt.Fprint(w, " ")
+		}
+		for x := 0; x < width; x++ {
+			if hex && x > 0 {
+				fmt.Fprint(w, " ")
+			}
+			switch c := cell(x, y); {
+			case c.Alive && c.Colour > 0:
+				fmt.Fprintf(w, "%c", 'A'+c.Colour-1)
+			case c.Alive:
+				fmt.Fprint(w, "#")
+			case c.Dying():
+				fmt.Fprint(w, "+")
+			default:
+				fmt.Fprint(w, ".")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
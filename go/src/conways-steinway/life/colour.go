@@ -0,0 +1,73 @@
+package life
+
+import "math/rand"
+
+// neighbourCell returns the cell at (x, y) as a neighbour sees it, resolving
+// coordinates beyond the border like neighbour does
+func (g *Grid) neighbourCell(x, y int) Cell {
+	if g.InBounds(x, y) {
+		return g.Cells[g.Index(x, y)]
+	}
+	switch g.Edge {
+	case EdgeWrap:
+		return g.Cells[g.Index(wrap(x, g.Width), wrap(y, g.Height))]
+	case EdgeAlive:
+		return Cell{Alive: true}
+	case EdgeMirror:
+		return g.Cells[g.Index(mirror(x, g.Width), mirror(y, g.Height))]
+	default:
+		return Cell{}
+	}
+}
+
+// birthColour returns the colour of a cell born at (x, y): the colour most of
+// its live neighbours have, the one missing from three differently coloured
+// parents under QuadLife, and otherwise the lowest of the tied colours
+func (g *Grid) birthColour(x, y int) uint8 {
+	var counts [maxColours + 1]int
+	parents := 0
+	for _, o := range g.Neighbourhood.offsetsAt(y) {
+		if c := g.neighbourCell(x+o.dx, y+o.dy); c.Alive {
+			counts[c.Colour]++
+			parents++
+		}
+	}
+	best, tied := 0, false
+	for colour := 1; colour < len(counts); colour++ {
+		switch {
+		case counts[colour] > counts[best]:
+			best, tied = colour, false
+		case counts[colour] == counts[best] && counts[colour] > 0:
+			tied = true
+		}
+	}
+	if tied && g.Rule.Colours == 4 && parents == 3 && counts[0] == 0 {
+		for colour := 1; colour <= 4; colour++ {
+			if counts[colour] == 0 {
+				return uint8(colour)
+			}
+		}
+	}
+	return uint8(best)
+}
+
+// SetColour sets the colour of the live cell at (x, y); dead cells and
+// coordinates off the grid are ignored
+func (g *Grid) SetColour(x, y int, colour uint8) {
+	if g.Alive(x, y) {
+		g.Cells[g.Index(x, y)].Colour = colour
+	}
+}
+
+// RandomColours gives every live cell a colour from 1 to the rule's colour
+// count, drawn from rng. It does nothing for uncoloured rules.
+func (g *Grid) RandomColours(rng *rand.Rand) {
+	if g.Rule.Colours < 2 {
+		return
+	}
+	for i := range g.Cells {
+		if g.Cells[i].Alive {
+			g.Cells[i].Colour = uint8(1 + rng.Intn(g.Rule.Colours))
+		}
+	}
+}
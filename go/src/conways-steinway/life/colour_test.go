@@ -0,0 +1,84 @@
+package life
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// colourGrid builds a grid from rows where digits are live cells of that
+// colour and '.' is dead
+func colourGrid(rule Rule, rows ...string) *Grid {
+	g := NewEmptyGrid(len(rows[0]), len(rows))
+	g.Rule = rule
+	for y, row := range rows {
+		for x, ch := range row {
+			if ch != '.' {
+				g.Cells[g.Index(x, y)] = Cell{Alive: true, Colour: uint8(ch - '0')}
+			}
+		}
+	}
+	return g
+}
+
+func TestImmigrationMajority(t *testing.T) {
+	g := colourGrid(Immigration,
+		".....",
+		".1...",
+		"..2..",
+		"...2.",
+		".....",
+	)
+	g.Step()
+	// The middle of the diagonal survives and keeps its colour
+	if c := g.Cells[g.Index(2, 2)]; !c.Alive || c.Colour != 2 {
+		t.Fatalf("centre = %+v, want a surviving colour 2 cell", c)
+	}
+	g = colourGrid(Immigration,
+		"1.2",
+		"...",
+		".1.",
+	)
+	g.Step()
+	if c := g.Cells[g.Index(1, 1)]; !c.Alive || c.Colour != 1 {
+		t.Fatalf("newborn = %+v, want colour 1 from two of its three parents", c)
+	}
+}
+
+func TestQuadLifeMissingColour(t *testing.T) {
+	g := colourGrid(QuadLife,
+		"1.2",
+		"...",
+		".4.",
+	)
+	g.Step()
+	if c := g.Cells[g.Index(1, 1)]; !c.Alive || c.Colour != 3 {
+		t.Fatalf("newborn = %+v, want colour 3, the one its parents lack", c)
+	}
+}
+
+func TestColouredRulesMatchLife(t *testing.T) {
+	plain := NewGrid(BoardWidth, 20, rand.New(rand.NewSource(4)), 0.4)
+	coloured := NewEmptyGrid(plain.Width, plain.Height)
+	copy(coloured.Cells, plain.Cells)
+	coloured.Rule = QuadLife
+	coloured.RandomColours(rand.New(rand.NewSource(5)))
+	for i := 0; i < 20; i++ {
+		plain.Step()
+		coloured.Step()
+	}
+	assertRows(t, coloured, plain.rows()...)
+	for i, c := range coloured.Cells {
+		if c.Alive && (c.Colour < 1 || c.Colour > 4) {
+			t.Fatalf("cell %d has colour %d", i, c.Colour)
+		}
+	}
+}
+
+func TestPrintColours(t *testing.T) {
+	var buf bytes.Buffer
+	colourGrid(QuadLife, "1.4", ".2.").Print(&buf)
+	if got := buf.String(); got != "A.D\n.B.\n" {
+		t.Fatalf("Print = %q", got)
+	}
+}
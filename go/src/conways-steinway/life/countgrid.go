@@ -0,0 +1,238 @@
+package life
+
+import "io"
+
+// CountGrid is a fixed-size board that keeps every cell's live neighbour
+// count and updates the counts only when a cell is born or dies. Step then
+// looks only at the cells that changed in the last generation and their
+// neighbours, since nothing else can change, so a board of still lifes and
+// a few oscillators costs almost nothing however large it is. It runs
+// two-state B/S rules on any neighbourhood and edge mode; the dying states
+// of Generations rules and colours are not tracked.
+type CountGrid struct {
+	Width  int
+	Height int
+	Edge   EdgeMode
+	Rule   Rule
+
+	Neighbourhood Neighbourhood
+
+	Generation int // number of generations stepped
+
+	cells  []bool
+	counts []uint8 // live neighbours of each cell
+
+	// watchers[start[i]:start[i+1]] are the cells that count cell i as a
+	// neighbour, once for each time they see it
+	start    []int32
+	watchers []int32
+
+	built         bool // counts and watchers match Edge and Neighbourhood
+	edge          EdgeMode
+	neighbourhood Neighbourhood
+
+	full    bool    // the next Step looks at every cell
+	changed []int32 // cells flipped since the last Step looked at them
+	queue   []int32 // scratch list of the cells the current Step looks at
+	mark    []bool  // cells already in the queue
+
+	population     int
+	births, deaths int // changes made by the last Step
+}
+
+// NewCountGrid returns an empty board running Conway's rule
+func NewCountGrid(width, height int) *CountGrid {
+	return &CountGrid{
+		Width:  width,
+		Height: height,
+		Rule:   Conway,
+		cells:  make([]bool, width*height),
+	}
+}
+
+// Size returns the width and height of the grid
+func (g *CountGrid) Size() (width, height int) { return g.Width, g.Height }
+
+// Alive reports whether the cell at (x, y) is alive; cells off the grid are dead
+func (g *CountGrid) Alive(x, y int) bool {
+	return x >= 0 && x < g.Width && y >= 0 && y < g.Height && g.cells[x+y*g.Width]
+}
+
+// SetAlive sets the state of the cell at (x, y); coordinates off the grid are ignored
+func (g *CountGrid) SetAlive(x, y int, alive bool) {
+	if x < 0 || x >= g.Width || y < 0 || y >= g.Height {
+		return
+	}
+	i := int32(x + y*g.Width)
+	if g.cells[i] == alive {
+		return
+	}
+	g.flip(i)
+	g.changed = append(g.changed, i)
+}
+
+// SetRule changes the rule the following generations are stepped with. Every
+// cell is looked at again by the next Step.
+func (g *CountGrid) SetRule(r Rule) {
+	g.Rule = r
+	g.full = true
+}
+
+// Population returns the number of live cells
+func (g *CountGrid) Population() int { return g.population }
+
+// Step advances the board by one generation
+func (g *CountGrid) Step() {
+	if !g.built || g.edge != g.Edge || g.neighbourhood != g.Neighbourhood {
+		g.build()
+	}
+
+	// A cell whose state and count are unchanged since it was last computed
+	// would only be given the same state again
+	queue := g.queue[:0]
+	if g.full {
+		for i := range g.cells {
+			queue = append(queue, int32(i))
+		}
+		g.full = false
+	} else {
+		for _, i := range g.changed {
+			queue = g.enqueue(queue, i)
+			for _, w := range g.watchers[g.start[i]:g.start[i+1]] {
+				queue = g.enqueue(queue, w)
+			}
+		}
+		for _, i := range queue {
+			g.mark[i] = false
+		}
+	}
+
+	// Flips are filtered into the front of the queue in place
+	flips := queue[:0]
+	for _, i := range queue {
+		if g.Rule.Next(g.cells[i], int(g.counts[i])) != g.cells[i] {
+			flips = append(flips, i)
+		}
+	}
+	g.births, g.deaths = 0, 0
+	for _, i := range flips {
+		g.flip(i)
+		if g.cells[i] {
+			g.births++
+		} else {
+			g.deaths++
+		}
+	}
+	g.changed = append(g.changed[:0], flips...)
+	g.queue = queue
+	g.Generation++
+}
+
+// enqueue adds cell i to queue unless it is already there
+func (g *CountGrid) enqueue(queue []int32, i int32) []int32 {
+	if g.mark[i] {
+		return queue
+	}
+	g.mark[i] = true
+	return append(queue, i)
+}
+
+// flip toggles cell i, updating the counts of the cells that see it
+func (g *CountGrid) flip(i int32) {
+	g.cells[i] = !g.cells[i]
+	d := uint8(1)
+	if g.cells[i] {
+		g.population++
+	} else {
+		g.population--
+		d = ^uint8(0) // subtracts one
+	}
+	if !g.built {
+		return
+	}
+	for _, w := range g.watchers[g.start[i]:g.start[i+1]] {
+		g.counts[w] += d
+	}
+}
+
+// build works out which cells see each other under the current edge mode
+// and neighbourhood and counts every cell's neighbours from scratch. The
+// next Step then looks at every cell.
+func (g *CountGrid) build() {
+	n := len(g.cells)
+	g.counts = make([]uint8, n)
+	g.start = make([]int32, n+1)
+	g.mark = make([]bool, n)
+	g.edge, g.neighbourhood = g.Edge, g.Neighbourhood
+
+	// The first pass sizes each cell's list of watchers, the second fills them in
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			for _, o := range g.Neighbourhood.offsetsAt(y) {
+				if j, _ := g.resolve(x+o.dx, y+o.dy); j >= 0 {
+					g.start[j+1]++
+				}
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		g.start[i+1] += g.start[i]
+	}
+	g.watchers = make([]int32, g.start[n])
+	fill := append([]int32(nil), g.start[:n]...)
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			w := x + y*g.Width
+			for _, o := range g.Neighbourhood.offsetsAt(y) {
+				j, alive := g.resolve(x+o.dx, y+o.dy)
+				if j < 0 {
+					if alive {
+						g.counts[w]++
+					}
+					continue
+				}
+				g.watchers[fill[j]] = int32(w)
+				fill[j]++
+				if g.cells[j] {
+					g.counts[w]++
+				}
+			}
+		}
+	}
+	g.built, g.full = true, true
+	g.changed = g.changed[:0]
+}
+
+// resolve returns the index of the cell the neighbour at (x, y) refers to
+// under the edge mode, or -1 when it lies beyond the border; alive then
+// reports whether it counts as live
+func (g *CountGrid) resolve(x, y int) (i int, alive bool) {
+	inside := x >= 0 && x < g.Width && y >= 0 && y < g.Height
+	if !inside {
+		switch g.Edge {
+		case EdgeWrap:
+			x, y = wrap(x, g.Width), wrap(y, g.Height)
+		case EdgeMirror:
+			x, y = mirror(x, g.Width), mirror(y, g.Height)
+		case EdgeAlive:
+			return -1, true
+		default:
+			return -1, false
+		}
+	}
+	return x + y*g.Width, false
+}
+
+// Stats returns births and deaths for the last generation together with the
+// current population and density
+func (g *CountGrid) Stats() Stats {
+	return newStats(g.births, g.deaths, g.population, len(g.cells))
+}
+
+// Print writes the current state of the game board to w. Hexagonal boards
+// are drawn with odd rows offset by half a cell.
+func (g *CountGrid) Print(w io.Writer) {
+	printCells(w, g.Width, g.Height, g.Neighbourhood == Hexagonal, func(x, y int) Cell {
+		return Cell{Alive: g.cells[x+y*g.Width]}
+	})
+}
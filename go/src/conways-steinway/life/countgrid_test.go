@@ -0,0 +1,123 @@
+package life
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCountGridMatchesGrid(t *testing.T) {
+	rules := []string{"B3/S23", "B36/S23", "B0123/S8", "B5-7/S4,6,8-10"}
+	for _, rule := range rules {
+		for n := range neighbourhoods {
+			for edge := range edgeModeNames {
+				rng := rand.New(rand.NewSource(int64(n*10 + edge)))
+				g := NewEmptyGrid(30, 24)
+				c := NewCountGrid(30, 24)
+				g.Rule, _ = ParseRule(rule)
+				c.Rule = g.Rule
+				g.Neighbourhood, c.Neighbourhood = Neighbourhood(n), Neighbourhood(n)
+				g.Edge, c.Edge = EdgeMode(edge), EdgeMode(edge)
+				for i := 0; i < 8; i++ {
+					x, y := rng.Intn(g.Width-4), rng.Intn(g.Height-4)
+					Randomize(rand.New(rand.NewSource(int64(i))), g, x, y, 5, 5, 0.5)
+					Randomize(rand.New(rand.NewSource(int64(i))), c, x, y, 5, 5, 0.5)
+				}
+				for gen := 1; gen <= 15; gen++ {
+					if gen == 8 {
+						// Cells set between generations are picked up too
+						g.SetAlive(15, 12, !g.Alive(15, 12))
+						c.SetAlive(15, 12, !c.Alive(15, 12))
+					}
+					g.Step()
+					c.Step()
+					for y := 0; y < g.Height; y++ {
+						for x := 0; x < g.Width; x++ {
+							if g.Alive(x, y) != c.Alive(x, y) {
+								t.Fatalf("%s %v %v generation %d: cell %d,%d differs", rule, g.Neighbourhood, g.Edge, gen, x, y)
+							}
+						}
+					}
+					if gs, cs := g.Stats(), c.Stats(); gs != cs {
+						t.Fatalf("%s %v %v generation %d: stats %+v, want %+v", rule, g.Neighbourhood, g.Edge, gen, cs, gs)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCountGridRebuildsOnEdgeChange(t *testing.T) {
+	c := NewCountGrid(5, 3)
+	for x := 0; x < 3; x++ {
+		c.SetAlive(x, 0, true)
+	}
+	c.Step()
+	c.Edge = EdgeWrap
+	c.Step()
+	// The vertical blinker at column 1 wraps through the bottom row
+	g := gridFromRows(
+		".#...",
+		".#...",
+		".....",
+	)
+	g.Edge = EdgeWrap
+	g.Step()
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 5; x++ {
+			if g.Alive(x, y) != c.Alive(x, y) {
+				t.Fatalf("cell %d,%d differs after changing the edge mode", x, y)
+			}
+		}
+	}
+}
+
+// staticBoard fills a board with blocks, leaving room in the middle for one
+// blinker to keep it from being entirely still
+func staticBoard(s Setter, width, height int) {
+	block := NewPattern([]Coord{{0, 0}, {1, 0}, {0, 1}, {1, 1}})
+	cx, cy := width/2, height/2
+	for y := 1; y+2 < height; y += 4 {
+		for x := 1; x+2 < width; x += 4 {
+			if abs(x-cx) > 6 || abs(y-cy) > 6 {
+				block.Place(s, x, y)
+			}
+		}
+	}
+	NewPattern([]Coord{{0, 0}, {1, 0}, {2, 0}}).Place(s, cx-1, cy)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func BenchmarkGridStepStatic(b *testing.B) {
+	g := NewEmptyGrid(BoardWidth, 512)
+	staticBoard(g, g.Width, g.Height)
+	for b.Loop() {
+		g.Step()
+	}
+}
+
+func BenchmarkCountGridStepStatic(b *testing.B) {
+	c := NewCountGrid(BoardWidth, 512)
+	staticBoard(c, c.Width, c.Height)
+	c.Step()
+	b.ReportAllocs()
+	for b.Loop() {
+		c.Step()
+	}
+}
+
+func BenchmarkCountGridStep88x512(b *testing.B) {
+	g, _ := randomPair(88, 512, 1)
+	c := NewCountGrid(88, 512)
+	for i, cell := range g.Cells {
+		c.SetAlive(i%88, i/88, cell.Alive)
+	}
+	for b.Loop() {
+		c.Step()
+	}
+}
@@ -0,0 +1,82 @@
+package life
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Crossover says how Cross combines two parent boards
+type Crossover int
+
+const (
+	// CrossoverHalves takes the left half from the first parent and the
+	// right half, the upper keys, from the second
+	CrossoverHalves Crossover = iota
+	// CrossoverRows takes even rows from the first parent and odd rows from
+	// the second
+	CrossoverRows
+	// CrossoverColumns takes even columns from the first parent and odd
+	// columns from the second
+	CrossoverColumns
+)
+
+var crossoverNames = [...]string{
+	CrossoverHalves:  "halves",
+	CrossoverRows:    "rows",
+	CrossoverColumns: "columns",
+}
+
+func (c Crossover) String() string {
+	if c < 0 || int(c) >= len(crossoverNames) {
+		return fmt.Sprintf("Crossover(%d)", int(c))
+	}
+	return crossoverNames[c]
+}
+
+// ParseCrossover converts a name such as "rows" into a Crossover
+func ParseCrossover(s string) (Crossover, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for c, n := range crossoverNames {
+		if n == name {
+			return Crossover(c), nil
+		}
+	}
+	return CrossoverHalves, fmt.Errorf("invalid crossover %q (want %s)", s, strings.Join(crossoverNames[:], ", "))
+}
+
+// Set implements flag.Value
+func (c *Crossover) Set(s string) error {
+	v, err := ParseCrossover(s)
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
+
+// Cross breeds a new board from a and b, taking each cell from one parent or
+// the other as how says. The child has a's size, rule, edge mode and
+// neighbourhood; cells beyond b's edges count as dead.
+func Cross(a, b *Grid, how Crossover) *Grid {
+	child := a.Clone()
+	child.Generation = 0
+	for y := 0; y < child.Height; y++ {
+		for x := 0; x < child.Width; x++ {
+			var fromB bool
+			switch how {
+			case CrossoverRows:
+				fromB = y%2 == 1
+			case CrossoverColumns:
+				fromB = x%2 == 1
+			default:
+				fromB = x >= child.Width/2
+			}
+			if fromB {
+				child.SetAlive(x, y, b.Alive(x, y))
+			} else {
+				child.SetAlive(x, y, a.Alive(x, y))
+			}
+		}
+	}
+	return child
+}
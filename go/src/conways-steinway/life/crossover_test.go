@@ -0,0 +1,53 @@
+package life
+
+import "testing"
+
+func TestCross(t *testing.T) {
+	a := gridFromRows(
+		"####",
+		"####",
+	)
+	b := gridFromRows(
+		"....",
+		"....",
+	)
+	tests := []struct {
+		how  Crossover
+		want []string
+	}{
+		{CrossoverHalves, []string{"##..", "##.."}},
+		{CrossoverRows, []string{"####", "...."}},
+		{CrossoverColumns, []string{"#.#.", "#.#."}},
+	}
+	for _, tt := range tests {
+		child := Cross(a, b, tt.how)
+		assertRows(t, child, tt.want...)
+		if child == a || child.Generation != 0 {
+			t.Fatalf("%v: the child should be a new board at generation 0", tt.how)
+		}
+	}
+	if !a.Alive(3, 1) {
+		t.Fatal("Cross changed a parent")
+	}
+}
+
+func TestCrossSmallerParent(t *testing.T) {
+	a := gridFromRows(
+		"....",
+		"....",
+	)
+	b := gridFromRows("###")
+	assertRows(t, Cross(a, b, CrossoverHalves), "..#.", "....")
+}
+
+func TestParseCrossover(t *testing.T) {
+	for c := range crossoverNames {
+		got, err := ParseCrossover(Crossover(c).String())
+		if err != nil || got != Crossover(c) {
+			t.Fatalf("ParseCrossover(%q) = %v, %v", Crossover(c), got, err)
+		}
+	}
+	if _, err := ParseCrossover("spliced"); err == nil {
+		t.Fatal("expected an error for an unknown crossover")
+	}
+}
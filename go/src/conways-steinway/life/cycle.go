@@ -0,0 +1,41 @@
+package life
+
+// CycleDetector watches successive generations of a board and reports when
+// one repeats an earlier state: a still life has period 1, a blinker period 2
+// and so on. Only the most recent generations are remembered, so cycles
+// longer than the window go unnoticed.
+type CycleDetector struct {
+	window int
+	seen   map[uint64]int // state hash to the generation it was last seen
+	order  []uint64       // hashes in the order they were observed, oldest first
+}
+
+// NewCycleDetector returns a detector remembering the last window generations
+func NewCycleDetector(window int) *CycleDetector {
+	return &CycleDetector{window: max(window, 1), seen: make(map[uint64]int)}
+}
+
+// Observe records the state of b at generation gen. When the state matches
+// one seen within the window it returns the cycle's period and true.
+func (d *CycleDetector) Observe(gen int, b Board) (period int, ok bool) {
+	h := Hash(b)
+	if prev, found := d.seen[h]; found && prev < gen {
+		period, ok = gen-prev, true
+	}
+	d.seen[h] = gen
+	d.order = append(d.order, h)
+	if len(d.order) > d.window {
+		old := d.order[0]
+		d.order = d.order[1:]
+		if d.seen[old] <= gen-d.window {
+			delete(d.seen, old)
+		}
+	}
+	return period, ok
+}
+
+// Reset forgets every observed state, e.g. after the board has been reseeded
+func (d *CycleDetector) Reset() {
+	clear(d.seen)
+	d.order = d.order[:0]
+}
@@ -0,0 +1,65 @@
+package life
+
+import "testing"
+
+func TestCycleDetectorPeriods(t *testing.T) {
+	tests := []struct {
+		name   string
+		rows   []string
+		period int
+	}{
+		{"block", []string{"....", ".##.", ".##.", "...."}, 1},
+		{"blinker", []string{".....", "..#..", "..#..", "..#..", "....."}, 2},
+	}
+	for _, tt := range tests {
+		g := gridFromRows(tt.rows...)
+		d := NewCycleDetector(16)
+		d.Observe(g.Generation, g)
+		var period int
+		var ok bool
+		for !ok && g.Generation < 10 {
+			g.Step()
+			period, ok = d.Observe(g.Generation, g)
+		}
+		if !ok || period != tt.period {
+			t.Fatalf("%s: period %d (found %v), want %d", tt.name, period, ok, tt.period)
+		}
+	}
+}
+
+func TestCycleDetectorIgnoresGliderOnTorusUntilItReturns(t *testing.T) {
+	g := gridFromRows(
+		".#....",
+		"..#...",
+		"###...",
+		"......",
+		"......",
+		"......",
+	)
+	g.Edge = EdgeWrap
+	d := NewCycleDetector(64)
+	d.Observe(g.Generation, g)
+	for g.Generation < 23 {
+		g.Step()
+		if _, ok := d.Observe(g.Generation, g); ok {
+			t.Fatalf("unexpected cycle at generation %d", g.Generation)
+		}
+	}
+	// On a 6x6 torus the glider is back where it started after 24 steps
+	g.Step()
+	if period, ok := d.Observe(g.Generation, g); !ok || period != 24 {
+		t.Fatalf("period %d (found %v), want 24", period, ok)
+	}
+}
+
+func TestCycleDetectorWindow(t *testing.T) {
+	g := gridFromRows(".....", "..#..", "..#..", "..#..", ".....")
+	d := NewCycleDetector(1)
+	d.Observe(g.Generation, g)
+	for i := 0; i < 4; i++ {
+		g.Step()
+		if _, ok := d.Observe(g.Generation, g); ok {
+			t.Fatal("a period-2 cycle should not be seen with a window of 1")
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package life
+
+// Clone returns a copy of the grid's cells and settings, without its history
+func (g *Grid) Clone() *Grid {
+	c := NewEmptyGrid(g.Width, g.Height)
+	copy(c.Cells, g.Cells)
+	c.Edge, c.Rule, c.Neighbourhood, c.Workers = g.Edge, g.Rule, g.Neighbourhood, g.Workers
+	c.Generation = g.Generation
+	return c
+}
+
+// Diff returns the cells that are alive in g but not in prev (born) and alive
+// in prev but not in g (died), row by row. Keeping a Clone of the last
+// generation and diffing against it lets a caller handle only the cells that
+// changed. Cells of g beyond prev's edges count as dead in prev, and cells of
+// prev beyond g's edges are ignored.
+func (g *Grid) Diff(prev *Grid) (born, died []Coord) {
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			now, was := g.Cells[g.Index(x, y)].Alive, prev.Alive(x, y)
+			switch {
+			case now && !was:
+				born = append(born, Coord{x, y})
+			case was && !now:
+				died = append(died, Coord{x, y})
+			}
+		}
+	}
+	return born, died
+}
@@ -0,0 +1,61 @@
+package life
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffBlinker(t *testing.T) {
+	g := gridFromRows(
+		".....",
+		".....",
+		".###.",
+		".....",
+		".....",
+	)
+	prev := g.Clone()
+	g.Step()
+	born, died := g.Diff(prev)
+	if want := []Coord{{2, 1}, {2, 3}}; !slices.Equal(born, want) {
+		t.Fatalf("born = %v, want %v", born, want)
+	}
+	if want := []Coord{{1, 2}, {3, 2}}; !slices.Equal(died, want) {
+		t.Fatalf("died = %v, want %v", died, want)
+	}
+	s := g.Stats()
+	if s.Births != len(born) || s.Deaths != len(died) {
+		t.Fatalf("stats %+v disagree with the diff", s)
+	}
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	g := gridFromRows(
+		"##",
+		"##",
+	)
+	if born, died := g.Diff(g.Clone()); born != nil || died != nil {
+		t.Fatalf("Diff of a copy = %v, %v, want nothing", born, died)
+	}
+}
+
+func TestDiffAgainstSmallerGrid(t *testing.T) {
+	g := gridFromRows(
+		"#.#",
+		"...",
+	)
+	prev := gridFromRows("#")
+	born, died := g.Diff(prev)
+	if !slices.Equal(born, []Coord{{2, 0}}) || died != nil {
+		t.Fatalf("Diff = %v, %v", born, died)
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	g := gridFromRows("#.")
+	g.Rule = BriansBrain
+	c := g.Clone()
+	g.SetAlive(1, 0, true)
+	if c.Alive(1, 0) || c.Rule != BriansBrain {
+		t.Fatal("the clone should keep its own cells and the original's rule")
+	}
+}
@@ -0,0 +1,91 @@
+package life
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EdgeMode controls what neighboursCount sees beyond the border of the grid
+type EdgeMode int
+
+const (
+	// EdgeDead treats every cell beyond the border as dead
+	EdgeDead EdgeMode = iota
+	// EdgeWrap joins opposite borders, turning the grid into a torus
+	EdgeWrap
+	// EdgeAlive treats every cell beyond the border as alive
+	EdgeAlive
+	// EdgeMirror reflects the cells along the border back onto the grid
+	EdgeMirror
+)
+
+var edgeModeNames = [...]string{
+	EdgeDead:   "dead",
+	EdgeWrap:   "wrap",
+	EdgeAlive:  "alive",
+	EdgeMirror: "mirror",
+}
+
+func (m EdgeMode) String() string {
+	if m < 0 || int(m) >= len(edgeModeNames) {
+		return fmt.Sprintf("EdgeMode(%d)", int(m))
+	}
+	return edgeModeNames[m]
+}
+
+// ParseEdgeMode converts a name such as "wrap" into an EdgeMode
+func ParseEdgeMode(s string) (EdgeMode, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for m, n := range edgeModeNames {
+		if n == name {
+			return EdgeMode(m), nil
+		}
+	}
+	return EdgeDead, fmt.Errorf("invalid edge mode %q (want dead, wrap, alive or mirror)", s)
+}
+
+// Set implements flag.Value
+func (m *EdgeMode) Set(s string) error {
+	v, err := ParseEdgeMode(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// neighbour reports whether the cell at (x, y) counts as a live neighbour,
+// resolving coordinates beyond the border according to the grid's EdgeMode
+func (g *Grid) neighbour(x, y int) bool {
+	if g.InBounds(x, y) {
+		return g.Cells[g.Index(x, y)].Alive
+	}
+	switch g.Edge {
+	case EdgeWrap:
+		return g.Alive(wrap(x, g.Width), wrap(y, g.Height))
+	case EdgeAlive:
+		return true
+	case EdgeMirror:
+		return g.Alive(mirror(x, g.Width), mirror(y, g.Height))
+	default:
+		return false
+	}
+}
+
+// wrap maps i onto [0, n) toroidally
+func wrap(i, n int) int {
+	return ((i % n) + n) % n
+}
+
+// mirror reflects i back onto [0, n), repeating the border cell
+func mirror(i, n int) int {
+	for i < 0 || i >= n {
+		if i < 0 {
+			i = -i - 1
+		}
+		if i >= n {
+			i = 2*n - i - 1
+		}
+	}
+	return i
+}
@@ -1,3 +0,0 @@
-module github.com/Jeff-Lowrey/conways-steinway/go/conways-steninway/life
-
-go 1.24.2
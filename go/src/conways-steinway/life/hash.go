@@ -0,0 +1,58 @@
+package life
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// Hasher is implemented by boards with a faster way to compute Hash
+type Hasher interface {
+	Hash() uint64
+}
+
+// Hash returns a stable 64-bit hash of the visible board's live cells, using
+// the board's own Hash method when it has one. It is the 64-bit FNV-1a hash
+// of the width and height as 32-bit little-endian integers followed by the
+// cells in reading order, packed eight to a byte with the first cell in the
+// least significant bit and the last byte padded with zeros. Other
+// implementations computing the same bytes get the same hash.
+func Hash(b Board) uint64 {
+	if h, ok := b.(Hasher); ok {
+		return h.Hash()
+	}
+	width, height := b.Size()
+	return hashCells(width, height, b.Alive)
+}
+
+// Hash returns the board's hash as described by the package-level Hash
+func (g *Grid) Hash() uint64 {
+	return hashCells(g.Width, g.Height, func(x, y int) bool { return g.Cells[x+y*g.Width].Alive })
+}
+
+func hashCells(width, height int, alive func(x, y int) bool) uint64 {
+	h := fnv.New64a()
+	var header [8]byte
+	binary.LittleEndian.PutUint32(header[:4], uint32(width))
+	binary.LittleEndian.PutUint32(header[4:], uint32(height))
+	h.Write(header[:])
+
+	buf := make([]byte, 0, (width*height+7)/8)
+	var b byte
+	var bit uint
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if alive(x, y) {
+				b |= 1 << bit
+			}
+			if bit++; bit == 8 {
+				buf = append(buf, b)
+				b, bit = 0, 0
+			}
+		}
+	}
+	if bit > 0 {
+		buf = append(buf, b)
+	}
+	h.Write(buf)
+	return h.Sum64()
+}
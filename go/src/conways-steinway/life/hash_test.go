@@ -0,0 +1,45 @@
+package life
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestHashIsStable(t *testing.T) {
+	// Reference values for other implementations to check against
+	for _, tc := range []struct {
+		rows []string
+		want uint64
+	}{
+		{[]string{"..."}, 0x861dbac2367a8a35},
+		{[]string{".#.", "..#", "###"}, 0xa72ecf9cb7703f9c},
+	} {
+		if got := gridFromRows(tc.rows...).Hash(); got != tc.want {
+			t.Errorf("Hash(%q) = %#x, want %#x", tc.rows, got, tc.want)
+		}
+	}
+}
+
+func TestHashAgreesAcrossBoards(t *testing.T) {
+	g, bits := randomPair(BoardWidth, 20, 6)
+	if g.Hash() != Hash(bits) {
+		t.Fatal("Grid and BitGrid with the same cells hash differently")
+	}
+	s := NewSparseGrid()
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			s.SetAlive(x+100, y-5, g.Alive(x, y))
+		}
+	}
+	if g.Hash() != Hash(&View{Plane: s, Origin: Coord{100, -5}, Width: g.Width, Height: g.Height}) {
+		t.Fatal("Grid and a view of a SparseGrid with the same cells hash differently")
+	}
+
+	other := NewGrid(BoardWidth, 20, rand.New(rand.NewSource(7)), 0.5)
+	if g.Hash() == other.Hash() {
+		t.Fatal("different boards hash the same")
+	}
+	if NewEmptyGrid(4, 2).Hash() == NewEmptyGrid(2, 4).Hash() {
+		t.Fatal("empty boards of different shapes hash the same")
+	}
+}
@@ -0,0 +1,260 @@
+package life
+
+import "errors"
+
+// HashLife is an unbounded board that stores its cells in a canonicalised
+// quadtree and memoises the evolution of every subtree, so boards with
+// regular structure can be advanced thousands of generations at once. It runs
+// two-state B/S rules on the Moore neighbourhood only.
+//
+// The memo tables keep every node ever built, so memory grows with the
+// variety of patterns seen rather than with the size of the board.
+type HashLife struct {
+	rule Rule
+
+	root       *node
+	generation int
+
+	on, off *node
+	nodes   map[[4]*node]*node
+	results map[resultKey]*node
+	empties []*node
+}
+
+// node is a square of 2^level cells on each side. Level-0 nodes are single
+// cells; every other node is made of four children one level down.
+type node struct {
+	level          int
+	nw, ne, sw, se *node
+	population     int
+}
+
+type resultKey struct {
+	n *node
+	j int
+}
+
+// ErrUnsupportedRule is returned when an engine cannot run the requested rule
+var ErrUnsupportedRule = errors.New("rule not supported by this engine")
+
+// NewHashLife returns an empty HashLife board running rule, which must be a
+// plain two-state rule without colours
+func NewHashLife(rule Rule) (*HashLife, error) {
+	if rule.States > 2 || rule.Colours > 1 {
+		return nil, ErrUnsupportedRule
+	}
+	h := &HashLife{
+		rule:    rule,
+		nodes:   make(map[[4]*node]*node),
+		results: make(map[resultKey]*node),
+	}
+	h.off = &node{}
+	h.on = &node{population: 1}
+	h.empties = []*node{h.off}
+	h.root = h.empty(3)
+	return h, nil
+}
+
+// SetRule changes the rule the following generations are stepped with,
+// forgetting every result memoised under the old one
+func (h *HashLife) SetRule(r Rule) {
+	h.rule = r
+	h.results = make(map[resultKey]*node)
+}
+
+// Generation returns the number of generations the board has been advanced
+func (h *HashLife) Generation() int { return h.generation }
+
+// Population returns the number of live cells
+func (h *HashLife) Population() int { return h.root.population }
+
+// join returns the canonical node with the given children
+func (h *HashLife) join(nw, ne, sw, se *node) *node {
+	key := [4]*node{nw, ne, sw, se}
+	if n, ok := h.nodes[key]; ok {
+		return n
+	}
+	n := &node{
+		level: nw.level + 1,
+		nw:    nw, ne: ne, sw: sw, se: se,
+		population: nw.population + ne.population + sw.population + se.population,
+	}
+	h.nodes[key] = n
+	return n
+}
+
+// empty returns the canonical empty node of a level
+func (h *HashLife) empty(level int) *node {
+	for len(h.empties) <= level {
+		e := h.empties[len(h.empties)-1]
+		h.empties = append(h.empties, h.join(e, e, e, e))
+	}
+	return h.empties[level]
+}
+
+// centre returns a node one level up with n in its middle
+func (h *HashLife) centre(n *node) *node {
+	e := h.empty(n.level - 1)
+	return h.join(
+		h.join(e, e, e, n.nw), h.join(e, e, n.ne, e),
+		h.join(e, n.sw, e, e), h.join(n.se, e, e, e),
+	)
+}
+
+// inner returns the middle half of n
+func (h *HashLife) inner(n *node) *node {
+	return h.join(n.nw.se, n.ne.sw, n.sw.ne, n.se.nw)
+}
+
+// half returns the extent of the root: cells lie in [-half, half) on both axes
+func (h *HashLife) half() int { return 1 << (h.root.level - 1) }
+
+// Alive reports whether the cell at (x, y) is alive
+func (h *HashLife) Alive(x, y int) bool {
+	half := h.half()
+	if x < -half || x >= half || y < -half || y >= half {
+		return false
+	}
+	n := h.root
+	x, y = x+half, y+half
+	for n.level > 0 {
+		if n.population == 0 {
+			return false
+		}
+		size := 1 << (n.level - 1)
+		switch {
+		case x < size && y < size:
+			n = n.nw
+		case y < size:
+			n, x = n.ne, x-size
+		case x < size:
+			n, y = n.sw, y-size
+		default:
+			n, x, y = n.se, x-size, y-size
+		}
+	}
+	return n.population == 1
+}
+
+// Cell returns the cell at (x, y)
+func (h *HashLife) Cell(x, y int) Cell { return Cell{Alive: h.Alive(x, y)} }
+
+// SetAlive sets the state of the cell at (x, y), growing the board as needed
+func (h *HashLife) SetAlive(x, y int, alive bool) {
+	for x < -h.half() || x >= h.half() || y < -h.half() || y >= h.half() {
+		h.root = h.centre(h.root)
+	}
+	half := h.half()
+	h.root = h.set(h.root, x+half, y+half, alive)
+}
+
+func (h *HashLife) set(n *node, x, y int, alive bool) *node {
+	if n.level == 0 {
+		if alive {
+			return h.on
+		}
+		return h.off
+	}
+	size := 1 << (n.level - 1)
+	switch {
+	case x < size && y < size:
+		return h.join(h.set(n.nw, x, y, alive), n.ne, n.sw, n.se)
+	case y < size:
+		return h.join(n.nw, h.set(n.ne, x-size, y, alive), n.sw, n.se)
+	case x < size:
+		return h.join(n.nw, n.ne, h.set(n.sw, x, y-size, alive), n.se)
+	default:
+		return h.join(n.nw, n.ne, n.sw, h.set(n.se, x-size, y-size, alive))
+	}
+}
+
+// Step advances the board by one generation
+func (h *HashLife) Step() { h.Advance(1) }
+
+// Advance moves the board forward n generations, taking the binary
+// decomposition of n in memoised power-of-two jumps
+func (h *HashLife) Advance(n int) {
+	for j := 0; n > 0; j, n = j+1, n>>1 {
+		if n&1 == 0 {
+			continue
+		}
+		// Every live cell must sit at least 2^j cells inside the root's
+		// middle half so nothing escapes the result
+		for h.root.level < j+2 || h.inner(h.root).population != h.root.population {
+			h.root = h.centre(h.root)
+		}
+		h.root = h.successor(h.centre(h.root), j)
+		h.generation += 1 << j
+	}
+}
+
+// successor returns the middle half of n advanced 2^j generations, where
+// j <= n.level-2
+func (h *HashLife) successor(n *node, j int) *node {
+	if n.population == 0 {
+		return n.nw
+	}
+	j = min(j, n.level-2)
+	key := resultKey{n, j}
+	if r, ok := h.results[key]; ok {
+		return r
+	}
+
+	var r *node
+	if n.level == 2 {
+		r = h.step4x4(n)
+	} else {
+		c1 := h.successor(n.nw, j)
+		c2 := h.successor(h.join(n.nw.ne, n.ne.nw, n.nw.se, n.ne.sw), j)
+		c3 := h.successor(n.ne, j)
+		c4 := h.successor(h.join(n.nw.sw, n.nw.se, n.sw.nw, n.sw.ne), j)
+		c5 := h.successor(h.inner(n), j)
+		c6 := h.successor(h.join(n.ne.sw, n.ne.se, n.se.nw, n.se.ne), j)
+		c7 := h.successor(n.sw, j)
+		c8 := h.successor(h.join(n.sw.ne, n.se.nw, n.sw.se, n.se.sw), j)
+		c9 := h.successor(n.se, j)
+		if j < n.level-2 {
+			r = h.join(
+				h.join(c1.se, c2.sw, c4.ne, c5.nw),
+				h.join(c2.se, c3.sw, c5.ne, c6.nw),
+				h.join(c4.se, c5.sw, c7.ne, c8.nw),
+				h.join(c5.se, c6.sw, c8.ne, c9.nw),
+			)
+		} else {
+			r = h.join(
+				h.successor(h.join(c1, c2, c4, c5), j),
+				h.successor(h.join(c2, c3, c5, c6), j),
+				h.successor(h.join(c4, c5, c7, c8), j),
+				h.successor(h.join(c5, c6, c8, c9), j),
+			)
+		}
+	}
+	h.results[key] = r
+	return r
+}
+
+// step4x4 applies the rule once to a 4x4 node, returning its middle 2x2
+func (h *HashLife) step4x4(n *node) *node {
+	var cells [4][4]bool
+	for y, row := range [2][2]*node{{n.nw, n.ne}, {n.sw, n.se}} {
+		for x, q := range row {
+			cells[2*y][2*x] = q.nw.population == 1
+			cells[2*y][2*x+1] = q.ne.population == 1
+			cells[2*y+1][2*x] = q.sw.population == 1
+			cells[2*y+1][2*x+1] = q.se.population == 1
+		}
+	}
+	next := func(x, y int) *node {
+		count := 0
+		for _, o := range neighbourhoods[Moore].offsets {
+			if cells[y+o.dy][x+o.dx] {
+				count++
+			}
+		}
+		if h.rule.Next(cells[y][x], count) {
+			return h.on
+		}
+		return h.off
+	}
+	return h.join(next(1, 1), next(2, 1), next(1, 2), next(2, 2))
+}
@@ -0,0 +1,75 @@
+package life
+
+import "testing"
+
+func newHashLife(t *testing.T, cells ...Coord) *HashLife {
+	t.Helper()
+	h, err := NewHashLife(Conway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range cells {
+		h.SetAlive(p.X, p.Y, true)
+	}
+	return h
+}
+
+func TestHashLifeBlinker(t *testing.T) {
+	h := newHashLife(t, Coord{-1, 0}, Coord{0, 0}, Coord{1, 0})
+	h.Step()
+	if !h.Alive(0, -1) || !h.Alive(0, 1) || h.Alive(-1, 0) {
+		t.Fatal("blinker did not turn vertical")
+	}
+	h.Advance(999)
+	if h.Generation() != 1000 {
+		t.Fatalf("Generation = %d, want 1000", h.Generation())
+	}
+	if !h.Alive(-1, 0) || !h.Alive(1, 0) || h.Population() != 3 {
+		t.Fatal("blinker should be horizontal after an even number of generations")
+	}
+}
+
+func TestHashLifeGliderFastForward(t *testing.T) {
+	glider := []Coord{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}
+	h := newHashLife(t, glider...)
+	h.Advance(4096)
+	if h.Population() != 5 {
+		t.Fatalf("Population = %d, want 5", h.Population())
+	}
+	for _, p := range glider {
+		if !h.Alive(p.X+1024, p.Y+1024) {
+			t.Fatalf("expected glider cell at %d,%d", p.X+1024, p.Y+1024)
+		}
+	}
+}
+
+func TestHashLifeMatchesSparseGrid(t *testing.T) {
+	rPentomino := []Coord{{1, 0}, {2, 0}, {0, 1}, {1, 1}, {1, 2}}
+	h := newHashLife(t, rPentomino...)
+	s := NewSparseGrid()
+	for _, p := range rPentomino {
+		s.SetAlive(p.X, p.Y, true)
+	}
+	for _, n := range []int{1, 2, 5, 13, 100} {
+		h.Advance(n)
+		Advance(s, n)
+		if h.Population() != s.Population() {
+			t.Fatalf("generation %d: population %d, sparse %d", h.Generation(), h.Population(), s.Population())
+		}
+		lo, hi, _ := s.Bounds()
+		for y := lo.Y - 1; y <= hi.Y+1; y++ {
+			for x := lo.X - 1; x <= hi.X+1; x++ {
+				if h.Alive(x, y) != s.Alive(x, y) {
+					t.Fatalf("generation %d: cell %d,%d differs", h.Generation(), x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestHashLifeRejectsGenerationsRules(t *testing.T) {
+	rule, _ := ParseRule("B2/S/C3")
+	if _, err := NewHashLife(rule); err != ErrUnsupportedRule {
+		t.Fatalf("err = %v, want ErrUnsupportedRule", err)
+	}
+}
@@ -0,0 +1,71 @@
+package life
+
+// history is a ring buffer of the most recent generations of a Grid
+type history struct {
+	states [][]Cell
+	start  int // index of the oldest state
+	n      int // number of states held
+}
+
+func newHistory(size int) *history {
+	return &history{states: make([][]Cell, size)}
+}
+
+// push records a copy of cells, overwriting the oldest state when full
+func (h *history) push(cells []Cell) {
+	i := (h.start + h.n) % len(h.states)
+	if h.n == len(h.states) {
+		h.start = (h.start + 1) % len(h.states)
+	} else {
+		h.n++
+	}
+	h.states[i] = append(h.states[i][:0], cells...)
+}
+
+// last returns the most recent state, leaving it held
+func (h *history) last() ([]Cell, bool) {
+	if h.n == 0 {
+		return nil, false
+	}
+	return h.states[(h.start+h.n-1)%len(h.states)], true
+}
+
+// drop removes the most recent state
+func (h *history) drop() {
+	h.n--
+}
+
+// SetHistory keeps the last n generations so StepBack can rewind to them;
+// n <= 0 disables the history. Previously kept generations are discarded.
+func (g *Grid) SetHistory(n int) {
+	if n <= 0 {
+		g.history = nil
+		return
+	}
+	g.history = newHistory(n)
+}
+
+// HistoryLen returns how many generations StepBack can currently rewind
+func (g *Grid) HistoryLen() int {
+	if g.history == nil {
+		return 0
+	}
+	return g.history.n
+}
+
+// StepBack restores the generation before the last Step. It reports false,
+// leaving the grid and its history unchanged, when no earlier generation is
+// kept or the last one kept is not the grid's size.
+func (g *Grid) StepBack() bool {
+	if g.history == nil {
+		return false
+	}
+	cells, ok := g.history.last()
+	if !ok || len(cells) != len(g.Cells) {
+		return false
+	}
+	g.history.drop()
+	copy(g.Cells, cells)
+	g.Generation--
+	return true
+}
@@ -0,0 +1,116 @@
+package life
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// LiveCells returns an iterator over the coordinates of b's live cells, row
+// by row. Boards with a LiveCells method of their own are walked with it, so
+// callers need not know how the cells are stored.
+func LiveCells(b Board) iter.Seq2[int, int] {
+	if l, ok := b.(interface{ LiveCells() iter.Seq2[int, int] }); ok {
+		return l.LiveCells()
+	}
+	return func(yield func(x, y int) bool) {
+		width, height := b.Size()
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if b.Alive(x, y) && !yield(x, y) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Row returns an iterator over row y of b, yielding each column with whether
+// its cell is alive. Boards with a Row method of their own are walked with it.
+func Row(b Board, y int) iter.Seq2[int, bool] {
+	if r, ok := b.(interface {
+		Row(y int) iter.Seq2[int, bool]
+	}); ok {
+		return r.Row(y)
+	}
+	return func(yield func(x int, alive bool) bool) {
+		width, height := b.Size()
+		if y < 0 || y >= height {
+			return
+		}
+		for x := 0; x < width; x++ {
+			if !yield(x, b.Alive(x, y)) {
+				return
+			}
+		}
+	}
+}
+
+// LiveCells returns an iterator over the coordinates of the live cells, row by row
+func (g *Grid) LiveCells() iter.Seq2[int, int] {
+	return func(yield func(x, y int) bool) {
+		for i, c := range g.Cells {
+			if c.Alive && !yield(i%g.Width, i/g.Width) {
+				return
+			}
+		}
+	}
+}
+
+// Row returns an iterator over row y, yielding each column with whether its
+// cell is alive; rows off the grid yield nothing
+func (g *Grid) Row(y int) iter.Seq2[int, bool] {
+	return func(yield func(x int, alive bool) bool) {
+		if y < 0 || y >= g.Height {
+			return
+		}
+		for x, c := range g.Cells[y*g.Width : (y+1)*g.Width] {
+			if !yield(x, c.Alive) {
+				return
+			}
+		}
+	}
+}
+
+// LiveCells returns an iterator over the coordinates of the live cells, row
+// by row, skipping empty words without looking at their bits
+func (b *BitGrid) LiveCells() iter.Seq2[int, int] {
+	return func(yield func(x, y int) bool) {
+		for k, w := range b.words {
+			y, base := k/b.stride, k%b.stride*64
+			for w != 0 {
+				if !yield(base+bits.TrailingZeros64(w), y) {
+					return
+				}
+				w &= w - 1
+			}
+		}
+	}
+}
+
+// Row returns an iterator over row y, yielding each column with whether its
+// cell is alive; rows off the grid yield nothing
+func (b *BitGrid) Row(y int) iter.Seq2[int, bool] {
+	return func(yield func(x int, alive bool) bool) {
+		if y < 0 || y >= b.Height {
+			return
+		}
+		row := b.words[y*b.stride : (y+1)*b.stride]
+		for x := 0; x < b.Width; x++ {
+			if !yield(x, row[x/64]>>(x%64)&1 == 1) {
+				return
+			}
+		}
+	}
+}
+
+// LiveCells returns an iterator over the coordinates of the live cells, in no
+// particular order
+func (s *SparseGrid) LiveCells() iter.Seq2[int, int] {
+	return func(yield func(x, y int) bool) {
+		for p, c := range s.cells {
+			if c.Alive && !yield(p.X, p.Y) {
+				return
+			}
+		}
+	}
+}
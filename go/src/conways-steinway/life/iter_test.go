@@ -0,0 +1,82 @@
+package life
+
+import (
+	"slices"
+	"testing"
+)
+
+// collect gathers the cells an iterator yields
+func collect(seq func(yield func(x, y int) bool)) []Coord {
+	var cells []Coord
+	seq(func(x, y int) bool {
+		cells = append(cells, Coord{x, y})
+		return true
+	})
+	return cells
+}
+
+func TestLiveCellsAgreeAcrossBoards(t *testing.T) {
+	g, b := randomPair(88, 6, 4)
+	want := collect(LiveCells(struct{ Board }{g})) // walked through Alive
+	if len(want) == 0 {
+		t.Fatal("the random board should have live cells")
+	}
+	if got := collect(g.LiveCells()); !slices.Equal(got, want) {
+		t.Fatalf("Grid.LiveCells = %v, want %v", got, want)
+	}
+	if got := collect(LiveCells(b)); !slices.Equal(got, want) {
+		t.Fatalf("BitGrid.LiveCells = %v, want %v", got, want)
+	}
+
+	s := NewSparseGrid()
+	for _, c := range want {
+		s.SetAlive(c.X, c.Y, true)
+	}
+	got := collect(s.LiveCells())
+	slices.SortFunc(got, func(a, b Coord) int { return (a.Y-b.Y)*1000 + a.X - b.X })
+	if !slices.Equal(got, want) {
+		t.Fatalf("SparseGrid.LiveCells = %v, want %v", got, want)
+	}
+}
+
+func TestLiveCellsStopsEarly(t *testing.T) {
+	g := gridFromRows(
+		"##.",
+		".##",
+	)
+	var seen []Coord
+	for x, y := range g.LiveCells() {
+		seen = append(seen, Coord{x, y})
+		if len(seen) == 2 {
+			break
+		}
+	}
+	if !slices.Equal(seen, []Coord{{0, 0}, {1, 0}}) {
+		t.Fatalf("seen = %v", seen)
+	}
+}
+
+func TestRow(t *testing.T) {
+	g := gridFromRows(
+		"...",
+		"#.#",
+	)
+	b := NewBitGrid(3, 2)
+	b.SetAlive(0, 1, true)
+	b.SetAlive(2, 1, true)
+	for _, board := range []Board{g, b, struct{ Board }{g}} {
+		var row []bool
+		for x, alive := range Row(board, 1) {
+			if x != len(row) {
+				t.Fatalf("%T: column %d out of order", board, x)
+			}
+			row = append(row, alive)
+		}
+		if !slices.Equal(row, []bool{true, false, true}) {
+			t.Fatalf("%T: Row(1) = %v", board, row)
+		}
+		for range Row(board, 2) {
+			t.Fatalf("%T: a row off the board should yield nothing", board)
+		}
+	}
+}
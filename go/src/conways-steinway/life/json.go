@@ -0,0 +1,76 @@
+package life
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// gridJSON is the JSON form of a Grid
+type gridJSON struct {
+	Width         int      `json:"width"`
+	Height        int      `json:"height"`
+	Rule          string   `json:"rule"`
+	Edge          string   `json:"edge"`
+	Neighbourhood string   `json:"neighbourhood"`
+	Generation    int      `json:"generation"`
+	Cells         [][2]int `json:"cells"` // live cells as [x, y], in reading order
+}
+
+// MarshalJSON encodes the grid's dimensions, rule, edge mode, neighbourhood,
+// generation number and live cells. Dying states, colours, ages and history
+// are not kept.
+func (g *Grid) MarshalJSON() ([]byte, error) {
+	v := gridJSON{
+		Width:         g.Width,
+		Height:        g.Height,
+		Rule:          g.Rule.String(),
+		Edge:          g.Edge.String(),
+		Neighbourhood: g.Neighbourhood.String(),
+		Generation:    g.Generation,
+		Cells:         [][2]int{},
+	}
+	for i, c := range g.Cells {
+		if c.Alive {
+			v.Cells = append(v.Cells, [2]int{i % g.Width, i / g.Width})
+		}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON replaces the grid with one decoded from MarshalJSON's format.
+// Missing rule, edge and neighbourhood fields keep their defaults.
+func (g *Grid) UnmarshalJSON(data []byte) error {
+	var v gridJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Width <= 0 || v.Height <= 0 {
+		return fmt.Errorf("grid size %dx%d is not positive", v.Width, v.Height)
+	}
+	ng := NewEmptyGrid(v.Width, v.Height)
+	ng.Workers = g.Workers
+	ng.Generation = v.Generation
+	if v.Rule != "" {
+		if err := ng.Rule.Set(v.Rule); err != nil {
+			return err
+		}
+	}
+	if v.Edge != "" {
+		if err := ng.Edge.Set(v.Edge); err != nil {
+			return err
+		}
+	}
+	if v.Neighbourhood != "" {
+		if err := ng.Neighbourhood.Set(v.Neighbourhood); err != nil {
+			return err
+		}
+	}
+	for _, c := range v.Cells {
+		if !ng.InBounds(c[0], c[1]) {
+			return fmt.Errorf("cell %d,%d is off the %dx%d grid", c[0], c[1], v.Width, v.Height)
+		}
+		ng.SetAlive(c[0], c[1], true)
+	}
+	*g = *ng
+	return nil
+}
@@ -0,0 +1,54 @@
+package life
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGridJSONRoundTrip(t *testing.T) {
+	g := gridFromRows(
+		".#...",
+		"..#..",
+		"###..",
+		".....",
+	)
+	g.Edge = EdgeWrap
+	g.Rule, _ = ParseRule("B36/S23")
+	g.Step()
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"width":5,"height":4,"rule":"B36/S23","edge":"wrap","neighbourhood":"moore","generation":1,` +
+		`"cells":[[0,1],[2,1],[1,2],[2,2],[0,3],[2,3]]}`
+	if string(data) != want {
+		t.Fatalf("Marshal = %s\nwant      %s", data, want)
+	}
+
+	var back Grid
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, &back, g.rows()...)
+	if back.Rule != g.Rule || back.Edge != g.Edge || back.Generation != 1 {
+		t.Fatalf("Unmarshal = rule %v edge %v generation %d", back.Rule, back.Edge, back.Generation)
+	}
+	back.Step()
+	g.Step()
+	assertRows(t, &back, g.rows()...)
+}
+
+func TestGridJSONErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"width":0,"height":3}`,
+		`{"width":3,"height":3,"rule":"X"}`,
+		`{"width":3,"height":3,"cells":[[3,0]]}`,
+		`{"width":3,"height":3,"edge":"sideways"}`,
+	} {
+		var g Grid
+		if err := json.Unmarshal([]byte(data), &g); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want an error", data)
+		}
+	}
+}
@@ -1,107 +1,191 @@
-package main
+// Package life implements Conway's Game of Life on a fixed-size board.
+package life
 
 import (
-	"fmt"
+	"io"
 	"math/rand"
-	"time"
+)
+
+const (
+	// BoardWidth is the width of the board, one column per piano key
+	BoardWidth = 88
+	// BoardHeight is the default height of the board
+	BoardHeight = 40
 )
 
 // Cell represents a cell in the grid
 type Cell struct {
 	Alive bool
+	// State counts the generations a cell has spent dying under a
+	// Generations rule: 0 for live and dead cells, 1 to Rule.States-2 after
+	// the cell stopped surviving
+	State uint8
+	// Colour is the cell's colour under a coloured rule such as Immigration,
+	// from 1 to Rule.Colours; 0 means uncoloured
+	Colour uint8
+	// Age counts the generations a live cell has survived: 0 when it is born
+	// or placed, saturating at the largest uint16
+	Age uint16
 }
 
+// Dying reports whether the cell is in one of a Generations rule's dying states
+func (c Cell) Dying() bool { return c.State > 0 }
+
 // Grid represents the game board
 type Grid struct {
-	Cells []Cell
+	Width  int
+	Height int
+	Cells  []Cell
+	Edge   EdgeMode // how neighbours beyond the border are treated
+	Rule   Rule     // birth/survival rule applied by Step
+
+	Neighbourhood Neighbourhood // cells counted as neighbours
+	Workers       int           // goroutines Step splits the rows between; 0 or 1 steps serially
+
+	Generation int // number of generations stepped
+
+	next    []Cell   // scratch buffer the next generation is computed into
+	history *history // earlier generations kept for StepBack
+
+	spans  []span // columns of each row holding live cells, found by findActive
+	active bool   // spans are in use, so dead areas are skipped
+
+	coupled []*Grid // layers of a Stack whose cells at the same position count as neighbours
+
+	births, deaths int // changes made by the last Step
 }
 
-// NewGrid returns a new Game of Life grid with random initial values
-func NewGrid(width, height int) *Grid {
-	grid := &Grid{
-		Cells: make([]Cell, width*height),
+// NewEmptyGrid returns a new Game of Life grid with every cell dead
+func NewEmptyGrid(width, height int) *Grid {
+	return &Grid{
+		Width:  width,
+		Height: height,
+		Cells:  make([]Cell, width*height),
+		Rule:   Conway,
+		next:   make([]Cell, width*height),
 	}
+}
+
+// NewGrid returns a new Game of Life grid with random initial values drawn
+// from rng, so the same seed always produces the same board. Each cell is
+// alive with probability density.
+func NewGrid(width, height int, rng *rand.Rand, density float64) *Grid {
+	grid := NewEmptyGrid(width, height)
 	for i := range grid.Cells {
-		// x := i % width
-		// y := i / width
-		grid.Cells[i] = Cell{Alive: rand.Intn(2) == 1} // Initialize random values
+		grid.Cells[i] = Cell{Alive: rng.Float64() < density} // Initialize random values
 	}
 
 	return grid
 }
 
-// Step simulates one generation of the Game of Life
-func (g *Grid) Step() {
-	for y := 0; y < g.Length(); y++ {
-		for x := 0; x < g.Length(); x++ {
-			count := g.neighboursCount(x, y)
-			if g.Cells[x+y*g.Length()].Alive && (count == 2 || count == 3) {
-				g.Cells[x+y*g.Length()].Alive = true
-			} else if !g.Cells[x+y*g.Length()].Alive && count == 3 {
-				g.Cells[x+y*g.Length()].Alive = true
-			}
-		}
-	}
+// Size returns the width and height of the grid
+func (g *Grid) Size() (width, height int) { return g.Width, g.Height }
+
+// InBounds reports whether (x, y) lies on the grid
+func (g *Grid) InBounds(x, y int) bool {
+	return x >= 0 && x < g.Width && y >= 0 && y < g.Height
 }
 
-func (g *Grid) Length() int { return len(g.Cells) }
+// Index returns the offset of (x, y) in Cells
+func (g *Grid) Index(x, y int) int { return x + y*g.Width }
 
-// neighboursCount returns the number of live neighbors for a given cell
-func (g *Grid) neighboursCount(x, y int) int {
-	count := 0
-	for dy := -1; dy <= 1; dy++ {
-		for dx := -1; dx <= 1; dx++ {
-			if dy == 0 && dx == 0 {
-				continue // Skip the center cell
-			}
-
-			ny := y + dy
-			nx := x + dx
+// Alive reports whether the cell at (x, y) is alive; cells off the grid are dead
+func (g *Grid) Alive(x, y int) bool {
+	return g.InBounds(x, y) && g.Cells[g.Index(x, y)].Alive
+}
 
-			liveNeighbors := 0
-			for nx := min(nx, g.Length()-1); nx < g.Length(); nx++ {
-				// ny := nx % g.Length()
-				if g.Cells[nx+ny*g.Length()].Alive {
-					liveNeighbors++
-				}
-			}
+// Age returns how many generations the cell at (x, y) has survived, or 0 if
+// it is dead or off the grid
+func (g *Grid) Age(x, y int) int {
+	if !g.Alive(x, y) {
+		return 0
+	}
+	return int(g.Cells[g.Index(x, y)].Age)
+}
 
-			count += liveNeighbors
-		}
+// SetAlive sets the state of the cell at (x, y); coordinates off the grid are ignored
+func (g *Grid) SetAlive(x, y int, alive bool) {
+	if g.InBounds(x, y) {
+		g.Cells[g.Index(x, y)] = Cell{Alive: alive}
 	}
+}
 
-	return count
+// SetRule changes the rule the following generations are stepped with
+func (g *Grid) SetRule(r Rule) { g.Rule = r }
+
+// Step simulates one generation using the grid's Rule. Every cell is computed
+// from the previous generation only; the result is written to a second buffer
+// which is then swapped in. Cells out of reach of any live cell are known to
+// stay dead and are skipped, which keeps sparse late-game boards cheap.
+func (g *Grid) Step() {
+	g.compute()
+	g.commit()
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
+// compute works out the next generation into the scratch buffer
+func (g *Grid) compute() {
+	if len(g.next) != len(g.Cells) {
+		g.next = make([]Cell, len(g.Cells))
+	}
+	if g.history != nil {
+		g.history.push(g.Cells)
 	}
-	return b
+	// Cells of a stacked layer can be born from the layers beside it even
+	// where the layer itself is dead
+	g.active = g.coupled == nil && g.findActive()
+	parallelRows(g.Height, g.Workers, g.stepRows)
 }
 
-// printGrid prints the current state of the game board
-func (g *Grid) printGrid() {
-	for y := 0; y < g.Length(); y++ {
-		for x := 0; x < g.Length(); x++ {
-			if g.Cells[x+y*g.Length()].Alive {
-				fmt.Print("#", x, y)
-			} else {
-				fmt.Print(".", x, y)
+// commit swaps in the generation compute worked out
+func (g *Grid) commit() {
+	g.countChanges()
+	g.Cells, g.next = g.next, g.Cells
+	g.Generation++
+}
+
+// stepRows computes rows [y0, y1) of the next generation
+func (g *Grid) stepRows(y0, y1 int) {
+	for y := y0; y < y1; y++ {
+		lo, hi := 0, g.Width-1
+		if g.active {
+			lo, hi = g.activeColumns(y)
+			row := g.next[y*g.Width : (y+1)*g.Width]
+			clear(row[:max(lo, 0)])
+			clear(row[min(hi+1, g.Width):])
+		}
+		for x := lo; x <= hi; x++ {
+			i := g.Index(x, y)
+			n := g.neighboursCount(x, y)
+			for _, layer := range g.coupled {
+				if layer.Cells[i].Alive {
+					n++
+				}
+			}
+			c := g.Rule.Advance(g.Cells[i], n)
+			if c.Alive && !g.Cells[i].Alive && g.Rule.Colours > 1 {
+				c.Colour = g.birthColour(x, y)
 			}
+			g.next[i] = c
 		}
-		fmt.Println()
 	}
 }
 
-func main() {
-	rand.Seed(time.Now().UnixNano())
-	grid := NewGrid(10, 10)
-
-	for generation := 0; generation < 10; generation++ {
-		grid.printGrid()
-		fmt.Printf("Generation %d\n", generation+1)
-		grid.Step()
-		time.Sleep(500 * time.Millisecond) // Pause for animation effect
+// neighboursCount returns the number of live neighbors for a given cell
+func (g *Grid) neighboursCount(x, y int) int {
+	count := 0
+	for _, o := range g.Neighbourhood.offsetsAt(y) {
+		if g.neighbour(x+o.dx, y+o.dy) {
+			count++
+		}
 	}
+
+	return count
+}
+
+// Print writes the current state of the game board to w. Hexagonal boards
+// are drawn with odd rows offset by half a cell.
+func (g *Grid) Print(w io.Writer) {
+	printCells(w, g.Width, g.Height, g.Neighbourhood == Hexagonal, func(x, y int) Cell {
+		return g.Cells[g.Index(x, y)]
+	})
 }
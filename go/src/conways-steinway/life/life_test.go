@@ -0,0 +1,562 @@
+package life
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// gridFromRows builds a grid from rows of '.' (dead) and '#' (alive)
+func gridFromRows(rows ...string) *Grid {
+	g := NewEmptyGrid(len(rows[0]), len(rows))
+	for y, row := range rows {
+		for x, c := range row {
+			g.SetAlive(x, y, c == '#')
+		}
+	}
+	return g
+}
+
+// rows renders a grid back into the format accepted by gridFromRows
+func (g *Grid) rows() []string {
+	out := make([]string, g.Height)
+	for y := range out {
+		var b strings.Builder
+		for x := 0; x < g.Width; x++ {
+			if g.Alive(x, y) {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		out[y] = b.String()
+	}
+	return out
+}
+
+func assertRows(t *testing.T, g *Grid, want ...string) {
+	t.Helper()
+	got := g.rows()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected grid:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestStepBlockIsStill(t *testing.T) {
+	g := gridFromRows(
+		"....",
+		".##.",
+		".##.",
+		"....",
+	)
+	g.Step()
+	assertRows(t, g,
+		"....",
+		".##.",
+		".##.",
+		"....",
+	)
+}
+
+func TestStepBlinkerOscillates(t *testing.T) {
+	g := gridFromRows(
+		".....",
+		"..#..",
+		"..#..",
+		"..#..",
+		".....",
+	)
+	g.Step()
+	assertRows(t, g,
+		".....",
+		".....",
+		".###.",
+		".....",
+		".....",
+	)
+	g.Step()
+	assertRows(t, g,
+		".....",
+		"..#..",
+		"..#..",
+		"..#..",
+		".....",
+	)
+}
+
+func TestStepGliderTranslates(t *testing.T) {
+	g := gridFromRows(
+		".#....",
+		"..#...",
+		"###...",
+		"......",
+		"......",
+		"......",
+	)
+	for i := 0; i < 4; i++ {
+		g.Step()
+	}
+	assertRows(t, g,
+		"......",
+		"..#...",
+		"...#..",
+		".###..",
+		"......",
+		"......",
+	)
+}
+
+func TestStepLonelyCellsDie(t *testing.T) {
+	g := gridFromRows(
+		"#...",
+		"....",
+		"...#",
+	)
+	g.Step()
+	assertRows(t, g,
+		"....",
+		"....",
+		"....",
+	)
+}
+
+func TestStepNonSquareBoard(t *testing.T) {
+	g := NewEmptyGrid(BoardWidth, 3)
+	g.SetAlive(BoardWidth-3, 1, true)
+	g.SetAlive(BoardWidth-2, 1, true)
+	g.SetAlive(BoardWidth-1, 1, true)
+	g.Step()
+	for y := 0; y < 3; y++ {
+		if !g.Alive(BoardWidth-2, y) {
+			t.Fatalf("expected vertical blinker at column %d row %d", BoardWidth-2, y)
+		}
+	}
+	if g.Alive(BoardWidth-3, 1) || g.Alive(BoardWidth-1, 1) {
+		t.Fatal("expected horizontal arms to die")
+	}
+}
+
+func TestEdgeWrapJoinsBorders(t *testing.T) {
+	g := gridFromRows(
+		".....",
+		".....",
+		"##..#",
+		".....",
+		".....",
+	)
+	g.Edge = EdgeWrap
+	g.Step()
+	assertRows(t, g,
+		".....",
+		"#....",
+		"#....",
+		"#....",
+		".....",
+	)
+}
+
+func TestEdgeDeadDropsPatterns(t *testing.T) {
+	g := gridFromRows(
+		".....",
+		".....",
+		"##..#",
+		".....",
+		".....",
+	)
+	g.Step()
+	assertRows(t, g,
+		".....",
+		".....",
+		".....",
+		".....",
+		".....",
+	)
+}
+
+func TestEdgeAliveFeedsBorder(t *testing.T) {
+	g := gridFromRows(
+		"...",
+		"...",
+		"...",
+	)
+	g.Edge = EdgeAlive
+	g.Step()
+	// Corners see five live neighbours, edge midpoints see three
+	assertRows(t, g,
+		".#.",
+		"#.#",
+		".#.",
+	)
+}
+
+func TestEdgeMirrorReflectsBorder(t *testing.T) {
+	g := gridFromRows(
+		"##...",
+		".....",
+		".....",
+	)
+	g.Edge = EdgeMirror
+	// (0,0) sees itself reflected three times plus (1,0) and its reflection
+	if n := g.neighboursCount(0, 0); n != 5 {
+		t.Fatalf("neighboursCount(0, 0) = %d, want 5", n)
+	}
+	if n := g.neighboursCount(2, 0); n != 2 {
+		t.Fatalf("neighboursCount(2, 0) = %d, want 2", n)
+	}
+}
+
+func TestParseEdgeMode(t *testing.T) {
+	for _, m := range []EdgeMode{EdgeDead, EdgeWrap, EdgeAlive, EdgeMirror} {
+		got, err := ParseEdgeMode(m.String())
+		if err != nil || got != m {
+			t.Fatalf("ParseEdgeMode(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseEdgeMode("bouncy"); err == nil {
+		t.Fatal("expected an error for an unknown edge mode")
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"B3/S23", "B3/S23"},
+		{"b36/s23", "B36/S23"},
+		{"B2/S", "B2/S"},
+		{"S23/B3", "B3/S23"},
+		{"B3S23", "B3/S23"},
+		{"23/3", "B3/S23"},
+		{"B2/S/C3", "B2/S/C3"},
+		{"/2/3", "B2/S/C3"},
+		{"345/2/4", "B2/S345/C4"},
+		{"B3/S23/C2", "B3/S23"},
+		{"B5-7/S4,6,8-10", "B567/S4,6,8-10"},
+		{"B3,4/S2", "B34/S2"},
+		{"immigration", "Immigration"},
+		{"QuadLife", "QuadLife"},
+		{"B3/S23/K4", "QuadLife"},
+		{"B36/S23/K2", "B36/S23/K2"},
+		{"BriansBrain", "B2/S/C3"},
+		{"Brian's Brain", "B2/S/C3"},
+	}
+	for _, tt := range tests {
+		r, err := ParseRule(tt.in)
+		if err != nil {
+			t.Fatalf("ParseRule(%q): %v", tt.in, err)
+		}
+		if r.String() != tt.want {
+			t.Fatalf("ParseRule(%q) = %s, want %s", tt.in, r, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "B9/S23", "X3/S23", "3", "B2/S/C1", "B3,25/S2", "B3/S23/K1", "B3/S23/K9", "B2/S/C3/K2"} {
+		if _, err := ParseRule(bad); err == nil {
+			t.Fatalf("ParseRule(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestStepHighLifeReplicator(t *testing.T) {
+	// Under B36/S23 a dead cell with six neighbours is born; under Life it is not
+	g := gridFromRows(
+		"###",
+		"#.#",
+		"#..",
+	)
+	g.Rule, _ = ParseRule("B36/S23")
+	g.Step()
+	if !g.Alive(1, 1) {
+		t.Fatal("expected a birth with six neighbours under HighLife")
+	}
+}
+
+func TestStepSeedsKillsEveryLiveCell(t *testing.T) {
+	g := gridFromRows(
+		"....",
+		".##.",
+		".##.",
+		"....",
+	)
+	g.Rule, _ = ParseRule("B2/S")
+	g.Step()
+	assertRows(t, g,
+		".##.",
+		"#..#",
+		"#..#",
+		".##.",
+	)
+}
+
+func TestStepGenerationsDecay(t *testing.T) {
+	g := gridFromRows(
+		".....",
+		".....",
+		"..#..",
+		".....",
+		".....",
+	)
+	g.Rule, _ = ParseRule("B3/S/C4")
+	g.Step()
+	if c := g.Cells[g.Index(2, 2)]; c.Alive || c.State != 1 {
+		t.Fatalf("after 1 step: %+v, want first dying state", c)
+	}
+	g.Step()
+	if c := g.Cells[g.Index(2, 2)]; c.State != 2 {
+		t.Fatalf("after 2 steps: %+v, want second dying state", c)
+	}
+	g.Step()
+	if c := g.Cells[g.Index(2, 2)]; c != (Cell{}) {
+		t.Fatalf("after 3 steps: %+v, want dead", c)
+	}
+}
+
+func TestBriansBrainWavefront(t *testing.T) {
+	// A firing pair fires the cells either side of it and then rests, so the
+	// wavefronts move apart rather than filling back in behind themselves
+	g := gridFromRows(
+		"....",
+		"....",
+		".##.",
+		"....",
+		"....",
+	)
+	g.Rule = BriansBrain
+	g.Step()
+	assertRows(t, g,
+		"....",
+		".##.",
+		"....",
+		".##.",
+		"....",
+	)
+	if !g.Cells[g.Index(1, 2)].Dying() || !g.Cells[g.Index(2, 2)].Dying() {
+		t.Fatal("the firing pair should be refractory")
+	}
+	g.Step()
+	assertRows(t, g,
+		".##.",
+		"....",
+		"#..#",
+		"....",
+		".##.",
+	)
+}
+
+func TestDyingCellsAreNotNeighbours(t *testing.T) {
+	g := gridFromRows(
+		"...",
+		"...",
+		"...",
+	)
+	g.Rule, _ = ParseRule("B1/S/C3")
+	g.Cells[g.Index(0, 0)] = Cell{State: 1}
+	g.Step()
+	if g.Alive(1, 1) {
+		t.Fatal("a dying cell must not cause a birth")
+	}
+}
+
+func TestNeighbourhoodSizes(t *testing.T) {
+	want := map[Neighbourhood]int{Moore: 8, VonNeumann: 4, Moore2: 24, Circular: 20, Hexagonal: 6}
+	for n, size := range want {
+		if n.Size() != size {
+			t.Fatalf("%v.Size() = %d, want %d", n, n.Size(), size)
+		}
+		got, err := ParseNeighbourhood(n.String())
+		if err != nil || got != n {
+			t.Fatalf("ParseNeighbourhood(%q) = %v, %v", n.String(), got, err)
+		}
+	}
+}
+
+func TestNeighboursCountByNeighbourhood(t *testing.T) {
+	g := gridFromRows(
+		"#####",
+		"#####",
+		"#####",
+		"#####",
+		"#####",
+	)
+	want := map[Neighbourhood]int{Moore: 8, VonNeumann: 4, Moore2: 24, Circular: 20, Hexagonal: 6}
+	for n, count := range want {
+		g.Neighbourhood = n
+		if got := g.neighboursCount(2, 2); got != count {
+			t.Fatalf("%v: neighboursCount = %d, want %d", n, got, count)
+		}
+	}
+}
+
+func TestStepVonNeumann(t *testing.T) {
+	// Under von Neumann B1/S, each live cell spawns its orthogonal neighbours
+	g := gridFromRows(
+		"...",
+		".#.",
+		"...",
+	)
+	g.Neighbourhood = VonNeumann
+	g.Rule, _ = ParseRule("B1/S")
+	g.Step()
+	assertRows(t, g,
+		".#.",
+		"#.#",
+		".#.",
+	)
+}
+
+func TestHexagonalNeighboursDependOnRowParity(t *testing.T) {
+	g := gridFromRows(
+		"....",
+		"..#.",
+		"....",
+		"....",
+	)
+	g.Neighbourhood = Hexagonal
+	// (2,1) is on an odd row, so it touches (2,0) and (3,0) above and
+	// (2,2) and (3,2) below
+	for _, c := range [][2]int{{2, 0}, {3, 0}, {1, 1}, {3, 1}, {2, 2}, {3, 2}} {
+		if n := g.neighboursCount(c[0], c[1]); n != 1 {
+			t.Fatalf("neighboursCount(%d, %d) = %d, want 1", c[0], c[1], n)
+		}
+	}
+	for _, c := range [][2]int{{1, 0}, {1, 2}} {
+		if n := g.neighboursCount(c[0], c[1]); n != 0 {
+			t.Fatalf("neighboursCount(%d, %d) = %d, want 0", c[0], c[1], n)
+		}
+	}
+}
+
+func TestPrintHexagonal(t *testing.T) {
+	g := gridFromRows(
+		"#..",
+		".#.",
+	)
+	g.Neighbourhood = Hexagonal
+	var b strings.Builder
+	g.Print(&b)
+	if want := "# . .\n . # .\n"; b.String() != want {
+		t.Fatalf("Print = %q, want %q", b.String(), want)
+	}
+}
+
+func TestStepBackRewindsGenerations(t *testing.T) {
+	g := gridFromRows(
+		".#....",
+		"..#...",
+		"###...",
+		"......",
+		"......",
+		"......",
+	)
+	start := g.rows()
+	g.SetHistory(3)
+	for i := 0; i < 5; i++ {
+		g.Step()
+	}
+	if g.Generation != 5 || g.HistoryLen() != 3 {
+		t.Fatalf("Generation = %d, HistoryLen = %d, want 5 and 3", g.Generation, g.HistoryLen())
+	}
+	for g.StepBack() {
+	}
+	if g.Generation != 2 {
+		t.Fatalf("rewound to generation %d, want 2", g.Generation)
+	}
+	// Replaying from generation 2 must lead back to the same place
+	g.Step()
+	g.Step()
+	g.Step()
+	want := gridFromRows(start...)
+	for i := 0; i < 5; i++ {
+		want.Step()
+	}
+	assertRows(t, g, want.rows()...)
+}
+
+func TestStepBackWithoutHistory(t *testing.T) {
+	g := gridFromRows("###")
+	g.Step()
+	if g.StepBack() {
+		t.Fatal("StepBack succeeded without a history")
+	}
+}
+
+func TestStepBackKeepsAGenerationThatDoesNotFit(t *testing.T) {
+	g := gridFromRows(
+		"......",
+		"..#...",
+		"..#...",
+		"..#...",
+		"......",
+	)
+	g.SetHistory(3)
+	g.Step()
+	g.Step()
+	cells := g.Cells
+	g.Cells = make([]Cell, len(cells)+1)
+	for range 3 {
+		if g.StepBack() {
+			t.Fatal("StepBack rewound to a generation of another size")
+		}
+	}
+	if g.HistoryLen() != 2 {
+		t.Fatalf("HistoryLen = %d after failing to step back, want 2 still", g.HistoryLen())
+	}
+	g.Cells = cells
+	if !g.StepBack() || !g.StepBack() || g.Generation != 0 {
+		t.Fatalf("rewound to generation %d, want 0", g.Generation)
+	}
+}
+
+func TestNewGridIsDeterministicForASeed(t *testing.T) {
+	a := NewGrid(BoardWidth, 10, rand.New(rand.NewSource(99)), 0.5)
+	b := NewGrid(BoardWidth, 10, rand.New(rand.NewSource(99)), 0.5)
+	assertRows(t, a, b.rows()...)
+	c := NewGrid(BoardWidth, 10, rand.New(rand.NewSource(100)), 0.5)
+	if strings.Join(a.rows(), "") == strings.Join(c.rows(), "") {
+		t.Fatal("different seeds produced the same board")
+	}
+}
+
+func TestNewGridDensity(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	if n := population(NewGrid(BoardWidth, BoardHeight, rng, 0)); n != 0 {
+		t.Fatalf("density 0: population = %d, want 0", n)
+	}
+	if n := population(NewGrid(BoardWidth, BoardHeight, rng, 1)); n != BoardWidth*BoardHeight {
+		t.Fatalf("density 1: population = %d, want every cell", n)
+	}
+	n := population(NewGrid(BoardWidth, BoardHeight, rng, 0.15))
+	if want := BoardWidth * BoardHeight * 15 / 100; n < want*8/10 || n > want*12/10 {
+		t.Fatalf("density 0.15: population = %d, want about %d", n, want)
+	}
+}
+
+func TestAge(t *testing.T) {
+	g := gridFromRows(
+		"........",
+		".##.....",
+		".##.....",
+		"........",
+		"........",
+		".....#..",
+		".....#..",
+		".....#..",
+		"........",
+	)
+	for i := 0; i < 3; i++ {
+		g.Step()
+	}
+	if got := g.Age(1, 1); got != 3 {
+		t.Fatalf("block cell age = %d, want 3", got)
+	}
+	// The blinker's centre survives every generation; its ends are reborn
+	if got := g.Age(5, 6); got != 3 {
+		t.Fatalf("blinker centre age = %d, want 3", got)
+	}
+	if got := g.Age(4, 6); got != 0 {
+		t.Fatalf("blinker end age = %d, want 0", got)
+	}
+	if got := g.Age(0, 0); got != 0 {
+		t.Fatalf("dead cell age = %d, want 0", got)
+	}
+}
@@ -0,0 +1,23 @@
+package life
+
+import "math/rand"
+
+// RuleSetter is implemented by boards whose rule can be changed between
+// generations
+type RuleSetter interface {
+	SetRule(r Rule)
+}
+
+// MutateRule returns r with one birth or survival count, chosen at random
+// from rng, added or removed. Counts run up to neighbours, the size of the
+// neighbourhood the rule is used with. Birth on zero neighbours is never
+// added, so an unbounded board cannot fill the plane.
+func MutateRule(rng *rand.Rand, r Rule, neighbours int) Rule {
+	n := rng.Intn(2*neighbours + 1)
+	if n < neighbours {
+		r.Birth ^= 1 << (n + 1)
+	} else {
+		r.Survive ^= 1 << (n - neighbours)
+	}
+	return r
+}
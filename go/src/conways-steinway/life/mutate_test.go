@@ -0,0 +1,64 @@
+package life
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+func TestMutateRuleChangesOneCount(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	seen := make(map[Rule]bool)
+	for i := 0; i < 200; i++ {
+		r := MutateRule(rng, Conway, 8)
+		changed := bits.OnesCount32(r.Birth^Conway.Birth) + bits.OnesCount32(r.Survive^Conway.Survive)
+		if changed != 1 {
+			t.Fatalf("%v differs from %v in %d counts, want 1", r, Conway, changed)
+		}
+		if r.Birth&1 != 0 {
+			t.Fatalf("%v gives birth on zero neighbours", r)
+		}
+		if (r.Birth|r.Survive)>>9 != 0 {
+			t.Fatalf("%v has counts beyond the Moore neighbourhood", r)
+		}
+		seen[r] = true
+	}
+	// B1-B8 and S0-S8 can each be toggled
+	if len(seen) != 17 {
+		t.Fatalf("saw %d different mutations, want 17", len(seen))
+	}
+}
+
+func TestMutateRuleKeepsStates(t *testing.T) {
+	r := MutateRule(rand.New(rand.NewSource(2)), BriansBrain, 8)
+	if r.States != 3 {
+		t.Fatalf("%v lost its Generations states", r)
+	}
+}
+
+func TestSetRuleOnCountGridRevisitsEveryCell(t *testing.T) {
+	c := NewCountGrid(5, 5)
+	for x := 1; x <= 3; x++ {
+		c.SetAlive(x, 2, true)
+	}
+	c.Step()
+	c.Step()
+	// Under B1/S a blinker's neighbours are all born at once, even the ones
+	// whose counts did not change in the last generation
+	r, _ := ParseRule("B1/S")
+	c.SetRule(r)
+	g := NewEmptyGrid(5, 5)
+	for x := 1; x <= 3; x++ {
+		g.SetAlive(x, 2, true)
+	}
+	g.Rule = r
+	c.Step()
+	g.Step()
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 5; x++ {
+			if c.Alive(x, y) != g.Alive(x, y) {
+				t.Fatalf("cell %d,%d differs after the rule change", x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package life
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Neighbourhood selects which surrounding cells neighboursCount looks at
+type Neighbourhood int
+
+const (
+	// Moore is the eight cells surrounding a cell
+	Moore Neighbourhood = iota
+	// VonNeumann is the four orthogonally adjacent cells
+	VonNeumann
+	// Moore2 is the 24 cells within a Chebyshev distance of two
+	Moore2
+	// Circular is the 20 cells within a Euclidean distance of about two,
+	// i.e. Moore2 without its corners
+	Circular
+	// Hexagonal treats the grid as a hex lattice in "odd-r" layout: odd rows
+	// sit half a cell to the right of even rows, giving each cell six
+	// neighbours. EdgeWrap needs an even height to keep the layout seamless.
+	Hexagonal
+)
+
+type offset struct{ dx, dy int }
+
+var neighbourhoods = [...]struct {
+	name    string
+	offsets []offset
+}{
+	Moore:      {"moore", square(1, func(dx, dy int) bool { return true })},
+	VonNeumann: {"von-neumann", square(1, func(dx, dy int) bool { return dx == 0 || dy == 0 })},
+	Moore2:     {"moore2", square(2, func(dx, dy int) bool { return true })},
+	Circular:   {"circular", square(2, func(dx, dy int) bool { return dx*dx+dy*dy <= 5 })},
+	Hexagonal:  {"hexagonal", hexEven},
+}
+
+// Hex neighbours in odd-r layout; rows above and below lean towards the
+// side the current row is shifted away from
+var (
+	hexEven = []offset{{-1, -1}, {0, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}}
+	hexOdd  = []offset{{0, -1}, {1, -1}, {-1, 0}, {1, 0}, {0, 1}, {1, 1}}
+)
+
+// square lists the offsets within radius r of the centre that satisfy keep
+func square(r int, keep func(dx, dy int) bool) []offset {
+	var offsets []offset
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			if (dx != 0 || dy != 0) && keep(dx, dy) {
+				offsets = append(offsets, offset{dx, dy})
+			}
+		}
+	}
+	return offsets
+}
+
+// Size returns the number of cells in the neighbourhood, the largest possible
+// live neighbour count
+func (n Neighbourhood) Size() int { return len(n.offsets()) }
+
+// offsetsAt returns the neighbour offsets for a cell on row y
+func (n Neighbourhood) offsetsAt(y int) []offset {
+	if n == Hexagonal && y&1 == 1 {
+		return hexOdd
+	}
+	return n.offsets()
+}
+
+func (n Neighbourhood) offsets() []offset {
+	if n < 0 || int(n) >= len(neighbourhoods) {
+		return neighbourhoods[Moore].offsets
+	}
+	return neighbourhoods[n].offsets
+}
+
+func (n Neighbourhood) String() string {
+	if n < 0 || int(n) >= len(neighbourhoods) {
+		return fmt.Sprintf("Neighbourhood(%d)", int(n))
+	}
+	return neighbourhoods[n].name
+}
+
+// ParseNeighbourhood converts a name such as "von-neumann" into a Neighbourhood
+func ParseNeighbourhood(s string) (Neighbourhood, error) {
+	name := strings.NewReplacer("_", "-", " ", "-").Replace(strings.ToLower(strings.TrimSpace(s)))
+	if name == "vonneumann" {
+		name = "von-neumann"
+	}
+	for n, nb := range neighbourhoods {
+		if nb.name == name {
+			return Neighbourhood(n), nil
+		}
+	}
+	if name == "hex" {
+		return Hexagonal, nil
+	}
+	return Moore, fmt.Errorf("invalid neighbourhood %q (want moore, von-neumann, moore2, circular or hexagonal)", s)
+}
+
+// Set implements flag.Value
+func (n *Neighbourhood) Set(s string) error {
+	v, err := ParseNeighbourhood(s)
+	if err != nil {
+		return err
+	}
+	*n = v
+	return nil
+}
@@ -0,0 +1,25 @@
+package life
+
+import "math/rand"
+
+// Perturb flips each cell of b with probability fraction, drawing from rng,
+// and returns the number of cells flipped. A little noise every few
+// generations stops a board from settling into still lifes for good. Boards
+// that are not Setters are left alone.
+func Perturb(rng *rand.Rand, b Board, fraction float64) int {
+	s, ok := b.(Setter)
+	if !ok || fraction <= 0 {
+		return 0
+	}
+	width, height := b.Size()
+	flipped := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if rng.Float64() < fraction {
+				s.SetAlive(x, y, !b.Alive(x, y))
+				flipped++
+			}
+		}
+	}
+	return flipped
+}
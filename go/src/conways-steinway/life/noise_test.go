@@ -0,0 +1,30 @@
+package life
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPerturbFlipsAFraction(t *testing.T) {
+	g := NewEmptyGrid(BoardWidth, BoardHeight)
+	flipped := Perturb(rand.New(rand.NewSource(1)), g, 0.01)
+	if n := g.Stats().Population; n != flipped {
+		t.Fatalf("population %d after flipping %d cells of an empty board", n, flipped)
+	}
+	// 1% of 3520 cells is about 35
+	if flipped < 15 || flipped > 60 {
+		t.Fatalf("flipped %d cells, want about 35", flipped)
+	}
+	Perturb(rand.New(rand.NewSource(1)), g, 0.01)
+	if n := g.Stats().Population; n != 0 {
+		t.Fatalf("the same draws should flip the same cells back, %d left alive", n)
+	}
+}
+
+func TestPerturbZero(t *testing.T) {
+	g := NewGrid(8, 8, rand.New(rand.NewSource(2)), 0.5)
+	before := g.Hash()
+	if n := Perturb(rand.New(rand.NewSource(3)), g, 0); n != 0 || g.Hash() != before {
+		t.Fatal("zero noise should leave the board alone")
+	}
+}
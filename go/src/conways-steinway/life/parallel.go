@@ -0,0 +1,24 @@
+package life
+
+import "sync"
+
+// parallelRows calls step over bands of rows covering [0, height), in
+// parallel when workers > 1. step must only write rows inside its band.
+func parallelRows(height, workers int, step func(y0, y1 int)) {
+	if workers <= 1 || height < 2 {
+		step(0, height)
+		return
+	}
+	workers = min(workers, height)
+	band := (height + workers - 1) / workers
+	var wg sync.WaitGroup
+	for y0 := 0; y0 < height; y0 += band {
+		y1 := min(y0+band, height)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			step(y0, y1)
+		}()
+	}
+	wg.Wait()
+}
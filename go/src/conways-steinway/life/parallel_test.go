@@ -0,0 +1,43 @@
+package life
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParallelStepMatchesSerial(t *testing.T) {
+	for _, workers := range []int{2, 3, 8, 64} {
+		serial, serialBits := randomPair(88, 41, 7)
+		parallel, parallelBits := randomPair(88, 41, 7)
+		serial.Edge, parallel.Edge = EdgeWrap, EdgeWrap
+		parallel.Workers, parallelBits.Workers = workers, workers
+		for gen := 0; gen < 25; gen++ {
+			serial.Step()
+			parallel.Step()
+			serialBits.Step()
+			parallelBits.Step()
+		}
+		for y := 0; y < serial.Height; y++ {
+			for x := 0; x < serial.Width; x++ {
+				if serial.Alive(x, y) != parallel.Alive(x, y) {
+					t.Fatalf("%d workers: grid cell %d,%d differs", workers, x, y)
+				}
+				if serialBits.Alive(x, y) != parallelBits.Alive(x, y) {
+					t.Fatalf("%d workers: bit grid cell %d,%d differs", workers, x, y)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkGridStepWorkers(b *testing.B) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			g, _ := randomPair(88, 512, 1)
+			g.Workers = workers
+			for b.Loop() {
+				g.Step()
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package life
+
+// Pattern is a set of live cells that can be stamped onto a board
+type Pattern struct {
+	Width, Height int     // bounding box
+	Rule          *Rule   // rule the pattern was designed for, nil if unknown
+	Cells         []Coord // live cells, relative to the top-left corner
+}
+
+// NewPattern returns the pattern made of cells given in arbitrary, possibly
+// negative, coordinates, moved so its bounding box starts at (0, 0)
+func NewPattern(cells []Coord) *Pattern {
+	p := &Pattern{}
+	if len(cells) == 0 {
+		return p
+	}
+	lo, hi := cells[0], cells[0]
+	for _, c := range cells[1:] {
+		lo = Coord{min(lo.X, c.X), min(lo.Y, c.Y)}
+		hi = Coord{max(hi.X, c.X), max(hi.Y, c.Y)}
+	}
+	p.Cells = make([]Coord, len(cells))
+	for i, c := range cells {
+		p.Cells[i] = Coord{c.X - lo.X, c.Y - lo.Y}
+	}
+	p.Width, p.Height = hi.X-lo.X+1, hi.Y-lo.Y+1
+	return p
+}
+
+// Place brings the pattern's live cells to life on b with its top-left corner
+// at (x, y). Other cells are left as they are.
+func (p *Pattern) Place(b Setter, x, y int) {
+	for _, c := range p.Cells {
+		b.SetAlive(x+c.X, y+c.Y, true)
+	}
+}
+
+// Transform is a rotation or reflection of a pattern
+type Transform int
+
+const (
+	// Rotate90 turns the pattern a quarter turn clockwise
+	Rotate90 Transform = iota
+	// Rotate180 turns the pattern half a turn
+	Rotate180
+	// Rotate270 turns the pattern a quarter turn anticlockwise
+	Rotate270
+	// FlipHorizontal mirrors the pattern left to right
+	FlipHorizontal
+	// FlipVertical mirrors the pattern top to bottom
+	FlipVertical
+)
+
+// Transform returns a copy of the pattern with t applied; the copy's
+// bounding box still starts at (0, 0)
+func (p *Pattern) Transform(t Transform) *Pattern {
+	w, h := p.Width, p.Height
+	q := &Pattern{Width: w, Height: h, Rule: p.Rule, Cells: make([]Coord, len(p.Cells))}
+	if t == Rotate90 || t == Rotate270 {
+		q.Width, q.Height = h, w
+	}
+	for i, c := range p.Cells {
+		switch t {
+		case Rotate90:
+			c = Coord{h - 1 - c.Y, c.X}
+		case Rotate180:
+			c = Coord{w - 1 - c.X, h - 1 - c.Y}
+		case Rotate270:
+			c = Coord{c.Y, w - 1 - c.X}
+		case FlipHorizontal:
+			c = Coord{w - 1 - c.X, c.Y}
+		case FlipVertical:
+			c = Coord{c.X, h - 1 - c.Y}
+		}
+		q.Cells[i] = c
+	}
+	return q
+}
+
+// Place stamps p onto the grid with its top-left corner at (x, y), after
+// applying the transforms in order. Cells falling off the grid are dropped.
+func (g *Grid) Place(p *Pattern, x, y int, transforms ...Transform) {
+	for _, t := range transforms {
+		p = p.Transform(t)
+	}
+	p.Place(g, x, y)
+}
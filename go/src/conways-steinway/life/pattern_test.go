@@ -0,0 +1,47 @@
+package life
+
+import "testing"
+
+// lShape is three cells down and one to the right:
+//
+//	#.
+//	#.
+//	##
+var lShape = NewPattern([]Coord{{5, 5}, {5, 6}, {5, 7}, {6, 7}})
+
+func TestNewPatternNormalises(t *testing.T) {
+	if lShape.Width != 2 || lShape.Height != 3 {
+		t.Fatalf("size = %dx%d, want 2x3", lShape.Width, lShape.Height)
+	}
+	if lShape.Cells[0] != (Coord{0, 0}) {
+		t.Fatalf("Cells = %v, want them moved to the origin", lShape.Cells)
+	}
+}
+
+func TestGridPlaceTransforms(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		transforms []Transform
+		want       []string
+	}{
+		{"none", nil, []string{"#..", "#..", "##."}},
+		{"rotate90", []Transform{Rotate90}, []string{"###", "#..", "..."}},
+		{"rotate180", []Transform{Rotate180}, []string{"##.", ".#.", ".#."}},
+		{"rotate270", []Transform{Rotate270}, []string{"..#", "###", "..."}},
+		{"flip-horizontal", []Transform{FlipHorizontal}, []string{".#.", ".#.", "##."}},
+		{"flip-vertical", []Transform{FlipVertical}, []string{"##.", "#..", "#.."}},
+		{"rotate90 twice", []Transform{Rotate90, Rotate90}, []string{"##.", ".#.", ".#."}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewEmptyGrid(3, 3)
+			g.Place(lShape, 0, 0, tc.transforms...)
+			assertRows(t, g, tc.want...)
+		})
+	}
+}
+
+func TestGridPlaceClipsAtTheEdge(t *testing.T) {
+	g := NewEmptyGrid(3, 3)
+	g.Place(lShape, 2, 1)
+	assertRows(t, g, "...", "..#", "..#")
+}
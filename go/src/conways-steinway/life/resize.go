@@ -0,0 +1,107 @@
+package life
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Anchor says which part of a grid stays in place when it is resized
+type Anchor int
+
+const (
+	// AnchorTopLeft keeps the top-left corner fixed, growing or cropping to
+	// the right and bottom
+	AnchorTopLeft Anchor = iota
+	// AnchorTop keeps the middle of the top edge fixed
+	AnchorTop
+	// AnchorTopRight keeps the top-right corner fixed
+	AnchorTopRight
+	// AnchorLeft keeps the middle of the left edge fixed
+	AnchorLeft
+	// AnchorCentre keeps the middle of the board in the middle
+	AnchorCentre
+	// AnchorRight keeps the middle of the right edge fixed
+	AnchorRight
+	// AnchorBottomLeft keeps the bottom-left corner fixed
+	AnchorBottomLeft
+	// AnchorBottom keeps the middle of the bottom edge fixed
+	AnchorBottom
+	// AnchorBottomRight keeps the bottom-right corner fixed
+	AnchorBottomRight
+)
+
+var anchorNames = [...]string{
+	AnchorTopLeft:     "top-left",
+	AnchorTop:         "top",
+	AnchorTopRight:    "top-right",
+	AnchorLeft:        "left",
+	AnchorCentre:      "centre",
+	AnchorRight:       "right",
+	AnchorBottomLeft:  "bottom-left",
+	AnchorBottom:      "bottom",
+	AnchorBottomRight: "bottom-right",
+}
+
+func (a Anchor) String() string {
+	if a < 0 || int(a) >= len(anchorNames) {
+		return fmt.Sprintf("Anchor(%d)", int(a))
+	}
+	return anchorNames[a]
+}
+
+// ParseAnchor converts a name such as "centre" or "bottom-left" into an Anchor
+func ParseAnchor(s string) (Anchor, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "center" {
+		name = "centre"
+	}
+	for a, n := range anchorNames {
+		if n == name {
+			return Anchor(a), nil
+		}
+	}
+	return AnchorTopLeft, fmt.Errorf("invalid anchor %q (want %s)", s, strings.Join(anchorNames[:], ", "))
+}
+
+// Set implements flag.Value
+func (a *Anchor) Set(s string) error {
+	v, err := ParseAnchor(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// anchorShift returns how far the old contents move when a side of length from
+// becomes to long; i is 0, 1 or 2 for the start, middle or end of the side
+func anchorShift(from, to, i int) int {
+	return (to - from) * i / 2
+}
+
+// Resize changes the grid to width by height cells, e.g. to widen a 61-key
+// board to 88 keys mid-performance. Cells where the old and new boards
+// overlap, as lined up by anchor, keep their state; new cells are dead.
+// Generations kept for StepBack are discarded since they no longer fit.
+func (g *Grid) Resize(width, height int, anchor Anchor) {
+	if width == g.Width && height == g.Height {
+		return
+	}
+	dx := anchorShift(g.Width, width, int(anchor)%3)
+	dy := anchorShift(g.Height, height, int(anchor)/3)
+	cells := make([]Cell, width*height)
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			nx, ny := x+dx, y+dy
+			if nx >= 0 && nx < width && ny >= 0 && ny < height {
+				cells[nx+ny*width] = g.Cells[g.Index(x, y)]
+			}
+		}
+	}
+	g.Width, g.Height = width, height
+	g.Cells = cells
+	g.next = make([]Cell, len(cells))
+	if g.history != nil {
+		g.history = newHistory(len(g.history.states))
+	}
+}
@@ -0,0 +1,76 @@
+package life
+
+import "testing"
+
+func TestResizeGrow(t *testing.T) {
+	for _, tc := range []struct {
+		anchor Anchor
+		want   []string
+	}{
+		{AnchorTopLeft, []string{"#...", ".#..", "....", "...."}},
+		{AnchorCentre, []string{"....", ".#..", "..#.", "...."}},
+		{AnchorBottomRight, []string{"....", "....", "..#.", "...#"}},
+		{AnchorTop, []string{".#..", "..#.", "....", "...."}},
+	} {
+		t.Run(tc.anchor.String(), func(t *testing.T) {
+			g := gridFromRows("#.", ".#")
+			g.Resize(4, 4, tc.anchor)
+			assertRows(t, g, tc.want...)
+		})
+	}
+}
+
+func TestResizeCrop(t *testing.T) {
+	g := gridFromRows(
+		"#...#",
+		".....",
+		"..#..",
+		".....",
+		"#...#",
+	)
+	g.Resize(3, 3, AnchorCentre)
+	assertRows(t, g, "...", ".#.", "...")
+
+	g = gridFromRows("#..", "...", "..#")
+	g.Resize(2, 2, AnchorBottomRight)
+	assertRows(t, g, "..", ".#")
+}
+
+func TestResizeKeepsStepping(t *testing.T) {
+	g := gridFromRows(
+		".....",
+		"..#..",
+		"..#..",
+		"..#..",
+		".....",
+	)
+	g.SetHistory(4)
+	g.Step()
+	g.Resize(7, 5, AnchorCentre)
+	if g.StepBack() || g.HistoryLen() != 0 {
+		t.Fatal("StepBack rewound to a generation of the old size")
+	}
+	g.Step()
+	if !g.StepBack() {
+		t.Fatal("StepBack did not rewind a generation of the new size")
+	}
+	g.Step()
+	assertRows(t, g,
+		".......",
+		"...#...",
+		"...#...",
+		"...#...",
+		".......",
+	)
+}
+
+func TestParseAnchor(t *testing.T) {
+	for _, s := range []string{"top-left", "Centre", "center", "bottom-right"} {
+		if _, err := ParseAnchor(s); err != nil {
+			t.Errorf("ParseAnchor(%q): %v", s, err)
+		}
+	}
+	if _, err := ParseAnchor("middle"); err == nil {
+		t.Error("ParseAnchor accepted an unknown anchor")
+	}
+}
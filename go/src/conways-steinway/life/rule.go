@@ -0,0 +1,252 @@
+package life
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Rule is an outer-totalistic birth/survival rule. Bit n of Birth is set when
+// a dead cell with n live neighbours is born; bit n of Survive is set when a
+// live cell with n live neighbours survives.
+//
+// States above 2 make it a Generations rule: a live cell that fails to survive
+// passes through States-2 dying states before it is dead. Dying cells neither
+// count as neighbours nor can be born into.
+//
+// Colours above 1 make it a coloured rule such as Immigration (2) or QuadLife
+// (4): survivors keep their colour and a newborn cell takes the colour most
+// of its live neighbours have.
+type Rule struct {
+	Birth   uint32
+	Survive uint32
+	States  int
+	Colours int
+}
+
+// Conway is the standard Game of Life rule, B3/S23
+var Conway = Rule{Birth: 1 << 3, Survive: 1<<2 | 1<<3}
+
+// Immigration is Life with two colours of cell
+var Immigration = Rule{Birth: Conway.Birth, Survive: Conway.Survive, Colours: 2}
+
+// QuadLife is Life with four colours of cell. A cell born to three parents
+// of different colours takes the fourth.
+var QuadLife = Rule{Birth: Conway.Birth, Survive: Conway.Survive, Colours: 4}
+
+// BriansBrain is the Generations rule B2/S/C3. Every cell is ready (dead),
+// firing (alive) or refractory (dying): a ready cell fires when exactly two
+// neighbours are firing, and a firing cell always becomes refractory for one
+// generation before it is ready again.
+var BriansBrain = Rule{Birth: 1 << 2, States: 3}
+
+// maxColours is the largest colour count a Rule can have
+const maxColours = 8
+
+// Next returns the state of a cell in the next generation
+func (r Rule) Next(alive bool, neighbours int) bool {
+	if alive {
+		return r.Survive&(1<<neighbours) != 0
+	}
+	return r.Birth&(1<<neighbours) != 0
+}
+
+// Advance returns the next state of cell c given its live neighbour count.
+// A surviving cell's Age goes up by one.
+func (r Rule) Advance(c Cell, neighbours int) Cell {
+	switch {
+	case c.State > 0:
+		if int(c.State)+2 >= r.States {
+			return Cell{}
+		}
+		return Cell{State: c.State + 1}
+	case c.Alive:
+		if r.Survive&(1<<neighbours) != 0 {
+			if c.Age < math.MaxUint16 {
+				c.Age++
+			}
+			return c
+		}
+		if r.States > 2 {
+			return Cell{State: 1}
+		}
+		return Cell{}
+	default:
+		return Cell{Alive: r.Birth&(1<<neighbours) != 0}
+	}
+}
+
+// ParseRule parses a rulestring in B/S notation, e.g. "B3/S23" (Life),
+// "B36/S23" (HighLife) or "B2/S" (Seeds). The older S/B form "23/3" is also
+// accepted. Generations rules add a state count, either as "B2/S/C3" or in
+// the S/B/C form "/2/3" (both Brian's Brain). Neighbour counts above 8, for
+// the larger neighbourhoods, are written as comma-separated numbers or ranges,
+// e.g. "B5-7/S4,6,8-10". The coloured rules are named "Immigration" and
+// "QuadLife", or given a colour count as in "B36/S23/K2"; Brian's Brain can
+// also be named "BriansBrain".
+func ParseRule(s string) (Rule, error) {
+	text := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	switch text {
+	case "":
+		return Rule{}, fmt.Errorf("empty rulestring")
+	case "IMMIGRATION":
+		return Immigration, nil
+	case "QUADLIFE":
+		return QuadLife, nil
+	case "BRIANSBRAIN", "BRIANS-BRAIN", "BRIAN'SBRAIN":
+		return BriansBrain, nil
+	}
+
+	var birth, survive, states, colours string
+	switch {
+	case strings.HasPrefix(text, "B") || strings.HasPrefix(text, "S"):
+		parts := strings.Split(text, "/")
+		if len(parts) == 1 {
+			// "B3S23" without a separator
+			if i := strings.IndexAny(text[1:], "BS"); i >= 0 {
+				parts = []string{text[:i+1], text[i+1:]}
+			}
+		}
+		for _, p := range parts {
+			switch {
+			case strings.HasPrefix(p, "B"):
+				birth = p[1:]
+			case strings.HasPrefix(p, "S"):
+				survive = p[1:]
+			case strings.HasPrefix(p, "C") || strings.HasPrefix(p, "G"):
+				states = p[1:]
+			case strings.HasPrefix(p, "K"):
+				colours = p[1:]
+			case p != "" && strings.Trim(p, "0123456789") == "":
+				states = p
+			default:
+				return Rule{}, fmt.Errorf("invalid rulestring %q", s)
+			}
+		}
+	default:
+		parts := strings.Split(text, "/")
+		switch len(parts) {
+		case 2:
+			survive, birth = parts[0], parts[1]
+		case 3:
+			survive, birth, states = parts[0], parts[1], parts[2]
+		default:
+			return Rule{}, fmt.Errorf("invalid rulestring %q", s)
+		}
+	}
+
+	var r Rule
+	var err error
+	if r.Birth, err = parseCounts(birth); err != nil {
+		return Rule{}, fmt.Errorf("invalid rulestring %q: %w", s, err)
+	}
+	if r.Survive, err = parseCounts(survive); err != nil {
+		return Rule{}, fmt.Errorf("invalid rulestring %q: %w", s, err)
+	}
+	if states != "" {
+		if _, err := fmt.Sscanf(states, "%d", &r.States); err != nil || r.States < 2 || r.States > 256 {
+			return Rule{}, fmt.Errorf("invalid rulestring %q: state count must be 2-256", s)
+		}
+	}
+	if colours != "" {
+		if _, err := fmt.Sscanf(colours, "%d", &r.Colours); err != nil || r.Colours < 2 || r.Colours > maxColours {
+			return Rule{}, fmt.Errorf("invalid rulestring %q: colour count must be 2-%d", s, maxColours)
+		}
+		if r.States > 2 {
+			return Rule{}, fmt.Errorf("invalid rulestring %q: a Generations rule cannot have colours", s)
+		}
+	}
+	return r, nil
+}
+
+// maxNeighbours is the largest neighbour count a Rule can express
+const maxNeighbours = 24
+
+// parseCounts turns a digit list such as "23", or a list of numbers and
+// ranges such as "4,6,8-10", into a neighbour-count mask
+func parseCounts(counts string) (uint32, error) {
+	var mask uint32
+	if !strings.ContainsAny(counts, ",-") {
+		for _, d := range counts {
+			if d < '0' || d > '8' {
+				return 0, fmt.Errorf("neighbour count %q out of range 0-8", d)
+			}
+			mask |= 1 << (d - '0')
+		}
+		return mask, nil
+	}
+	for _, item := range strings.Split(counts, ",") {
+		var lo, hi int
+		if _, err := fmt.Sscanf(item, "%d-%d", &lo, &hi); err != nil {
+			if _, err := fmt.Sscanf(item, "%d", &lo); err != nil {
+				return 0, fmt.Errorf("invalid neighbour count %q", item)
+			}
+			hi = lo
+		}
+		if lo < 0 || hi > maxNeighbours || lo > hi {
+			return 0, fmt.Errorf("neighbour count %q out of range 0-%d", item, maxNeighbours)
+		}
+		for n := lo; n <= hi; n++ {
+			mask |= 1 << n
+		}
+	}
+	return mask, nil
+}
+
+// String formats the rule in B/S notation, with a /C suffix for Generations
+// rules and a /K suffix for coloured ones other than Immigration and QuadLife
+func (r Rule) String() string {
+	switch r {
+	case Immigration:
+		return "Immigration"
+	case QuadLife:
+		return "QuadLife"
+	}
+	s := "B" + formatCounts(r.Birth) + "/S" + formatCounts(r.Survive)
+	if r.States > 2 {
+		s += fmt.Sprintf("/C%d", r.States)
+	}
+	if r.Colours > 1 {
+		s += fmt.Sprintf("/K%d", r.Colours)
+	}
+	return s
+}
+
+func formatCounts(mask uint32) string {
+	if mask>>9 == 0 {
+		var b strings.Builder
+		for n := 0; n <= 8; n++ {
+			if mask&(1<<n) != 0 {
+				b.WriteByte(byte('0' + n))
+			}
+		}
+		return b.String()
+	}
+	var items []string
+	for n := 0; n <= maxNeighbours; n++ {
+		if mask&(1<<n) == 0 {
+			continue
+		}
+		hi := n
+		for hi < maxNeighbours && mask&(1<<(hi+1)) != 0 {
+			hi++
+		}
+		if hi > n {
+			items = append(items, fmt.Sprintf("%d-%d", n, hi))
+		} else {
+			items = append(items, fmt.Sprint(n))
+		}
+		n = hi
+	}
+	return strings.Join(items, ",")
+}
+
+// Set implements flag.Value
+func (r *Rule) Set(s string) error {
+	v, err := ParseRule(s)
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
@@ -0,0 +1,86 @@
+package life
+
+// Soup summarises how a random starting board played out
+type Soup struct {
+	Lifespan      int // generations before the board died out or entered a cycle
+	MaxPopulation int // largest population seen
+	Period        int // period of the cycle it settled into; 0 if it never did
+	Oscillators   int // separate groups of cells still changing once it settled
+}
+
+// Score ranks soups by how musically interesting they are likely to be: long
+// lives matter most, then busy boards and lasting oscillators
+func (s Soup) Score() float64 {
+	return float64(s.Lifespan) + float64(s.MaxPopulation)/10 + 20*float64(s.Oscillators)
+}
+
+// RunSoup steps b until it dies out, enters a cycle no longer than window, or
+// limit generations have passed, and reports what happened
+func RunSoup(b Board, limit, window int) Soup {
+	var s Soup
+	cycles := NewCycleDetector(window)
+	for gen := 0; gen < limit; gen++ {
+		pop := population(b)
+		s.MaxPopulation = max(s.MaxPopulation, pop)
+		if pop == 0 {
+			s.Lifespan = gen
+			return s
+		}
+		if period, ok := cycles.Observe(gen, b); ok {
+			s.Lifespan, s.Period = gen-period, period
+			s.Oscillators = oscillators(b, period)
+			return s
+		}
+		b.Step()
+	}
+	s.Lifespan = limit
+	return s
+}
+
+// oscillators steps b through one period of its cycle and counts the
+// 8-connected groups of cells that change along the way
+func oscillators(b Board, period int) int {
+	width, height := b.Size()
+	first := make([]bool, width*height)
+	changing := make([]bool, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			first[x+y*width] = b.Alive(x, y)
+		}
+	}
+	for i := 0; i < period; i++ {
+		b.Step()
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if b.Alive(x, y) != first[x+y*width] {
+					changing[x+y*width] = true
+				}
+			}
+		}
+	}
+
+	groups := 0
+	var stack []Coord
+	for start, c := range changing {
+		if !c {
+			continue
+		}
+		groups++
+		changing[start] = false
+		stack = append(stack[:0], Coord{start % width, start / width})
+		for len(stack) > 0 {
+			p := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					x, y := p.X+dx, p.Y+dy
+					if x >= 0 && x < width && y >= 0 && y < height && changing[x+y*width] {
+						changing[x+y*width] = false
+						stack = append(stack, Coord{x, y})
+					}
+				}
+			}
+		}
+	}
+	return groups
+}
@@ -0,0 +1,48 @@
+package life
+
+import "testing"
+
+func TestRunSoupDiesOut(t *testing.T) {
+	g := gridFromRows(
+		".....",
+		"..#..",
+		".....",
+	)
+	s := RunSoup(g, 100, 16)
+	if s.Lifespan != 1 || s.MaxPopulation != 1 || s.Period != 0 {
+		t.Fatalf("RunSoup = %+v, want it dead after one generation", s)
+	}
+}
+
+func TestRunSoupCountsOscillators(t *testing.T) {
+	g := gridFromRows(
+		"...........",
+		"..#.....#..",
+		"..#.....#..",
+		"..#.....#..",
+		"...........",
+		"....##.....",
+		"....##.....",
+	)
+	s := RunSoup(g, 100, 16)
+	if s.Lifespan != 0 || s.Period != 2 || s.Oscillators != 2 || s.MaxPopulation != 10 {
+		t.Fatalf("RunSoup = %+v, want two blinkers and a block already in a period 2 cycle", s)
+	}
+}
+
+func TestRunSoupLimit(t *testing.T) {
+	g := NewEmptyGrid(20, 20)
+	g.Edge = EdgeWrap
+	g.Place(NewPattern([]Coord{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}), 0, 0)
+	if s := RunSoup(g, 30, 8); s.Lifespan != 30 {
+		t.Fatalf("Lifespan = %d, want the limit since the glider's 80-generation cycle exceeds the window", s.Lifespan)
+	}
+}
+
+func TestSoupScorePrefersLongerLives(t *testing.T) {
+	short := Soup{Lifespan: 100, MaxPopulation: 500}
+	long := Soup{Lifespan: 400, MaxPopulation: 300, Oscillators: 2}
+	if short.Score() >= long.Score() {
+		t.Fatalf("Score: short %v >= long %v", short.Score(), long.Score())
+	}
+}
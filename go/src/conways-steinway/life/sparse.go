@@ -0,0 +1,173 @@
+package life
+
+import "io"
+
+// SparseGrid is an unbounded board that stores only the cells that are not
+// dead, so patterns can travel arbitrarily far from where they started.
+// Rules that give birth with zero neighbours (B0) are not supported, as they
+// would fill the infinite plane.
+type SparseGrid struct {
+	Rule          Rule
+	Neighbourhood Neighbourhood
+
+	cells map[Coord]Cell
+
+	births, deaths int // changes made by the last Step
+}
+
+// NewSparseGrid returns an empty unbounded board running Conway's rule
+func NewSparseGrid() *SparseGrid {
+	return &SparseGrid{Rule: Conway, cells: make(map[Coord]Cell)}
+}
+
+// Alive reports whether the cell at (x, y) is alive
+func (s *SparseGrid) Alive(x, y int) bool { return s.cells[Coord{x, y}].Alive }
+
+// Cell returns the cell at (x, y)
+func (s *SparseGrid) Cell(x, y int) Cell { return s.cells[Coord{x, y}] }
+
+// SetAlive sets the state of the cell at (x, y)
+func (s *SparseGrid) SetAlive(x, y int, alive bool) {
+	if alive {
+		s.cells[Coord{x, y}] = Cell{Alive: true}
+	} else {
+		delete(s.cells, Coord{x, y})
+	}
+}
+
+// SetRule changes the rule the following generations are stepped with
+func (s *SparseGrid) SetRule(r Rule) { s.Rule = r }
+
+// Population returns the number of live cells
+func (s *SparseGrid) Population() int {
+	n := 0
+	for _, c := range s.cells {
+		if c.Alive {
+			n++
+		}
+	}
+	return n
+}
+
+// Bounds returns the smallest rectangle containing every live cell. ok is
+// false when the board is empty.
+func (s *SparseGrid) Bounds() (lo, hi Coord, ok bool) {
+	for p, c := range s.cells {
+		if !c.Alive {
+			continue
+		}
+		if !ok {
+			lo, hi, ok = p, p, true
+			continue
+		}
+		lo.X, lo.Y = min(lo.X, p.X), min(lo.Y, p.Y)
+		hi.X, hi.Y = max(hi.X, p.X), max(hi.Y, p.Y)
+	}
+	return lo, hi, ok
+}
+
+// Step advances the board by one generation. Only live cells, their
+// neighbours and dying cells are visited.
+func (s *SparseGrid) Step() {
+	counts := make(map[Coord]int, len(s.cells)*4)
+	for p, c := range s.cells {
+		if !c.Alive {
+			continue
+		}
+		for _, o := range s.Neighbourhood.offsetsAt(p.Y) {
+			counts[Coord{p.X + o.dx, p.Y + o.dy}]++
+		}
+	}
+
+	s.births, s.deaths = 0, 0
+	next := make(map[Coord]Cell, len(s.cells))
+	for p, c := range s.cells {
+		n := s.Rule.Advance(c, counts[p])
+		if n != (Cell{}) {
+			next[p] = n
+		}
+		if c.Alive && !n.Alive {
+			s.deaths++
+		}
+	}
+	for p, n := range counts {
+		if _, visited := s.cells[p]; visited {
+			continue
+		}
+		if c := s.Rule.Advance(Cell{}, n); c.Alive {
+			next[p] = c
+			s.births++
+		}
+	}
+	s.cells = next
+}
+
+// Age returns how many generations the cell at (x, y) has survived, or 0 if
+// it is dead
+func (s *SparseGrid) Age(x, y int) int {
+	if c := s.cells[Coord{x, y}]; c.Alive {
+		return int(c.Age)
+	}
+	return 0
+}
+
+// Plane is an unbounded board
+type Plane interface {
+	Alive(x, y int) bool
+	Cell(x, y int) Cell
+	Step()
+}
+
+// View projects a fixed-size window of a Plane, e.g. the 88 columns mapped to
+// the piano, so an unbounded board can be used as a Board
+type View struct {
+	Plane         Plane
+	Origin        Coord // plane coordinate shown at the view's top left
+	Width, Height int
+}
+
+// Size returns the dimensions of the view
+func (v *View) Size() (width, height int) { return v.Width, v.Height }
+
+// Alive reports whether the cell at (x, y) within the view is alive
+func (v *View) Alive(x, y int) bool {
+	return x >= 0 && x < v.Width && y >= 0 && y < v.Height && v.Plane.Alive(v.Origin.X+x, v.Origin.Y+y)
+}
+
+// SetAlive sets the cell at (x, y) within the view, if the plane allows it
+func (v *View) SetAlive(x, y int, alive bool) {
+	if s, ok := v.Plane.(Setter); ok && x >= 0 && x < v.Width && y >= 0 && y < v.Height {
+		s.SetAlive(v.Origin.X+x, v.Origin.Y+y, alive)
+	}
+}
+
+// Step advances the underlying board
+func (v *View) Step() { v.Plane.Step() }
+
+// SetRule changes the rule of the plane, if it has a RuleSetter
+func (v *View) SetRule(r Rule) {
+	if s, ok := v.Plane.(RuleSetter); ok {
+		s.SetRule(r)
+	}
+}
+
+// Age returns the age of the cell at (x, y) of the window, or 0 if the plane
+// does not track ages
+func (v *View) Age(x, y int) int {
+	if a, ok := v.Plane.(Ager); ok {
+		return a.Age(v.Origin.X+x, v.Origin.Y+y)
+	}
+	return 0
+}
+
+// Advance moves the underlying board forward n generations
+func (v *View) Advance(n int) { Advance(v.Plane, n) }
+
+// Print writes the cells inside the view to w
+func (v *View) Print(w io.Writer) {
+	s, ok := v.Plane.(*SparseGrid)
+	hex := ok && s.Neighbourhood == Hexagonal
+	printCells(w, v.Width, v.Height, hex, func(x, y int) Cell {
+		return v.Plane.Cell(v.Origin.X+x, v.Origin.Y+y)
+	})
+}
@@ -0,0 +1,80 @@
+package life
+
+import "testing"
+
+func TestSparseGliderLeavesStartingArea(t *testing.T) {
+	s := NewSparseGrid()
+	for _, p := range []Coord{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}} {
+		s.SetAlive(p.X, p.Y, true)
+	}
+	for i := 0; i < 400; i++ {
+		s.Step()
+	}
+	if s.Population() != 5 {
+		t.Fatalf("Population = %d, want 5", s.Population())
+	}
+	lo, hi, ok := s.Bounds()
+	if !ok {
+		t.Fatal("glider vanished")
+	}
+	// A glider moves one cell diagonally every four generations
+	if lo != (Coord{100, 100}) || hi != (Coord{102, 102}) {
+		t.Fatalf("Bounds = %v..%v, want 100,100..102,102", lo, hi)
+	}
+}
+
+func TestSparseMatchesDenseGrid(t *testing.T) {
+	g := gridFromRows(
+		"..........",
+		"...##.....",
+		"..##......",
+		"...#......",
+		"..........",
+		"..........",
+		"..........",
+		"..........",
+	)
+	s := NewSparseGrid()
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			s.SetAlive(x, y, g.Alive(x, y))
+		}
+	}
+	// The R-pentomino stays inside the dense grid for its first few steps
+	for i := 0; i < 3; i++ {
+		g.Step()
+		s.Step()
+	}
+	v := &View{Plane: s, Width: g.Width, Height: g.Height}
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			if g.Alive(x, y) != v.Alive(x, y) {
+				t.Fatalf("cell %d,%d: dense %v, sparse %v", x, y, g.Alive(x, y), v.Alive(x, y))
+			}
+		}
+	}
+}
+
+func TestViewOffsetsIntoPlane(t *testing.T) {
+	s := NewSparseGrid()
+	s.SetAlive(-5, 7, true)
+	v := &View{Plane: s, Origin: Coord{-10, 5}, Width: 8, Height: 4}
+	if !v.Alive(5, 2) {
+		t.Fatal("expected the live cell at view position 5,2")
+	}
+	if v.Alive(-1, 0) || v.Alive(8, 0) {
+		t.Fatal("cells outside the view must read as dead")
+	}
+}
+
+func TestSparseAgeThroughView(t *testing.T) {
+	s := NewSparseGrid()
+	for _, c := range []Coord{{10, 10}, {11, 10}, {10, 11}, {11, 11}} {
+		s.SetAlive(c.X, c.Y, true)
+	}
+	v := &View{Plane: s, Origin: Coord{8, 8}, Width: 8, Height: 8}
+	Advance(v, 5)
+	if got := v.Age(2, 2); got != 5 {
+		t.Fatalf("Age = %d, want 5", got)
+	}
+}
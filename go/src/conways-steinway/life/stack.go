@@ -0,0 +1,76 @@
+package life
+
+import "fmt"
+
+// Stack is a pile of same-sized grids stepped together as a three-dimensional
+// Life: besides its own neighbours, each cell counts the cells at the same
+// position in the layers directly above and below it. A layer's rule then
+// sees up to two more neighbours than its neighbourhood holds, so activity
+// in one layer seeds births in the next.
+type Stack struct {
+	Layers []*Grid
+
+	Generation int // number of generations stepped
+}
+
+// NewStack stacks layers, bottom first. The grids must all be the same size
+// and should from then on be stepped only through the stack.
+func NewStack(layers ...*Grid) (*Stack, error) {
+	if len(layers) < 2 {
+		return nil, fmt.Errorf("a stack needs at least two layers, got %d", len(layers))
+	}
+	for i, g := range layers {
+		if g.Width != layers[0].Width || g.Height != layers[0].Height {
+			return nil, fmt.Errorf("stack layer %d is %dx%d, want %dx%d", i, g.Width, g.Height, layers[0].Width, layers[0].Height)
+		}
+		g.coupled = nil
+		if i > 0 {
+			g.coupled = append(g.coupled, layers[i-1])
+		}
+		if i < len(layers)-1 {
+			g.coupled = append(g.coupled, layers[i+1])
+		}
+	}
+	return &Stack{Layers: layers}, nil
+}
+
+// Step advances every layer by one generation. Each layer's next generation
+// is worked out from the current generation of all of them before any is
+// replaced.
+func (s *Stack) Step() {
+	for _, g := range s.Layers {
+		g.compute()
+	}
+	for _, g := range s.Layers {
+		g.commit()
+	}
+	s.Generation++
+}
+
+// Layer returns a Board showing layer i. Stepping it steps the whole stack,
+// once for each generation the layer is asked to advance beyond the stack's,
+// so that layers played at different rates all see the same stack.
+func (s *Stack) Layer(i int) Board {
+	return &stackLayer{Grid: s.Layers[i], stack: s}
+}
+
+// stackLayer is one layer of a Stack seen as a Board of its own
+type stackLayer struct {
+	*Grid
+	stack      *Stack
+	generation int
+}
+
+func (l *stackLayer) Step() {
+	l.generation++
+	for l.stack.Generation < l.generation {
+		l.stack.Step()
+	}
+}
+
+// Advance steps the layer n generations
+func (l *stackLayer) Advance(n int) {
+	for i := 0; i < n; i++ {
+		l.Step()
+	}
+}
@@ -0,0 +1,85 @@
+package life
+
+import "testing"
+
+func TestStackCountsAdjacentLayers(t *testing.T) {
+	bottom := gridFromRows(
+		"#..",
+		"...",
+		"..#",
+	)
+	top := gridFromRows(
+		"...",
+		".#.",
+		"...",
+	)
+	s, err := NewStack(bottom, top)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Step()
+	// The centre of the bottom layer has two neighbours of its own and one
+	// above it; the lone cell on top sees nothing alive below and dies
+	assertRows(t, bottom,
+		"...",
+		".#.",
+		"...",
+	)
+	assertRows(t, top,
+		"...",
+		"...",
+		"...",
+	)
+	if s.Generation != 1 || bottom.Generation != 1 || top.Generation != 1 {
+		t.Fatalf("generations = %d, %d, %d, want 1", s.Generation, bottom.Generation, top.Generation)
+	}
+}
+
+func TestStackMiddleLayerSeesBoth(t *testing.T) {
+	layers := []*Grid{gridFromRows("#..", "...", "..."), gridFromRows("...", "...", "..#"), gridFromRows("...", ".#.", "...")}
+	s, err := NewStack(layers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Step()
+	// The middle layer's centre has one neighbour of its own and one in the
+	// layer above, too few to be born; the bottom layer cannot see the top
+	if layers[1].Alive(1, 1) || layers[0].Alive(1, 1) {
+		t.Fatal("no cell should be born")
+	}
+	layers[1].SetAlive(0, 1, true)
+	layers[1].SetAlive(2, 2, true)
+	layers[2].SetAlive(1, 1, true)
+	s.Step()
+	if !layers[1].Alive(1, 1) {
+		t.Fatal("the middle layer's centre should be born with two neighbours and one above")
+	}
+}
+
+func TestStackLayersStepTogether(t *testing.T) {
+	s, err := NewStack(NewEmptyGrid(4, 4), NewEmptyGrid(4, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, slow := s.Layer(0), s.Layer(1)
+	for i := 0; i < 4; i++ {
+		fast.Step()
+	}
+	slow.Step()
+	if s.Generation != 4 {
+		t.Fatalf("stack generation = %d, want 4 after the fastest layer's fourth step", s.Generation)
+	}
+	Advance(slow, 4)
+	if s.Generation != 5 {
+		t.Fatalf("stack generation = %d, want 5", s.Generation)
+	}
+}
+
+func TestNewStackRejectsMismatchedLayers(t *testing.T) {
+	if _, err := NewStack(NewEmptyGrid(4, 4), NewEmptyGrid(4, 5)); err == nil {
+		t.Fatal("expected an error for layers of different sizes")
+	}
+	if _, err := NewStack(NewEmptyGrid(4, 4)); err == nil {
+		t.Fatal("expected an error for a single layer")
+	}
+}
@@ -0,0 +1,96 @@
+package life
+
+// Stats summarises a board and the change made by its last generation
+type Stats struct {
+	Births     int     // cells born by the last Step
+	Deaths     int     // cells that stopped being alive in the last Step
+	Population int     // live cells now
+	Density    float64 // live cells as a fraction of the board's area
+}
+
+// StatsOf returns the statistics for b. Boards that do not track births and
+// deaths report only their population and density.
+func StatsOf(b Board) Stats {
+	if s, ok := b.(interface{ Stats() Stats }); ok {
+		return s.Stats()
+	}
+	width, height := b.Size()
+	return newStats(0, 0, population(b), width*height)
+}
+
+func newStats(births, deaths, population, area int) Stats {
+	s := Stats{Births: births, Deaths: deaths, Population: population}
+	if area > 0 {
+		s.Density = float64(population) / float64(area)
+	}
+	return s
+}
+
+// Stats returns births and deaths for the last generation together with the
+// current population and density
+func (g *Grid) Stats() Stats {
+	n := 0
+	for _, c := range g.Cells {
+		if c.Alive {
+			n++
+		}
+	}
+	return newStats(g.births, g.deaths, n, len(g.Cells))
+}
+
+// countChanges records the births and deaths between the current and next
+// buffers
+func (g *Grid) countChanges() {
+	g.births, g.deaths = 0, 0
+	for i, c := range g.Cells {
+		switch n := g.next[i]; {
+		case n.Alive && !c.Alive:
+			g.births++
+		case c.Alive && !n.Alive:
+			g.deaths++
+		}
+	}
+}
+
+// Stats returns births and deaths for the last generation together with the
+// current population and density
+func (b *BitGrid) Stats() Stats {
+	return newStats(b.births, b.deaths, b.Population(), b.Width*b.Height)
+}
+
+// Stats returns births and deaths for the last generation together with the
+// current population. Density is measured against the bounding box of the
+// live cells.
+func (s *SparseGrid) Stats() Stats {
+	area := 0
+	if lo, hi, ok := s.Bounds(); ok {
+		area = (hi.X - lo.X + 1) * (hi.Y - lo.Y + 1)
+	}
+	return newStats(s.births, s.deaths, s.Population(), area)
+}
+
+// Stats returns the population and density inside the view, with births and
+// deaths taken from the whole plane when it tracks them
+func (v *View) Stats() Stats {
+	var births, deaths int
+	if s, ok := v.Plane.(interface{ Stats() Stats }); ok {
+		plane := s.Stats()
+		births, deaths = plane.Births, plane.Deaths
+	}
+	return newStats(births, deaths, population(v), v.Width*v.Height)
+}
+
+// CentreOfMass returns the mean position of b's live cells, or false if none
+// are alive
+func CentreOfMass(b Board) (x, y float64, ok bool) {
+	n := 0
+	for cx, cy := range LiveCells(b) {
+		x += float64(cx)
+		y += float64(cy)
+		n++
+	}
+	if n == 0 {
+		return 0, 0, false
+	}
+	return x / float64(n), y / float64(n), true
+}
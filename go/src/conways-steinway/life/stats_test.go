@@ -0,0 +1,62 @@
+package life
+
+import "testing"
+
+func TestGridStatsBlinker(t *testing.T) {
+	g := gridFromRows(".....", "..#..", "..#..", "..#..", ".....")
+	if s := g.Stats(); s.Population != 3 || s.Births != 0 || s.Deaths != 0 {
+		t.Fatalf("before stepping: %+v", s)
+	}
+	g.Step()
+	s := g.Stats()
+	if s.Births != 2 || s.Deaths != 2 || s.Population != 3 {
+		t.Fatalf("after stepping: %+v, want 2 births, 2 deaths, population 3", s)
+	}
+	if s.Density != 3.0/25 {
+		t.Fatalf("Density = %v, want %v", s.Density, 3.0/25)
+	}
+}
+
+func TestStatsAgreeAcrossBoards(t *testing.T) {
+	g, b := randomPair(88, 20, 3)
+	sparse := NewSparseGrid()
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			sparse.SetAlive(x, y, g.Alive(x, y))
+		}
+	}
+	g.Step()
+	b.Step()
+	sparse.Step()
+	gs, bs, ss := g.Stats(), b.Stats(), sparse.Stats()
+	if gs != bs {
+		t.Fatalf("grid %+v, bit grid %+v", gs, bs)
+	}
+	// The sparse board lets patterns spill past the grid's dead edges
+	if ss.Births < gs.Births || ss.Deaths != gs.Deaths {
+		t.Fatalf("grid %+v, sparse %+v", gs, ss)
+	}
+}
+
+func TestStatsOfFallsBackToPopulation(t *testing.T) {
+	h, _ := NewHashLife(Conway)
+	h.SetAlive(0, 0, true)
+	h.SetAlive(1, 0, true)
+	v := &View{Plane: h, Width: 4, Height: 2}
+	if s := StatsOf(v); s.Population != 2 || s.Density != 0.25 {
+		t.Fatalf("StatsOf(view) = %+v", s)
+	}
+}
+
+func TestCentreOfMass(t *testing.T) {
+	g := NewEmptyGrid(10, 10)
+	if _, _, ok := CentreOfMass(g); ok {
+		t.Error("empty board has a centre of mass")
+	}
+	g.SetAlive(1, 2, true)
+	g.SetAlive(5, 2, true)
+	g.SetAlive(3, 8, true)
+	if x, y, ok := CentreOfMass(g); !ok || x != 3 || y != 4 {
+		t.Errorf("CentreOfMass = %g, %g, %v, want 3, 4", x, y, ok)
+	}
+}
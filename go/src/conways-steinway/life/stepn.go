@@ -0,0 +1,27 @@
+package life
+
+import "context"
+
+// StepN steps the grid n times, calling fn after each generation with the
+// new generation number. It stops early and returns the error if fn returns
+// one; fn may be nil.
+func (g *Grid) StepN(n int, fn func(gen int, g *Grid) error) error {
+	return g.StepNContext(context.Background(), n, fn)
+}
+
+// StepNContext is StepN that also stops, returning ctx.Err(), once ctx is
+// cancelled. Cancellation is checked before each generation.
+func (g *Grid) StepNContext(ctx context.Context, n int, fn func(gen int, g *Grid) error) error {
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		g.Step()
+		if fn != nil {
+			if err := fn(g.Generation, g); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
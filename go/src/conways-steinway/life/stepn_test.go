@@ -0,0 +1,61 @@
+package life
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStepN(t *testing.T) {
+	g := gridFromRows(
+		".....",
+		"..#..",
+		"..#..",
+		"..#..",
+		".....",
+	)
+	var gens []int
+	err := g.StepN(3, func(gen int, g *Grid) error {
+		gens = append(gens, gen)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gens) != 3 || gens[0] != 1 || gens[2] != 3 {
+		t.Fatalf("callback saw generations %v, want 1 2 3", gens)
+	}
+	assertRows(t, g, ".....", ".....", ".###.", ".....", ".....")
+
+	if err := g.StepN(2, nil); err != nil || g.Generation != 5 {
+		t.Fatalf("StepN with no callback: err %v, generation %d", err, g.Generation)
+	}
+}
+
+func TestStepNStopsOnError(t *testing.T) {
+	g := NewEmptyGrid(4, 4)
+	stop := errors.New("stop")
+	err := g.StepN(10, func(gen int, g *Grid) error {
+		if gen == 4 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || g.Generation != 4 {
+		t.Fatalf("StepN = %v at generation %d, want the callback's error at 4", err, g.Generation)
+	}
+}
+
+func TestStepNContextCancel(t *testing.T) {
+	g := NewEmptyGrid(4, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	err := g.StepNContext(ctx, 10, func(gen int, g *Grid) error {
+		if gen == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || g.Generation != 2 {
+		t.Fatalf("StepNContext = %v at generation %d, want cancellation after 2", err, g.Generation)
+	}
+}
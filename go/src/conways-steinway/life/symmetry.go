@@ -0,0 +1,105 @@
+package life
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Symmetry constrains a random board to look the same after a reflection or
+// rotation
+type Symmetry int
+
+const (
+	// SymmetryNone draws every cell independently
+	SymmetryNone Symmetry = iota
+	// SymmetryHorizontal mirrors the left half onto the right, so the
+	// keyboard plays mirrored chords
+	SymmetryHorizontal
+	// SymmetryVertical mirrors the top half onto the bottom
+	SymmetryVertical
+	// SymmetryFourFold mirrors both ways
+	SymmetryFourFold
+	// SymmetryRotational looks the same after a half turn
+	SymmetryRotational
+)
+
+var symmetryNames = [...]string{
+	SymmetryNone:       "none",
+	SymmetryHorizontal: "horizontal",
+	SymmetryVertical:   "vertical",
+	SymmetryFourFold:   "four-fold",
+	SymmetryRotational: "rotational",
+}
+
+func (s Symmetry) String() string {
+	if s < 0 || int(s) >= len(symmetryNames) {
+		return fmt.Sprintf("Symmetry(%d)", int(s))
+	}
+	return symmetryNames[s]
+}
+
+// ParseSymmetry converts a name such as "four-fold" into a Symmetry
+func ParseSymmetry(s string) (Symmetry, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	switch name {
+	case "", "off":
+		name = "none"
+	case "4-fold", "fourfold":
+		name = "four-fold"
+	}
+	for sym, n := range symmetryNames {
+		if n == name {
+			return Symmetry(sym), nil
+		}
+	}
+	return SymmetryNone, fmt.Errorf("invalid symmetry %q (want %s)", s, strings.Join(symmetryNames[:], ", "))
+}
+
+// Set implements flag.Value
+func (s *Symmetry) Set(v string) error {
+	sym, err := ParseSymmetry(v)
+	if err != nil {
+		return err
+	}
+	*s = sym
+	return nil
+}
+
+// images returns the cells of a width by height rectangle that (x, y) must
+// match under the symmetry, including (x, y) itself
+func (s Symmetry) images(x, y, width, height int) []Coord {
+	mx, my := width-1-x, height-1-y
+	switch s {
+	case SymmetryHorizontal:
+		return []Coord{{x, y}, {mx, y}}
+	case SymmetryVertical:
+		return []Coord{{x, y}, {x, my}}
+	case SymmetryFourFold:
+		return []Coord{{x, y}, {mx, y}, {x, my}, {mx, my}}
+	case SymmetryRotational:
+		return []Coord{{x, y}, {mx, my}}
+	}
+	return []Coord{{x, y}}
+}
+
+// RandomizeSymmetric is Randomize with the rectangle's cells constrained by
+// sym: one cell of each symmetric set is drawn from rng and copied to the rest
+func RandomizeSymmetric(rng *rand.Rand, b Setter, x, y, width, height int, density float64, sym Symmetry) {
+	alive := make([]bool, width*height)
+	for dy := 0; dy < height; dy++ {
+		for dx := 0; dx < width; dx++ {
+			// The image earliest in reading order has already been drawn
+			first := dx + dy*width
+			for _, c := range sym.images(dx, dy, width, height) {
+				first = min(first, c.X+c.Y*width)
+			}
+			if first == dx+dy*width {
+				alive[first] = rng.Float64() < density
+			} else {
+				alive[dx+dy*width] = alive[first]
+			}
+			b.SetAlive(x+dx, y+dy, alive[dx+dy*width])
+		}
+	}
+}
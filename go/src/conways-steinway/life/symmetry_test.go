@@ -0,0 +1,53 @@
+package life
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRandomizeSymmetric(t *testing.T) {
+	for _, tc := range []struct {
+		sym  Symmetry
+		same func(g *Grid, x, y int) bool
+	}{
+		{SymmetryHorizontal, func(g *Grid, x, y int) bool { return g.Alive(x, y) == g.Alive(g.Width-1-x, y) }},
+		{SymmetryVertical, func(g *Grid, x, y int) bool { return g.Alive(x, y) == g.Alive(x, g.Height-1-y) }},
+		{SymmetryFourFold, func(g *Grid, x, y int) bool {
+			return g.Alive(x, y) == g.Alive(g.Width-1-x, y) && g.Alive(x, y) == g.Alive(x, g.Height-1-y)
+		}},
+		{SymmetryRotational, func(g *Grid, x, y int) bool { return g.Alive(x, y) == g.Alive(g.Width-1-x, g.Height-1-y) }},
+	} {
+		t.Run(tc.sym.String(), func(t *testing.T) {
+			// Odd sizes check the cells on the axes too
+			g := NewEmptyGrid(BoardWidth+1, 11)
+			RandomizeSymmetric(rand.New(rand.NewSource(3)), g, 0, 0, g.Width, g.Height, 0.5, tc.sym)
+			for y := 0; y < g.Height; y++ {
+				for x := 0; x < g.Width; x++ {
+					if !tc.same(g, x, y) {
+						t.Fatalf("cell %d,%d breaks the symmetry:\n%s", x, y, g.rows())
+					}
+				}
+			}
+			if population(g) == 0 {
+				t.Fatal("board is empty")
+			}
+		})
+	}
+}
+
+func TestRandomizeWithoutSymmetryMatchesNewGrid(t *testing.T) {
+	g := NewEmptyGrid(BoardWidth, 10)
+	Randomize(rand.New(rand.NewSource(8)), g, 0, 0, g.Width, g.Height, 0.5)
+	assertRows(t, g, NewGrid(BoardWidth, 10, rand.New(rand.NewSource(8)), 0.5).rows()...)
+}
+
+func TestParseSymmetry(t *testing.T) {
+	for _, s := range []string{"none", "Horizontal", "4-fold", "rotational"} {
+		if _, err := ParseSymmetry(s); err != nil {
+			t.Errorf("ParseSymmetry(%q): %v", s, err)
+		}
+	}
+	if _, err := ParseSymmetry("diagonal"); err == nil {
+		t.Error("ParseSymmetry accepted an unknown symmetry")
+	}
+}
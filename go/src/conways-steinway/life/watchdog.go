@@ -0,0 +1,43 @@
+package life
+
+// Watchdog notices when a board has died out or stopped changing, so the
+// runner can reseed it before the piano falls silent
+type Watchdog struct {
+	// Patience is how many consecutive generations the board may stay empty
+	// or unchanged before Check fires
+	Patience int
+
+	last  uint64
+	still int
+	seen  bool
+}
+
+// Check records the state of b and reports whether it has been empty or
+// static for longer than the watchdog's patience
+func (w *Watchdog) Check(b Board) bool {
+	h := Hash(b)
+	empty := population(b) == 0
+	if w.seen && (h == w.last || empty) {
+		w.still++
+	} else if empty {
+		w.still = 1
+	} else {
+		w.still = 0
+	}
+	w.last, w.seen = h, true
+	return w.still > w.Patience
+}
+
+// Reset forgets the board's history, e.g. after it has been reseeded
+func (w *Watchdog) Reset() {
+	w.still, w.seen = 0, false
+}
+
+// population counts the live cells of the visible board
+func population(b Board) int {
+	n := 0
+	for range LiveCells(b) {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,56 @@
+package life
+
+import "testing"
+
+func TestWatchdogFiresOnStillLife(t *testing.T) {
+	g := gridFromRows("....", ".##.", ".##.", "....")
+	w := &Watchdog{Patience: 2}
+	fired := 0
+	for i := 0; i < 5; i++ {
+		if w.Check(g) {
+			fired = i
+			break
+		}
+		g.Step()
+	}
+	// Unchanged at checks 1 and 2 is within patience; the third repeat fires
+	if fired != 3 {
+		t.Fatalf("fired at check %d, want 3", fired)
+	}
+}
+
+func TestWatchdogFiresOnEmptyBoard(t *testing.T) {
+	g := gridFromRows("#...", "....", "...#")
+	w := &Watchdog{}
+	if w.Check(g) {
+		t.Fatal("fired on a live board")
+	}
+	g.Step()
+	if !w.Check(g) {
+		t.Fatal("did not fire once the board died out")
+	}
+}
+
+func TestWatchdogIgnoresOscillators(t *testing.T) {
+	g := gridFromRows(".....", "..#..", "..#..", "..#..", ".....")
+	w := &Watchdog{Patience: 1}
+	for i := 0; i < 10; i++ {
+		if w.Check(g) {
+			t.Fatalf("fired on a blinker at generation %d", i)
+		}
+		g.Step()
+	}
+}
+
+func TestWatchdogReset(t *testing.T) {
+	g := gridFromRows("....", "....")
+	w := &Watchdog{}
+	if !w.Check(g) {
+		t.Fatal("did not fire on an empty board")
+	}
+	w.Reset()
+	g.SetAlive(0, 0, true)
+	if w.Check(g) {
+		t.Fatal("fired straight after a reset")
+	}
+}
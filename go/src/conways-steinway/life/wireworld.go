@@ -0,0 +1,157 @@
+package life
+
+import (
+	"fmt"
+	"io"
+)
+
+// WireState is the state of one Wireworld cell
+type WireState uint8
+
+const (
+	// WireEmpty never changes
+	WireEmpty WireState = iota
+	// WireConductor becomes an electron head when one or two of its
+	// neighbours are heads
+	WireConductor
+	// WireHead is the front of an electron; it becomes a tail
+	WireHead
+	// WireTail is the back of an electron; it becomes a conductor again
+	WireTail
+)
+
+// wireChars draws each state, and is the layout ParseWireworld reads
+var wireChars = [...]byte{WireEmpty: '.', WireConductor: '#', WireHead: '@', WireTail: '~'}
+
+// Wireworld runs Brian Silverman's Wireworld on a fixed-size board with dead
+// edges. Electrons travel along conductors one cell per generation, so loops
+// of wire act as clocks and the electron heads, which count as the live
+// cells, fire at fixed intervals.
+type Wireworld struct {
+	Width  int
+	Height int
+	Cells  []WireState
+
+	Generation int // number of generations stepped
+
+	next []WireState
+
+	births, deaths int // changes made by the last Step
+}
+
+// NewWireworld returns a board of empty cells
+func NewWireworld(width, height int) *Wireworld {
+	return &Wireworld{
+		Width:  width,
+		Height: height,
+		Cells:  make([]WireState, width*height),
+		next:   make([]WireState, width*height),
+	}
+}
+
+// Size returns the width and height of the board
+func (w *Wireworld) Size() (width, height int) { return w.Width, w.Height }
+
+// State returns the state of the cell at (x, y); cells off the board are empty
+func (w *Wireworld) State(x, y int) WireState {
+	if x < 0 || x >= w.Width || y < 0 || y >= w.Height {
+		return WireEmpty
+	}
+	return w.Cells[x+y*w.Width]
+}
+
+// SetState sets the state of the cell at (x, y); coordinates off the board are ignored
+func (w *Wireworld) SetState(x, y int, s WireState) {
+	if x >= 0 && x < w.Width && y >= 0 && y < w.Height {
+		w.Cells[x+y*w.Width] = s
+	}
+}
+
+// Alive reports whether the cell at (x, y) is an electron head
+func (w *Wireworld) Alive(x, y int) bool { return w.State(x, y) == WireHead }
+
+// Place copies layout onto the board with its top-left corner at (x, y)
+func (w *Wireworld) Place(layout *Wireworld, x, y int) {
+	for ly := 0; ly < layout.Height; ly++ {
+		for lx := 0; lx < layout.Width; lx++ {
+			w.SetState(x+lx, y+ly, layout.Cells[lx+ly*layout.Width])
+		}
+	}
+}
+
+// Step advances every electron by one cell
+func (w *Wireworld) Step() {
+	if len(w.next) != len(w.Cells) {
+		w.next = make([]WireState, len(w.Cells))
+	}
+	w.births, w.deaths = 0, 0
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			i := x + y*w.Width
+			s := w.Cells[i]
+			switch s {
+			case WireHead:
+				s = WireTail
+				w.deaths++
+			case WireTail:
+				s = WireConductor
+			case WireConductor:
+				if n := w.heads(x, y); n == 1 || n == 2 {
+					s = WireHead
+					w.births++
+				}
+			}
+			w.next[i] = s
+		}
+	}
+	w.Cells, w.next = w.next, w.Cells
+	w.Generation++
+}
+
+// heads counts the electron heads among the eight neighbours of (x, y)
+func (w *Wireworld) heads(x, y int) int {
+	n := 0
+	for _, o := range Moore.offsets() {
+		if w.State(x+o.dx, y+o.dy) == WireHead {
+			n++
+		}
+	}
+	return n
+}
+
+// Stats returns the heads created and removed by the last generation together
+// with the current number of heads
+func (w *Wireworld) Stats() Stats {
+	n := 0
+	for _, s := range w.Cells {
+		if s == WireHead {
+			n++
+		}
+	}
+	return newStats(w.births, w.deaths, n, len(w.Cells))
+}
+
+// Print writes the board to w using the characters ParseWireworld reads
+func (w *Wireworld) Print(out io.Writer) {
+	row := make([]byte, w.Width)
+	for y := 0; y < w.Height; y++ {
+		for x := range row {
+			row[x] = wireChars[w.Cells[x+y*w.Width]]
+		}
+		fmt.Fprintf(out, "%s\n", row)
+	}
+}
+
+// ParseWireState returns the state drawn as ch: '.' or ' ' for empty, '#' for
+// a conductor, '@' for an electron head and '~' for a tail
+func ParseWireState(ch rune) (WireState, bool) {
+	if ch == ' ' {
+		return WireEmpty, true
+	}
+	for s, c := range wireChars {
+		if rune(c) == ch {
+			return WireState(s), true
+		}
+	}
+	return WireEmpty, false
+}
@@ -0,0 +1,78 @@
+package life
+
+import (
+	"strings"
+	"testing"
+)
+
+// wireworldFromRows builds a board from rows drawn with the Print characters
+func wireworldFromRows(rows ...string) *Wireworld {
+	w := NewWireworld(len(rows[0]), len(rows))
+	for y, row := range rows {
+		for x, ch := range row {
+			s, _ := ParseWireState(ch)
+			w.SetState(x, y, s)
+		}
+	}
+	return w
+}
+
+func assertWires(t *testing.T, w *Wireworld, want ...string) {
+	t.Helper()
+	var b strings.Builder
+	w.Print(&b)
+	if got := strings.TrimSuffix(b.String(), "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("unexpected board:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestWireworldElectronTravels(t *testing.T) {
+	w := wireworldFromRows("~@####")
+	w.Step()
+	assertWires(t, w, "#~@###")
+	w.Step()
+	assertWires(t, w, "##~@##")
+	if !w.Alive(3, 0) || w.Alive(2, 0) {
+		t.Fatal("only the electron head should count as alive")
+	}
+}
+
+func TestWireworldClockLoop(t *testing.T) {
+	// An electron circling a loop of six cells fires each cell every six
+	// generations
+	rows := []string{
+		".~@.",
+		"#..#",
+		".##.",
+	}
+	w := wireworldFromRows(rows...)
+	w.Step()
+	if !w.Alive(3, 1) {
+		t.Fatal("the head should move round the loop")
+	}
+	for i := 1; i < 6; i++ {
+		w.Step()
+	}
+	assertWires(t, w, rows...)
+	if s := w.Stats(); s.Population != 1 || s.Births != 1 || s.Deaths != 1 {
+		t.Fatalf("stats = %+v, want one head moved", s)
+	}
+}
+
+func TestWireworldCrowdedConductorStaysPut(t *testing.T) {
+	// A conductor next to three heads does not fire
+	w := wireworldFromRows(
+		"@@@",
+		".#.",
+	)
+	w.Step()
+	if w.State(1, 1) != WireConductor {
+		t.Fatalf("state = %d, want conductor", w.State(1, 1))
+	}
+}
+
+func TestWireworldPlace(t *testing.T) {
+	w := NewWireworld(4, 2)
+	w.Place(wireworldFromRows("@#", "~#"), 2, 1)
+	assertWires(t, w, "....", "..@#")
+}
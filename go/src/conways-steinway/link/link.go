@@ -0,0 +1,429 @@
+// Package link follows the tempo and beat of an Ableton Link session on the
+// local network, so a performance can play in time with Link-enabled DAWs,
+// DJ software and apps. It speaks Link's discovery and measurement protocols,
+// version 1, as a silent peer: it hears the session's timeline and measures
+// the session's clock against its own, but never changes either.
+package link
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MulticastAddr is the group and port Link peers announce themselves on
+const MulticastAddr = "224.76.78.75:20808"
+
+// Timeline relates a session's beats to local time
+type Timeline struct {
+	Tempo float64   // beats a minute
+	Zero  time.Time // when beat 0 falls
+}
+
+// Beat returns the beat falling at t
+func (tl Timeline) Beat(t time.Time) float64 { return t.Sub(tl.Zero).Minutes() * tl.Tempo }
+
+// Time returns when beat falls
+func (tl Timeline) Time(beat float64) time.Time {
+	return tl.Zero.Add(time.Duration(beat / tl.Tempo * float64(time.Minute)))
+}
+
+// Session listens for the peers of the Link sessions on the network and
+// follows the one with the most peers
+type Session struct {
+	conn    *net.UDPConn
+	mu      sync.Mutex
+	peers   map[nodeID]*peer
+	offsets map[nodeID]int64 // microseconds each measured session's clock is ahead of ours
+	done    chan struct{}
+}
+
+// peer is what a peer last announced of itself
+type peer struct {
+	state   peerState
+	expires time.Time
+}
+
+// Join starts listening for Link peers
+func Join() (*Session, error) {
+	group, err := net.ResolveUDPAddr("udp4", MulticastAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		return nil, fmt.Errorf("Ableton Link: %w", err)
+	}
+	s := newSession()
+	s.conn = conn
+	go s.listen()
+	return s, nil
+}
+
+func newSession() *Session {
+	return &Session{peers: make(map[nodeID]*peer), offsets: make(map[nodeID]int64), done: make(chan struct{})}
+}
+
+// listen handles announcements until the session is closed
+func (s *Session) listen() {
+	defer close(s.done)
+	b := make([]byte, maxMessageSize)
+	for {
+		n, _, err := s.conn.ReadFromUDP(b)
+		if err != nil {
+			return
+		}
+		if m, err := parseDiscovery(b[:n]); err == nil {
+			s.heard(m, time.Now())
+		}
+	}
+}
+
+// heard records an announcement, and starts measuring the clock of a
+// session newly followed
+func (s *Session) heard(m discovery, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch m.kind {
+	case alive, response:
+		if m.state.endpoint == nil {
+			return
+		}
+		s.peers[m.id] = &peer{m.state, now.Add(time.Duration(m.ttl) * time.Second)}
+	case byeBye:
+		delete(s.peers, m.id)
+		return
+	}
+	p, ok := s.leader(now)
+	if !ok {
+		return
+	}
+	if _, ok := s.offsets[p.session]; !ok {
+		s.offsets[p.session] = unmeasured
+		go s.measure(p.session, p.endpoint)
+	}
+}
+
+// unmeasured marks a session whose clock is being measured
+const unmeasured = -1 << 63
+
+// leader returns the latest state announced by a peer of the session with
+// the most live peers, and forgets the peers that have gone quiet
+func (s *Session) leader(now time.Time) (peerState, bool) {
+	counts := make(map[nodeID]int)
+	for id, p := range s.peers {
+		if now.After(p.expires) {
+			delete(s.peers, id)
+			continue
+		}
+		counts[p.state.session]++
+	}
+	var best *peer
+	for _, p := range s.peers {
+		c, bc := 0, 0
+		if best != nil {
+			c, bc = counts[p.state.session], counts[best.state.session]
+		}
+		if best == nil || c > bc || c == bc && p.expires.After(best.expires) {
+			best = p
+		}
+	}
+	if best == nil {
+		return peerState{}, false
+	}
+	return best.state, true
+}
+
+// Peers returns how many peers the followed session has, 0 when no session
+// has been heard
+func (s *Session) Peers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.leader(time.Now())
+	if !ok {
+		return 0
+	}
+	n := 0
+	for _, q := range s.peers {
+		if q.state.session == p.session {
+			n++
+		}
+	}
+	return n
+}
+
+// Timeline returns the followed session's timeline, or false when no session
+// has been heard or its clock has not been measured yet
+func (s *Session) Timeline() (Timeline, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.leader(time.Now())
+	if !ok {
+		return Timeline{}, false
+	}
+	offset, ok := s.offsets[p.session]
+	if !ok || offset == unmeasured {
+		return Timeline{}, false
+	}
+	return p.timeline.local(offset), true
+}
+
+// Wait waits up to timeout for a session's timeline
+func (s *Session) Wait(timeout time.Duration) (Timeline, bool) {
+	deadline := time.Now().Add(timeout)
+	for {
+		if tl, ok := s.Timeline(); ok || !time.Now().Before(deadline) {
+			return tl, ok
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Close stops listening
+func (s *Session) Close() error {
+	err := s.conn.Close()
+	<-s.done
+	return err
+}
+
+// epoch is the start of the local clock Link's host times count from
+var epoch = time.Now()
+
+// micros returns t as a host time
+func micros(t time.Time) int64 { return t.Sub(epoch).Microseconds() }
+
+// local converts a timeline on a session's clock, offset microseconds ahead
+// of ours, to local time
+func (t timeline) local(offset int64) Timeline {
+	tempo := 60e6 / float64(t.microsPerBeat)
+	// The session's clock reads timeOrigin as beatOrigin falls
+	origin := epoch.Add(time.Duration(t.timeOrigin-offset) * time.Microsecond)
+	beats := float64(t.beatOrigin) / 1e6
+	return Timeline{Tempo: tempo, Zero: origin.Add(-time.Duration(beats / tempo * float64(time.Minute)))}
+}
+
+// Measurements are taken until there are measurePoints of them, waiting at
+// most measureWait for each reply and giving up after measureTries silences
+const (
+	measurePoints = 100
+	measureWait   = 50 * time.Millisecond
+	measureTries  = 5
+)
+
+// measure measures how far ahead of ours the clock of a session is by
+// pinging the peer at endpoint, recording the median of the measurements,
+// or forgetting the session to try again later if the peer does not reply
+func (s *Session) measure(session nodeID, endpoint *net.UDPAddr) {
+	offset, err := measure(session, endpoint)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		delete(s.offsets, session)
+		return
+	}
+	s.offsets[session] = offset
+}
+
+func measure(session nodeID, endpoint *net.UDPAddr) (int64, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	var data []float64
+	ping := appendPing(nil, micros(time.Now()), 0)
+	b := make([]byte, maxMessageSize)
+	for tries := 0; len(data) < measurePoints; {
+		if _, err := conn.WriteToUDP(ping, endpoint); err != nil {
+			return 0, err
+		}
+		conn.SetReadDeadline(time.Now().Add(measureWait))
+		n, _, err := conn.ReadFromUDP(b)
+		if err != nil {
+			if tries++; tries == measureTries {
+				return 0, errors.New("Ableton Link: peer does not answer pings")
+			}
+			ping = appendPing(nil, micros(time.Now()), 0)
+			continue
+		}
+		now := micros(time.Now())
+		p, err := parsePong(b[:n])
+		if err != nil || p.session != session {
+			continue
+		}
+		tries = 0
+		// The session's clock read gt halfway between the ping and the pong,
+		// and, for the ping before, pgt halfway between it and its pong
+		if p.gt != 0 && p.ht != 0 {
+			data = append(data, float64(p.gt)-float64(now+p.ht)/2)
+			if p.pgt != 0 {
+				data = append(data, float64(p.gt+p.pgt)/2-float64(p.ht))
+			}
+		}
+		ping = appendPing(nil, now, p.gt)
+	}
+	slices.Sort(data)
+	return int64(data[len(data)/2]), nil
+}
+
+// maxMessageSize is the longest message Link sends
+const maxMessageSize = 512
+
+// nodeID identifies a peer or a session
+type nodeID [8]byte
+
+// Discovery message kinds
+const (
+	alive    = 1
+	response = 2
+	byeBye   = 3
+)
+
+var (
+	discoveryHeader   = []byte("_asdp_v\x01")
+	measurementHeader = []byte("_link_v\x01")
+)
+
+// Measurement message kinds
+const (
+	ping = 1
+	pong = 2
+)
+
+// discovery is a peer's announcement of itself
+type discovery struct {
+	kind  uint8
+	ttl   uint8 // seconds the announcement holds for
+	id    nodeID
+	state peerState
+}
+
+// peerState is what a peer announces of its session
+type peerState struct {
+	session  nodeID
+	timeline timeline
+	endpoint *net.UDPAddr // where the peer answers pings
+}
+
+// timeline is a session's timeline on its own clock, in microseconds and
+// millionths of a beat
+type timeline struct {
+	microsPerBeat, beatOrigin, timeOrigin int64
+}
+
+// parseDiscovery parses a discovery message
+func parseDiscovery(b []byte) (discovery, error) {
+	var m discovery
+	if !bytes.HasPrefix(b, discoveryHeader) || len(b) < len(discoveryHeader)+12 {
+		return m, errors.New("not a Link discovery message")
+	}
+	b = b[len(discoveryHeader):]
+	m.kind, m.ttl = b[0], b[1]
+	copy(m.id[:], b[4:12])
+	err := entries(b[12:], func(key string, v []byte) error {
+		switch key {
+		case "sess":
+			if len(v) != 8 {
+				return errors.New("bad session")
+			}
+			copy(m.state.session[:], v)
+		case "tmln":
+			if len(v) != 24 {
+				return errors.New("bad timeline")
+			}
+			m.state.timeline = timeline{int64(binary.BigEndian.Uint64(v)),
+				int64(binary.BigEndian.Uint64(v[8:])), int64(binary.BigEndian.Uint64(v[16:]))}
+			if m.state.timeline.microsPerBeat <= 0 {
+				return errors.New("bad tempo")
+			}
+		case "mep4":
+			if len(v) != 6 {
+				return errors.New("bad endpoint")
+			}
+			m.state.endpoint = &net.UDPAddr{IP: net.IP(slices.Clone(v[:4])), Port: int(binary.BigEndian.Uint16(v[4:]))}
+		}
+		return nil
+	})
+	if err == nil && m.kind != byeBye && m.state.timeline.microsPerBeat == 0 {
+		err = errors.New("no timeline")
+	}
+	return m, err
+}
+
+// pongReply is what a pong carries: the session's clock as the peer sent
+// it, and the host time and session clock reading the ping carried back
+type pongReply struct {
+	session     nodeID
+	gt, pgt, ht int64
+}
+
+// parsePong parses a pong
+func parsePong(b []byte) (pongReply, error) {
+	var p pongReply
+	if !bytes.HasPrefix(b, measurementHeader) || len(b) < len(measurementHeader)+1 || b[len(measurementHeader)] != pong {
+		return p, errors.New("not a Link pong")
+	}
+	err := entries(b[len(measurementHeader)+1:], func(key string, v []byte) error {
+		if key == "sess" && len(v) == 8 {
+			copy(p.session[:], v)
+			return nil
+		}
+		if len(v) != 8 {
+			return nil
+		}
+		t := int64(binary.BigEndian.Uint64(v))
+		switch key {
+		case "__gt":
+			p.gt = t
+		case "_pgt":
+			p.pgt = t
+		case "__ht":
+			p.ht = t
+		}
+		return nil
+	})
+	return p, err
+}
+
+// appendPing appends a ping sent at host time ht, carrying the session
+// clock's reading from the last pong, if any
+func appendPing(b []byte, ht, pgt int64) []byte {
+	b = append(b, measurementHeader...)
+	b = append(b, ping)
+	b = appendEntry(b, "__ht", binary.BigEndian.AppendUint64(nil, uint64(ht)))
+	if pgt != 0 {
+		b = appendEntry(b, "_pgt", binary.BigEndian.AppendUint64(nil, uint64(pgt)))
+	}
+	return b
+}
+
+// appendEntry appends a payload entry: its four-letter key, the size of its
+// value and the value
+func appendEntry(b []byte, key string, v []byte) []byte {
+	b = append(b, key...)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(v)))
+	return append(b, v...)
+}
+
+// entries calls f with the key and value of each entry of a payload
+func entries(b []byte, f func(key string, v []byte) error) error {
+	for len(b) > 0 {
+		if len(b) < 8 {
+			return errors.New("truncated payload entry")
+		}
+		size := binary.BigEndian.Uint32(b[4:])
+		if uint64(size) > uint64(len(b)-8) {
+			return errors.New("truncated payload entry")
+		}
+		if err := f(string(b[:4]), b[8:8+size]); err != nil {
+			return err
+		}
+		b = b[8+size:]
+	}
+	return nil
+}
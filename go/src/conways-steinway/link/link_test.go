@@ -0,0 +1,114 @@
+package link
+
+import (
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+// appendAlive appends a peer's announcement of itself
+func appendAlive(b []byte, id nodeID, s peerState) []byte {
+	b = append(b, discoveryHeader...)
+	b = append(b, alive, 5, 0, 0)
+	b = append(b, id[:]...)
+	tl := binary.BigEndian.AppendUint64(nil, uint64(s.timeline.microsPerBeat))
+	tl = binary.BigEndian.AppendUint64(tl, uint64(s.timeline.beatOrigin))
+	tl = binary.BigEndian.AppendUint64(tl, uint64(s.timeline.timeOrigin))
+	b = appendEntry(b, "tmln", tl)
+	b = appendEntry(b, "sess", s.session[:])
+	b = appendEntry(b, "stst", make([]byte, 17))
+	ep := append(s.endpoint.IP.To4(), 0, 0)
+	binary.BigEndian.PutUint16(ep[4:], uint16(s.endpoint.Port))
+	return appendEntry(b, "mep4", ep)
+}
+
+// fakePeer answers pings as a peer of session would whose clock is ahead of
+// ours by offset microseconds
+func fakePeer(t *testing.T, session nodeID, offset int64) *net.UDPAddr {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		b := make([]byte, maxMessageSize)
+		for {
+			n, from, err := conn.ReadFromUDP(b)
+			if err != nil {
+				return
+			}
+			if n <= len(measurementHeader) || b[len(measurementHeader)] != ping {
+				continue
+			}
+			reply := append(measurementHeader, pong)
+			reply = appendEntry(reply, "sess", session[:])
+			reply = appendEntry(reply, "__gt", binary.BigEndian.AppendUint64(nil, uint64(micros(time.Now())+offset)))
+			reply = append(reply, b[len(measurementHeader)+1:n]...)
+			conn.WriteToUDP(reply, from)
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr)
+}
+
+func TestParseDiscovery(t *testing.T) {
+	state := peerState{session: nodeID{1, 2, 3, 4, 5, 6, 7, 8}, timeline: timeline{500000, 3000000, 123456789},
+		endpoint: &net.UDPAddr{IP: net.IPv4(192, 168, 1, 9), Port: 20809}}
+	m, err := parseDiscovery(appendAlive(nil, nodeID{9}, state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.kind != alive || m.ttl != 5 || m.id != (nodeID{9}) || m.state.session != state.session || m.state.timeline != state.timeline {
+		t.Errorf("parsed %+v, want %+v", m, state)
+	}
+	if m.state.endpoint.String() != "192.168.1.9:20809" {
+		t.Errorf("endpoint %v, want 192.168.1.9:20809", m.state.endpoint)
+	}
+	if _, err := parseDiscovery([]byte("_asdp_v\x01\x01")); err == nil {
+		t.Error("parsed a truncated message")
+	}
+}
+
+func TestTimelineLocal(t *testing.T) {
+	// 120 bpm, the session's clock a second ahead of ours reading 10s as
+	// beat 4 falls, so beat 0 fell 8s on our clock
+	tl := timeline{microsPerBeat: 500000, beatOrigin: 4000000, timeOrigin: 10000000}.local(1000000)
+	if tl.Tempo != 120 {
+		t.Errorf("tempo %g, want 120", tl.Tempo)
+	}
+	if d := tl.Zero.Sub(epoch); d != 7*time.Second {
+		t.Errorf("beat 0 at %v, want 7s", d)
+	}
+	if b := tl.Beat(tl.Time(13.5)); math.Abs(b-13.5) > 1e-6 {
+		t.Errorf("beat %g round trips to %g", 13.5, b)
+	}
+}
+
+func TestSessionMeasures(t *testing.T) {
+	session := nodeID{7}
+	const offset = 5_000_000 // the peer's clock is 5s ahead
+	s := newSession()
+	now := time.Now()
+	// Beat 0 falls now, at 90 bpm
+	state := peerState{session: session, timeline: timeline{microsPerBeat: 666667, timeOrigin: micros(now) + offset},
+		endpoint: fakePeer(t, session, offset)}
+	m, err := parseDiscovery(appendAlive(nil, nodeID{1}, state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.heard(m, now)
+	tl, ok := s.Wait(5 * time.Second)
+	if !ok {
+		t.Fatal("no timeline measured")
+	}
+	if math.Abs(tl.Tempo-90) > 0.01 {
+		t.Errorf("tempo %g, want 90", tl.Tempo)
+	}
+	if d := tl.Zero.Sub(now); d < -2*time.Millisecond || d > 2*time.Millisecond {
+		t.Errorf("beat 0 %v from when it fell", d)
+	}
+	if n := s.Peers(); n != 1 {
+		t.Errorf("%d peers, want 1", n)
+	}
+}
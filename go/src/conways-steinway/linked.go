@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/link"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// linkWait is how long --link listens for a session before playing alone
+const linkWait = 2 * time.Second
+
+// linkLead is the least time left to get going before the bar a performance
+// joins a session on
+const linkLead = 100 * time.Millisecond
+
+// timekeeper is an output that sends notes as their ticks fall
+type timekeeper interface {
+	KeepTime(clock music.Clock, start time.Time)
+}
+
+// follower keeps a performance in time with an Ableton Link session: its
+// beats fall on the session's from the bar it joined on, and its tempo
+// follows the session's
+type follower struct {
+	session *link.Session
+	origin  float64 // the session's beat the performance's first falls on
+	outputs []timekeeper
+}
+
+// joinLink listens for a Link session and, if one is found, sets clock to
+// its tempo and returns a follower and the start of the session's next bar.
+// With no session it plays alone, from now.
+func joinLink(clock *music.Clock) (*follower, time.Time, error) {
+	s, err := link.Join()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	tl, ok := s.Wait(linkWait)
+	if !ok {
+		s.Close()
+		fmt.Fprintf(status, "No Ableton Link session found; playing alone at %g bpm\n", clock.BPM)
+		return nil, time.Now(), nil
+	}
+	bar := float64(clock.Meter.Beats)
+	origin := math.Ceil(tl.Beat(time.Now().Add(linkLead))/bar) * bar
+	clock.BPM = tl.Tempo
+	fmt.Fprintf(status, "Following an Ableton Link session at %.1f bpm (peers: %d)\n", tl.Tempo, s.Peers())
+	return &follower{session: s, origin: origin}, tl.Time(origin), nil
+}
+
+// follow returns the clock and start that keep the performance on the
+// session's beats, re-timing the outputs when the session's tempo or beat
+// has moved
+func (f *follower) follow(clock music.Clock, start time.Time) (music.Clock, time.Time) {
+	tl, ok := f.session.Timeline()
+	if !ok {
+		return clock, start
+	}
+	at := tl.Time(f.origin)
+	if tl.Tempo == clock.BPM && at.Sub(start).Abs() < time.Millisecond {
+		return clock, start
+	}
+	clock.BPM = tl.Tempo
+	for _, o := range f.outputs {
+		o.KeepTime(clock, at)
+	}
+	return clock, at
+}
@@ -0,0 +1,154 @@
+package live
+
+import (
+	"fmt"
+	"sync"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// ins returns the input ports of the registered driver
+func ins() ([]drivers.In, error) {
+	if drivers.Get() == nil {
+		return nil, ErrNoDriver
+	}
+	return drivers.Ins()
+}
+
+// InPorts returns the names of the MIDI input ports, indexed as OpenInput
+// numbers them
+func InPorts() ([]string, error) {
+	ports, err := ins()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(ports))
+	for i, p := range ports {
+		names[i] = p.String()
+	}
+	return names, nil
+}
+
+// FindInput returns the name of the input port OpenInput would choose by
+// selector, without opening it
+func FindInput(selector string) (string, error) {
+	names, err := InPorts()
+	if err != nil {
+		return "", err
+	}
+	i, err := choose("input", names, selector)
+	if err != nil {
+		return "", err
+	}
+	return names[i], nil
+}
+
+// OpenInput opens the input port chosen by selector, as Open chooses an
+// output port, and listens for the notes played on it
+func OpenInput(selector string) (*Input, error) {
+	ports, err := ins()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(ports))
+	for i, p := range ports {
+		names[i] = p.String()
+	}
+	i, err := choose("input", names, selector)
+	if err != nil {
+		return nil, err
+	}
+	if err := ports[i].Open(); err != nil {
+		return nil, fmt.Errorf("MIDI port %q: %w", names[i], err)
+	}
+	in := &Input{port: ports[i], rolled: make(chan struct{})}
+	if in.stop, err = ports[i].Listen(in.handle, drivers.ListenConfig{SysEx: true}); err != nil {
+		ports[i].Close()
+		return nil, fmt.Errorf("MIDI port %q: %w", names[i], err)
+	}
+	return in, nil
+}
+
+// Input collects the notes played and the controls moved on a MIDI input
+// port, e.g. a keyboard, until they are taken, and follows the transport of
+// a DAW or sequencer sending to it
+type Input struct {
+	port     drivers.In
+	stop     func()
+	mu       sync.Mutex
+	notes    []music.NoteEvent
+	controls []music.Control
+	rolling  bool          // a Start, Continue or MMC Play has been heard since any Stop
+	rolled   chan struct{} // closed when the transport next rolls
+}
+
+// Name returns the name of the port
+func (in *Input) Name() string { return in.port.String() }
+
+// handle keeps the note-ons and control changes among the messages the
+// driver hears, and follows the transport messages
+func (in *Input) handle(msg []byte, _ int32) {
+	var ch, key, vel uint8
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	switch m := midi.Message(msg); {
+	case m.GetNoteStart(&ch, &key, &vel):
+		in.notes = append(in.notes, music.NoteEvent{Pitch: int(key), Velocity: int(vel), Channel: int(ch) + 1})
+	case m.GetControlChange(&ch, &key, &vel):
+		in.controls = append(in.controls, music.Control{Controller: int(key), Value: int(vel), Channel: int(ch) + 1})
+	case m.Is(midi.StartMsg), m.Is(midi.ContinueMsg), isMMC(msg, mmcPlay), isMMC(msg, mmcDeferredPlay):
+		if !in.rolling {
+			in.rolling = true
+			close(in.rolled)
+		}
+	case m.Is(midi.StopMsg), isMMC(msg, mmcStop), isMMC(msg, mmcPause):
+		if in.rolling {
+			in.rolling = false
+			in.rolled = make(chan struct{})
+		}
+	}
+}
+
+// Rolling reports whether the transport heard on the port is rolling: a
+// Start, Continue or MMC Play has been heard, and no Stop or MMC Stop since
+func (in *Input) Rolling() bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.rolling
+}
+
+// Rolled returns a channel that is closed once the transport is rolling
+func (in *Input) Rolled() <-chan struct{} {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.rolled
+}
+
+// Take returns the notes played since it was last called, oldest first,
+// with their pitches, velocities and channels
+func (in *Input) Take() []music.NoteEvent {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	notes := in.notes
+	in.notes = nil
+	return notes
+}
+
+// TakeControls returns the control changes heard since it was last called,
+// oldest first, with their controllers, values and channels
+func (in *Input) TakeControls() []music.Control {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	controls := in.controls
+	in.controls = nil
+	return controls
+}
+
+// Close stops listening and closes the port
+func (in *Input) Close() error {
+	in.stop()
+	return in.port.Close()
+}
@@ -0,0 +1,457 @@
+// Package live streams a performance to a MIDI output port as it is played,
+// e.g. to a software synth or a Disklavier. Ports are reached through the
+// gomidi driver registered in the binary: build with -tags rtmidi for the
+// RtMidi driver, which needs cgo and ALSA, CoreMIDI or WinMM.
+package live
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// ErrNoDriver is returned when the binary was built without a MIDI driver
+var ErrNoDriver = errors.New("no MIDI driver in this build: rebuild with go build -tags rtmidi ./conways-steinway, which needs cgo and, on Linux, libasound2-dev")
+
+// outs returns the output ports of the registered driver
+func outs() ([]drivers.Out, error) {
+	if drivers.Get() == nil {
+		return nil, ErrNoDriver
+	}
+	return drivers.Outs()
+}
+
+// Ports returns the names of the MIDI output ports, indexed as Open numbers them
+func Ports() ([]string, error) {
+	ports, err := outs()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(ports))
+	for i, p := range ports {
+		names[i] = p.String()
+	}
+	return names, nil
+}
+
+// Find returns the name of the output port Open would choose by selector,
+// without opening it
+func Find(selector string) (string, error) {
+	names, err := Ports()
+	if err != nil {
+		return "", err
+	}
+	i, err := choose("output", names, selector)
+	if err != nil {
+		return "", err
+	}
+	return names[i], nil
+}
+
+// Open opens the output port chosen by selector, which is an index into
+// Ports, a port's full name or, failing that, part of one name, ignoring case
+func Open(selector string) (*Output, error) {
+	ports, err := outs()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(ports))
+	for i, p := range ports {
+		names[i] = p.String()
+	}
+	i, err := choose("output", names, selector)
+	if err != nil {
+		return nil, err
+	}
+	if err := ports[i].Open(); err != nil {
+		return nil, fmt.Errorf("MIDI port %q: %w", names[i], err)
+	}
+	return &Output{port: ports[i]}, nil
+}
+
+// VirtualPortName is the name OpenVirtual gives the port it creates
+const VirtualPortName = "Conways Steinway"
+
+// virtualDriver is a driver that can create ports of its own, as RtMidi can
+// with ALSA and CoreMIDI but not WinMM
+type virtualDriver interface {
+	OpenVirtualOut(name string) (drivers.Out, error)
+}
+
+// OpenVirtual creates an output port named VirtualPortName that DAWs and
+// softsynths can connect to, without a loopback driver
+func OpenVirtual() (*Output, error) {
+	d := drivers.Get()
+	if d == nil {
+		return nil, ErrNoDriver
+	}
+	v, ok := d.(virtualDriver)
+	if !ok {
+		return nil, fmt.Errorf("the %s MIDI driver cannot create virtual ports", d)
+	}
+	out, err := v.OpenVirtualOut(VirtualPortName)
+	if err != nil {
+		return nil, fmt.Errorf("virtual MIDI port %q: %w", VirtualPortName, err)
+	}
+	return &Output{port: out}, nil
+}
+
+// choose returns the index of the port selector names among the names of
+// the kind, input or output, of port
+func choose(kind string, names []string, selector string) (int, error) {
+	selector = strings.TrimSpace(selector)
+	if i, err := strconv.Atoi(selector); err == nil {
+		if i < 0 || i >= len(names) {
+			return 0, fmt.Errorf("no MIDI %s port %d (there are %d)", kind, i, len(names))
+		}
+		return i, nil
+	}
+	for i, name := range names {
+		if name == selector {
+			return i, nil
+		}
+	}
+	match := -1
+	for i, name := range names {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(selector)) {
+			if match >= 0 {
+				return 0, fmt.Errorf("MIDI %s port %q is ambiguous: %q and %q", kind, selector, names[match], name)
+			}
+			match = i
+		}
+	}
+	if match < 0 {
+		return 0, fmt.Errorf("no MIDI %s port matches %q", kind, selector)
+	}
+	return match, nil
+}
+
+// port is the part of a driver's output port an Output uses
+type port interface {
+	Send(data []byte) error
+	Close() error
+	String() string
+}
+
+// Output sends the notes of a performance to an open port the moment their
+// NoteOn and NoteOff events are published, or with KeepTime when their ticks
+// fall. It silences every note still sounding when it is closed.
+type Output struct {
+	port     port
+	sounding map[[2]uint8]bool // channel and key of each note on
+	err      error             // first failed send
+	sync     bool              // send MIDI clock with KeepTime
+	mmc      bool              // send MIDI Machine Control with KeepTime
+	mpe      *zone             // member channels of the notes, with UseMPE
+	tuning   *music.Tuning     // tuning notes are bent to, with Retune
+
+	// With KeepTime, messages wait in queue, soonest first, for schedule to
+	// send them; mu guards the fields above and below from then on
+	mu      sync.Mutex
+	clock   *music.Clock
+	start   time.Time
+	queue   []timed
+	closing bool
+	pulse   int64     // the next clock pulse to send
+	paused  time.Time // when Pause was called, or zero
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+// PulsesPerQuarter is the rate MIDI clock ticks at, in pulses a quarter note
+const PulsesPerQuarter = 24
+
+// timed is a message to be sent at a time
+type timed struct {
+	at  time.Time
+	msg midi.Message
+}
+
+// Name returns the name of the port
+func (o *Output) Name() string { return o.port.String() }
+
+// SendClock makes KeepTime send MIDI clock as well as the notes: a Start at
+// tick 0, PulsesPerQuarter clock pulses a quarter note at the clock's tempo,
+// and a Stop when the output is closed, so that sequencers and drum machines
+// can follow the performance. It must be called before KeepTime.
+func (o *Output) SendClock() { o.sync = true }
+
+// KeepTime makes the output send each message when its tick on clock falls,
+// counting from start, rather than as soon as it is handled, so that notes the
+// sequencer spreads across a step are heard as it spreads them. Called again
+// it re-times the messages handled from then on, and the clock.
+func (o *Output) KeepTime(clock music.Clock, start time.Time) {
+	if o.done != nil {
+		o.mu.Lock()
+		o.clock, o.start = &clock, start
+		o.mu.Unlock()
+		select {
+		case o.wake <- struct{}{}:
+		default:
+		}
+		return
+	}
+	o.clock, o.start = &clock, start
+	o.wake, o.done = make(chan struct{}, 1), make(chan struct{})
+	if o.mmc {
+		o.at(0, midi.SPP(0))
+		o.at(0, mmc(mmcPlay))
+	}
+	if o.sync {
+		o.at(0, midi.Start())
+	}
+	go o.schedule()
+}
+
+// Pause holds back the messages still to be sent, and with SendClock the
+// clock, sending a Stop, until Resume. With SendMMC it sends an MMC Stop and
+// the Song Position Pointer of where the performance stopped. It does
+// nothing without KeepTime.
+func (o *Output) Pause() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.clock == nil || !o.paused.IsZero() {
+		return
+	}
+	o.paused = time.Now()
+	if o.sync && o.err == nil {
+		o.send(midi.Stop())
+	}
+	if o.mmc {
+		for _, msg := range []midi.Message{mmc(mmcStop), o.position(o.paused)} {
+			if o.err == nil {
+				o.send(msg)
+			}
+		}
+	}
+}
+
+// Resume sends the messages Pause held back, and those handled since, later
+// by the time the output was paused for, and with SendClock sends a Continue
+// and carries on the clock from where it stopped. With SendMMC it sends the
+// Song Position Pointer of where the performance goes on, then an MMC Play.
+func (o *Output) Resume() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.paused.IsZero() {
+		return
+	}
+	d := time.Since(o.paused)
+	o.paused = time.Time{}
+	o.start = o.start.Add(d)
+	for i := range o.queue {
+		o.queue[i].at = o.queue[i].at.Add(d)
+	}
+	if o.mmc && o.err == nil {
+		o.send(o.position(time.Now()))
+	}
+	if o.sync && o.err == nil {
+		o.send(midi.Continue())
+	}
+	if o.mmc && o.err == nil {
+		o.send(mmc(mmcPlay))
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Handle sends NoteOn, NoteOff, Control and Program events to the port, and
+// Expression events with UseMPE. A failed send is kept for Close to return
+// and stops any more being sent.
+func (o *Output) Handle(e events.Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.err != nil {
+		return
+	}
+	switch e := e.(type) {
+	case events.NoteOn:
+		ch, key := uint8(e.Note.Channel-1)&0x0f, uint8(e.Note.Pitch)
+		if o.mpe != nil {
+			member, stolen, steal := o.mpe.assign(ch, key)
+			if steal {
+				k, _ := o.tuned(stolen[1])
+				delete(o.sounding, [2]uint8{member, k})
+				o.at(e.Note.Start, midi.NoteOff(member, k))
+			}
+			ch = member
+		}
+		key, detune := o.tuned(key)
+		if o.tuning != nil {
+			o.at(e.Note.Start, midi.Pitchbend(ch, bend(detune)))
+		}
+		if o.sounding == nil {
+			o.sounding = make(map[[2]uint8]bool)
+		}
+		o.sounding[[2]uint8{ch, key}] = true
+		o.at(e.Note.Start, midi.NoteOn(ch, key, uint8(e.Note.Velocity)))
+	case events.NoteOff:
+		ch, key := uint8(e.Note.Channel-1)&0x0f, uint8(e.Note.Pitch)
+		if o.mpe != nil {
+			member, ok := o.mpe.release(ch, key)
+			if !ok {
+				return // stolen by a later note
+			}
+			ch = member
+		}
+		key, _ = o.tuned(key)
+		delete(o.sounding, [2]uint8{ch, key})
+		o.at(e.Note.End(), midi.NoteOff(ch, key))
+	case events.Expression:
+		x := e.Expression
+		if o.mpe == nil {
+			return
+		}
+		if member, ok := o.mpe.notes[[2]uint8{uint8(x.Channel-1) & 0x0f, uint8(x.Pitch)}]; ok {
+			_, detune := o.tuned(uint8(x.Pitch))
+			o.at(x.Tick, midi.Pitchbend(member, bend(x.Bend+detune)))
+			o.at(x.Tick, midi.AfterTouch(member, pressure(x.Pressure)))
+		}
+	case events.Control:
+		c := e.Control
+		o.at(c.Tick, midi.ControlChange(o.channel(c.Channel), uint8(c.Controller), uint8(c.Value)))
+	case events.Program:
+		o.at(e.Start, midi.ProgramChange(o.channel(e.Channel), uint8(e.Program-1)&0x7f))
+	}
+}
+
+// channel returns the channel a message for every note on channel goes to:
+// the master channel with UseMPE
+func (o *Output) channel(channel int) uint8 {
+	if o.mpe != nil {
+		return mpeMaster
+	}
+	return uint8(channel-1) & 0x0f
+}
+
+// at sends msg at tick, or now without KeepTime. Messages for the same time
+// are sent in the order they were handled.
+func (o *Output) at(tick int64, msg midi.Message) {
+	if o.clock == nil {
+		o.send(msg)
+		return
+	}
+	t := o.start.Add(o.clock.Time(tick))
+	i := sort.Search(len(o.queue), func(i int) bool { return o.queue[i].at.After(t) })
+	o.queue = slices.Insert(o.queue, i, timed{t, msg})
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// schedule sends queued messages, and with SendClock clock pulses, as they
+// fall due until the output closes, waiting for each with a waiter so that
+// they go out within a fraction of a millisecond of their time. A pulse due
+// at the same time as messages is sent after them.
+func (o *Output) schedule() {
+	defer close(o.done)
+	var w waiter
+	for {
+		o.mu.Lock()
+		if o.closing {
+			o.mu.Unlock()
+			return
+		}
+		due := time.Now().Add(time.Hour)
+		if o.paused.IsZero() {
+			for {
+				next, pulse := o.next()
+				if next.After(time.Now()) {
+					due = next
+					break
+				}
+				if o.err == nil {
+					if pulse {
+						o.send(midi.TimingClock())
+					} else {
+						o.send(o.queue[0].msg)
+					}
+				}
+				if pulse {
+					o.pulse++
+				} else {
+					o.queue = o.queue[1:]
+				}
+			}
+		}
+		o.mu.Unlock()
+		w.wait(due, o.wake)
+	}
+}
+
+// next returns when the next message or clock pulse is due, and whether it
+// is a pulse, or a time an hour away when nothing is waiting
+func (o *Output) next() (time.Time, bool) {
+	var at time.Time
+	if len(o.queue) > 0 {
+		at = o.queue[0].at
+	}
+	if o.sync {
+		p := o.start.Add(o.clock.Time(o.pulse * music.TicksPerQuarter / PulsesPerQuarter))
+		if at.IsZero() || p.Before(at) {
+			return p, true
+		}
+	}
+	if at.IsZero() {
+		return time.Now().Add(time.Hour), false
+	}
+	return at, false
+}
+
+func (o *Output) send(msg midi.Message) {
+	if err := o.port.Send(msg); err != nil {
+		o.err = fmt.Errorf("MIDI port %q: %w", o.port.String(), err)
+	}
+}
+
+// Close sends any messages still waiting for their time straight away, with
+// SendClock a Stop and with SendMMC an MMC Stop, then note-offs for the notes
+// still sounding, closes the port and returns the first error sending to it
+func (o *Output) Close() error {
+	if o.done != nil {
+		o.mu.Lock()
+		for _, t := range o.queue {
+			if o.err == nil {
+				o.send(t.msg)
+			}
+		}
+		if o.sync && o.paused.IsZero() && o.err == nil {
+			o.send(midi.Stop())
+		}
+		if o.mmc && o.paused.IsZero() && o.err == nil {
+			o.send(mmc(mmcStop))
+		}
+		o.queue, o.closing = nil, true
+		o.mu.Unlock()
+		select {
+		case o.wake <- struct{}{}:
+		default:
+		}
+		<-o.done
+	}
+	for v := range o.sounding {
+		if o.err != nil {
+			break
+		}
+		o.send(midi.NoteOff(v[0], v[1]))
+	}
+	o.sounding = nil
+	if err := o.port.Close(); err != nil && o.err == nil {
+		o.err = err
+	}
+	return o.err
+}
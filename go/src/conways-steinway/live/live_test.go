@@ -0,0 +1,456 @@
+package live
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2/drivers"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+func TestChoose(t *testing.T) {
+	names := []string{"Midi Through Port-0", "FLUID Synth (1234)", "Disklavier MIDI 1"}
+	for _, tc := range []struct {
+		selector string
+		want     int
+	}{
+		{"1", 1},
+		{"Disklavier MIDI 1", 2},
+		{"fluid", 1},
+		{" disklavier ", 2},
+	} {
+		if got, err := choose("output", names, tc.selector); err != nil || got != tc.want {
+			t.Errorf("choose(%q) = %d, %v, want %d", tc.selector, got, err, tc.want)
+		}
+	}
+	for _, selector := range []string{"3", "-1", "piano", "midi"} {
+		if got, err := choose("output", names, selector); err == nil {
+			t.Errorf("choose(%q) = %d, want an error", selector, got)
+		}
+	}
+}
+
+// fakePort records what is sent to it
+type fakePort struct {
+	sent   [][]byte
+	closed bool
+}
+
+func (p *fakePort) Send(data []byte) error {
+	p.sent = append(p.sent, append([]byte(nil), data...))
+	return nil
+}
+
+func (p *fakePort) Close() error {
+	p.closed = true
+	return nil
+}
+
+func (p *fakePort) String() string { return "fake" }
+
+func TestOutputSendsNotes(t *testing.T) {
+	p := &fakePort{}
+	o := &Output{port: p}
+	c4 := music.NoteEvent{Pitch: 60, Velocity: 96, Channel: 2}
+	g4 := music.NoteEvent{Pitch: 67, Velocity: 80, Channel: 1}
+	o.Handle(events.NoteOn{Note: c4})
+	o.Handle(events.NoteOn{Note: g4})
+	o.Handle(events.Generation{})
+	o.Handle(events.NoteOff{Note: c4})
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]byte{{0x91, 60, 96}, {0x90, 67, 80}, {0x81, 60, 0}, {0x80, 67, 0}}
+	if len(p.sent) != len(want) {
+		t.Fatalf("sent % x, want % x", p.sent, want)
+	}
+	for i := range want {
+		if !bytes.Equal(p.sent[i], want[i]) {
+			t.Fatalf("sent % x, want % x", p.sent, want)
+		}
+	}
+	if !p.closed {
+		t.Fatal("the port was not closed")
+	}
+}
+
+// fakeOut is a driver port backed by a fakePort
+type fakeOut struct {
+	fakePort
+	name string
+}
+
+func (o *fakeOut) Open() error             { return nil }
+func (o *fakeOut) IsOpen() bool            { return !o.closed }
+func (o *fakeOut) Number() int             { return -1 }
+func (o *fakeOut) String() string          { return o.name }
+func (o *fakeOut) Underlying() interface{} { return nil }
+
+// virtualDriverFake is a driver with no ports of its own that can create one
+type virtualDriverFake struct{ created *fakeOut }
+
+func (d *virtualDriverFake) Ins() ([]drivers.In, error)   { return nil, nil }
+func (d *virtualDriverFake) Outs() ([]drivers.Out, error) { return nil, nil }
+func (d *virtualDriverFake) String() string               { return "fake" }
+func (d *virtualDriverFake) Close() error                 { return nil }
+
+func (d *virtualDriverFake) OpenVirtualOut(name string) (drivers.Out, error) {
+	d.created = &fakeOut{name: name}
+	return d.created, nil
+}
+
+func TestOutputSendsControls(t *testing.T) {
+	p := &fakePort{}
+	o := &Output{port: p}
+	o.Handle(events.Program{Channel: 3, Program: 49})
+	o.Handle(events.Control{Control: music.Control{Controller: music.SustainPedal, Value: 127, Channel: 3}})
+	if len(p.sent) != 2 || !bytes.Equal(p.sent[0], []byte{0xc2, 48}) || !bytes.Equal(p.sent[1], []byte{0xb2, 64, 127}) {
+		t.Fatalf("sent % x, want c2 30, b2 40 7f", p.sent)
+	}
+}
+
+func TestOpenVirtual(t *testing.T) {
+	if _, err := OpenVirtual(); err != ErrNoDriver {
+		t.Fatalf("without a driver got %v, want ErrNoDriver", err)
+	}
+	d := &virtualDriverFake{}
+	drivers.Register(d)
+	defer delete(drivers.REGISTRY, d.String())
+
+	o, err := OpenVirtual()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Name() != VirtualPortName {
+		t.Fatalf("port named %q, want %q", o.Name(), VirtualPortName)
+	}
+	o.Handle(events.NoteOn{Note: music.NoteEvent{Pitch: 21, Velocity: 1, Channel: 1}})
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.created.sent) != 2 || !d.created.closed {
+		t.Fatalf("sent % x, closed %v; want a note-on and its note-off, then closed", d.created.sent, d.created.closed)
+	}
+}
+
+func TestOutputKeepsTime(t *testing.T) {
+	p := &fakePort{}
+	o := &Output{port: p}
+	// A quarter note lasts a hundredth of a second, and a tick about 21µs
+	o.KeepTime(music.Clock{BPM: 6000, GenerationsPerBeat: 1, Meter: music.TimeSignature{Beats: 4, Unit: 4}}, time.Now())
+	late := music.NoteEvent{Pitch: 62, Velocity: 80, Start: 480 * 100, Channel: 1}
+	soon := music.NoteEvent{Pitch: 60, Velocity: 96, Start: 240, Duration: 240, Channel: 1}
+	o.Handle(events.NoteOn{Note: late})
+	o.Handle(events.NoteOn{Note: soon})
+	o.Handle(events.NoteOff{Note: soon})
+	time.Sleep(100 * time.Millisecond)
+	o.mu.Lock()
+	sent := len(p.sent)
+	o.mu.Unlock()
+	if sent != 2 {
+		t.Fatalf("sent % x before the late note was due, want the early note on and off", p.sent)
+	}
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]byte{{0x90, 60, 96}, {0x80, 60, 0}, {0x90, 62, 80}, {0x80, 62, 0}}
+	if len(p.sent) != len(want) {
+		t.Fatalf("sent % x, want % x", p.sent, want)
+	}
+	for i := range want {
+		if !bytes.Equal(p.sent[i], want[i]) {
+			t.Fatalf("sent % x, want % x", p.sent, want)
+		}
+	}
+}
+
+func TestOutputSendsClock(t *testing.T) {
+	p := &fakePort{}
+	o := &Output{port: p}
+	o.SendClock()
+	// A quarter note lasts a tenth of a second, and a clock pulse about 4ms
+	o.KeepTime(music.Clock{BPM: 600, GenerationsPerBeat: 1, Meter: music.TimeSignature{Beats: 4, Unit: 4}}, time.Now())
+	n := music.NoteEvent{Pitch: 60, Velocity: 96, Duration: 240, Channel: 1}
+	o.Handle(events.NoteOn{Note: n})
+	o.Handle(events.NoteOff{Note: n})
+	time.Sleep(150 * time.Millisecond)
+	o.Pause()
+	o.mu.Lock()
+	paused := len(p.sent)
+	o.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	o.mu.Lock()
+	if len(p.sent) != paused {
+		t.Errorf("sent % x while paused", p.sent[paused:])
+	}
+	o.mu.Unlock()
+	o.Resume()
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(p.sent[0], []byte{0xfa}) || !bytes.Equal(p.sent[1], []byte{0x90, 60, 96}) {
+		t.Fatalf("sent % x, want a start then the note on", p.sent[:2])
+	}
+	pulses, stopped, continued := 0, 0, 0
+	for _, m := range p.sent {
+		switch m[0] {
+		case 0xf8:
+			pulses++
+		case 0xfc:
+			stopped++
+		case 0xfb:
+			continued++
+		}
+	}
+	// 24 pulses a quarter note over about a quarter and a half
+	if pulses < 24 || pulses > 48 {
+		t.Errorf("sent %d clock pulses in about 150ms, want about 36", pulses)
+	}
+	if stopped != 2 || continued != 1 {
+		t.Errorf("sent %d stops and %d continues, want a stop for the pause and at the end with a continue between", stopped, continued)
+	}
+	if last := p.sent[len(p.sent)-1]; !bytes.Equal(last, []byte{0xfc}) {
+		t.Errorf("last sent % x, want a stop after the note off", last)
+	}
+}
+
+func TestOutputSendsMMC(t *testing.T) {
+	p := &fakePort{}
+	o := &Output{port: p}
+	o.SendMMC()
+	// A sixteenth note lasts 25ms
+	o.KeepTime(music.Clock{BPM: 600, GenerationsPerBeat: 1, Meter: music.TimeSignature{Beats: 4, Unit: 4}}, time.Now())
+	time.Sleep(110 * time.Millisecond)
+	o.Pause()
+	o.Resume()
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	play, stop := []byte{0xf0, 0x7f, 0x7f, 0x06, 0x02, 0xf7}, []byte{0xf0, 0x7f, 0x7f, 0x06, 0x01, 0xf7}
+	if len(p.sent) != 7 {
+		t.Fatalf("sent % x, want a pointer and a play, a stop and a pointer, a pointer and a play, and a stop", p.sent)
+	}
+	for i, want := range [][]byte{{0xf2, 0, 0}, play, stop, nil, nil, play, stop} {
+		if want != nil && !bytes.Equal(p.sent[i], want) {
+			t.Errorf("sent % x, want % x", p.sent[i], want)
+		}
+	}
+	// About four sixteenths in
+	if at := p.sent[3]; at[0] != 0xf2 || at[1] < 3 || at[1] > 5 || at[2] != 0 {
+		t.Errorf("paused at % x, want a pointer about 4 sixteenths in", at)
+	}
+	if !bytes.Equal(p.sent[4], p.sent[3]) {
+		t.Errorf("resumed at % x, want % x where it paused", p.sent[4], p.sent[3])
+	}
+}
+
+// fakeIn is a driver input port that hands its listener to the test
+type fakeIn struct {
+	name    string
+	onMsg   func(msg []byte, milliseconds int32)
+	stopped bool
+	closed  bool
+}
+
+func (p *fakeIn) Open() error             { return nil }
+func (p *fakeIn) Close() error            { p.closed = true; return nil }
+func (p *fakeIn) IsOpen() bool            { return !p.closed }
+func (p *fakeIn) Number() int             { return 0 }
+func (p *fakeIn) String() string          { return p.name }
+func (p *fakeIn) Underlying() interface{} { return nil }
+
+func (p *fakeIn) Listen(onMsg func(msg []byte, milliseconds int32), _ drivers.ListenConfig) (func(), error) {
+	p.onMsg = onMsg
+	return func() { p.stopped = true }, nil
+}
+
+// inDriverFake is a driver with input ports and no outputs
+type inDriverFake struct{ ins []drivers.In }
+
+func (d *inDriverFake) Ins() ([]drivers.In, error)   { return d.ins, nil }
+func (d *inDriverFake) Outs() ([]drivers.Out, error) { return nil, nil }
+func (d *inDriverFake) String() string               { return "fake-in" }
+func (d *inDriverFake) Close() error                 { return nil }
+
+func TestInput(t *testing.T) {
+	if _, err := OpenInput("0"); err != ErrNoDriver {
+		t.Fatalf("without a driver got %v, want ErrNoDriver", err)
+	}
+	through, keys := &fakeIn{name: "Midi Through"}, &fakeIn{name: "Digital Piano MIDI 1"}
+	d := &inDriverFake{ins: []drivers.In{through, keys}}
+	drivers.Register(d)
+	defer delete(drivers.REGISTRY, d.String())
+
+	in, err := OpenInput("piano")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Name() != keys.name || keys.onMsg == nil {
+		t.Fatalf("listening to %q, want %q", in.Name(), keys.name)
+	}
+	keys.onMsg([]byte{0x90, 60, 100}, 0)
+	keys.onMsg([]byte{0x90, 60, 0}, 0) // a note-off
+	keys.onMsg([]byte{0xb0, 64, 127}, 0)
+	keys.onMsg([]byte{0x93, 33, 20}, 0)
+	want := []music.NoteEvent{{Pitch: 60, Velocity: 100, Channel: 1}, {Pitch: 33, Velocity: 20, Channel: 4}}
+	if got := in.Take(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("took %v, want %v", got, want)
+	}
+	if got := in.Take(); len(got) != 0 {
+		t.Errorf("took %v again", got)
+	}
+	if got := in.TakeControls(); len(got) != 1 || got[0] != (music.Control{Controller: 64, Value: 127, Channel: 1}) {
+		t.Errorf("took controls %v, want the pedal", got)
+	}
+	if in.Rolling() {
+		t.Error("rolling before any Start")
+	}
+	for _, tc := range []struct {
+		msg  []byte
+		want bool
+	}{
+		{[]byte{0xfa}, true},
+		{[]byte{0xfc}, false},
+		{[]byte{0xf0, 0x7f, 0x7f, 0x06, 0x02, 0xf7}, true},
+		{[]byte{0xf0, 0x7f, 0x01, 0x06, 0x01, 0xf7}, false},
+		{[]byte{0xfb}, true},
+	} {
+		keys.onMsg(tc.msg, 0)
+		if in.Rolling() != tc.want {
+			t.Errorf("after % x rolling is %v, want %v", tc.msg, !tc.want, tc.want)
+		}
+		select {
+		case <-in.Rolled():
+			if !tc.want {
+				t.Errorf("Rolled closed after % x", tc.msg)
+			}
+		default:
+			if tc.want {
+				t.Errorf("Rolled open after % x", tc.msg)
+			}
+		}
+	}
+	if err := in.Close(); err != nil || !keys.stopped || !keys.closed {
+		t.Errorf("Close() = %v, stopped %v, closed %v", err, keys.stopped, keys.closed)
+	}
+}
+
+func TestOutputPlaysMPE(t *testing.T) {
+	p := &fakePort{}
+	o := &Output{port: p}
+	o.UseMPE()
+	if len(p.sent) != 4*(mpeMembers+1) || !bytes.Equal(p.sent[2], []byte{0xb0, 6, mpeMembers}) || !bytes.Equal(p.sent[6], []byte{0xb1, 6, MPEBendRange}) {
+		t.Fatalf("configured with % x, want the MPE configuration and bend ranges", p.sent)
+	}
+	p.sent = nil
+	c4 := music.NoteEvent{Pitch: 60, Velocity: 96, Channel: 1}
+	e4 := music.NoteEvent{Pitch: 64, Velocity: 80, Channel: 1}
+	o.Handle(events.NoteOn{Note: c4})
+	o.Handle(events.NoteOn{Note: e4})
+	o.Handle(events.Expression{Expression: music.Expression{Pitch: 64, Channel: 1, Bend: MPEBendRange / 2, Pressure: 1}})
+	o.Handle(events.Control{Control: music.Control{Controller: music.SustainPedal, Value: 127, Channel: 1}})
+	o.Handle(events.NoteOff{Note: c4})
+	want := [][]byte{{0x91, 60, 96}, {0x92, 64, 80}, {0xe2, 0x00, 0x60}, {0xd2, 127}, {0xb0, 64, 127}, {0x81, 60, 0}}
+	if len(p.sent) != len(want) {
+		t.Fatalf("sent % x, want % x", p.sent, want)
+	}
+	for i := range want {
+		if !bytes.Equal(p.sent[i], want[i]) {
+			t.Fatalf("sent % x, want % x", p.sent, want)
+		}
+	}
+
+	// With every member channel busy the note started longest ago, E4 now,
+	// gives up its channel
+	p.sent = nil
+	for k := range mpeMembers {
+		o.Handle(events.NoteOn{Note: music.NoteEvent{Pitch: 70 + k, Velocity: 64, Channel: 1}})
+	}
+	if !bytes.Equal(p.sent[len(p.sent)-2], []byte{0x82, 64, 0}) || !bytes.Equal(p.sent[len(p.sent)-1], []byte{0x92, 70 + mpeMembers - 1, 64}) {
+		t.Errorf("sent % x, want E4 stopped for the last note", p.sent[len(p.sent)-2:])
+	}
+	p.sent = nil
+	o.Handle(events.NoteOff{Note: e4})
+	if len(p.sent) != 0 {
+		t.Errorf("sent % x for a stolen note's end", p.sent)
+	}
+}
+
+func TestOutputRetunes(t *testing.T) {
+	var scl strings.Builder
+	scl.WriteString("19-edo\n19\n")
+	for i := 1; i < 19; i++ {
+		fmt.Fprintf(&scl, "%.5f\n", 1200*float64(i)/19)
+	}
+	scl.WriteString("2/1\n")
+	tuning, err := music.ParseScala(strings.NewReader(scl.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &fakePort{}
+	(&Output{port: p}).Retune(tuning, false)
+	if len(p.sent) != 2 || len(p.sent[0]) != 8+64*4 {
+		t.Fatalf("sent %d messages, want two tuning changes of 64 keys", len(p.sent))
+	}
+	// Key 60 keeps its pitch, and 61 is a nineteenth of an octave above it
+	c4 := p.sent[0][7+60*4:]
+	if !bytes.Equal(c4[:4], []byte{60, 60, 0, 0}) {
+		t.Errorf("key 60 tuned to % x", c4[:4])
+	}
+	frac := int(math.Round(12.0 / 19 * 16384))
+	if want := []byte{61, 60, byte(frac >> 7), byte(frac & 0x7f)}; !bytes.Equal(c4[4:8], want) {
+		t.Errorf("key 61 tuned to % x, want % x", c4[4:8], want)
+	}
+
+	p = &fakePort{}
+	o := &Output{port: p}
+	o.Retune(tuning, true)
+	p.sent = nil
+	// 61 is nearer 61 than 60 in equal temperament, and bent down to it
+	note := music.NoteEvent{Pitch: 61, Velocity: 90, Channel: 1}
+	o.Handle(events.NoteOn{Note: note})
+	o.Handle(events.NoteOff{Note: note})
+	b := 8192 + int(math.Round((12.0/19-1)/MPEBendRange*8191))
+	want := [][]byte{{0xe1, byte(b & 0x7f), byte(b >> 7)}, {0x91, 61, 90}, {0x81, 61, 0}}
+	if len(p.sent) != len(want) {
+		t.Fatalf("sent % x, want % x", p.sent, want)
+	}
+	for i := range want {
+		if !bytes.Equal(p.sent[i], want[i]) {
+			t.Fatalf("sent % x, want % x", p.sent, want)
+		}
+	}
+}
+
+func TestWaiter(t *testing.T) {
+	var w waiter
+	worst := time.Duration(0)
+	for i := 0; i < 20; i++ {
+		deadline := time.Now().Add(5 * time.Millisecond)
+		if !w.wait(deadline, nil) {
+			t.Fatal("wait returned false with nothing to wake it")
+		}
+		worst = max(worst, time.Since(deadline))
+	}
+	if worst > 2*time.Millisecond {
+		t.Errorf("woke up to %v late, want within 2ms", worst)
+	}
+
+	wake := make(chan struct{}, 1)
+	wake <- struct{}{}
+	begun := time.Now()
+	if w.wait(time.Now().Add(time.Hour), wake) {
+		t.Error("wait returned true when woken")
+	}
+	if d := time.Since(begun); d > 100*time.Millisecond {
+		t.Errorf("waking took %v", d)
+	}
+}
@@ -0,0 +1,43 @@
+package live
+
+import (
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// The MIDI Machine Control commands the transport sends and follows
+const (
+	mmcStop         = 0x01
+	mmcPlay         = 0x02
+	mmcDeferredPlay = 0x03
+	mmcPause        = 0x09
+)
+
+// mmc returns the MMC command cmd for every device, as a system exclusive
+// message
+func mmc(cmd byte) midi.Message {
+	return midi.Message{0xf0, 0x7f, 0x7f, 0x06, cmd, 0xf7}
+}
+
+// isMMC reports whether msg is the MMC command cmd, for any device
+func isMMC(msg []byte, cmd byte) bool {
+	return len(msg) == 6 && msg[0] == 0xf0 && msg[1] == 0x7f && msg[3] == 0x06 && msg[4] == cmd && msg[5] == 0xf7
+}
+
+// SendMMC makes KeepTime send MIDI Machine Control as well as the notes: a
+// Song Position Pointer at the top and an MMC Play as the performance
+// starts, an MMC Stop with a Song Position Pointer locating where it stopped
+// on Pause, the pointer again and an MMC Play on Resume, and an MMC Stop
+// when the output is closed, so that a DAW can locate and chase the
+// performance. It must be called before KeepTime.
+func (o *Output) SendMMC() { o.mmc = true }
+
+// position returns the Song Position Pointer, in sixteenth notes from the
+// start, of time at on the output's clock
+func (o *Output) position(at time.Time) midi.Message {
+	ticks := max(o.clock.Ticks(at.Sub(o.start)), 0)
+	return midi.SPP(uint16(min(ticks/(music.TicksPerQuarter/4), 1<<14-1)))
+}
@@ -0,0 +1,96 @@
+package live
+
+import (
+	"math"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// MPEBendRange is the pitch bend range, in semitones, UseMPE sets the member
+// channels to: the 48 the MPE specification makes their default
+const MPEBendRange = 48
+
+// The lower zone UseMPE configures: its master channel, 1, carries what acts
+// on every note, such as the pedal, and each of the 15 member channels after
+// it one note at a time
+const (
+	mpeMaster  = 0
+	mpeMembers = 15
+)
+
+// zone hands out the member channels of an MPE zone to the notes sounding
+type zone struct {
+	notes map[[2]uint8]uint8 // member channel of each note, by its own channel and key
+	keys  [mpeMembers + 1][2]uint8
+	busy  [mpeMembers + 1]bool
+	used  [mpeMembers + 1]int64 // when each member channel was last given out
+	count int64
+}
+
+// assign gives the note with key on channel a member channel: a free one,
+// that whose last note started longest ago, or failing any the channel of the
+// note started longest ago, which is returned to be stopped
+func (z *zone) assign(ch, key uint8) (member uint8, stolen [2]uint8, steal bool) {
+	if z.notes == nil {
+		z.notes = make(map[[2]uint8]uint8)
+	}
+	best := -1
+	for m := 1; m <= mpeMembers; m++ {
+		if best < 0 || z.busy[m] != z.busy[best] && !z.busy[m] || z.busy[m] == z.busy[best] && z.used[m] < z.used[best] {
+			best = m
+		}
+	}
+	member = uint8(best)
+	if z.busy[best] {
+		stolen, steal = z.keys[best], true
+		delete(z.notes, stolen)
+	}
+	z.count++
+	z.notes[[2]uint8{ch, key}] = member
+	z.keys[best], z.busy[best], z.used[best] = [2]uint8{ch, key}, true, z.count
+	return member, stolen, steal
+}
+
+// release frees the member channel of the note with key on channel,
+// returning it, or false if the note has none
+func (z *zone) release(ch, key uint8) (uint8, bool) {
+	m, ok := z.notes[[2]uint8{ch, key}]
+	if ok {
+		delete(z.notes, [2]uint8{ch, key})
+		z.busy[m] = false
+	}
+	return m, ok
+}
+
+// UseMPE makes the output play MIDI Polyphonic Expression, for MPE synths:
+// each note plays on a member channel of its own, which the note's
+// expressions bend and press without touching any other, and the pedal and
+// programs go to the master channel that controls them all. It configures a
+// lower zone of 15 member channels at once, with a pitch bend range of
+// MPEBendRange, and must be called before any events are handled.
+func (o *Output) UseMPE() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.mpe = &zone{}
+	rpn := func(ch, param, value uint8) {
+		for _, cc := range [][2]uint8{{101, 0}, {100, param}, {6, value}, {38, 0}} {
+			if o.err == nil {
+				o.send(midi.ControlChange(ch, cc[0], cc[1]))
+			}
+		}
+	}
+	rpn(mpeMaster, 6, mpeMembers) // the MPE configuration message
+	for m := uint8(1); m <= mpeMembers; m++ {
+		rpn(m, 0, MPEBendRange) // pitch bend sensitivity
+	}
+}
+
+// bend returns the pitch bend of a bend by semitones on a member channel
+func bend(semitones float64) int16 {
+	return int16(math.Round(min(max(semitones/MPEBendRange, -1), 1) * 8191))
+}
+
+// pressure returns the channel pressure of a pressure from 0 to 1
+func pressure(p float64) uint8 {
+	return uint8(math.Round(min(max(p, 0), 1) * 127))
+}
@@ -0,0 +1,6 @@
+//go:build rtmidi
+
+package live
+
+// The RtMidi driver registers itself with gomidi when it is linked in
+import _ "gitlab.com/gomidi/midi/v2/drivers/rtmididrv"
@@ -0,0 +1,64 @@
+package live
+
+import (
+	"math"
+
+	"gitlab.com/gomidi/midi/v2"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// Retune makes the output play in tuning. With bend each note plays on a
+// member channel of its own, as UseMPE sets up, from the equal-tempered key
+// nearest its pitch bent the rest of the way, which any MPE synth follows;
+// without, MIDI Tuning Standard single note tuning changes retune every key
+// at once, for synths that accept them. It must be called before any events
+// are handled.
+func (o *Output) Retune(tuning *music.Tuning, bend bool) {
+	if bend && o.mpe == nil {
+		o.UseMPE()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if bend {
+		o.tuning = tuning
+		return
+	}
+	for _, m := range tuningChanges(tuning) {
+		if o.err == nil {
+			o.send(m)
+		}
+	}
+}
+
+// tuningChanges returns real-time single note tuning changes, to every
+// device, retuning the 128 keys to tuning
+func tuningChanges(tuning *music.Tuning) []midi.Message {
+	const perMessage = 64
+	var msgs []midi.Message
+	for first := 0; first < 128; first += perMessage {
+		data := []byte{0x7f, 0x7f, 0x08, 0x02, 0, perMessage}
+		for key := first; key < first+perMessage; key++ {
+			p := min(max(tuning.Pitch(key), 0), 127+16383.0/16384)
+			semitone := math.Floor(p)
+			frac := int(math.Round((p - semitone) * 16384))
+			if frac == 16384 {
+				semitone, frac = semitone+1, 0
+			}
+			data = append(data, uint8(key), uint8(semitone), uint8(frac>>7), uint8(frac&0x7f))
+		}
+		msgs = append(msgs, midi.SysEx(data))
+	}
+	return msgs
+}
+
+// tuned returns the key a note struck on key plays and the semitones it is
+// bent by, in the tuning Retune bends notes to
+func (o *Output) tuned(key uint8) (uint8, float64) {
+	if o.tuning == nil {
+		return key, 0
+	}
+	p := o.tuning.Pitch(int(key))
+	k := min(max(math.Round(p), 0), 127)
+	return uint8(k), p - k
+}
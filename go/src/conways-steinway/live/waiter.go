@@ -0,0 +1,54 @@
+package live
+
+import (
+	"runtime"
+	"time"
+)
+
+const (
+	minSlack = 200 * time.Microsecond
+	maxSlack = 5 * time.Millisecond
+)
+
+// waiter waits for deadlines more closely than a timer alone, which can fire
+// a millisecond or more late: it sleeps until a little before the deadline,
+// by as long as its timers have lately been late, then spins out the rest.
+// Deadlines are read on the monotonic clock, so changes to the wall clock
+// do not move them.
+type waiter struct {
+	slack time.Duration // how long before a deadline the timer is set for
+}
+
+// wait returns true at deadline, or false as soon as wake receives
+func (w *waiter) wait(deadline time.Time, wake <-chan struct{}) bool {
+	if w.slack == 0 {
+		w.slack = time.Millisecond
+	}
+	if d := time.Until(deadline) - w.slack; d > 0 {
+		target := time.Now().Add(d)
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-wake:
+			timer.Stop()
+			return false
+		}
+		w.correct(time.Since(target))
+	}
+	for time.Now().Before(deadline) {
+		select {
+		case <-wake:
+			return false
+		default:
+		}
+		runtime.Gosched()
+	}
+	return true
+}
+
+// correct moves the slack a quarter of the way towards twice how late the
+// last timer fired, keeping it between minSlack and maxSlack
+func (w *waiter) correct(late time.Duration) {
+	w.slack += (2*late - w.slack) / 4
+	w.slack = min(max(w.slack, minSlack), maxSlack)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/config"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// looper keeps the notes the performer plays over the last --loop bars and,
+// driven by controllers on the MIDI input, captures them as a loop it plays
+// again under the boards as they go on playing
+type looper struct {
+	bus  *events.Bus
+	seq  *music.Sequencer
+	loop *music.Loop
+
+	actions  map[int]string    // what each controller does to the loop
+	sounding []music.NoteEvent // notes the loop has started and not yet ended
+	playing  bool              // set while the looper publishes its own notes
+}
+
+// newLooper returns a looper for cfg, or nil when there is to be none
+func newLooper(cfg *config.Config, clock music.Clock, bus *events.Bus, seq *music.Sequencer) (*looper, error) {
+	if cfg.Loop <= 0 {
+		return nil, nil
+	}
+	if cfg.MIDIIn == "" {
+		return nil, fmt.Errorf("--loop is played with controllers on the MIDI input, so it needs --midi-in")
+	}
+	actions := map[int]string{cfg.LoopRecord: "record", cfg.LoopOverdub: "overdub", cfg.LoopClear: "clear"}
+	for _, cc := range []int{cfg.LoopRecord, cfg.LoopOverdub, cfg.LoopClear} {
+		if cc < 0 || cc > 127 {
+			return nil, fmt.Errorf("loop controller %d is not between 0 and 127", cc)
+		}
+	}
+	if len(actions) < 3 {
+		return nil, fmt.Errorf("--loop-record, --loop-overdub and --loop-clear need a controller each")
+	}
+	bar := int64(clock.Meter.Beats) * clock.TicksPerBeat()
+	return &looper{bus: bus, seq: seq, loop: music.NewLoop(int64(cfg.Loop) * bar), actions: actions}, nil
+}
+
+// handle keeps the notes the performer ends for the loop to take
+func (l *looper) handle(e events.Event) {
+	if e, ok := e.(events.NoteOff); ok && !l.playing {
+		l.loop.Record(e.Note)
+	}
+}
+
+// step acts on the controllers moved before tick, each pressed when it goes
+// to 64 or above, and plays the notes of the loop that fall in the tick
+func (l *looper) step(tick int, controls []music.Control) {
+	now, next := l.seq.Tick(tick), l.seq.Tick(tick+1)
+	for _, c := range controls {
+		action, ok := l.actions[c.Controller]
+		if !ok || c.Value < 64 {
+			continue
+		}
+		switch action {
+		case "record":
+			l.loop.Capture(now)
+		case "overdub":
+			l.loop.Overdub(now)
+		case "clear":
+			l.loop.Clear()
+		}
+		l.bus.Publish(events.Loop{Tick: tick, Action: action, Notes: l.loop.Len()})
+	}
+	l.playing = true
+	defer func() { l.playing = false }()
+	l.end(tick, func(n music.NoteEvent) bool { return n.End() <= now })
+	for _, n := range l.loop.Due(now, next) {
+		l.bus.Publish(events.NoteOn{Tick: tick, Note: n})
+		l.sounding = append(l.sounding, n)
+	}
+}
+
+// end publishes the end of the loop's sounding notes that done reports
+// finished
+func (l *looper) end(tick int, done func(music.NoteEvent) bool) {
+	keep := l.sounding[:0]
+	for _, n := range l.sounding {
+		if done(n) {
+			l.bus.Publish(events.NoteOff{Tick: tick, Note: n})
+		} else {
+			keep = append(keep, n)
+		}
+	}
+	l.sounding = keep
+}
+
+// close ends the loop's notes still sounding when the run stops at tick
+func (l *looper) close(tick int) {
+	l.playing = true
+	defer func() { l.playing = false }()
+	end := l.seq.Tick(tick)
+	for i, n := range l.sounding {
+		l.sounding[i].Duration = min(n.Duration, max(end-n.Start, 1))
+	}
+	l.end(tick, func(music.NoteEvent) bool { return true })
+}
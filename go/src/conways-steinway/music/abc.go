@@ -0,0 +1,120 @@
+package music
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// abcBarsPerLine is how many bars each line of tune holds
+const abcBarsPerLine = 4
+
+// WriteABC writes a melody reduction of tracks as a tune in ABC notation:
+// the highest note struck in each step of the clock, leaving out the drums,
+// held until the next step that strikes one, or until it ends. The tune is in
+// bars of the clock's time signature, quantized as WriteMusicXML quantizes
+// its score, with notes tied across bar lines and into lengths notation can
+// show.
+func WriteABC(w io.Writer, title string, clock Clock, tracks []Track) error {
+	if err := clock.Validate(); err != nil {
+		return fmt.Errorf("abc: %w", err)
+	}
+	tune := Track{Notes: melody(tracks, clock.TicksPerStep())}
+	s := newScore(clock, []Track{tune})
+	notes := s.staff(tune.Notes, func(NoteEvent) bool { return true })
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "X:1")
+	fmt.Fprintf(bw, "T:%s\n", strings.Join(strings.Fields(title), " "))
+	fmt.Fprintf(bw, "M:%v\n", clock.Meter)
+	fmt.Fprintf(bw, "L:1/%d\n", 4*TicksPerQuarter/s.quantum)
+	fmt.Fprintf(bw, "Q:1/%d=%d\n", clock.Meter.Unit, max(int(math.Round(clock.BPM)), 1))
+	fmt.Fprintln(bw, "K:C")
+	for bar := 0; bar < s.bars; bar++ {
+		chords := s.chords(notes, bar)
+		if len(chords) == 0 {
+			bw.WriteString("Z")
+		}
+		sharp := make(map[string]bool) // accidentals carry to the end of the bar
+		for i, c := range chords {
+			if i > 0 {
+				bw.WriteByte(' ')
+			}
+			bw.WriteString(abcNote(c, sharp))
+		}
+		switch {
+		case bar == s.bars-1:
+			bw.WriteString(" |]\n")
+		case bar%abcBarsPerLine == abcBarsPerLine-1:
+			bw.WriteString(" |\n")
+		default:
+			bw.WriteString(" | ")
+		}
+	}
+	return bw.Flush()
+}
+
+// melody reduces the pitched notes of tracks to one line: the highest note
+// struck in each step ticksPerStep ticks long, cut short where the next one
+// starts
+func melody(tracks []Track, ticksPerStep int64) []NoteEvent {
+	highest := make(map[int64]NoteEvent)
+	for _, t := range tracks {
+		if t.Channel == DrumChannel {
+			continue
+		}
+		for _, n := range t.Notes {
+			step := n.Start / ticksPerStep
+			if h, ok := highest[step]; !ok || n.Pitch > h.Pitch || n.Pitch == h.Pitch && n.Start < h.Start {
+				highest[step] = n
+			}
+		}
+	}
+	out := make([]NoteEvent, 0, len(highest))
+	for _, n := range highest {
+		out = append(out, n)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+	for i := range out {
+		if i+1 < len(out) && out[i].End() > out[i+1].Start {
+			out[i].Duration = out[i+1].Start - out[i].Start
+		}
+	}
+	return out
+}
+
+// abcNote writes the highest note of a chord, or a rest, with its length in
+// quanta and a tie if it carries on. Sharps are always marked; sharp records
+// the notes sharpened so far in the bar, which a later natural must cancel.
+func abcNote(c chord, sharp map[string]bool) string {
+	length := ""
+	if c.length.quanta > 1 {
+		length = fmt.Sprint(c.length.quanta)
+	}
+	if len(c.notes) == 0 {
+		return "z" + length
+	}
+	n := c.notes[len(c.notes)-1]
+	step, alter, octave := spell(n.pitch)
+	name := step
+	switch {
+	case octave >= 5:
+		name = strings.ToLower(step) + strings.Repeat("'", octave-5)
+	case octave < 4:
+		name += strings.Repeat(",", 4-octave)
+	}
+	if alter != 0 {
+		sharp[name] = true
+		name = "^" + name
+	} else if sharp[name] {
+		delete(sharp, name)
+		name = "=" + name
+	}
+	if _, carry := c.tied(n); carry {
+		length += "-"
+	}
+	return name + length
+}
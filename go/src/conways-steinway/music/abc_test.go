@@ -0,0 +1,36 @@
+package music
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteABC(t *testing.T) {
+	// In 3/4 at a generation a beat: a C major triad, then F#4 above an E3
+	// held from the triad, then F4 and, in the next bar, a drum the
+	// reduction leaves out
+	clock := Clock{BPM: 100, GenerationsPerBeat: 1, Meter: TimeSignature{3, 4}}
+	beat := int64(TicksPerQuarter)
+	piano := []NoteEvent{
+		{Pitch: 60, Velocity: 80, Start: 0, Duration: beat, Channel: 1},
+		{Pitch: 64, Velocity: 80, Start: 0, Duration: beat, Channel: 1},
+		{Pitch: 67, Velocity: 80, Start: 5, Duration: 2 * beat, Channel: 1},
+		{Pitch: 52, Velocity: 80, Start: 0, Duration: 3 * beat, Channel: 1},
+		{Pitch: 66, Velocity: 80, Start: beat, Duration: beat, Channel: 1},
+		{Pitch: 65, Velocity: 80, Start: 2 * beat, Duration: 2 * beat, Channel: 1},
+	}
+	drums := []NoteEvent{{Pitch: int(Kick), Velocity: 100, Start: 4 * beat, Duration: beat, Channel: DrumChannel}}
+	var buf bytes.Buffer
+	if err := WriteABC(&buf, "A\ntest", clock, []Track{{Channel: 1, Notes: piano}, {Channel: DrumChannel, Notes: drums}}); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"X:1", "T:A test", "M:3/4", "L:1/32", "Q:1/4=100", "K:C",
+		// G4 is cut short where F#4 is struck, and F4 needs a natural
+		"G8 ^F8 =F8- | F8 z16 |]", "",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("WriteABC wrote\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package music
+
+import "math"
+
+// Arc shapes the dynamics of a whole performance from how the board's
+// population is trending: a growing board swells into a crescendo and a
+// dying one fades away. The trend is the relative change in population from
+// one generation to the next, smoothed so that a single burst of births does
+// not jolt the music, and velocities are scaled by up to Depth either way.
+type Arc struct {
+	Depth  float64 // largest fraction velocities are raised or lowered by, 0 to 1; 0 keeps them
+	Attack float64 // how fast a rising trend is followed, 0 to 1; 1 follows it at once
+	Decay  float64 // how fast a falling trend is followed, 0 to 1
+
+	trend float64
+	last  int // population of the previous generation, or -1 before the first
+}
+
+// NewArc returns an arc that raises or lowers velocities by up to depth,
+// following trends with the given attack and decay
+func NewArc(depth, attack, decay float64) *Arc {
+	return &Arc{Depth: depth, Attack: attack, Decay: decay, last: -1}
+}
+
+// Update takes the population of the next generation and returns the factor
+// its velocities are scaled by, from 1-Depth to 1+Depth
+func (a *Arc) Update(population int) float64 {
+	if a.last >= 0 {
+		change := float64(population-a.last) / float64(max(a.last, 1))
+		change = max(min(change, 1), -1)
+		rate := a.Decay
+		if change > a.trend {
+			rate = a.Attack
+		}
+		a.trend += min(max(rate, 0), 1) * (change - a.trend)
+	}
+	a.last = population
+	return 1 + min(max(a.Depth, 0), 1)*a.trend
+}
+
+// ScaleVelocities returns velocities multiplied by factor, kept between 1 and 127
+func ScaleVelocities(velocities []int, factor float64) []int {
+	out := make([]int, len(velocities))
+	for i, v := range velocities {
+		out[i] = clampVelocity(int(math.Round(float64(v) * factor)))
+	}
+	return out
+}
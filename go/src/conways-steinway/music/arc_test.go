@@ -0,0 +1,34 @@
+package music
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestArc(t *testing.T) {
+	a := NewArc(0.5, 1, 0.5)
+	if got := a.Update(100); got != 1 {
+		t.Errorf("first generation scaled by %g, want 1", got)
+	}
+	// Doubling is followed at once, to the full depth
+	if got := a.Update(200); got != 1.5 {
+		t.Errorf("doubling scaled by %g, want 1.5", got)
+	}
+	// A steady population decays the trend halfway back each generation
+	if got := a.Update(200); got != 1.25 {
+		t.Errorf("holding steady scaled by %g, want 1.25", got)
+	}
+	// Halving pulls it down halfway, back to level
+	if got := a.Update(100); got != 1 {
+		t.Errorf("halving scaled by %g", got)
+	}
+	if got := NewArc(0, 1, 1); got.Update(1) != 1 || got.Update(1000) != 1 {
+		t.Error("an arc without depth changed the dynamics")
+	}
+}
+
+func TestScaleVelocities(t *testing.T) {
+	if got, want := ScaleVelocities([]int{1, 64, 100}, 1.5), []int{2, 96, 127}; !slices.Equal(got, want) {
+		t.Errorf("ScaleVelocities = %v, want %v", got, want)
+	}
+}
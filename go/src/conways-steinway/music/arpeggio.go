@@ -0,0 +1,93 @@
+package music
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// ArpeggioOrder is the order an arpeggiated chord's keys are struck in
+type ArpeggioOrder int
+
+const (
+	ArpeggioUp     ArpeggioOrder = iota // lowest key first
+	ArpeggioDown                        // highest key first
+	ArpeggioRandom                      // a fresh shuffle every time
+)
+
+var arpeggioOrderNames = [...]string{
+	ArpeggioUp:     "up",
+	ArpeggioDown:   "down",
+	ArpeggioRandom: "random",
+}
+
+func (o ArpeggioOrder) String() string {
+	if o < 0 || int(o) >= len(arpeggioOrderNames) {
+		return fmt.Sprintf("ArpeggioOrder(%d)", int(o))
+	}
+	return arpeggioOrderNames[o]
+}
+
+// ParseArpeggioOrder converts a name such as "down" into an ArpeggioOrder
+func ParseArpeggioOrder(s string) (ArpeggioOrder, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for o, n := range arpeggioOrderNames {
+		if n == name {
+			return ArpeggioOrder(o), nil
+		}
+	}
+	return ArpeggioUp, fmt.Errorf("invalid arpeggio order %q (want %s)", s, strings.Join(arpeggioOrderNames[:], ", "))
+}
+
+// Set implements flag.Value
+func (o *ArpeggioOrder) Set(s string) error {
+	p, err := ParseArpeggioOrder(s)
+	if err != nil {
+		return err
+	}
+	*o = p
+	return nil
+}
+
+// Arpeggiator spreads the keys of a crowded step across the step instead of
+// striking them all at once, as no pianist, and no player piano, can strike
+// twenty keys together
+type Arpeggiator struct {
+	Above int           // most keys struck together; more are arpeggiated
+	Order ArpeggioOrder // order the keys are struck in
+
+	rng *rand.Rand
+}
+
+// NewArpeggiator returns an arpeggiator that shuffles keys, for
+// ArpeggioRandom, with random numbers from rng
+func NewArpeggiator(rng *rand.Rand, above int, order ArpeggioOrder) *Arpeggiator {
+	return &Arpeggiator{Above: above, Order: order, rng: rng}
+}
+
+// Spread returns the ticks after the step that each of n keys, lowest
+// first, is struck on when the step lasts span ticks: evenly across the step
+// in the arpeggiator's order when there are more than Above of them, or nil
+// when they are struck together
+func (a *Arpeggiator) Spread(n int, span int64) []int64 {
+	if n <= a.Above || n < 2 {
+		return nil
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	switch a.Order {
+	case ArpeggioDown:
+		for i := range order {
+			order[i] = n - 1 - i
+		}
+	case ArpeggioRandom:
+		a.rng.Shuffle(n, func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+	offsets := make([]int64, n)
+	for place, i := range order {
+		offsets[i] = int64(place) * span / int64(n)
+	}
+	return offsets
+}
@@ -0,0 +1,38 @@
+package music
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestParseArpeggioOrder(t *testing.T) {
+	for _, o := range []ArpeggioOrder{ArpeggioUp, ArpeggioDown, ArpeggioRandom} {
+		if got, err := ParseArpeggioOrder(o.String()); err != nil || got != o {
+			t.Errorf("ParseArpeggioOrder(%q) = %v, %v", o, got, err)
+		}
+	}
+	if _, err := ParseArpeggioOrder("sideways"); err == nil {
+		t.Error("ParseArpeggioOrder accepted sideways")
+	}
+}
+
+func TestSpread(t *testing.T) {
+	a := NewArpeggiator(rand.New(rand.NewSource(1)), 3, ArpeggioUp)
+	if got := a.Spread(3, 120); got != nil {
+		t.Errorf("three keys spread %v, want struck together", got)
+	}
+	if got, want := a.Spread(4, 120), []int64{0, 30, 60, 90}; !slices.Equal(got, want) {
+		t.Errorf("up: %v, want %v", got, want)
+	}
+	a.Order = ArpeggioDown
+	if got, want := a.Spread(4, 120), []int64{90, 60, 30, 0}; !slices.Equal(got, want) {
+		t.Errorf("down: %v, want %v", got, want)
+	}
+	a.Order = ArpeggioRandom
+	got := a.Spread(6, 120)
+	slices.Sort(got)
+	if want := []int64{0, 20, 40, 60, 80, 100}; !slices.Equal(got, want) {
+		t.Errorf("random: %v, want a shuffle of %v", got, want)
+	}
+}
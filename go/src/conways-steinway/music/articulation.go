@@ -0,0 +1,55 @@
+package music
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Articulation is how long the notes of a channel sound for, as a fraction
+// of the step they are struck on, from just above 0 to 1. Legato, the zero
+// Articulation, holds a note instead for as long as its key is struck, tied
+// across the steps.
+type Articulation float64
+
+const (
+	// Legato holds each note while its key is struck
+	Legato Articulation = 0
+	// Tenuto sounds each note for the whole of its step, striking a key held
+	// from the step before afresh
+	Tenuto Articulation = 1
+	// Staccato sounds each note for under a third of its step
+	Staccato Articulation = 0.3
+)
+
+var articulationNames = map[Articulation]string{Legato: "legato", Tenuto: "tenuto", Staccato: "staccato"}
+
+func (a Articulation) String() string {
+	if name, ok := articulationNames[a]; ok {
+		return name
+	}
+	return strconv.FormatFloat(float64(a)*100, 'g', -1, 64) + "%"
+}
+
+// ParseArticulation reads "legato", "tenuto" or "staccato", or the
+// percentage of the step the notes sound for, e.g. "60%"
+func ParseArticulation(s string) (Articulation, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for a, n := range articulationNames {
+		if n == name {
+			return a, nil
+		}
+	}
+	if percent, ok := strings.CutSuffix(name, "%"); ok {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(percent), 64); err == nil && f > 0 && f <= 100 {
+			return Articulation(f / 100), nil
+		}
+	}
+	return Legato, fmt.Errorf("invalid articulation %q (want legato, tenuto, staccato, or a percentage of the step above 0 and up to 100%%)", s)
+}
+
+// length returns how many ticks a note struck at start sounds for, in a step
+// ending at tick next, never less than one
+func (a Articulation) length(start, next int64, step int64) int64 {
+	return max(min(int64(float64(a)*float64(step)+0.5), next-start), 1)
+}
@@ -0,0 +1,18 @@
+package music
+
+// BassKeys is the span of the bottom two octaves of the piano, A0 to G#2,
+// that a bass line plays in
+const BassKeys = 24
+
+// BassLine returns the single key a bass line plays for keys struck at
+// velocities: the loudest of them, the lowest where several are as loud,
+// folded down into the bottom two octaves. keys must not be empty.
+func BassLine(keys []Key, velocities []int) (Key, int) {
+	loudest := 0
+	for i := range keys {
+		if velocities[i] > velocities[loudest] || velocities[i] == velocities[loudest] && keys[i] < keys[loudest] {
+			loudest = i
+		}
+	}
+	return keys[loudest] % BassKeys, velocities[loudest]
+}
@@ -0,0 +1,21 @@
+package music
+
+import "testing"
+
+func TestBassLine(t *testing.T) {
+	for _, tc := range []struct {
+		keys       []Key
+		velocities []int
+		key        Key
+		velocity   int
+	}{
+		{[]Key{5}, []int{64}, 5, 64},
+		{[]Key{30, 50, 70}, []int{40, 90, 90}, 2, 90},
+		{[]Key{87}, []int{100}, 15, 100},
+	} {
+		key, velocity := BassLine(tc.keys, tc.velocities)
+		if key != tc.key || velocity != tc.velocity {
+			t.Errorf("BassLine(%v, %v) = %d, %d, want %d, %d", tc.keys, tc.velocities, key, velocity, tc.key, tc.velocity)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package music
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Quality is the kind of a chord
+type Quality int
+
+const (
+	Major           Quality = iota // root, major third and fifth
+	Minor                          // root, minor third and fifth
+	Diminished                     // root, minor third and diminished fifth
+	Augmented                      // root, major third and augmented fifth
+	Dominant7                      // major triad and minor seventh
+	Major7                         // major triad and major seventh
+	Minor7                         // minor triad and minor seventh
+	HalfDiminished7                // diminished triad and minor seventh
+	Diminished7                    // diminished triad and diminished seventh
+	// Cluster is five or more keys each within a tone of the next, the
+	// Python and Rust players' other kind of chord
+	Cluster
+)
+
+var qualityNames = [...]string{
+	Major:           "major",
+	Minor:           "minor",
+	Diminished:      "diminished",
+	Augmented:       "augmented",
+	Dominant7:       "7",
+	Major7:          "major 7",
+	Minor7:          "minor 7",
+	HalfDiminished7: "half-diminished 7",
+	Diminished7:     "diminished 7",
+	Cluster:         "cluster",
+}
+
+func (q Quality) String() string {
+	if q < 0 || int(q) >= len(qualityNames) {
+		return fmt.Sprintf("Quality(%d)", int(q))
+	}
+	return qualityNames[q]
+}
+
+// chordTones are the semitones above the root of each chord tone, in the
+// order root, third, fifth and seventh. Triads come first so that three
+// keys are never taken for a seventh.
+var chordTones = []struct {
+	quality Quality
+	tones   []int
+}{
+	{Major, []int{0, 4, 7}},
+	{Minor, []int{0, 3, 7}},
+	{Diminished, []int{0, 3, 6}},
+	{Augmented, []int{0, 4, 8}},
+	{Dominant7, []int{0, 4, 7, 10}},
+	{Major7, []int{0, 4, 7, 11}},
+	{Minor7, []int{0, 3, 7, 10}},
+	{HalfDiminished7, []int{0, 3, 6, 10}},
+	{Diminished7, []int{0, 3, 6, 9}},
+}
+
+// Chord is a chord recognised among the keys struck together
+type Chord struct {
+	Root      int // pitch class of the root, 0 for C to 11 for B
+	Quality   Quality
+	Inversion int   // 0 in root position, 1 with the third in the bass, and so on
+	Keys      []Key // keys making up the chord, lowest first
+}
+
+// String names the chord, e.g. "C major", "G 7, first inversion" or
+// "F# cluster"
+func (c Chord) String() string {
+	s := noteNames[c.Root] + " " + c.Quality.String()
+	if c.Inversion > 0 {
+		s += ", " + [...]string{"first", "second", "third"}[c.Inversion-1] + " inversion"
+	}
+	return s
+}
+
+// strayKeys is the most keys struck that a triad of neighbouring keys among
+// them is taken to be the chord of, the triad and three stray keys; among
+// more it is only a chance alignment in a crowd
+const strayKeys = 6
+
+// DetectChord looks for a chord among keys, which must be lowest first, as
+// Played returns them. When three or four pitch classes are struck between
+// them and form a triad or seventh chord, in any octaves and any inversion,
+// that is the chord; otherwise, among no more than strayKeys keys, the first
+// three neighbouring keys that form a triad are, and failing that five or
+// more keys each within a tone of the next make a cluster.
+func DetectChord(keys []Key) (Chord, bool) {
+	if len(keys) < 3 {
+		return Chord{}, false
+	}
+	if c, ok := matchChord(keys); ok {
+		return c, true
+	}
+	for i := 0; i+3 <= len(keys) && len(keys) <= strayKeys; i++ {
+		if c, ok := matchChord(keys[i : i+3]); ok {
+			return c, true
+		}
+	}
+	if runs := clusters(keys); len(runs) > 0 {
+		cluster := slices.Clone(keys[runs[0][0]:runs[0][1]])
+		return Chord{Root: cluster[0].Note() % 12, Quality: Cluster, Keys: cluster}, true
+	}
+	return Chord{}, false
+}
+
+// clusterSize is the fewest keys, each within a tone of the next, that make
+// a cluster
+const clusterSize = 5
+
+// clusters returns the start and end indexes of each cluster in keys, which
+// must be lowest first
+func clusters(keys []Key) [][2]int {
+	var runs [][2]int
+	start := 0
+	for i := 1; i <= len(keys); i++ {
+		if i < len(keys) && keys[i]-keys[i-1] <= 2 {
+			continue
+		}
+		if i-start >= clusterSize {
+			runs = append(runs, [2]int{start, i})
+		}
+		start = i
+	}
+	return runs
+}
+
+// matchChord reports whether the pitch classes of keys are exactly those of
+// one chord. Roots are tried from the bass up, so a symmetric chord such as
+// an augmented triad is named after its lowest note.
+func matchChord(keys []Key) (Chord, bool) {
+	var classes [12]bool
+	n := 0
+	for _, k := range keys {
+		if pc := k.Note() % 12; !classes[pc] {
+			classes[pc] = true
+			n++
+		}
+	}
+	bass := keys[0].Note() % 12
+	for _, ct := range chordTones {
+		if len(ct.tones) != n {
+			continue
+		}
+		for up := 0; up < 12; up++ {
+			root := (bass + up) % 12
+			if !classes[root] || !hasTones(classes, root, ct.tones) {
+				continue
+			}
+			inversion := slices.Index(ct.tones, (bass-root+12)%12)
+			return Chord{Root: root, Quality: ct.quality, Inversion: inversion, Keys: slices.Clone(keys)}, true
+		}
+	}
+	return Chord{}, false
+}
+
+// hasTones reports whether every tone above root is among classes
+func hasTones(classes [12]bool, root int, tones []int) bool {
+	for _, t := range tones {
+		if !classes[(root+t)%12] {
+			return false
+		}
+	}
+	return true
+}
+
+// Voicing says how a recognised chord's keys are rearranged before they are
+// played
+type Voicing int
+
+const (
+	// VoicingNone plays the keys as the board struck them
+	VoicingNone Voicing = iota
+	// VoicingClose plays each chord tone once, in root position within the
+	// octave starting at the root at or below the lowest key
+	VoicingClose
+	// VoicingOpen plays the close voicing with the third raised an octave
+	VoicingOpen
+	// VoicingDrop2 plays the close voicing with the second tone from the top
+	// dropped an octave, into the bass
+	VoicingDrop2
+)
+
+var voicingNames = [...]string{
+	VoicingNone:  "none",
+	VoicingClose: "close",
+	VoicingOpen:  "open",
+	VoicingDrop2: "drop-2",
+}
+
+func (v Voicing) String() string {
+	if v < 0 || int(v) >= len(voicingNames) {
+		return fmt.Sprintf("Voicing(%d)", int(v))
+	}
+	return voicingNames[v]
+}
+
+// ParseVoicing converts a name such as "close" into a Voicing
+func ParseVoicing(s string) (Voicing, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for v, n := range voicingNames {
+		if n == name {
+			return Voicing(v), nil
+		}
+	}
+	return VoicingNone, fmt.Errorf("invalid voicing %q (want %s)", s, strings.Join(voicingNames[:], ", "))
+}
+
+// Set implements flag.Value
+func (v *Voicing) Set(s string) error {
+	p, err := ParseVoicing(s)
+	if err != nil {
+		return err
+	}
+	*v = p
+	return nil
+}
+
+// Voice returns the keys to play for the chord under v, lowest first.
+// Clusters, and chords that would not fit on the keyboard, are played as
+// struck.
+func (c Chord) Voice(v Voicing) []Key {
+	if v == VoicingNone || c.Quality == Cluster || len(c.Keys) == 0 {
+		return slices.Clone(c.Keys)
+	}
+	var tones []int
+	for _, ct := range chordTones {
+		if ct.quality == c.Quality {
+			tones = ct.tones
+		}
+	}
+	root := c.Keys[0] - Key((c.Keys[0].Note()-c.Root+12)%12)
+	if root < 0 {
+		root += 12
+	}
+	voiced := make([]Key, len(tones))
+	for i, t := range tones {
+		voiced[i] = root + Key(t)
+	}
+	switch v {
+	case VoicingOpen:
+		voiced[1] += 12
+	case VoicingDrop2:
+		if voiced[len(voiced)-2] < 12 {
+			for i := range voiced {
+				voiced[i] += 12 // to leave room below to drop into
+			}
+		}
+		voiced[len(voiced)-2] -= 12
+	}
+	slices.Sort(voiced)
+	if voiced[len(voiced)-1] >= Keys {
+		return slices.Clone(c.Keys)
+	}
+	return voiced
+}
+
+// AvoidSemitones returns keys, which must be lowest first, without each key
+// a semitone above the last one kept, so that a dense cluster of cells
+// sounds as a chord rather than a forearm on the keyboard
+func AvoidSemitones(keys []Key) []Key {
+	var out []Key
+	for _, k := range keys {
+		if len(out) > 0 && k-out[len(out)-1] <= 1 {
+			continue
+		}
+		out = append(out, k)
+	}
+	return out
+}
+
+// Revoice returns keys with the chord's keys replaced by its voicing under
+// v, lowest first and without repeats
+func (c Chord) Revoice(keys []Key, v Voicing) []Key {
+	var out []Key
+	for _, k := range keys {
+		if !slices.Contains(c.Keys, k) {
+			out = append(out, k)
+		}
+	}
+	out = append(out, c.Voice(v)...)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
@@ -0,0 +1,115 @@
+package music
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectChord(t *testing.T) {
+	for _, tc := range []struct {
+		keys []Key
+		want string
+	}{
+		{[]Key{39, 43, 46}, "C major"},                        // C4 E4 G4
+		{[]Key{43, 46, 51}, "C major, first inversion"},       // E4 G4 C5
+		{[]Key{34, 39, 42}, "C minor, second inversion"},      // G3 C4 D#4
+		{[]Key{22, 38, 41, 44}, "G 7"},                        // G2 B3 D4 F4, spread out
+		{[]Key{38, 41, 44, 46}, "G 7, first inversion"},       // B3 D4 F4 G4
+		{[]Key{39, 43, 47}, "C augmented"},                    // named after the bass
+		{[]Key{0, 1, 2, 50, 54, 57}, "B major"},               // B4 D#5 F#5 among other keys
+		{[]Key{10, 11, 13, 14, 16, 40}, "G cluster"},          // G1 up to B1
+		{[]Key{41, 44, 47, 51}, "D half-diminished 7"},        // D4 F4 G#4 C5
+		{[]Key{39, 42, 45, 48, 51}, "C diminished 7"},         // C doubled an octave up
+		{[]Key{31, 34, 38, 39}, "C major 7, first inversion"}, // E3 G3 B3 C4
+	} {
+		c, ok := DetectChord(tc.keys)
+		if !ok || c.String() != tc.want {
+			t.Errorf("DetectChord(%v) = %v, %v, want %s", tc.keys, c, ok, tc.want)
+		}
+	}
+	// Seven keys and more are too many for a triad among them to be the
+	// chord of the generation
+	crowd := []Key{0, 1, 5, 39, 43, 46, 70} // C major among A0 A#0 D1 and G6
+	for _, keys := range [][]Key{nil, {39, 43}, {0, 1, 2}, {0, 1, 2, 3}, {39, 40, 41, 50, 60}, crowd} {
+		if c, ok := DetectChord(keys); ok {
+			t.Errorf("DetectChord(%v) = %v, want no chord", keys, c)
+		}
+	}
+}
+
+func TestDetectChordAmongManyKeys(t *testing.T) {
+	// Every other key of the keyboard, a dense generation's 44 keys, makes
+	// no triad of the generation, but runs on as a cluster from the bass
+	var keys []Key
+	for k := Key(0); k < Keys; k += 2 {
+		keys = append(keys, k)
+	}
+	c, ok := DetectChord(keys)
+	if !ok || c.Quality != Cluster || len(c.Keys) != len(keys) {
+		t.Errorf("DetectChord of %d keys = %v, %v, want a cluster of them all", len(keys), c, ok)
+	}
+	// Spread a fifth apart they are no chord at all
+	keys = keys[:0]
+	for k := Key(0); k < Keys; k += 7 {
+		keys = append(keys, k)
+	}
+	if c, ok := DetectChord(keys); ok {
+		t.Errorf("DetectChord(%v) = %v, want no chord", keys, c)
+	}
+}
+
+func TestVoice(t *testing.T) {
+	c, _ := DetectChord([]Key{43, 46, 51}) // C major, first inversion
+	for _, tc := range []struct {
+		voicing Voicing
+		want    []Key
+	}{
+		{VoicingNone, []Key{43, 46, 51}},
+		{VoicingClose, []Key{39, 43, 46}},
+		{VoicingOpen, []Key{39, 46, 55}},
+		{VoicingDrop2, []Key{31, 39, 46}},
+	} {
+		if got := c.Voice(tc.voicing); !slices.Equal(got, tc.want) {
+			t.Errorf("Voice(%v) = %v, want %v", tc.voicing, got, tc.want)
+		}
+	}
+	// A C7 at the top of the keyboard cannot be closed up from the root
+	top, _ := DetectChord([]Key{83, 86, 87})
+	if got := top.Voice(VoicingOpen); !slices.Equal(got, top.Keys) {
+		t.Errorf("Voice at the top = %v, want %v", got, top.Keys)
+	}
+}
+
+func TestVoiceDrop2(t *testing.T) {
+	// G7 drops its fifth below the root; C1 major has no room to, so is
+	// raised an octave first
+	for _, tc := range []struct{ keys, want []Key }{
+		{[]Key{10, 14, 17, 20}, []Key{5, 10, 14, 20}},
+		{[]Key{3, 7, 10}, []Key{7, 15, 22}},
+	} {
+		c, _ := DetectChord(tc.keys)
+		if got := c.Voice(VoicingDrop2); !slices.Equal(got, tc.want) {
+			t.Errorf("%v Voice(drop-2) = %v, want %v", c, got, tc.want)
+		}
+	}
+	if v, err := ParseVoicing("Drop-2"); err != nil || v != VoicingDrop2 {
+		t.Errorf("ParseVoicing(Drop-2) = %v, %v", v, err)
+	}
+}
+
+func TestAvoidSemitones(t *testing.T) {
+	if got, want := AvoidSemitones([]Key{39, 40, 41, 42, 44, 46, 47}), []Key{39, 41, 44, 46}; !slices.Equal(got, want) {
+		t.Errorf("AvoidSemitones = %v, want %v", got, want)
+	}
+	if got := AvoidSemitones(nil); len(got) != 0 {
+		t.Errorf("AvoidSemitones(nil) = %v", got)
+	}
+}
+
+func TestRevoice(t *testing.T) {
+	keys := []Key{0, 1, 2, 50, 54, 57}
+	c, _ := DetectChord(keys)
+	if got, want := c.Revoice(keys, VoicingOpen), []Key{0, 1, 2, 50, 57, 66}; !slices.Equal(got, want) {
+		t.Errorf("Revoice = %v, want %v", got, want)
+	}
+}
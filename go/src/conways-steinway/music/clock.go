@@ -0,0 +1,117 @@
+package music
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeSignature is a metre such as 4/4 or 6/8: Beats to the bar, each one
+// a 1/Unit note
+type TimeSignature struct {
+	Beats, Unit int
+}
+
+func (t TimeSignature) String() string { return fmt.Sprintf("%d/%d", t.Beats, t.Unit) }
+
+// ParseTimeSignature converts a metre such as "3/4" into a TimeSignature.
+// The unit must be a power of two no longer than a whole note, as a Standard
+// MIDI File can record it.
+func ParseTimeSignature(s string) (TimeSignature, error) {
+	beats, unit, ok := strings.Cut(strings.TrimSpace(s), "/")
+	b, err1 := strconv.Atoi(strings.TrimSpace(beats))
+	u, err2 := strconv.Atoi(strings.TrimSpace(unit))
+	if !ok || err1 != nil || err2 != nil || b < 1 || b > 255 || u < 1 || u > 64 || u&(u-1) != 0 {
+		return TimeSignature{}, fmt.Errorf("invalid time signature %q (want beats/unit, e.g. 4/4 or 6/8)", s)
+	}
+	return TimeSignature{b, u}, nil
+}
+
+// Set implements flag.Value
+func (t *TimeSignature) Set(s string) error {
+	v, err := ParseTimeSignature(s)
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+// Clock relates the steps of a performance, one generation of the fastest
+// layer each, to musical time and to real time. The sequencer, the MIDI file
+// and live playback all take their timing from it.
+type Clock struct {
+	BPM                float64       // beats a minute
+	GenerationsPerBeat int           // steps to each beat
+	Meter              TimeSignature // beats to the bar and the note each beat is
+	Swing              float64       // percent, 0 to 100, that off-beat steps are delayed; see SwingTick
+}
+
+// NewClock returns a clock at 120 beats a minute in 4/4 with one generation
+// to the beat, so a generation lasts half a second
+func NewClock() Clock {
+	return Clock{BPM: 120, GenerationsPerBeat: 1, Meter: TimeSignature{4, 4}}
+}
+
+// Validate reports an error when the clock cannot keep time
+func (c Clock) Validate() error {
+	if c.BPM <= 0 {
+		return fmt.Errorf("tempo %g bpm is not positive", c.BPM)
+	}
+	if c.GenerationsPerBeat < 1 || int64(c.GenerationsPerBeat) > c.TicksPerBeat() {
+		return fmt.Errorf("%d generations per beat is not between 1 and %d", c.GenerationsPerBeat, c.TicksPerBeat())
+	}
+	if c.Meter.Beats < 1 || c.Meter.Unit < 1 {
+		return fmt.Errorf("invalid time signature %v", c.Meter)
+	}
+	if c.Swing < 0 || c.Swing > 100 {
+		return fmt.Errorf("swing %g%% is not between 0 and 100", c.Swing)
+	}
+	return nil
+}
+
+// TicksPerBeat returns the sequencer ticks in one beat
+func (c Clock) TicksPerBeat() int64 { return TicksPerQuarter * 4 / int64(max(c.Meter.Unit, 1)) }
+
+// TicksPerStep returns the sequencer ticks in one step of the clock
+func (c Clock) TicksPerStep() int64 {
+	return max(c.TicksPerBeat()/int64(max(c.GenerationsPerBeat, 1)), 1)
+}
+
+// QuarterNote returns how long a quarter note lasts, as a MIDI file's tempo
+// records it
+func (c Clock) QuarterNote() time.Duration {
+	return time.Duration(float64(time.Minute) / c.BPM * float64(c.Meter.Unit) / 4)
+}
+
+// Time returns how long after the start the given tick falls
+func (c Clock) Time(tick int64) time.Duration {
+	return time.Duration(float64(c.QuarterNote()) * float64(tick) / TicksPerQuarter)
+}
+
+// Ticks returns the nearest whole number of ticks to d
+func (c Clock) Ticks(d time.Duration) int64 {
+	return int64(math.Round(float64(d) * TicksPerQuarter / float64(c.QuarterNote())))
+}
+
+// StepTick returns the tick the given step falls on, late by the clock's
+// swing if it is an off-beat
+func (c Clock) StepTick(step int) int64 { return SwingTick(step, c.TicksPerStep(), c.Swing) }
+
+// StepTime returns how long after the start the given step falls
+func (c Clock) StepTime(step int) time.Duration { return c.Time(c.StepTick(step)) }
+
+// SwingTick returns the tick step falls on when steps are ticksPerStep apart
+// and swing percent of a shuffle is played. Steps pair up, on-beat and
+// off-beat, and the off-beat, odd step is delayed: by nothing with no swing,
+// and at 100% by a third of a step, so the pair plays as the long and short
+// notes of a triplet.
+func SwingTick(step int, ticksPerStep int64, swing float64) int64 {
+	tick := int64(step) * ticksPerStep
+	if step%2 != 0 && swing > 0 {
+		tick += int64(math.Round(float64(ticksPerStep) * min(swing, 100) / 300))
+	}
+	return tick
+}
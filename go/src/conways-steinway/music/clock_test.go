@@ -0,0 +1,68 @@
+package music
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeSignature(t *testing.T) {
+	if got, err := ParseTimeSignature(" 6/8 "); err != nil || got != (TimeSignature{6, 8}) {
+		t.Errorf("ParseTimeSignature(6/8) = %v, %v", got, err)
+	}
+	for _, s := range []string{"", "4", "4/3", "0/4", "4/128", "x/4"} {
+		if _, err := ParseTimeSignature(s); err == nil {
+			t.Errorf("ParseTimeSignature(%q) succeeded", s)
+		}
+	}
+}
+
+func TestClock(t *testing.T) {
+	c := NewClock()
+	if c.TicksPerStep() != TicksPerQuarter || c.StepTime(3) != 1500*time.Millisecond {
+		t.Errorf("default clock: %d ticks a step, step 3 at %v", c.TicksPerStep(), c.StepTime(3))
+	}
+	// Three generations to each eighth-note beat of 6/8
+	c = Clock{BPM: 180, GenerationsPerBeat: 3, Meter: TimeSignature{6, 8}}
+	if got := c.TicksPerStep(); got != 80 {
+		t.Errorf("6/8 ticks a step = %d, want 80", got)
+	}
+	if got := c.StepTime(3); got != time.Second/3 {
+		t.Errorf("6/8 beat lasts %v, want a third of a second", got)
+	}
+	for _, bad := range []Clock{
+		{BPM: 0, GenerationsPerBeat: 1, Meter: TimeSignature{4, 4}},
+		{BPM: 120, GenerationsPerBeat: 0, Meter: TimeSignature{4, 4}},
+		{BPM: 120, GenerationsPerBeat: 481, Meter: TimeSignature{4, 4}},
+	} {
+		if bad.Validate() == nil {
+			t.Errorf("%+v validated", bad)
+		}
+	}
+}
+
+func TestSwing(t *testing.T) {
+	c := NewClock()
+	c.GenerationsPerBeat = 2
+	for _, tc := range []struct {
+		swing float64
+		want  []int64
+	}{
+		{0, []int64{0, 240, 480, 720}},
+		{50, []int64{0, 280, 480, 760}},
+		{100, []int64{0, 320, 480, 800}},
+	} {
+		c.Swing = tc.swing
+		for step, want := range tc.want {
+			if got := c.StepTick(step); got != want {
+				t.Errorf("swing %g%%: step %d on tick %d, want %d", tc.swing, step, got, want)
+			}
+		}
+	}
+	if c.StepTime(1) != 333333333*time.Nanosecond {
+		t.Errorf("full swing: off-beat at %v, want two thirds of the beat", c.StepTime(1))
+	}
+	c.Swing = 101
+	if c.Validate() == nil {
+		t.Error("swing of 101% validated")
+	}
+}
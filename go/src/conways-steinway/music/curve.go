@@ -0,0 +1,129 @@
+package music
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VelocityMap reshapes the velocities notes are played at once they have
+// been worked out, to suit how the piano or synth playing them responds: a
+// named curve, or a table of breakpoints joined by straight lines. The zero
+// VelocityMap is linear, leaving velocities alone.
+type VelocityMap struct {
+	curve  string          // name of the curve; empty for a table
+	points []velocityPoint // breakpoints of a table, in order of velocity in
+}
+
+type velocityPoint struct{ in, out int }
+
+// velocityCurves are the named curves, over velocities from 0 to 1
+var velocityCurves = map[string]func(x float64) float64{
+	// linear leaves velocities as they are
+	"linear": func(x float64) float64 { return x },
+	// soft plays every velocity louder, for instruments that need a
+	// heavy touch
+	"soft": func(x float64) float64 { return 1 - (1-x)*(1-x) },
+	// hard plays every velocity quieter, for instruments that are loud for
+	// a light touch
+	"hard": func(x float64) float64 { return x * x },
+	// s-curve keeps quiet notes quiet and loud ones loud, spreading out the
+	// velocities in the middle
+	"s-curve": func(x float64) float64 { return x * x * (3 - 2*x) },
+}
+
+// VelocityCurves returns the names of the curves ParseVelocityMap knows
+func VelocityCurves() []string {
+	names := make([]string, 0, len(velocityCurves))
+	for n := range velocityCurves {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseVelocityMap reads the name of a curve, e.g. "soft", or a table of
+// breakpoints as velocity in:out pairs separated by commas, e.g.
+// "1:20,64:80,127:120". Velocities below the first breakpoint or above the
+// last play at its own.
+func ParseVelocityMap(s string) (VelocityMap, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if _, ok := velocityCurves[name]; ok {
+		return VelocityMap{curve: name}, nil
+	}
+	if !strings.Contains(name, ":") {
+		return VelocityMap{}, fmt.Errorf("invalid velocity map %q (want %s, or in:out breakpoints such as 1:20,127:120)", s, strings.Join(VelocityCurves(), ", "))
+	}
+	var m VelocityMap
+	for _, field := range strings.Split(name, ",") {
+		in, out, ok := strings.Cut(field, ":")
+		a, err1 := strconv.Atoi(strings.TrimSpace(in))
+		b, err2 := strconv.Atoi(strings.TrimSpace(out))
+		if !ok || err1 != nil || err2 != nil || a < 0 || a > 127 || b < 0 || b > 127 {
+			return VelocityMap{}, fmt.Errorf("invalid velocity breakpoint %q (want in:out, each 0 to 127)", field)
+		}
+		if n := len(m.points); n > 0 && a <= m.points[n-1].in {
+			return VelocityMap{}, fmt.Errorf("velocity breakpoint %q is not above the one before", field)
+		}
+		m.points = append(m.points, velocityPoint{a, b})
+	}
+	if len(m.points) < 2 {
+		return VelocityMap{}, fmt.Errorf("velocity map %q needs at least two breakpoints", s)
+	}
+	return m, nil
+}
+
+// Apply returns the velocity v is played at, from 1 to 127
+func (m VelocityMap) Apply(v int) int {
+	v = clampVelocity(v)
+	if len(m.points) > 0 {
+		return clampVelocity(m.lookup(v))
+	}
+	curve, ok := velocityCurves[m.curve]
+	if !ok {
+		return v
+	}
+	x := float64(v-1) / 126
+	return clampVelocity(1 + int(math.Round(126*curve(x))))
+}
+
+// lookup returns the velocity the table plays v at
+func (m VelocityMap) lookup(v int) int {
+	first, last := m.points[0], m.points[len(m.points)-1]
+	if v <= first.in {
+		return first.out
+	}
+	if v >= last.in {
+		return last.out
+	}
+	i := sort.Search(len(m.points), func(i int) bool { return m.points[i].in >= v })
+	lo, hi := m.points[i-1], m.points[i]
+	return lo.out + int(math.Round(float64((v-lo.in)*(hi.out-lo.out))/float64(hi.in-lo.in)))
+}
+
+// String formats the map as ParseVelocityMap reads it
+func (m VelocityMap) String() string {
+	if len(m.points) == 0 {
+		if m.curve == "" {
+			return "linear"
+		}
+		return m.curve
+	}
+	fields := make([]string, len(m.points))
+	for i, p := range m.points {
+		fields[i] = strconv.Itoa(p.in) + ":" + strconv.Itoa(p.out)
+	}
+	return strings.Join(fields, ",")
+}
+
+// Set implements flag.Value
+func (m *VelocityMap) Set(s string) error {
+	p, err := ParseVelocityMap(s)
+	if err != nil {
+		return err
+	}
+	*m = p
+	return nil
+}
@@ -0,0 +1,41 @@
+package music
+
+import "testing"
+
+func TestVelocityMap(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		in   []int
+		want []int
+	}{
+		{"linear", []int{1, 64, 127}, []int{1, 64, 127}},
+		{"Soft", []int{1, 64, 127}, []int{1, 96, 127}},
+		{"hard", []int{1, 64, 127}, []int{1, 33, 127}},
+		{"s-curve", []int{1, 32, 64, 96, 127}, []int{1, 20, 64, 108, 127}},
+		{"1:20, 64:80, 127:120", []int{0, 1, 33, 64, 100, 127}, []int{20, 20, 50, 80, 103, 120}},
+		{"32:0,96:127", []int{10, 64, 120}, []int{1, 64, 127}},
+	} {
+		m, err := ParseVelocityMap(tc.spec)
+		if err != nil {
+			t.Errorf("ParseVelocityMap(%q): %v", tc.spec, err)
+			continue
+		}
+		for i, v := range tc.in {
+			if got := m.Apply(v); got != tc.want[i] {
+				t.Errorf("%s Apply(%d) = %d, want %d", tc.spec, v, got, tc.want[i])
+			}
+		}
+	}
+	var zero VelocityMap
+	if zero.Apply(50) != 50 || zero.String() != "linear" {
+		t.Errorf("zero VelocityMap plays 50 at %d and is named %q", zero.Apply(50), zero.String())
+	}
+	if m, _ := ParseVelocityMap("1:20,127:120"); m.String() != "1:20,127:120" {
+		t.Errorf("String() = %q", m.String())
+	}
+	for _, spec := range []string{"loud", "64:80", "1:20,1:30", "1:20,128:120", "1:x,2:3"} {
+		if _, err := ParseVelocityMap(spec); err == nil {
+			t.Errorf("ParseVelocityMap accepted %q", spec)
+		}
+	}
+}
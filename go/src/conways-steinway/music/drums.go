@@ -0,0 +1,68 @@
+package music
+
+import "github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+
+// DrumChannel is the MIDI channel General MIDI reserves for percussion
+const DrumChannel = 10
+
+// Drum is a General MIDI percussion sound, numbered by the note that plays it
+// on DrumChannel
+type Drum int
+
+const (
+	Kick        Drum = 36 // bass drum 1
+	Snare       Drum = 38 // acoustic snare
+	ClosedHiHat Drum = 42
+	LowTom      Drum = 45
+	OpenHiHat   Drum = 46
+	HighTom     Drum = 50
+	Crash       Drum = 49 // crash cymbal 1
+	Ride        Drum = 51 // ride cymbal 1
+)
+
+var drumNames = map[Drum]string{
+	Kick:        "kick",
+	Snare:       "snare",
+	ClosedHiHat: "closed hi-hat",
+	LowTom:      "low tom",
+	OpenHiHat:   "open hi-hat",
+	HighTom:     "high tom",
+	Crash:       "crash",
+	Ride:        "ride",
+}
+
+func (d Drum) String() string {
+	if name, ok := drumNames[d]; ok {
+		return name
+	}
+	return Key(int(d) - LowestNote).String()
+}
+
+// Key returns the key whose note plays the drum, so drums can be sequenced
+// like any other keys
+func (d Drum) Key() Key { return Key(int(d) - LowestNote) }
+
+// DrumKit is the kit a drum row plays. The row's 88 columns are split into
+// as many equal zones as there are drums, from the kick on the left to the
+// ride on the right, and a zone strikes its drum when any cell in it lives.
+var DrumKit = []Drum{Kick, Snare, ClosedHiHat, OpenHiHat, LowTom, HighTom, Crash, Ride}
+
+// Drums returns the drums row y of b strikes, in kit order, with the
+// velocity of each: that of the loudest cell in its zone under d. Rows are
+// numbered as Played numbers them.
+func (d Dynamics) Drums(b life.Board, y int) ([]Drum, []int) {
+	loudest := make([]int, len(DrumKit))
+	for k, v := range d.Velocities(b, y) {
+		zone := int(k) * len(DrumKit) / Keys
+		loudest[zone] = max(loudest[zone], v)
+	}
+	var drums []Drum
+	var velocities []int
+	for zone, v := range loudest {
+		if v > 0 {
+			drums = append(drums, DrumKit[zone])
+			velocities = append(velocities, v)
+		}
+	}
+	return drums, velocities
+}
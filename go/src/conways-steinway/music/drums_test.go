@@ -0,0 +1,28 @@
+package music
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+func TestDrums(t *testing.T) {
+	g := life.NewEmptyGrid(Keys, 1)
+	// Two cells in the kick's zone, the second beside the one in the snare's,
+	// and one in the ride's
+	for _, x := range []int{0, 10, 11, 87} {
+		g.SetAlive(x, 0, true)
+	}
+	d := NewDynamics()
+	drums, velocities := d.Drums(g, 0)
+	if want := []Drum{Kick, Snare, Ride}; !slices.Equal(drums, want) {
+		t.Fatalf("Drums = %v, want %v", drums, want)
+	}
+	if want := []int{d.Velocity(1, 0), d.Velocity(1, 0), d.Velocity(0, 0)}; !slices.Equal(velocities, want) {
+		t.Errorf("velocities = %v, want %v", velocities, want)
+	}
+	if Snare.Key().Note() != 38 || Snare.String() != "snare" {
+		t.Errorf("snare is key %v, %q", Snare.Key(), Snare)
+	}
+}
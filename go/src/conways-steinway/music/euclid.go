@@ -0,0 +1,79 @@
+package music
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Euclid is the Euclidean rhythm E(Pulses,Steps): Pulses onsets spread as
+// evenly as they can be over a bar of Steps steps, as Bresenham's algorithm
+// spreads them, starting on the first step, the whole turned Rotation steps
+// later. E(3,8) is the tresillo, x..x..x.
+type Euclid struct {
+	Pulses, Steps int
+	Rotation      int
+}
+
+// Open reports whether step, counting from 0, is an onset of the rhythm. A
+// rhythm of no steps is open on every step.
+func (e Euclid) Open(step int) bool {
+	if e.Steps <= 0 {
+		return true
+	}
+	i := ((step-e.Rotation)%e.Steps + e.Steps) % e.Steps
+	return i*e.Pulses%e.Steps < e.Pulses
+}
+
+// Pattern returns the rhythm's bar, x for an onset and . for a rest
+func (e Euclid) Pattern() string {
+	var b strings.Builder
+	for i := range e.Steps {
+		if e.Open(i) {
+			b.WriteByte('x')
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+// String formats the rhythm as ParseEuclid reads it, e.g. "E(5,8)" or
+// "E(3,8,2)"
+func (e Euclid) String() string {
+	if e.Rotation != 0 {
+		return fmt.Sprintf("E(%d,%d,%d)", e.Pulses, e.Steps, e.Rotation)
+	}
+	return fmt.Sprintf("E(%d,%d)", e.Pulses, e.Steps)
+}
+
+// ParseEuclid reads a rhythm as "E(pulses,steps)" or "E(pulses,steps,rotation)",
+// the E and brackets being optional
+func ParseEuclid(s string) (Euclid, error) {
+	var e Euclid
+	spec := strings.TrimSpace(s)
+	if len(spec) > 0 && (spec[0] == 'E' || spec[0] == 'e') {
+		spec = spec[1:]
+	}
+	spec = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(spec), "("), ")")
+	fields := strings.Split(spec, ",")
+	if len(fields) < 2 || len(fields) > 3 {
+		return e, fmt.Errorf("invalid rhythm %q (want E(pulses,steps) or E(pulses,steps,rotation))", s)
+	}
+	values := make([]int, len(fields))
+	for i, f := range fields {
+		v, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return e, fmt.Errorf("invalid rhythm %q: %q is not a number", s, f)
+		}
+		values[i] = v
+	}
+	e.Pulses, e.Steps = values[0], values[1]
+	if len(values) == 3 {
+		e.Rotation = values[2]
+	}
+	if e.Steps < 1 || e.Pulses < 0 || e.Pulses > e.Steps {
+		return e, fmt.Errorf("invalid rhythm %q: want from 0 to %d pulses over at least one step", s, max(e.Steps, 1))
+	}
+	return e, nil
+}
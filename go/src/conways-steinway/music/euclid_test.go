@@ -0,0 +1,40 @@
+package music
+
+import "testing"
+
+func TestEuclid(t *testing.T) {
+	for _, tc := range []struct {
+		spec, pattern, name string
+	}{
+		{"E(3,8)", "x..x..x.", "E(3,8)"},
+		{"e(5, 8)", "x.x.xx.x", "E(5,8)"},
+		{"4,16", "x...x...x...x...", "E(4,16)"},
+		{"E(3,8,2)", "x.x..x..", "E(3,8,2)"},
+		{"E(0,4)", "....", "E(0,4)"},
+		{"E(4,4)", "xxxx", "E(4,4)"},
+	} {
+		e, err := ParseEuclid(tc.spec)
+		if err != nil {
+			t.Errorf("ParseEuclid(%q): %v", tc.spec, err)
+			continue
+		}
+		if got := e.Pattern(); got != tc.pattern {
+			t.Errorf("%s pattern %s, want %s", tc.spec, got, tc.pattern)
+		}
+		if got := e.String(); got != tc.name {
+			t.Errorf("%s String() = %s, want %s", tc.spec, got, tc.name)
+		}
+	}
+	e := Euclid{Pulses: 3, Steps: 8}
+	if !e.Open(8) || e.Open(-1) || !e.Open(-2) {
+		t.Error("rhythm does not repeat every bar either way")
+	}
+	if !(Euclid{}).Open(3) {
+		t.Error("empty rhythm closed")
+	}
+	for _, spec := range []string{"E(5)", "E(9,8)", "E(1,0)", "E(-1,4)", "E(a,8)", "E(1,2,3,4)"} {
+		if _, err := ParseEuclid(spec); err == nil {
+			t.Errorf("ParseEuclid accepted %q", spec)
+		}
+	}
+}
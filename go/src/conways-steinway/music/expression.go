@@ -0,0 +1,47 @@
+package music
+
+import "github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+
+// Expression shapes a sounding note from a tick on, for MIDI Polyphonic
+// Expression: how far its pitch is bent and how hard its key is pressed
+type Expression struct {
+	Pitch    int     // MIDI note number of the note
+	Channel  int     // MIDI channel of the note, 1 to 16
+	Tick     int64   // tick the expression takes effect on
+	Bend     float64 // semitones, up or down
+	Pressure float64 // 0 to 1
+}
+
+// pressedAge is the age from which a cell presses its key fully
+const pressedAge = 16
+
+// restingPressure is the pressure of a newborn cell's key
+const restingPressure = 0.5
+
+// Expressions returns the expression of each key struck by row y of b, as
+// Played numbers the rows, from its cell. A cell with the two or three live
+// neighbours that keep it alive plays in tune; a lonelier one bends down, by
+// depth semitones with none, and a more crowded one up, by depth with all
+// eight. A newborn cell presses its key half way and one pressedAge old or
+// older fully; cells of boards that do not track age are newborn.
+func Expressions(b life.Board, y int, depth float64) map[Key]Expression {
+	x := make(map[Key]Expression)
+	eachCell(b, y, func(k Key, neighbours, age int) {
+		bend := float64(neighbours) - 2.5
+		if bend < 0 {
+			bend /= 2.5
+		} else {
+			bend /= 5.5
+		}
+		grown := float64(min(max(age, 0), pressedAge)) / pressedAge
+		x[k] = Expression{Bend: depth * bend, Pressure: restingPressure + (1-restingPressure)*grown}
+	})
+	return x
+}
+
+// FollowExpressions returns the expression of each of keys, which are the
+// keys struck after transposing them by semitones and moving them by
+// octaves, as Follow does for velocities, preferring the harder pressed
+func FollowExpressions(struck map[Key]Expression, keys []Key, semitones int) []Expression {
+	return follow(struck, keys, semitones, Expression{Pressure: restingPressure}, func(v, w Expression) bool { return v.Pressure > w.Pressure })
+}
@@ -0,0 +1,44 @@
+package music
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+func TestExpressions(t *testing.T) {
+	g := life.NewEmptyGrid(Keys, 3)
+	// A lone cell at A0, a block, one of whose cells is on the note row, and
+	// a crowd of five around C3
+	g.SetAlive(0, 2, true)
+	for _, c := range [][2]int{{10, 1}, {11, 1}, {10, 2}, {11, 2}, {38, 1}, {39, 1}, {40, 1}, {38, 2}, {40, 2}, {39, 2}} {
+		g.SetAlive(c[0], c[1], true)
+	}
+	got := Expressions(g, -1, 2)
+	if len(got) != 6 {
+		t.Fatalf("Expressions = %v, want six keys", got)
+	}
+	for k, bend := range map[Key]float64{0: -2, 10: 2 * 0.5 / 5.5, 39: 2 * 2.5 / 5.5} {
+		if math.Abs(got[k].Bend-bend) > 1e-9 {
+			t.Errorf("key %v bent %g, want %g", k, got[k].Bend, bend)
+		}
+	}
+	if p := got[0].Pressure; p < restingPressure || p > 1 {
+		t.Errorf("pressure %g, want between %g and 1", p, restingPressure)
+	}
+	for range pressedAge {
+		g.Step()
+	}
+	if p := Expressions(g, -1, 2)[10].Pressure; p != 1 {
+		t.Errorf("a block %d generations old presses %g, want 1", pressedAge, p)
+	}
+}
+
+func TestFollowExpressions(t *testing.T) {
+	struck := map[Key]Expression{0: {Pressure: 0.6}, 12: {Pressure: 0.9, Bend: 1}}
+	got := FollowExpressions(struck, []Key{26, 7}, 2)
+	if got[0] != struck[12] || got[1] != (Expression{Pressure: restingPressure}) {
+		t.Errorf("FollowExpressions = %v", got)
+	}
+}
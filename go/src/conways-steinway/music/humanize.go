@@ -0,0 +1,32 @@
+package music
+
+import "math/rand"
+
+// Humanizer nudges the notes a sequencer starts by small random amounts, so
+// that chords are not struck with machine precision and repeated notes are
+// not all equally loud. Its random numbers come from a seeded source, so a
+// seed plays the same performance every time.
+type Humanizer struct {
+	Timing   int64 // most ticks a note starts early or late
+	Velocity int   // most a note's velocity is raised or lowered
+
+	rng *rand.Rand
+}
+
+// NewHumanizer returns a humanizer that takes its random numbers from rng
+func NewHumanizer(rng *rand.Rand, timing int64, velocity int) *Humanizer {
+	return &Humanizer{Timing: timing, Velocity: velocity, rng: rng}
+}
+
+// Nudge returns the ticks to move a note's start by, from Timing early to
+// Timing late but never more than limit either way, and its velocity raised
+// or lowered by up to Velocity, kept between 1 and 127
+func (h *Humanizer) Nudge(velocity int, limit int64) (offset int64, nudged int) {
+	if t := min(h.Timing, limit); t > 0 {
+		offset = h.rng.Int63n(2*t+1) - t
+	}
+	if h.Velocity > 0 {
+		velocity += h.rng.Intn(2*h.Velocity+1) - h.Velocity
+	}
+	return offset, clampVelocity(velocity)
+}
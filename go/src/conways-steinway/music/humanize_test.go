@@ -0,0 +1,37 @@
+package music
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestHumanizerNudge(t *testing.T) {
+	h := NewHumanizer(rand.New(rand.NewSource(1)), 10, 5)
+	early, late := false, false
+	for i := 0; i < 1000; i++ {
+		offset, v := h.Nudge(125, 8)
+		if offset < -8 || offset > 8 || v < 120 || v > 127 {
+			t.Fatalf("nudge %d: offset %d, velocity %d", i, offset, v)
+		}
+		early, late = early || offset < 0, late || offset > 0
+	}
+	if !early || !late {
+		t.Errorf("offsets were never early (%v) or never late (%v)", !early, !late)
+	}
+}
+
+func TestHumanizerReproducible(t *testing.T) {
+	play := func() []NoteEvent {
+		s := NewSequencer()
+		s.Humanize = NewHumanizer(rand.New(rand.NewSource(42)), 20, 10)
+		started, _ := s.Play(0, 1, []Key{39, 43, 46})
+		more, _ := s.Play(1, 1, []Key{40})
+		return append(started, more...)
+	}
+	a, b := play(), play()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("note %d: %v then %v with the same seed", i, a[i], b[i])
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package music
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// WriteLilyPond writes tracks as a LilyPond score that the lilypond program
+// typesets. It is laid out as WriteMusicXML lays out its score: each track on
+// a piano staff split at middle C, or a drum staff for DrumChannel, in bars of
+// the clock's time signature, with notes sounding together written as tied
+// chords. A dynamic is marked wherever the loudest newly struck note of a
+// staff changes level.
+func WriteLilyPond(w io.Writer, title string, clock Clock, tracks []Track) error {
+	if err := clock.Validate(); err != nil {
+		return fmt.Errorf("lilypond: %w", err)
+	}
+	s := newScore(clock, tracks)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, `\version "2.24.0"`)
+	fmt.Fprintf(bw, "\\header {\n  title = %s\n  tagline = ##f\n}\n\n", lilyString(title))
+	fmt.Fprintln(bw, `\score {`)
+	fmt.Fprintln(bw, "  <<")
+	for i, t := range tracks {
+		s.writeLilyPart(bw, t, i == 0)
+	}
+	fmt.Fprintln(bw, "  >>")
+	fmt.Fprintln(bw, "  \\layout { }")
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// writeLilyPart writes the staves of one part; the first part also carries
+// the tempo mark
+func (s *score) writeLilyPart(w io.Writer, t Track, first bool) {
+	staves := s.staves(t)
+	if t.Channel == DrumChannel {
+		fmt.Fprintf(w, "    \\new DrumStaff \\with { instrumentName = %s } \\drummode {\n", lilyString(t.Name))
+		s.writeLilyStaff(w, staves[0], "      ", "", first, true)
+		fmt.Fprintln(w, "    }")
+		return
+	}
+	fmt.Fprintf(w, "    \\new PianoStaff \\with { instrumentName = %s } <<\n", lilyString(t.Name))
+	for i, clef := range []string{"treble", "bass"} {
+		fmt.Fprintf(w, "      \\new Staff {\n")
+		s.writeLilyStaff(w, staves[i], "        ", clef, first && i == 0, false)
+		fmt.Fprintln(w, "      }")
+	}
+	fmt.Fprintln(w, "    >>")
+}
+
+// writeLilyStaff writes the bars of one staff, a line to a bar
+func (s *score) writeLilyStaff(w io.Writer, notes []engraved, indent, clef string, tempo, drums bool) {
+	if clef != "" {
+		fmt.Fprintf(w, "%s\\clef %s\n", indent, clef)
+	}
+	if !drums {
+		fmt.Fprintf(w, "%s\\key c \\major\n", indent)
+	}
+	fmt.Fprintf(w, "%s\\time %v\n", indent, s.clock.Meter)
+	if tempo {
+		fmt.Fprintf(w, "%s\\tempo %d = %d\n", indent, s.clock.Meter.Unit, max(int(math.Round(s.clock.BPM)), 1))
+	}
+	dynamic := ""
+	for bar := 0; bar < s.bars; bar++ {
+		chords := s.chords(notes, bar)
+		if len(chords) == 0 {
+			fmt.Fprintf(w, "%sR1*%v |\n", indent, s.clock.Meter)
+			continue
+		}
+		var b strings.Builder
+		b.WriteString(indent)
+		for i, c := range chords {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(lilyChord(c, drums))
+			loudest := 0
+			for _, n := range c.notes {
+				if stop, _ := c.tied(n); !stop {
+					loudest = max(loudest, n.velocity)
+				}
+			}
+			if mark := dynamicMark(loudest); loudest > 0 && mark != dynamic {
+				b.WriteString(`\` + mark)
+				dynamic = mark
+			}
+		}
+		b.WriteString(" |\n")
+		io.WriteString(w, b.String())
+	}
+}
+
+// lilyChord writes a chord as a note, a chord in angle brackets or a rest,
+// with a tilde on each note tied to the next chord
+func lilyChord(c chord, drums bool) string {
+	d := fmt.Sprint(c.length.value)
+	if c.length.dotted {
+		d += "."
+	}
+	if len(c.notes) == 0 {
+		return "r" + d
+	}
+	names := make([]string, len(c.notes))
+	for i, n := range c.notes {
+		if drums {
+			names[i] = lilyDrum(n.pitch)
+		} else {
+			names[i] = lilyPitch(n.pitch)
+		}
+		if _, carry := c.tied(n); carry {
+			names[i] += "~"
+		}
+	}
+	if len(names) == 1 {
+		// A lone note's tie follows its duration
+		name, tie := strings.CutSuffix(names[0], "~")
+		if tie {
+			return name + d + "~"
+		}
+		return name + d
+	}
+	return "<" + strings.Join(names, " ") + ">" + d
+}
+
+// lilyPitch names a MIDI note in LilyPond's absolute octaves, with sharps:
+// c' is middle C, c the octave below and c, the one below that
+func lilyPitch(pitch int) string {
+	step, alter, octave := spell(pitch)
+	name := strings.ToLower(step)
+	if alter != 0 {
+		name += "is"
+	}
+	if octave > 3 {
+		name += strings.Repeat("'", octave-3)
+	} else if octave < 3 {
+		name += strings.Repeat(",", 3-octave)
+	}
+	return name
+}
+
+// lilyDrums are the drummode names of the kit's drums
+var lilyDrums = map[Drum]string{
+	Kick:        "bd",
+	Snare:       "sn",
+	ClosedHiHat: "hhc",
+	LowTom:      "toml",
+	OpenHiHat:   "hho",
+	HighTom:     "tomh",
+	Crash:       "cymc",
+	Ride:        "cymr",
+}
+
+// lilyDrum names the drum a note plays on DrumChannel; notes outside the kit
+// are written as a side stick
+func lilyDrum(pitch int) string {
+	if name, ok := lilyDrums[Drum(pitch)]; ok {
+		return name
+	}
+	return "ss"
+}
+
+// dynamicMark returns the dynamic, from ppp to fff, a velocity is played at
+func dynamicMark(velocity int) string {
+	marks := []string{"ppp", "pp", "p", "mp", "mf", "f", "ff", "fff"}
+	return marks[min(max(velocity, 0)/16, len(marks)-1)]
+}
+
+// lilyString quotes s as a LilyPond string
+func lilyString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,50 @@
+package music
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLilyPitch(t *testing.T) {
+	for pitch, want := range map[int]string{60: "c'", 61: "cis'", 48: "c", 47: "b,", 21: "a,,,", 108: "c'''''"} {
+		if got := lilyPitch(pitch); got != want {
+			t.Errorf("lilyPitch(%d) = %q, want %q", pitch, got, want)
+		}
+	}
+}
+
+func TestWriteLilyPond(t *testing.T) {
+	// C4 and E3 for two beats, then G4 from the last beat of the first bar
+	// into the second, in 3/4; the bass rests in the second bar
+	clock := Clock{BPM: 90, GenerationsPerBeat: 1, Meter: TimeSignature{3, 4}}
+	notes := []NoteEvent{
+		{Pitch: 60, Velocity: 90, Start: 0, Duration: 2 * TicksPerQuarter, Channel: 1},
+		{Pitch: 52, Velocity: 90, Start: 0, Duration: 2 * TicksPerQuarter, Channel: 1},
+		{Pitch: 67, Velocity: 45, Start: 2 * TicksPerQuarter, Duration: 2*TicksPerQuarter + 7, Channel: 1},
+	}
+	drums := []NoteEvent{
+		{Pitch: int(Kick), Velocity: 100, Start: 0, Duration: TicksPerQuarter, Channel: DrumChannel},
+		{Pitch: int(ClosedHiHat), Velocity: 100, Start: 0, Duration: TicksPerQuarter, Channel: DrumChannel},
+	}
+	var buf bytes.Buffer
+	tracks := []Track{{Name: `Piano "1"`, Channel: 1, Notes: notes}, {Name: "Drums", Channel: DrumChannel, Notes: drums}}
+	if err := WriteLilyPond(&buf, "Test", clock, tracks); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`\new PianoStaff \with { instrumentName = "Piano \"1\"" }`,
+		`\tempo 4 = 90`,
+		"c'2\\f g'4~\\p |\n        g'4 r2 |\n",
+		"\\clef bass\n        \\key c \\major\n        \\time 3/4\n        e2\\f r4 |\n        R1*3/4 |\n",
+		"<bd hhc>4\\ff r2 |\n      R1*3/4 |\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("no %q in\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, `\tempo`); n != 1 {
+		t.Errorf("%d tempo marks, want 1", n)
+	}
+}
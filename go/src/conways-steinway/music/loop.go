@@ -0,0 +1,98 @@
+package music
+
+import "sort"
+
+// Loop keeps the notes played over the last Length ticks so that they can be
+// captured and played back over and over, in passes of Length ticks, while
+// the performance goes on. Only notes that have ended are kept, so a note
+// still sounding when the loop is captured is left out of it.
+type Loop struct {
+	Length int64 // ticks in each pass of the loop
+
+	history []NoteEvent // notes ended in about the last Length ticks
+	notes   []NoteEvent // notes of the loop, each Start ticks into a pass
+	origin  int64       // tick the loop's first pass starts on
+	taken   int64       // tick up to which history has gone into the loop
+}
+
+// NewLoop returns an empty loop whose passes last length ticks
+func NewLoop(length int64) *Loop {
+	return &Loop{Length: length}
+}
+
+// Record keeps n, which has just ended, among the notes Capture and Overdub
+// can take, forgetting those that started too long before it to be taken
+func (l *Loop) Record(n NoteEvent) {
+	l.history = append(l.history, n)
+	keep := l.history[:0]
+	for _, h := range l.history {
+		if h.Start >= n.Start-l.Length {
+			keep = append(keep, h)
+		}
+	}
+	l.history = keep
+}
+
+// Capture replaces the loop with the notes that started in the Length ticks
+// before now, which it plays again from now on, and returns how many it took
+func (l *Loop) Capture(now int64) int {
+	l.notes, l.origin, l.taken = nil, now, now-l.Length
+	return l.take(now)
+}
+
+// Overdub adds the notes that started in the Length ticks before now, and
+// have not gone into the loop already, to it at the point of the pass they
+// were played on, and returns how many it took. It captures an empty loop.
+func (l *Loop) Overdub(now int64) int {
+	if len(l.notes) == 0 {
+		return l.Capture(now)
+	}
+	l.taken = max(l.taken, now-l.Length)
+	return l.take(now)
+}
+
+// take adds the notes that started from l.taken up to now to the loop
+func (l *Loop) take(now int64) int {
+	taken := 0
+	for _, n := range l.history {
+		if n.Start < l.taken || n.Start >= now {
+			continue
+		}
+		n.Start = ((n.Start-l.origin)%l.Length + l.Length) % l.Length
+		n.Duration = min(n.Duration, l.Length)
+		l.notes = append(l.notes, n)
+		taken++
+	}
+	sort.SliceStable(l.notes, func(i, j int) bool { return l.notes[i].Start < l.notes[j].Start })
+	l.taken = now
+	return taken
+}
+
+// Clear empties the loop, so that it plays nothing
+func (l *Loop) Clear() {
+	l.notes = nil
+}
+
+// Len returns the number of notes in each pass of the loop
+func (l *Loop) Len() int { return len(l.notes) }
+
+// Due returns the notes the loop plays that start from tick from up to tick
+// to, at the ticks they start on, in order. Nothing is played before the
+// loop was captured.
+func (l *Loop) Due(from, to int64) []NoteEvent {
+	var due []NoteEvent
+	from = max(from, l.origin)
+	if len(l.notes) == 0 || from >= to {
+		return nil
+	}
+	pass := l.origin + (from-l.origin)/l.Length*l.Length
+	for ; pass < to; pass += l.Length {
+		for _, n := range l.notes {
+			if start := pass + n.Start; start >= from && start < to {
+				n.Start = start
+				due = append(due, n)
+			}
+		}
+	}
+	return due
+}
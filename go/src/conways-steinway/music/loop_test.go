@@ -0,0 +1,41 @@
+package music
+
+import "testing"
+
+func TestLoop(t *testing.T) {
+	l := NewLoop(100)
+	l.Record(NoteEvent{Pitch: 60, Start: 10, Duration: 20, Channel: 1})
+	l.Record(NoteEvent{Pitch: 62, Start: 120, Duration: 30, Channel: 1})
+	l.Record(NoteEvent{Pitch: 64, Start: 180, Duration: 10, Channel: 1})
+	if n := l.Capture(200); n != 2 {
+		t.Fatalf("Capture(200) took %d notes, want the 2 of the last pass", n)
+	}
+	if due := l.Due(0, 200); len(due) != 0 {
+		t.Errorf("Due before the loop was captured = %v", due)
+	}
+	due := l.Due(200, 400)
+	want := []int64{220, 280, 320, 380}
+	if len(due) != len(want) {
+		t.Fatalf("Due(200, 400) = %v, want notes starting on %v", due, want)
+	}
+	for i, n := range due {
+		if n.Start != want[i] {
+			t.Errorf("note %d starts on %d, want %d", i, n.Start, want[i])
+		}
+	}
+
+	l.Record(NoteEvent{Pitch: 67, Start: 250, Duration: 10, Channel: 2})
+	if n := l.Overdub(260); n != 1 {
+		t.Fatalf("Overdub(260) took %d notes, want the one played since", n)
+	}
+	if n := l.Overdub(270); n != 0 {
+		t.Errorf("Overdub(270) took %d notes again", n)
+	}
+	if due := l.Due(300, 400); len(due) != 3 || due[1].Pitch != 67 || due[1].Start != 350 {
+		t.Errorf("Due(300, 400) after overdubbing = %v, want the new note on 350", due)
+	}
+	l.Clear()
+	if due := l.Due(400, 500); len(due) != 0 || l.Len() != 0 {
+		t.Errorf("Due after Clear = %v", due)
+	}
+}
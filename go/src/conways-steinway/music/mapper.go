@@ -0,0 +1,193 @@
+package music
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// Mapper decides which keys a board strikes in a generation, and how loud.
+// Packages may add their own with RegisterMapper, for --mapper to choose.
+type Mapper interface {
+	// Strike returns the velocity, as d plays them, of each key struck by the
+	// part of b playing from row y, numbered as Played numbers them, in
+	// generation, counting from 1
+	Strike(b life.Board, y, generation int, d Dynamics) map[Key]int
+}
+
+// MapperFunc is a Mapper of a function
+type MapperFunc func(b life.Board, y, generation int, d Dynamics) map[Key]int
+
+func (f MapperFunc) Strike(b life.Board, y, generation int, d Dynamics) map[Key]int {
+	return f(b, y, generation, d)
+}
+
+// DefaultMapper is the name of the mapper played unless --mapper says otherwise
+const DefaultMapper = "row"
+
+var (
+	mappersMu sync.RWMutex
+	mappers   = map[string]Mapper{
+		DefaultMapper:     MapperFunc(RowMapper),
+		"column-sum":      MapperFunc(ColumnSumMapper),
+		"piano-roll":      MapperFunc(PianoRollMapper),
+		"centre-weighted": MapperFunc(CentreWeightedMapper),
+	}
+)
+
+// RegisterMapper makes m available under name, for --mapper. It panics if
+// the name is empty or taken or m is nil, so is meant to be called from init.
+func RegisterMapper(name string, m Mapper) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	mappersMu.Lock()
+	defer mappersMu.Unlock()
+	if m == nil || name == "" {
+		panic("music: RegisterMapper of a nil or unnamed mapper")
+	}
+	if _, taken := mappers[name]; taken {
+		panic(fmt.Sprintf("music: RegisterMapper called twice for %q", name))
+	}
+	mappers[name] = m
+}
+
+// LookupMapper returns the mapper registered under name
+func LookupMapper(name string) (Mapper, bool) {
+	mappersMu.RLock()
+	defer mappersMu.RUnlock()
+	m, ok := mappers[strings.ToLower(strings.TrimSpace(name))]
+	return m, ok
+}
+
+// Mappers returns the names of the registered mappers, in order
+func Mappers() []string {
+	mappersMu.RLock()
+	defer mappersMu.RUnlock()
+	names := make([]string, 0, len(mappers))
+	for n := range mappers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MapperName names a registered Mapper, as a flag.Value
+type MapperName string
+
+func (n MapperName) String() string { return string(n) }
+
+// Set implements flag.Value
+func (n *MapperName) Set(s string) error {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if _, ok := LookupMapper(name); !ok {
+		return fmt.Errorf("invalid mapper %q (want %s)", s, strings.Join(Mappers(), ", "))
+	}
+	*n = MapperName(name)
+	return nil
+}
+
+// Mapper returns the mapper named, or the default one if it is not registered
+func (n MapperName) Mapper() Mapper {
+	if m, ok := LookupMapper(string(n)); ok {
+		return m
+	}
+	m, _ := LookupMapper(DefaultMapper)
+	return m
+}
+
+// StruckKeys returns the keys of struck, which a Mapper returned, in order
+// from the lowest
+func StruckKeys(struck map[Key]int) []Key {
+	keys := make([]Key, 0, len(struck))
+	for k := range struck {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// RowMapper strikes the key of each live cell of row y, as loud as d plays
+// the cell
+func RowMapper(b life.Board, y, _ int, d Dynamics) map[Key]int {
+	return d.Velocities(b, y)
+}
+
+// columnDensity is how many times the average column's live cells a column
+// needs to strike its key, for ColumnSumMapper
+const columnDensity = 1.5
+
+// columnCounts returns the weight of the live cells of each column that has
+// a key, each cell weighing what weight gives its row
+func columnCounts(b life.Board, weight func(y int) float64) []float64 {
+	width, height := b.Size()
+	counts := make([]float64, min(width, Keys))
+	for y := 0; y < height; y++ {
+		w := weight(y)
+		if w <= 0 {
+			continue
+		}
+		for x, alive := range life.Row(b, y) {
+			if x >= len(counts) {
+				break
+			}
+			if alive {
+				counts[x] += w
+			}
+		}
+	}
+	return counts
+}
+
+// ColumnSumMapper strikes the key of each column of the whole board holding
+// at least half as many live cells again as the average column, louder the
+// nearer it comes to the fullest column
+func ColumnSumMapper(b life.Board, _, _ int, d Dynamics) map[Key]int {
+	return densest(columnCounts(b, func(int) float64 { return 1 }), d)
+}
+
+// densest strikes the key of each column whose count is at least
+// columnDensity times the average, as loud as it comes to the largest count
+func densest(counts []float64, d Dynamics) map[Key]int {
+	total, fullest := 0.0, 0.0
+	for _, c := range counts {
+		total += c
+		fullest = max(fullest, c)
+	}
+	struck := make(map[Key]int)
+	if total == 0 {
+		return struck
+	}
+	mean := total / float64(len(counts))
+	for x, c := range counts {
+		if c > 0 && c >= columnDensity*mean {
+			struck[Key(x)] = d.Loudness(c / fullest)
+		}
+	}
+	return struck
+}
+
+// PianoRollMapper reads the board as a piano roll, a row to each step: it
+// plays row y in the first generation, as RowMapper does, and the next row
+// down in each generation after, wrapping from the bottom row to the top
+func PianoRollMapper(b life.Board, y, generation int, d Dynamics) map[Key]int {
+	_, height := b.Size()
+	if height == 0 {
+		return map[Key]int{}
+	}
+	row := ((y+generation-1)%height + height) % height
+	return d.Velocities(b, row)
+}
+
+// CentreWeightedMapper is ColumnSumMapper with each cell weighing one on the
+// board's middle row and less the further it is from it, down to nothing at
+// the top and bottom edges, so that the middle of the board is heard most
+func CentreWeightedMapper(b life.Board, _, _ int, d Dynamics) map[Key]int {
+	_, height := b.Size()
+	middle, reach := float64(height-1)/2, float64(height)/2
+	return densest(columnCounts(b, func(y int) float64 {
+		dy := float64(y) - middle
+		return 1 - max(dy, -dy)/reach
+	}), d)
+}
@@ -0,0 +1,83 @@
+package music
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+func TestMappers(t *testing.T) {
+	d := NewDynamics()
+	g := life.NewEmptyGrid(Keys, 5)
+	// A column of five at C4, three at the top of A0 and one in the bottom row at B0
+	for y := 0; y < 5; y++ {
+		g.SetAlive(39, y, true)
+	}
+	for y := 0; y < 3; y++ {
+		g.SetAlive(0, y, true)
+	}
+	g.SetAlive(2, 4, true)
+
+	for _, tc := range []struct {
+		name       string
+		generation int
+		want       []Key
+	}{
+		{"row", 1, []Key{2, 39}},
+		{"piano-roll", 1, []Key{2, 39}},
+		{"piano-roll", 2, []Key{0, 39}},
+		{"piano-roll", 4, []Key{0, 39}},
+		{"piano-roll", 5, []Key{39}},
+		{"column-sum", 7, []Key{0, 2, 39}},
+		{"centre-weighted", 1, []Key{0, 2, 39}},
+	} {
+		m, ok := LookupMapper(tc.name)
+		if !ok {
+			t.Fatalf("mapper %q not registered", tc.name)
+		}
+		if got := StruckKeys(m.Strike(g, -1, tc.generation, d)); !slices.Equal(got, tc.want) {
+			t.Errorf("%s in generation %d struck %v, want %v", tc.name, tc.generation, got, tc.want)
+		}
+	}
+	if v := ColumnSumMapper(g, -1, 1, d); v[39] != d.Max || v[0] >= v[39] {
+		t.Errorf("column-sum velocities %v, want the fullest column loudest at %d", v, d.Max)
+	}
+	if centre, sum := CentreWeightedMapper(g, -1, 1, d), ColumnSumMapper(g, -1, 1, d); centre[2] >= sum[2] || centre[0] <= sum[0] {
+		t.Errorf("centre-weighted velocities %v, want B0 at the bottom edge quieter and A0 nearer the middle louder than column-sum's %v", centre, sum)
+	}
+	if got := ColumnSumMapper(life.NewEmptyGrid(Keys, 5), -1, 1, d); len(got) != 0 {
+		t.Errorf("column-sum of an empty board struck %v", got)
+	}
+}
+
+func TestRegisterMapper(t *testing.T) {
+	lowest := MapperFunc(func(life.Board, int, int, Dynamics) map[Key]int { return map[Key]int{0: 1} })
+	RegisterMapper("Test-Lowest", lowest)
+	defer func() {
+		mappersMu.Lock()
+		delete(mappers, "test-lowest")
+		mappersMu.Unlock()
+	}()
+	if !slices.Contains(Mappers(), "test-lowest") {
+		t.Errorf("Mappers() = %v, missing the registered mapper", Mappers())
+	}
+	var n MapperName
+	if err := n.Set(" TEST-lowest "); err != nil || n != "test-lowest" {
+		t.Fatalf("Set(test-lowest) = %v, %q", err, n)
+	}
+	if got := n.Mapper().Strike(nil, 0, 1, NewDynamics()); got[0] != 1 {
+		t.Errorf("registered mapper struck %v", got)
+	}
+	if err := n.Set("diagonal"); err == nil {
+		t.Error("Set(diagonal) succeeded")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("registering a mapper twice did not panic")
+			}
+		}()
+		RegisterMapper("row", lowest)
+	}()
+}
@@ -0,0 +1,135 @@
+package music
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// Metric is a measure of a board, from 0 to 1, that can move a MIDI
+// controller
+type Metric int
+
+const (
+	// MetricDensity is the fraction of the board's cells alive
+	MetricDensity Metric = iota
+	// MetricBirthRate is the cells born by the last step as a fraction of
+	// the population, at most 1
+	MetricBirthRate
+	// MetricDeathRate is the cells that died in the last step as a fraction
+	// of the population, at most 1
+	MetricDeathRate
+	// MetricCentreX is how far across the board its live cells' centre of
+	// mass is, from 0 on the left to 1 on the right; an empty board is
+	// centred
+	MetricCentreX
+	// MetricCentreY is how far down the board its live cells' centre of mass
+	// is, from 0 at the top to 1 at the bottom; an empty board is centred
+	MetricCentreY
+)
+
+var metricNames = [...]string{
+	MetricDensity:   "density",
+	MetricBirthRate: "birth-rate",
+	MetricDeathRate: "death-rate",
+	MetricCentreX:   "centre-x",
+	MetricCentreY:   "centre-y",
+}
+
+func (m Metric) String() string {
+	if m < 0 || int(m) >= len(metricNames) {
+		return fmt.Sprintf("Metric(%d)", int(m))
+	}
+	return metricNames[m]
+}
+
+// ParseMetric converts a name such as "density" into a Metric
+func ParseMetric(s string) (Metric, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for m, n := range metricNames {
+		if n == name {
+			return Metric(m), nil
+		}
+	}
+	return MetricDensity, fmt.Errorf("invalid metric %q (want %s)", s, strings.Join(metricNames[:], ", "))
+}
+
+// Measure returns the metric of b, whose statistics are stats
+func (m Metric) Measure(b life.Board, stats life.Stats) float64 {
+	switch m {
+	case MetricDensity:
+		return stats.Density
+	case MetricBirthRate:
+		return min(float64(stats.Births)/float64(max(stats.Population, 1)), 1)
+	case MetricDeathRate:
+		return min(float64(stats.Deaths)/float64(max(stats.Population, 1)), 1)
+	case MetricCentreX, MetricCentreY:
+		x, y, ok := life.CentreOfMass(b)
+		width, height := b.Size()
+		if !ok {
+			return 0.5
+		}
+		if m == MetricCentreX {
+			return x / float64(max(width-1, 1))
+		}
+		return y / float64(max(height-1, 1))
+	}
+	return 0
+}
+
+// Modulation moves a MIDI controller with a metric of the board each
+// generation, the metric times Scale, at most 1, moving it from 0 to 127
+type Modulation struct {
+	Metric     Metric
+	Scale      float64
+	Controller int // 0 to 127
+}
+
+// Value returns the controller's value for b, whose statistics are stats
+func (m Modulation) Value(b life.Board, stats life.Stats) int {
+	v := min(max(m.Metric.Measure(b, stats)*m.Scale, 0), 1)
+	return int(math.Round(127 * v))
+}
+
+// String formats the modulation as ParseModulation reads it
+func (m Modulation) String() string {
+	s := m.Metric.String()
+	if m.Scale != 1 {
+		s += "*" + strconv.FormatFloat(m.Scale, 'g', -1, 64)
+	}
+	return s + "->cc" + strconv.Itoa(m.Controller)
+}
+
+// ParseModulation reads a modulation as metric->ccN, e.g. "density->cc1", the
+// metric optionally scaled, as in "birth-rate*4->cc11"
+func ParseModulation(s string) (Modulation, error) {
+	m := Modulation{Scale: 1}
+	metric, cc, ok := strings.Cut(s, "->")
+	if !ok {
+		return m, fmt.Errorf("invalid modulation %q (want metric->ccN, e.g. density->cc1)", s)
+	}
+	if name, scale, ok := strings.Cut(metric, "*"); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(scale), 64)
+		if err != nil || f <= 0 {
+			return m, fmt.Errorf("invalid modulation %q: scale %q is not a positive number", s, strings.TrimSpace(scale))
+		}
+		metric, m.Scale = name, f
+	}
+	var err error
+	if m.Metric, err = ParseMetric(metric); err != nil {
+		return m, fmt.Errorf("invalid modulation %q: %w", s, err)
+	}
+	cc = strings.ToLower(strings.TrimSpace(cc))
+	n, err := strconv.Atoi(strings.TrimPrefix(cc, "cc"))
+	if err != nil || n < 0 || n > 127 {
+		return m, fmt.Errorf("invalid modulation %q: controller %q is not cc0 to cc127", s, cc)
+	}
+	if n == SustainPedal {
+		return m, fmt.Errorf("invalid modulation %q: cc%d is the sustain pedal", s, n)
+	}
+	m.Controller = n
+	return m, nil
+}
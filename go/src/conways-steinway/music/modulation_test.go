@@ -0,0 +1,46 @@
+package music
+
+import (
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+func TestModulation(t *testing.T) {
+	g := life.NewEmptyGrid(11, 5)
+	g.SetAlive(10, 4, true)
+	g.SetAlive(10, 2, true)
+	stats := life.Stats{Births: 1, Deaths: 4, Population: 2, Density: 0.25}
+	for _, tc := range []struct {
+		spec, name string
+		want       int
+	}{
+		{"density->cc1", "density->cc1", 32},
+		{" Density * 2 -> CC1", "density*2->cc1", 64},
+		{"density*8->cc1", "density*8->cc1", 127},
+		{"birth-rate->cc11", "birth-rate->cc11", 64},
+		{"death-rate->cc12", "death-rate->cc12", 127},
+		{"centre-x->cc10", "centre-x->cc10", 127},
+		{"centre-y->cc74", "centre-y->cc74", 95},
+	} {
+		m, err := ParseModulation(tc.spec)
+		if err != nil {
+			t.Errorf("ParseModulation(%q): %v", tc.spec, err)
+			continue
+		}
+		if got := m.String(); got != tc.name {
+			t.Errorf("%q String() = %q, want %q", tc.spec, got, tc.name)
+		}
+		if got := m.Value(g, stats); got != tc.want {
+			t.Errorf("%s Value = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+	if got := MetricCentreX.Measure(life.NewEmptyGrid(4, 4), life.Stats{}); got != 0.5 {
+		t.Errorf("centre-x of an empty board = %g, want 0.5", got)
+	}
+	for _, spec := range []string{"density", "heat->cc1", "density->cc128", "density->1x", "density*0->cc1", "density->cc64"} {
+		if _, err := ParseModulation(spec); err == nil {
+			t.Errorf("ParseModulation accepted %q", spec)
+		}
+	}
+}
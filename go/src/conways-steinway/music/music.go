@@ -0,0 +1,53 @@
+// Package music turns boards into piano notes: each of the board's 88
+// columns is one key of the piano, from A0 on the left to C8 on the right.
+package music
+
+import (
+	"fmt"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// Keys is the number of keys on a piano, and of columns on the board
+const Keys = life.BoardWidth
+
+// LowestNote is the MIDI note number of the lowest key, A0
+const LowestNote = 21
+
+// Key is a piano key numbered from 0 (A0) to 87 (C8)
+type Key int
+
+// Note returns the key's MIDI note number, 21 to 108
+func (k Key) Note() int { return int(k) + LowestNote }
+
+var noteNames = [...]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// String returns the key's note name in scientific pitch notation, e.g. "A0"
+// or "C4" for middle C
+func (k Key) String() string {
+	if k < 0 || k >= Keys {
+		return fmt.Sprintf("Key(%d)", int(k))
+	}
+	n := k.Note()
+	return fmt.Sprintf("%s%d", noteNames[n%12], n/12-1)
+}
+
+// Played returns the keys struck by row y of b: one for each live cell, in
+// order from the lowest key. Negative rows count up from the bottom, so -1 is
+// the bottom row. Columns beyond the 88th are not played.
+func Played(b life.Board, y int) []Key {
+	_, height := b.Size()
+	if y < 0 {
+		y += height
+	}
+	var keys []Key
+	for x, alive := range life.Row(b, y) {
+		if x >= Keys {
+			break
+		}
+		if alive {
+			keys = append(keys, Key(x))
+		}
+	}
+	return keys
+}
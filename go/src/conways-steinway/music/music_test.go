@@ -0,0 +1,48 @@
+package music
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+func TestKeyNames(t *testing.T) {
+	tests := []struct {
+		key  Key
+		name string
+		note int
+	}{
+		{0, "A0", 21},
+		{2, "B0", 23},
+		{3, "C1", 24},
+		{39, "C4", 60},
+		{48, "A4", 69},
+		{87, "C8", 108},
+	}
+	for _, tt := range tests {
+		if tt.key.String() != tt.name || tt.key.Note() != tt.note {
+			t.Fatalf("Key(%d) = %s, note %d; want %s, note %d", int(tt.key), tt.key, tt.key.Note(), tt.name, tt.note)
+		}
+	}
+	if s := Key(88).String(); s != "Key(88)" {
+		t.Fatalf("Key(88).String() = %q", s)
+	}
+}
+
+func TestPlayed(t *testing.T) {
+	g := life.NewEmptyGrid(100, 3)
+	g.SetAlive(5, 0, true)
+	for _, x := range []int{0, 39, 87, 95} {
+		g.SetAlive(x, 2, true)
+	}
+	if keys := Played(g, -1); !slices.Equal(keys, []Key{0, 39, 87}) {
+		t.Fatalf("bottom row = %v, want A0 C4 C8", keys)
+	}
+	if keys := Played(g, 0); !slices.Equal(keys, []Key{5}) {
+		t.Fatalf("top row = %v, want D1", keys)
+	}
+	if keys := Played(g, 1); keys != nil {
+		t.Fatalf("empty row = %v, want none", keys)
+	}
+}
@@ -0,0 +1,164 @@
+package music
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WriteMusicXML writes tracks as a MusicXML 4.0 partwise score that notation
+// programs such as MuseScore and Finale can open and engrave. Each track is
+// a part, on a grand staff split at middle C, or on one percussion staff for
+// DrumChannel, in bars of the clock's time signature. Notes are quantized to
+// the nearest 32nd note, or beat if that is shorter, and notes sounding
+// together are written as chords, tied across bar lines and into lengths
+// notation can show.
+func WriteMusicXML(w io.Writer, title string, clock Clock, tracks []Track) error {
+	if err := clock.Validate(); err != nil {
+		return fmt.Errorf("musicxml: %w", err)
+	}
+	s := newScore(clock, tracks)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`)
+	fmt.Fprintln(bw, `<!DOCTYPE score-partwise PUBLIC "-//Recordare//DTD MusicXML 4.0 Partwise//EN" "http://www.musicxml.org/dtds/partwise.dtd">`)
+	fmt.Fprintln(bw, `<score-partwise version="4.0">`)
+	fmt.Fprintf(bw, "  <work><work-title>%s</work-title></work>\n", escape(title))
+	fmt.Fprintln(bw, "  <part-list>")
+	for i, t := range tracks {
+		fmt.Fprintf(bw, "    <score-part id=\"P%d\"><part-name>%s</part-name></score-part>\n", i+1, escape(t.Name))
+	}
+	fmt.Fprintln(bw, "  </part-list>")
+	for i, t := range tracks {
+		fmt.Fprintf(bw, "  <part id=\"P%d\">\n", i+1)
+		s.writePart(bw, t)
+		fmt.Fprintln(bw, "  </part>")
+	}
+	fmt.Fprintln(bw, "</score-partwise>")
+	return bw.Flush()
+}
+
+// divisions is the number of MusicXML divisions in a quarter note, one to a
+// quantum
+func (s *score) divisions() int { return int(TicksPerQuarter / s.quantum) }
+
+// writePart writes the bars of one part
+func (s *score) writePart(w io.Writer, t Track) {
+	drums := t.Channel == DrumChannel
+	staves := s.staves(t)
+	for bar := 0; bar < s.bars; bar++ {
+		fmt.Fprintf(w, "    <measure number=\"%d\">\n", bar+1)
+		if bar == 0 {
+			s.writeAttributes(w, drums)
+		}
+		for i, notes := range staves {
+			if i > 0 {
+				fmt.Fprintf(w, "      <backup><duration>%d</duration></backup>\n", s.bar)
+			}
+			s.writeBar(w, s.chords(notes, bar), i+1, drums)
+		}
+		fmt.Fprintln(w, "    </measure>")
+	}
+}
+
+// writeAttributes writes the first bar's divisions, key, time signature,
+// clefs and tempo
+func (s *score) writeAttributes(w io.Writer, drums bool) {
+	fmt.Fprintln(w, "      <attributes>")
+	fmt.Fprintf(w, "        <divisions>%d</divisions>\n", s.divisions())
+	fmt.Fprintln(w, "        <key><fifths>0</fifths></key>")
+	fmt.Fprintf(w, "        <time><beats>%d</beats><beat-type>%d</beat-type></time>\n", s.clock.Meter.Beats, s.clock.Meter.Unit)
+	if drums {
+		fmt.Fprintln(w, "        <clef><sign>percussion</sign></clef>")
+	} else {
+		fmt.Fprintln(w, "        <staves>2</staves>")
+		fmt.Fprintln(w, `        <clef number="1"><sign>G</sign><line>2</line></clef>`)
+		fmt.Fprintln(w, `        <clef number="2"><sign>F</sign><line>4</line></clef>`)
+	}
+	fmt.Fprintln(w, "      </attributes>")
+	quarters := s.clock.BPM * 4 / float64(s.clock.Meter.Unit)
+	fmt.Fprintf(w, "      <direction placement=\"above\"><direction-type><metronome><beat-unit>quarter</beat-unit><per-minute>%s</per-minute></metronome></direction-type><sound tempo=\"%s\"/></direction>\n",
+		formatFloat(quarters), formatFloat(quarters))
+}
+
+// writeBar writes one staff's chords in a bar, or a whole-bar rest if it
+// has none
+func (s *score) writeBar(w io.Writer, chords []chord, staff int, drums bool) {
+	if len(chords) == 0 {
+		fmt.Fprintf(w, "      <note><rest measure=\"yes\"/><duration>%d</duration><voice>%d</voice><staff>%d</staff></note>\n", s.bar, staff, staff)
+	}
+	for _, c := range chords {
+		writeChord(w, c, staff, drums)
+	}
+}
+
+// writeChord writes the notes of a chord, or a rest if there are none, tied
+// to the notes before and after them where they carry on
+func writeChord(w io.Writer, c chord, staff int, drums bool) {
+	l := c.length
+	dot := ""
+	if l.dotted {
+		dot = "<dot/>"
+	}
+	if len(c.notes) == 0 {
+		fmt.Fprintf(w, "      <note><rest/><duration>%d</duration><voice>%d</voice><type>%s</type>%s<staff>%d</staff></note>\n", l.quanta, staff, l.name, dot, staff)
+		return
+	}
+	for i, n := range c.notes {
+		var b strings.Builder
+		fmt.Fprintf(&b, "      <note dynamics=\"%s\">", formatFloat(math.Round(float64(n.velocity)*1000/90)/10))
+		if i > 0 {
+			b.WriteString("<chord/>")
+		}
+		step, alter, octave := spell(n.pitch)
+		if drums {
+			fmt.Fprintf(&b, "<unpitched><display-step>%s</display-step><display-octave>%d</display-octave></unpitched>", step, octave)
+		} else if alter != 0 {
+			fmt.Fprintf(&b, "<pitch><step>%s</step><alter>%d</alter><octave>%d</octave></pitch>", step, alter, octave)
+		} else {
+			fmt.Fprintf(&b, "<pitch><step>%s</step><octave>%d</octave></pitch>", step, octave)
+		}
+		fmt.Fprintf(&b, "<duration>%d</duration>", l.quanta)
+		stop, carry := c.tied(n)
+		if stop {
+			b.WriteString(`<tie type="stop"/>`)
+		}
+		if carry {
+			b.WriteString(`<tie type="start"/>`)
+		}
+		fmt.Fprintf(&b, "<voice>%d</voice><type>%s</type>%s", staff, l.name, dot)
+		fmt.Fprintf(&b, "<staff>%d</staff>", staff)
+		if stop || carry {
+			b.WriteString("<notations>")
+			if stop {
+				b.WriteString(`<tied type="stop"/>`)
+			}
+			if carry {
+				b.WriteString(`<tied type="start"/>`)
+			}
+			b.WriteString("</notations>")
+		}
+		b.WriteString("</note>\n")
+		io.WriteString(w, b.String())
+	}
+}
+
+// spell names a MIDI note as a step, with sharps, and octave
+func spell(pitch int) (step string, alter, octave int) {
+	name := noteNames[pitch%12]
+	if len(name) > 1 {
+		alter = 1
+	}
+	return name[:1], alter, pitch/12 - 1
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func formatFloat(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
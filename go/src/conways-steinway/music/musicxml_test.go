@@ -0,0 +1,94 @@
+package music
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// xmlScore is the part of a partwise score the tests check
+type xmlScore struct {
+	Parts []struct {
+		Measures []struct {
+			Notes []struct {
+				Chord    *struct{} `xml:"chord"`
+				Rest     *struct{} `xml:"rest"`
+				Step     string    `xml:"pitch>step"`
+				Octave   int       `xml:"pitch>octave"`
+				Duration int       `xml:"duration"`
+				Staff    int       `xml:"staff"`
+				Ties     []struct {
+					Type string `xml:"type,attr"`
+				} `xml:"tie"`
+			} `xml:"note"`
+		} `xml:"measure"`
+	} `xml:"part"`
+}
+
+func TestWriteMusicXML(t *testing.T) {
+	// Three beats of C4 with E3 below, then G4 from the last beat of the
+	// first bar into the second, in 3/4
+	clock := Clock{BPM: 90, GenerationsPerBeat: 1, Meter: TimeSignature{3, 4}}
+	notes := []NoteEvent{
+		{Pitch: 60, Velocity: 90, Start: 0, Duration: 2 * TicksPerQuarter, Channel: 1},
+		{Pitch: 52, Velocity: 90, Start: 0, Duration: 2 * TicksPerQuarter, Channel: 1},
+		{Pitch: 67, Velocity: 45, Start: 2 * TicksPerQuarter, Duration: 2*TicksPerQuarter + 7, Channel: 1},
+	}
+	var buf bytes.Buffer
+	if err := WriteMusicXML(&buf, "Test & score", clock, []Track{{Name: "Piano", Channel: 1, Notes: notes}}); err != nil {
+		t.Fatal(err)
+	}
+	var score xmlScore
+	if err := xml.Unmarshal(buf.Bytes(), &score); err != nil {
+		t.Fatalf("%v in\n%s", err, buf.String())
+	}
+	if len(score.Parts) != 1 || len(score.Parts[0].Measures) != 2 {
+		t.Fatalf("want one part of two bars in\n%s", buf.String())
+	}
+	ties := 0
+	for m, measure := range score.Parts[0].Measures {
+		length := map[int]int{}
+		for _, n := range measure.Notes {
+			if n.Chord == nil {
+				length[n.Staff] += n.Duration
+			}
+			for _, tie := range n.Ties {
+				if tie.Type == "start" {
+					ties++
+				} else {
+					ties--
+				}
+			}
+		}
+		// Three quarter notes of eight divisions each on both staves
+		if length[1] != 24 || length[2] != 24 {
+			t.Errorf("bar %d lasts %v divisions on each staff, want 24", m+1, length)
+		}
+	}
+	if ties != 0 {
+		t.Errorf("%d ties left open", ties)
+	}
+	first := score.Parts[0].Measures[0].Notes[0]
+	if first.Step != "C" || first.Octave != 4 || first.Staff != 1 || first.Duration != 16 {
+		t.Errorf("first note %+v, want a half-note C4 on the treble staff", first)
+	}
+	if !strings.Contains(buf.String(), "Test &amp; score") || !strings.Contains(buf.String(), `<sound tempo="90"/>`) {
+		t.Error("title or tempo missing")
+	}
+}
+
+func TestSplitLengths(t *testing.T) {
+	s := newScore(NewClock(), nil)
+	var names []string
+	for _, l := range s.split(29) {
+		name := l.name
+		if l.dotted {
+			name = "dotted " + name
+		}
+		names = append(names, name)
+	}
+	if got := strings.Join(names, ", "); got != "dotted half, eighth, 32nd" {
+		t.Errorf("29 32nds split into %s", got)
+	}
+}
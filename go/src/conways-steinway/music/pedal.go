@@ -0,0 +1,49 @@
+package music
+
+// SustainPedal is the MIDI controller number of the sustain (damper) pedal
+const SustainPedal = 64
+
+// BankSelect and BankSelectLSB are the MIDI controller numbers selecting the
+// bank the next program change chooses from, by its high and low seven bits
+const (
+	BankSelect    = 0
+	BankSelectLSB = 32
+)
+
+// Control is a MIDI control change: a controller on a channel set to a value
+// at a tick
+type Control struct {
+	Controller int   // 0 to 127, e.g. SustainPedal
+	Value      int   // 0 to 127; for a pedal, 127 is down and 0 is up
+	Tick       int64 // tick the change happens on
+	Channel    int   // MIDI channel, 1 to 16
+}
+
+// Pedal decides when the sustain pedal is held down, from how crowded the
+// board is and whether a chord is being struck. The pedal goes down when the
+// board's density reaches Press and comes up when it falls below Release,
+// so a density hovering around one threshold does not pump the pedal.
+type Pedal struct {
+	Press   float64 // density at or above which the pedal goes down; 0 never presses it for density
+	Release float64 // density below which it comes up again; 0, or more than Press, is Press
+	Chords  bool    // also hold the pedal down while a chord is struck
+
+	down bool
+}
+
+// Update moves the pedal for a generation with the given density, in which a
+// chord was or was not struck. It reports whether the pedal is now down and
+// whether that changed.
+func (p *Pedal) Update(density float64, chord bool) (down, changed bool) {
+	release := p.Release
+	if release <= 0 || release > p.Press {
+		release = p.Press
+	}
+	crowded := p.Press > 0 && (density >= p.Press || p.down && density >= release)
+	down = crowded || p.Chords && chord
+	changed, p.down = down != p.down, down
+	return down, changed
+}
+
+// Down reports whether the pedal is down
+func (p *Pedal) Down() bool { return p.down }
@@ -0,0 +1,35 @@
+package music
+
+import "testing"
+
+func TestPedalHysteresis(t *testing.T) {
+	p := &Pedal{Press: 0.3, Release: 0.2}
+	for i, tc := range []struct {
+		density      float64
+		down, change bool
+	}{
+		{0.1, false, false},
+		{0.3, true, true},
+		{0.25, true, false},
+		{0.2, true, false},
+		{0.19, false, true},
+		{0.25, false, false},
+	} {
+		if down, changed := p.Update(tc.density, false); down != tc.down || changed != tc.change {
+			t.Errorf("step %d, density %g: down %v changed %v, want %v %v", i, tc.density, down, changed, tc.down, tc.change)
+		}
+	}
+}
+
+func TestPedalChords(t *testing.T) {
+	p := &Pedal{Chords: true}
+	if down, changed := p.Update(0.9, false); down || changed {
+		t.Errorf("pressed for density %v without a density threshold", down)
+	}
+	if down, changed := p.Update(0, true); !down || !changed {
+		t.Errorf("chord: down %v changed %v", down, changed)
+	}
+	if down, changed := p.Update(0, false); down || !changed || p.Down() {
+		t.Errorf("after the chord: down %v changed %v", down, changed)
+	}
+}
@@ -0,0 +1,65 @@
+package music
+
+import "slices"
+
+// Phrases finds where the phrases of a performance end, from how the
+// population of the board playing it moves: a phrase ends when the
+// population dips well below its recent level, or when the board enters a
+// cycle, once the phrase has lasted MinLength generations
+type Phrases struct {
+	Dip       float64 // fraction of its recent level the population must fall by, 0 to 1
+	MinLength int     // fewest generations in a phrase
+
+	level  float64 // recent level of the population, a moving average
+	length int     // generations since the phrase began
+	dipped bool    // population is below its recent level by Dip
+}
+
+// phraseLevel is how much of the population each generation moves the
+// recent level by
+const phraseLevel = 0.2
+
+// NewPhrases returns a detector of phrases ending on dips of dip and lasting
+// at least minLength generations
+func NewPhrases(dip float64, minLength int) *Phrases {
+	return &Phrases{Dip: dip, MinLength: minLength, level: -1}
+}
+
+// End is called once a generation with the board's population, and with
+// cycle when the board has just entered a cycle. It reports whether the
+// phrase ends with the generation, and why.
+func (p *Phrases) End(population int, cycle bool) (string, bool) {
+	if p.level < 0 {
+		p.level = float64(population)
+	}
+	dipped := float64(population) < p.level*(1-p.Dip)
+	p.level += phraseLevel * (float64(population) - p.level)
+	p.length++
+	reason := ""
+	switch {
+	case cycle:
+		reason = "board entered a cycle"
+	case dipped && !p.dipped:
+		reason = "population dipped"
+	}
+	p.dipped = dipped
+	if reason == "" || p.length < p.MinLength {
+		return "", false
+	}
+	p.length = 0
+	return reason, true
+}
+
+// TonicChord returns the tonic triad of the scale built on root that a
+// cadence resolves to, around middle C with the root doubled an octave and
+// a half below: minor when the scale has a minor third and no major one
+func TonicChord(root PitchClass, s Scale) Chord {
+	c := Chord{Root: int(root), Quality: Major}
+	third := 4
+	if slices.Contains(s.Steps, 3) && !slices.Contains(s.Steps, 4) {
+		c.Quality, third = Minor, 3
+	}
+	tonic := Key(60 - LowestNote + int(root))
+	c.Keys = []Key{tonic - 24, tonic, tonic + Key(third), tonic + 7}
+	return c
+}
@@ -0,0 +1,37 @@
+package music
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPhrases(t *testing.T) {
+	p := NewPhrases(0.3, 3)
+	var ends []string
+	for _, population := range []int{100, 100, 100, 60, 50, 100, 100, 100, 100, 40, 100} {
+		if reason, ok := p.End(population, false); ok {
+			ends = append(ends, reason)
+		}
+	}
+	if !slices.Equal(ends, []string{"population dipped", "population dipped"}) {
+		t.Errorf("phrases ended on %v, want each of the two dips", ends)
+	}
+	if _, ok := p.End(100, true); ok {
+		t.Error("a cycle ended a phrase one generation long")
+	}
+	p.End(100, false)
+	if reason, ok := p.End(100, true); !ok || reason != "board entered a cycle" {
+		t.Errorf("End on a cycle = %q, %v", reason, ok)
+	}
+}
+
+func TestTonicChord(t *testing.T) {
+	c := TonicChord(0, Chromatic)
+	if c.Quality != Major || !slices.Equal(c.Keys, []Key{15, 39, 43, 46}) {
+		t.Errorf("C tonic = %v %v, want C major on C2 and C4", c, c.Keys)
+	}
+	minor, _ := ParseScale("minor")
+	if c := TonicChord(9, minor); c.Quality != Minor || c.String() != "A minor" || c.Keys[2]-c.Keys[1] != 3 {
+		t.Errorf("A minor tonic = %v %v", c, c.Keys)
+	}
+}
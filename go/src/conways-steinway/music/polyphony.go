@@ -0,0 +1,106 @@
+package music
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Steal says which notes give way when more would sound at once than a
+// sequencer's Polyphony allows
+type Steal int
+
+const (
+	// StealQuietest ends or drops the quietest notes, newest first among
+	// those equally quiet
+	StealQuietest Steal = iota
+	// StealOldest ends the notes that have sounded longest
+	StealOldest
+	// StealScale ends or drops notes outside the sequencer's scale first,
+	// then the quietest
+	StealScale
+)
+
+var stealNames = [...]string{
+	StealQuietest: "quietest",
+	StealOldest:   "oldest",
+	StealScale:    "scale",
+}
+
+func (s Steal) String() string {
+	if s < 0 || int(s) >= len(stealNames) {
+		return fmt.Sprintf("Steal(%d)", int(s))
+	}
+	return stealNames[s]
+}
+
+// ParseSteal converts a name such as "oldest" into a Steal
+func ParseSteal(s string) (Steal, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for st, n := range stealNames {
+		if n == name {
+			return Steal(st), nil
+		}
+	}
+	return StealQuietest, fmt.Errorf("invalid voice stealing %q (want %s)", s, strings.Join(stealNames[:], ", "))
+}
+
+// Set implements flag.Value
+func (s *Steal) Set(v string) error {
+	p, err := ParseSteal(v)
+	if err != nil {
+		return err
+	}
+	*s = p
+	return nil
+}
+
+// limit keeps no more than Polyphony notes sounding after a step struck on
+// tick, by ending notes that were already sounding and dropping those just
+// started, chosen by Steal. It returns started without the dropped notes and
+// ended with the notes cut short.
+func (s *Sequencer) limit(tick int64, started, ended []NoteEvent) ([]NoteEvent, []NoteEvent) {
+	excess := len(s.sounding) - s.Polyphony
+	if s.Polyphony <= 0 || excess <= 0 {
+		return started, ended
+	}
+	isNew := make(map[voice]bool, len(started))
+	for _, n := range started {
+		isNew[voice{n.Channel, n.Pitch}] = true
+	}
+	victims := make([]voice, 0, len(s.sounding))
+	for v := range s.sounding {
+		victims = append(victims, v)
+	}
+	outside := func(v voice) bool {
+		return s.Steal == StealScale && !s.Scale.Contains(Key(v.pitch-LowestNote), s.Root)
+	}
+	sort.Slice(victims, func(i, j int) bool {
+		a, b := s.sounding[victims[i]], s.sounding[victims[j]]
+		if oa, ob := outside(victims[i]), outside(victims[j]); oa != ob {
+			return oa
+		}
+		if s.Steal != StealOldest && a.Velocity != b.Velocity {
+			return a.Velocity < b.Velocity
+		}
+		if a.Start != b.Start {
+			return (a.Start < b.Start) == (s.Steal == StealOldest)
+		}
+		if a.Channel != b.Channel {
+			return a.Channel < b.Channel
+		}
+		return a.Pitch < b.Pitch
+	})
+	for _, v := range victims[:excess] {
+		n := s.sounding[v]
+		if isNew[v] {
+			delete(s.sounding, v)
+			started = slices.DeleteFunc(started, func(n NoteEvent) bool { return voice{n.Channel, n.Pitch} == v })
+		} else {
+			ended = append(ended, s.end(v, n, max(tick, n.Start+1)))
+		}
+		s.Dropped++
+	}
+	return started, ended
+}
@@ -0,0 +1,76 @@
+package music
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Grid is a musical grid that times are moved onto: every Unit-th of a whole
+// note, e.g. 16 for sixteenths, or with Triplet three in the time of two of
+// them. The zero Grid leaves times where they are.
+type Grid struct {
+	Unit    int // note value of the grid's lines, a power of two from 1 to 64; 0 for no grid
+	Triplet bool
+}
+
+// Ticks returns the ticks between the grid's lines, or 0 for no grid
+func (g Grid) Ticks() int64 {
+	if g.Unit <= 0 {
+		return 0
+	}
+	ticks := 4 * TicksPerQuarter / int64(g.Unit)
+	if g.Triplet {
+		ticks = ticks * 2 / 3
+	}
+	return ticks
+}
+
+// Snap returns the line of the grid nearest tick, later ones winning ties
+func (g Grid) Snap(tick int64) int64 {
+	ticks := g.Ticks()
+	if ticks == 0 {
+		return tick
+	}
+	return (tick + ticks/2) / ticks * ticks
+}
+
+// String formats the grid as ParseGrid reads it
+func (g Grid) String() string {
+	if g.Unit <= 0 {
+		return "off"
+	}
+	s := "1/" + strconv.Itoa(g.Unit)
+	if g.Triplet {
+		s += "t"
+	}
+	return s
+}
+
+// ParseGrid reads a note value such as "1/16", a triplet such as "1/8t", or
+// "off" for no grid
+func ParseGrid(s string) (Grid, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "off" || name == "" {
+		return Grid{}, nil
+	}
+	var g Grid
+	name, g.Triplet = strings.CutSuffix(name, "t")
+	unit, ok := strings.CutPrefix(name, "1/")
+	n, err := strconv.Atoi(unit)
+	if !ok || err != nil || n < 1 || n > 64 || n&(n-1) != 0 {
+		return Grid{}, fmt.Errorf("invalid grid %q (want a note value from 1/1 to 1/64 such as 1/16, a triplet such as 1/8t, or off)", s)
+	}
+	g.Unit = n
+	return g, nil
+}
+
+// Set implements flag.Value
+func (g *Grid) Set(s string) error {
+	p, err := ParseGrid(s)
+	if err != nil {
+		return err
+	}
+	*g = p
+	return nil
+}
@@ -0,0 +1,54 @@
+package music
+
+import "testing"
+
+func TestGrid(t *testing.T) {
+	for _, tc := range []struct {
+		s     string
+		ticks int64
+	}{
+		{"off", 0}, {"1/4", 480}, {"1/16", 120}, {"1/8t", 160}, {"1/4T", 320},
+	} {
+		g, err := ParseGrid(tc.s)
+		if err != nil {
+			t.Fatalf("ParseGrid(%q): %v", tc.s, err)
+		}
+		if g.Ticks() != tc.ticks {
+			t.Errorf("%q is %d ticks, want %d", tc.s, g.Ticks(), tc.ticks)
+		}
+	}
+	if got := (Grid{Unit: 8, Triplet: true}).String(); got != "1/8t" {
+		t.Errorf("String() = %q, want 1/8t", got)
+	}
+	for _, s := range []string{"1/3", "1/128", "16", "1/8x"} {
+		if _, err := ParseGrid(s); err == nil {
+			t.Errorf("ParseGrid accepted %q", s)
+		}
+	}
+
+	g := Grid{Unit: 16}
+	for _, tc := range []struct{ tick, want int64 }{{0, 0}, {59, 0}, {60, 120}, {170, 120}, {181, 240}} {
+		if got := g.Snap(tc.tick); got != tc.want {
+			t.Errorf("Snap(%d) = %d, want %d", tc.tick, got, tc.want)
+		}
+	}
+	if got := (Grid{}).Snap(77); got != 77 {
+		t.Errorf("no grid moved 77 to %d", got)
+	}
+}
+
+func TestSequencerGrid(t *testing.T) {
+	// Three steps to the quarter note, moved onto sixteenths
+	s := NewSequencer()
+	s.TicksPerStep, s.Grid = 160, Grid{Unit: 16}
+	for step, want := range []int64{0, 120, 360, 480} {
+		if got := s.Tick(step); got != want {
+			t.Errorf("step %d on tick %d, want %d", step, got, want)
+		}
+	}
+	s.Grid = Grid{Unit: 4}
+	s.Play(0, 1, []Key{39})
+	if _, ended := s.Play(1, 1, nil); len(ended) != 1 || ended[0].Duration != 1 {
+		t.Errorf("a note ended on the line it started on is %v, want it a tick long", ended)
+	}
+}
@@ -0,0 +1,173 @@
+package music
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PitchClass is a note name without an octave, 0 for C to 11 for B
+type PitchClass int
+
+func (p PitchClass) String() string { return noteNames[((int(p)%12)+12)%12] }
+
+// ParsePitchClass converts a note name such as "C", "F#" or "Bb" into a
+// PitchClass
+func ParsePitchClass(s string) (PitchClass, error) {
+	name := strings.TrimSpace(s)
+	if name == "" {
+		return 0, fmt.Errorf("invalid note name %q", s)
+	}
+	base := strings.Index("C D EF G A B", strings.ToUpper(name[:1]))
+	if base < 0 {
+		return 0, fmt.Errorf("invalid note name %q", s)
+	}
+	pc := base
+	for _, accidental := range name[1:] {
+		switch accidental {
+		case '#', '♯':
+			pc++
+		case 'b', '♭':
+			pc--
+		default:
+			return 0, fmt.Errorf("invalid note name %q", s)
+		}
+	}
+	return PitchClass((pc + 12) % 12), nil
+}
+
+// Set implements flag.Value
+func (p *PitchClass) Set(s string) error {
+	v, err := ParsePitchClass(s)
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// Interval returns the semitones from one pitch class to the nearest note of
+// another, from 5 down to 6 up
+func Interval(from, to PitchClass) int {
+	d := ((int(to)-int(from))%12 + 12) % 12
+	if d > 6 {
+		d -= 12
+	}
+	return d
+}
+
+// Scale is the set of pitch classes notes are kept to, as semitones above
+// the root in ascending order from 0
+type Scale struct {
+	Name  string // the scale's name, or "" for a custom interval list
+	Steps []int
+}
+
+// Scales are the named scales ParseScale accepts
+var Scales = []Scale{
+	{"chromatic", []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}},
+	{"major", []int{0, 2, 4, 5, 7, 9, 11}},
+	{"minor", []int{0, 2, 3, 5, 7, 8, 10}},
+	{"pentatonic", []int{0, 2, 4, 7, 9}},
+	{"minor-pentatonic", []int{0, 3, 5, 7, 10}},
+	{"dorian", []int{0, 2, 3, 5, 7, 9, 10}},
+	{"whole-tone", []int{0, 2, 4, 6, 8, 10}},
+}
+
+// Chromatic is the scale of every pitch class, which keeps every note
+var Chromatic = Scales[0]
+
+func (s Scale) String() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	steps := make([]string, len(s.Steps))
+	for i, st := range s.Steps {
+		steps[i] = strconv.Itoa(st)
+	}
+	return strings.Join(steps, ",")
+}
+
+// ParseScale converts a scale name such as "dorian" ("natural-minor" is
+// also accepted for the minor scale), or a custom list of semitones above
+// the root such as "0,3,5,7,10", into a Scale
+func ParseScale(s string) (Scale, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "natural-minor" {
+		name = "minor"
+	}
+	for _, sc := range Scales {
+		if sc.Name == name {
+			return sc, nil
+		}
+	}
+	var steps []int
+	for _, f := range strings.FieldsFunc(name, func(r rune) bool { return r == ',' || r == ' ' }) {
+		st, err := strconv.Atoi(f)
+		if err != nil || st < 0 || st > 11 {
+			names := make([]string, len(Scales))
+			for i, sc := range Scales {
+				names[i] = sc.Name
+			}
+			return Scale{}, fmt.Errorf("invalid scale %q (want one of %s, or semitones 0 to 11 such as 0,2,4,7,9)", s, strings.Join(names, ", "))
+		}
+		steps = append(steps, st)
+	}
+	slices.Sort(steps)
+	steps = slices.Compact(steps)
+	if len(steps) == 0 || steps[0] != 0 {
+		return Scale{}, fmt.Errorf("invalid scale %q: the root, 0, must be among its steps", s)
+	}
+	return Scale{Steps: steps}, nil
+}
+
+// Set implements flag.Value
+func (s *Scale) Set(v string) error {
+	p, err := ParseScale(v)
+	if err != nil {
+		return err
+	}
+	*s = p
+	return nil
+}
+
+// Contains reports whether k is in the scale built on root
+func (s Scale) Contains(k Key, root PitchClass) bool {
+	step := ((k.Note()-int(root))%12 + 12) % 12
+	return slices.Contains(s.Steps, step)
+}
+
+// Fit returns the key k is kept to in the scale built on root. A key in the
+// scale is kept; one outside it is dropped, or with snap moved to the
+// nearest key in the scale, the lower on a tie, that is on the keyboard.
+func (s Scale) Fit(k Key, root PitchClass, snap bool) (Key, bool) {
+	if len(s.Steps) == 0 || s.Contains(k, root) {
+		return k, true
+	}
+	if !snap {
+		return 0, false
+	}
+	for d := Key(1); d < 12; d++ {
+		if k-d >= 0 && s.Contains(k-d, root) {
+			return k - d, true
+		}
+		if k+d < Keys && s.Contains(k+d, root) {
+			return k + d, true
+		}
+	}
+	return 0, false
+}
+
+// Quantize returns keys kept to the scale built on root by Fit, lowest
+// first and without repeats
+func (s Scale) Quantize(keys []Key, root PitchClass, snap bool) []Key {
+	out := make([]Key, 0, len(keys))
+	for _, k := range keys {
+		if q, ok := s.Fit(k, root, snap); ok {
+			out = append(out, q)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
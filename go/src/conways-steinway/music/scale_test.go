@@ -0,0 +1,73 @@
+package music
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParsePitchClass(t *testing.T) {
+	for s, want := range map[string]PitchClass{"C": 0, "c#": 1, "Bb": 10, "B#": 0, "Cb": 11, " F♯ ": 6} {
+		if got, err := ParsePitchClass(s); err != nil || got != want {
+			t.Errorf("ParsePitchClass(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "H", "C+", "#"} {
+		if _, err := ParsePitchClass(s); err == nil {
+			t.Errorf("ParsePitchClass(%q) succeeded", s)
+		}
+	}
+}
+
+func TestParseScale(t *testing.T) {
+	for s, want := range map[string][]int{
+		"Dorian":        {0, 2, 3, 5, 7, 9, 10},
+		"natural-minor": {0, 2, 3, 5, 7, 8, 10},
+		"0,7,4,4":       {0, 4, 7},
+		"0 3 5":         {0, 3, 5},
+	} {
+		if got, err := ParseScale(s); err != nil || !slices.Equal(got.Steps, want) {
+			t.Errorf("ParseScale(%q) = %v, %v, want %v", s, got.Steps, err, want)
+		}
+	}
+	for _, s := range []string{"", "lydian-ish", "2,4", "0,12"} {
+		if _, err := ParseScale(s); err == nil {
+			t.Errorf("ParseScale(%q) succeeded", s)
+		}
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	major, _ := ParseScale("major")
+	// C4 to G4 in C major: C#4 snaps down to C4 and D#4 down to D4
+	keys := []Key{39, 40, 41, 42, 43}
+	if got, want := major.Quantize([]Key{40, 42, 43}, 0, true), []Key{39, 41, 43}; !slices.Equal(got, want) {
+		t.Errorf("snapped = %v, want %v", got, want)
+	}
+	if got, want := major.Quantize(keys, 0, false), []Key{39, 41, 43}; !slices.Equal(got, want) {
+		t.Errorf("dropped = %v, want %v", got, want)
+	}
+	// In D major C4 lies a semitone from both B3 and C#4, and the lower wins
+	d, _ := ParsePitchClass("D")
+	if got, want := major.Quantize([]Key{39}, d, true), []Key{38}; !slices.Equal(got, want) {
+		t.Errorf("C4 in D major = %v, want %v", got, want)
+	}
+	// G#0 would be off the keyboard, so A0 snaps up to A#0
+	wholeTone, _ := ParseScale("whole-tone")
+	if got, want := wholeTone.Quantize([]Key{0}, 0, true), []Key{1}; !slices.Equal(got, want) {
+		t.Errorf("A0 in C whole-tone = %v, want %v", got, want)
+	}
+	if got := Chromatic.Quantize(keys, 5, false); !slices.Equal(got, keys) {
+		t.Errorf("chromatic = %v, want every key", got)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	for _, tc := range []struct {
+		from, to PitchClass
+		want     int
+	}{{0, 7, -5}, {0, 5, 5}, {0, 6, 6}, {7, 2, -5}, {11, 0, 1}, {4, 4, 0}} {
+		if got := Interval(tc.from, tc.to); got != tc.want {
+			t.Errorf("Interval(%v, %v) = %d, want %d", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package music
+
+import (
+	"math"
+	"sort"
+)
+
+// score is the layout of a notated score, shared by every part and by the
+// MusicXML and LilyPond writers: the quantum notes are rounded to, the quanta
+// in a bar and the number of bars
+type score struct {
+	clock   Clock
+	quantum int64 // ticks in the shortest length written
+	bar     int   // quanta in a bar
+	bars    int
+	lengths []noteLength
+}
+
+// noteLength is a length notation can show as one note: a type, perhaps
+// dotted
+type noteLength struct {
+	quanta int
+	value  int // 1 for a whole note, 2 for a half and so on
+	name   string
+	dotted bool
+}
+
+func newScore(clock Clock, tracks []Track) *score {
+	// A whole note is divided into 32 quanta, or into beats if they are
+	// shorter than a 32nd note
+	perWhole := max(32, clock.Meter.Unit)
+	s := &score{
+		clock:   clock,
+		quantum: TicksPerQuarter * 4 / int64(perWhole),
+		bar:     clock.Meter.Beats * perWhole / clock.Meter.Unit,
+	}
+	names := []string{"whole", "half", "quarter", "eighth", "16th", "32nd", "64th"}
+	for i, name := range names {
+		q := perWhole >> i
+		if q < 1 {
+			break
+		}
+		if q%2 == 0 {
+			s.lengths = append(s.lengths, noteLength{q + q/2, 1 << i, name, true})
+		}
+		s.lengths = append(s.lengths, noteLength{q, 1 << i, name, false})
+	}
+	end := 0
+	for _, t := range tracks {
+		for _, n := range t.Notes {
+			end = max(end, s.quantize(n.End()))
+		}
+	}
+	s.bars = max((end+s.bar-1)/s.bar, 1)
+	return s
+}
+
+func (s *score) quantize(tick int64) int {
+	return int(math.Round(float64(tick) / float64(s.quantum)))
+}
+
+// engraved is a note quantized to the score's quanta, from start to end
+type engraved struct {
+	pitch, velocity int
+	start, end      int
+}
+
+// staves returns a track's notes on the staves they are engraved on: the
+// treble from middle C up and the bass below it, or one percussion staff for
+// DrumChannel
+func (s *score) staves(t Track) [][]engraved {
+	if t.Channel == DrumChannel {
+		return [][]engraved{s.staff(t.Notes, func(NoteEvent) bool { return true })}
+	}
+	return [][]engraved{
+		s.staff(t.Notes, func(n NoteEvent) bool { return n.Pitch >= 60 }),
+		s.staff(t.Notes, func(n NoteEvent) bool { return n.Pitch < 60 }),
+	}
+}
+
+// staff returns the notes engraved on a staff, each pitch's notes cut short
+// where the next one starts so none overlap
+func (s *score) staff(notes []NoteEvent, keep func(NoteEvent) bool) []engraved {
+	var out []engraved
+	for _, n := range notes {
+		if keep(n) {
+			start := s.quantize(n.Start)
+			out = append(out, engraved{n.Pitch, n.Velocity, start, max(s.quantize(n.End()), start+1)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].pitch != out[j].pitch {
+			return out[i].pitch < out[j].pitch
+		}
+		return out[i].start < out[j].start
+	})
+	kept := out[:0]
+	for i, e := range out {
+		if i+1 < len(out) && out[i+1].pitch == e.pitch && out[i+1].start < e.end {
+			e.end = out[i+1].start
+		}
+		if e.end > e.start {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// chord is the notes of a staff sounding together for one length, a rest if
+// there are none
+type chord struct {
+	notes  []engraved
+	start  int
+	length noteLength
+}
+
+// tied reports whether n is tied to the previous chord, and whether to the
+// next
+func (c chord) tied(n engraved) (stop, carry bool) {
+	return n.start < c.start, n.end > c.start+c.length.quanta
+}
+
+// chords returns a staff's chords in the given bar, split wherever a note
+// starts or ends and into lengths notation can show, or none when the staff
+// rests for the whole bar
+func (s *score) chords(notes []engraved, bar int) []chord {
+	from := bar * s.bar
+	to := from + s.bar
+	cuts := map[int]bool{from: true, to: true}
+	for _, n := range notes {
+		for _, q := range []int{n.start, n.end} {
+			if q > from && q < to {
+				cuts[q] = true
+			}
+		}
+	}
+	var bounds []int
+	for q := range cuts {
+		bounds = append(bounds, q)
+	}
+	sort.Ints(bounds)
+	var out []chord
+	for i := 0; i+1 < len(bounds); i++ {
+		start, end := bounds[i], bounds[i+1]
+		var sounding []engraved
+		for _, n := range notes {
+			if n.start <= start && n.end > start {
+				sounding = append(sounding, n)
+			}
+		}
+		if len(sounding) == 0 && start == from && end == to {
+			return nil
+		}
+		for _, l := range s.split(end - start) {
+			out = append(out, chord{sounding, start, l})
+			start += l.quanta
+		}
+	}
+	return out
+}
+
+// split breaks a length into lengths notation can show, longest first
+func (s *score) split(quanta int) []noteLength {
+	var out []noteLength
+	for quanta > 0 {
+		for _, l := range s.lengths {
+			if l.quanta <= quanta {
+				out = append(out, l)
+				quanta -= l.quanta
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,236 @@
+package music
+
+import "sort"
+
+// TicksPerQuarter is the resolution of the sequencer's clock, in ticks per
+// quarter note
+const TicksPerQuarter = 480
+
+// NoteEvent is one note: a key sounded on a MIDI channel from tick Start for
+// Duration ticks. A note that has started but not yet ended has no Duration.
+type NoteEvent struct {
+	Pitch    int   // MIDI note number
+	Velocity int   // 1 to 127
+	Start    int64 // tick the note starts on
+	Duration int64 // ticks the note sounds for
+	Channel  int   // MIDI channel, 1 to 16
+}
+
+// End returns the tick the note stops on
+func (n NoteEvent) End() int64 { return n.Start + n.Duration }
+
+// voice identifies a sounding note
+type voice struct {
+	channel, pitch int
+}
+
+// Sequencer turns the keys struck in each step of the clock into notes. A key
+// struck in consecutive steps on the same channel is held as one note, which
+// ends at the first step the key is not struck, so a note lasts as long as
+// the cell striking it lives; with Retrigger, and always on DrumChannel, it
+// is struck afresh every step instead, as it is on a channel articulated
+// shorter than legato. Every note that starts is matched by exactly one that
+// ends.
+type Sequencer struct {
+	TicksPerStep int64        // ticks between steps of the clock
+	Velocity     int          // velocity of every note
+	Retrigger    bool         // end and restart held notes at every step, for percussive styles
+	Swing        float64      // percent, 0 to 100, that off-beat steps are delayed, as SwingTick plays them
+	Grid         Grid         // grid the steps' ticks are moved onto, before humanizing; the zero Grid leaves them
+	Humanize     *Humanizer   // nudges the start and velocity of every note struck; nil plays them as given
+	Arpeggio     *Arpeggiator // spreads crowded steps' notes across the step; nil strikes them together
+	Polyphony    int          // most notes sounding at once on every channel together; 0 is unlimited
+	Steal        Steal        // which notes give way to keep within Polyphony
+	Scale        Scale        // scale StealScale keeps notes of, built on Root
+	Root         PitchClass   // root of Scale
+	Dropped      int          // notes ended early or never started to keep within Polyphony
+
+	// Articulations holds how long the notes of each MIDI channel sound for,
+	// with channel 0's for the channels not given; legato where neither is
+	Articulations map[int]Articulation
+
+	sounding  map[voice]NoteEvent
+	releasing map[voice]int64 // tick each note just struck on an articulated channel ends on
+	holds     []hold          // steps held back by ritardandos, in order of step
+}
+
+// hold delays a step, and every step after it, by ticks
+type hold struct {
+	step  int
+	ticks int64
+	total int64 // ticks of this hold and every one before it
+}
+
+// NewSequencer returns a sequencer stepping a sixteenth note at a time
+func NewSequencer() *Sequencer {
+	return &Sequencer{TicksPerStep: TicksPerQuarter / 4, Velocity: 96, Scale: Chromatic, sounding: make(map[voice]NoteEvent)}
+}
+
+// Play strikes keys on channel at the given clock step. It returns the notes
+// that start, without durations, and the notes on channel that end because
+// their key is no longer struck, with durations. Steps must not go backwards.
+func (s *Sequencer) Play(step int, channel int, keys []Key) (started, ended []NoteEvent) {
+	return s.Strike(step, channel, keys, nil)
+}
+
+// Strike is Play with the velocity of each key given; a key held from an
+// earlier step keeps the velocity it started with unless it is retriggered.
+// With nil velocities every note has the sequencer's Velocity.
+func (s *Sequencer) Strike(step int, channel int, keys []Key, velocities []int) (started, ended []NoteEvent) {
+	tick, next := s.Tick(step), s.Tick(step+1)
+	// Steps finer than the grid can fall on the same line as the next one
+	next = max(next, tick+1)
+	articulation := s.articulation(channel)
+	struck := make(map[int]bool, len(keys))
+	var striking []int // indexes of the keys that start notes
+	for i, k := range keys {
+		pitch := k.Note()
+		struck[pitch] = true
+		if _, ok := s.sounding[voice{channel, pitch}]; ok && !s.Retrigger && channel != DrumChannel && articulation == Legato {
+			continue
+		}
+		striking = append(striking, i)
+	}
+	// Notes are arpeggiated across the step, up to the next one's tick
+	var spread []int64
+	if s.Arpeggio != nil {
+		spread = s.Arpeggio.Spread(len(striking), next-tick)
+	}
+	for j, i := range striking {
+		pitch := keys[i].Note()
+		v := voice{channel, pitch}
+		velocity := s.Velocity
+		if velocities != nil {
+			velocity = velocities[i]
+		}
+		start := tick
+		if spread != nil {
+			start += spread[j]
+		}
+		if s.Humanize != nil {
+			// Kept within a third of a step, so a note never starts before
+			// the one struck a step earlier even when the steps swing
+			var offset int64
+			offset, velocity = s.Humanize.Nudge(velocity, s.TicksPerStep/3-1)
+			start = max(start+offset, 0)
+			if spread != nil {
+				start = min(max(start, tick), next-1)
+			}
+		}
+		if held, ok := s.sounding[v]; ok {
+			// A note struck early cuts the one it retriggers short, though
+			// never to nothing
+			end := min(tick, start)
+			if end <= held.Start {
+				end = held.Start + 1
+				start = max(start, end)
+			}
+			ended = append(ended, s.end(v, held, end))
+		}
+		n := NoteEvent{Pitch: pitch, Velocity: velocity, Start: start, Channel: channel}
+		s.sounding[v] = n
+		started = append(started, n)
+		if articulation != Legato {
+			if s.releasing == nil {
+				s.releasing = make(map[voice]int64)
+			}
+			s.releasing[v] = start + articulation.length(start, next, next-tick)
+		}
+	}
+	for v, n := range s.sounding {
+		if v.channel == channel && !struck[v.pitch] {
+			ended = append(ended, s.end(v, n, max(tick, n.Start+1)))
+		}
+	}
+	started, ended = s.limit(tick, started, ended)
+	sortNotes(ended)
+	return started, ended
+}
+
+// Restrike is Strike with every key struck afresh, as with Retrigger, even
+// if it is held from the step before
+func (s *Sequencer) Restrike(step int, channel int, keys []Key, velocities []int) (started, ended []NoteEvent) {
+	retrigger := s.Retrigger
+	s.Retrigger = true
+	defer func() { s.Retrigger = retrigger }()
+	return s.Strike(step, channel, keys, velocities)
+}
+
+// Release ends the notes just struck on channels articulated shorter than
+// legato, each once it has sounded for its length, and returns them, to
+// follow the notes' starts. Notes it is not called for end when their key is
+// next struck or left, as held notes do.
+func (s *Sequencer) Release() []NoteEvent {
+	var ended []NoteEvent
+	for v, end := range s.releasing {
+		if n, ok := s.sounding[v]; ok && end > n.Start {
+			ended = append(ended, s.end(v, n, end))
+		}
+	}
+	clear(s.releasing)
+	sortNotes(ended)
+	return ended
+}
+
+// articulation returns the articulation of channel
+func (s *Sequencer) articulation(channel int) Articulation {
+	if a, ok := s.Articulations[channel]; ok {
+		return a
+	}
+	return s.Articulations[0]
+}
+
+// Flush ends every sounding note at the given clock step, e.g. when the
+// performance stops, and returns them
+func (s *Sequencer) Flush(step int) []NoteEvent {
+	tick := s.Tick(step)
+	var ended []NoteEvent
+	for v, n := range s.sounding {
+		ended = append(ended, s.end(v, n, tick))
+	}
+	sortNotes(ended)
+	return ended
+}
+
+// Tick returns the tick the given clock step falls on, on the grid and held
+// back by the ritardandos before it
+func (s *Sequencer) Tick(step int) int64 {
+	tick := s.Grid.Snap(SwingTick(step, s.TicksPerStep, s.Swing))
+	if i := sort.Search(len(s.holds), func(i int) bool { return s.holds[i].step > step }); i > 0 {
+		tick += s.holds[i-1].total
+	}
+	return tick
+}
+
+// Ritardando slows the steps after step over the next steps of them, each
+// lasting longer than the one before until the last is half as long again,
+// and then returns to tempo
+func (s *Sequencer) Ritardando(step, steps int) {
+	for i := 1; i <= steps; i++ {
+		s.holds = append(s.holds, hold{step: step + i, ticks: s.TicksPerStep * int64(i) / int64(2*steps)})
+	}
+	sort.SliceStable(s.holds, func(i, j int) bool { return s.holds[i].step < s.holds[j].step })
+	var total int64
+	for i := range s.holds {
+		total += s.holds[i].ticks
+		s.holds[i].total = total
+	}
+}
+
+// end stops the note n sounding as v at tick
+func (s *Sequencer) end(v voice, n NoteEvent, tick int64) NoteEvent {
+	delete(s.sounding, v)
+	n.Duration = tick - n.Start
+	return n
+}
+
+// sortNotes orders notes by channel and then pitch, so the order of notes
+// ending together does not depend on map iteration
+func sortNotes(notes []NoteEvent) {
+	sort.Slice(notes, func(i, j int) bool {
+		if notes[i].Channel != notes[j].Channel {
+			return notes[i].Channel < notes[j].Channel
+		}
+		return notes[i].Pitch < notes[j].Pitch
+	})
+}
@@ -0,0 +1,244 @@
+package music
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestSequencerHoldsRepeatedKeys(t *testing.T) {
+	s := NewSequencer()
+	started, ended := s.Play(0, 1, []Key{39, 46})
+	if len(started) != 2 || ended != nil {
+		t.Fatalf("step 0: started %v, ended %v", started, ended)
+	}
+	// C4 is struck again and keeps sounding; G4 stops
+	started, ended = s.Play(1, 1, []Key{39})
+	if started != nil {
+		t.Fatalf("step 1 started %v, want nothing", started)
+	}
+	want := []NoteEvent{{Pitch: 67, Velocity: 96, Start: 0, Duration: 120, Channel: 1}}
+	if !slices.Equal(ended, want) {
+		t.Fatalf("step 1 ended %v, want %v", ended, want)
+	}
+	ended = s.Flush(4)
+	want = []NoteEvent{{Pitch: 60, Velocity: 96, Start: 0, Duration: 480, Channel: 1}}
+	if !slices.Equal(ended, want) {
+		t.Fatalf("flush ended %v, want %v", ended, want)
+	}
+	if ended := s.Flush(5); ended != nil {
+		t.Fatalf("second flush ended %v", ended)
+	}
+}
+
+func TestSequencerKeepsChannelsApart(t *testing.T) {
+	s := NewSequencer()
+	s.Play(0, 1, []Key{0})
+	started, _ := s.Play(0, 2, []Key{0})
+	if len(started) != 1 || started[0].Channel != 2 {
+		t.Fatalf("the same key on another channel should start its own note, got %v", started)
+	}
+	// Nothing struck on channel 2 ends only channel 2's note
+	_, ended := s.Play(1, 2, nil)
+	if len(ended) != 1 || ended[0].Channel != 2 {
+		t.Fatalf("ended %v, want channel 2's A0", ended)
+	}
+	if ended := s.Flush(2); len(ended) != 1 || ended[0].Channel != 1 || ended[0].End() != 240 {
+		t.Fatalf("flush ended %v, want channel 1's A0 ending at tick 240", ended)
+	}
+}
+
+func TestSequencerPairsEveryNote(t *testing.T) {
+	s := NewSequencer()
+	steps := [][]Key{{1, 2, 3}, {2, 3, 4}, {}, {1, 4}, {1, 2, 3, 4}}
+	on, off := 0, 0
+	for step, keys := range steps {
+		started, ended := s.Play(step, 1, keys)
+		on += len(started)
+		off += len(ended)
+		for _, n := range ended {
+			if n.Duration <= 0 {
+				t.Fatalf("step %d: note %v has no duration", step, n)
+			}
+		}
+	}
+	off += len(s.Flush(len(steps)))
+	if on != off {
+		t.Fatalf("%d notes started but %d ended", on, off)
+	}
+}
+
+func TestSequencerRetrigger(t *testing.T) {
+	s := NewSequencer()
+	s.Retrigger = true
+	s.Play(0, 1, []Key{39})
+	started, ended := s.Strike(1, 1, []Key{39}, []int{50})
+	wantEnded := []NoteEvent{{Pitch: 60, Velocity: 96, Start: 0, Duration: 120, Channel: 1}}
+	wantStarted := []NoteEvent{{Pitch: 60, Velocity: 50, Start: 120, Channel: 1}}
+	if !slices.Equal(ended, wantEnded) || !slices.Equal(started, wantStarted) {
+		t.Fatalf("step 1: started %v, ended %v; want %v and %v", started, ended, wantStarted, wantEnded)
+	}
+	if ended := s.Flush(2); len(ended) != 1 || ended[0].Duration != 120 {
+		t.Fatalf("flush ended %v, want the restarted note after one step", ended)
+	}
+}
+
+func TestSequencerSwing(t *testing.T) {
+	s := NewSequencer()
+	s.Swing = 100
+	s.Play(0, 1, []Key{39})
+	started, ended := s.Play(1, 1, []Key{40})
+	if len(started) != 1 || started[0].Start != 160 || len(ended) != 1 || ended[0].Duration != 160 {
+		t.Fatalf("step 1: started %v, ended %v; want the off-beat 40 ticks late", started, ended)
+	}
+	if ended := s.Flush(2); len(ended) != 1 || ended[0].Duration != 80 {
+		t.Fatalf("flush ended %v, want the off-beat note shortened to 80 ticks", ended)
+	}
+}
+
+func TestSequencerHumanizeRetrigger(t *testing.T) {
+	s := NewSequencer()
+	s.Retrigger = true
+	s.Humanize = NewHumanizer(rand.New(rand.NewSource(7)), 1000, 0)
+	var last NoteEvent
+	for step := 0; step < 50; step++ {
+		started, ended := s.Play(step, 1, []Key{39})
+		if step > 0 && (len(ended) != 1 || ended[0].Duration <= 0 || ended[0].End() > started[0].Start) {
+			t.Fatalf("step %d: %v ended after %v started", step, ended, started)
+		}
+		if d := started[0].Start - int64(step)*s.TicksPerStep; d < -39 || d > 39 {
+			t.Fatalf("step %d: note nudged %d ticks, more than a third of a step", step, d)
+		}
+		last = started[0]
+	}
+	if last.Velocity != s.Velocity {
+		t.Errorf("velocity %d changed without velocity humanization", last.Velocity)
+	}
+}
+
+func TestSequencerArpeggio(t *testing.T) {
+	s := NewSequencer()
+	s.Swing = 100
+	s.Arpeggio = NewArpeggiator(nil, 2, ArpeggioUp)
+	s.Play(0, 1, []Key{39})
+	started, _ := s.Play(1, 1, []Key{39, 43, 46, 51})
+	// The off-beat swings to tick 160 and the next step falls on 240, so the
+	// three new keys share 80 ticks; the held key is not struck again
+	var starts []int64
+	for _, n := range started {
+		starts = append(starts, n.Start)
+	}
+	if want := []int64{160, 186, 213}; !slices.Equal(starts, want) {
+		t.Fatalf("arpeggio starts %v, want %v", starts, want)
+	}
+	if _, ended := s.Play(2, 1, nil); len(ended) != 4 || ended[3].End() != 240 || ended[3].Duration <= 0 {
+		t.Fatalf("ended %v, want every note ended on tick 240", ended)
+	}
+}
+
+func TestSequencerPolyphony(t *testing.T) {
+	for _, tc := range []struct {
+		steal Steal
+		keep  []int // pitches sounding after the second step
+	}{
+		// D4, E4 and G4 are the quietest
+		{StealQuietest, []int{60, 65}},
+		// C4 and E4 have sounded longest, then D4 is the lowest new note
+		{StealOldest, []int{65, 67}},
+		// F4 is outside the pentatonic scale, then D4 and E4 are the quietest
+		{StealScale, []int{60, 67}},
+	} {
+		s := NewSequencer()
+		s.Polyphony, s.Steal = 2, tc.steal
+		s.Scale, _ = ParseScale("pentatonic")
+		s.Strike(0, 1, []Key{39, 43}, []int{90, 60}) // C4 and E4
+		// C4 and E4 held, with D4, F4 and G4
+		started, ended := s.Strike(1, 1, []Key{39, 41, 43, 44, 46}, []int{90, 40, 60, 100, 80})
+		var got []int
+		for v := range s.sounding {
+			got = append(got, v.pitch)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tc.keep) {
+			t.Errorf("%v: sounding %v, want %v", tc.steal, got, tc.keep)
+		}
+		if s.Dropped != 3 || len(started)+2-len(ended) != 2 {
+			t.Errorf("%v: dropped %d, started %v, ended %v", tc.steal, s.Dropped, started, ended)
+		}
+	}
+}
+
+func TestSequencerRestrike(t *testing.T) {
+	s := NewSequencer()
+	s.Play(0, 1, []Key{39})
+	started, ended := s.Restrike(1, 1, []Key{39}, []int{48})
+	if len(ended) != 1 || ended[0].End() != 120 || len(started) != 1 || started[0].Velocity != 48 {
+		t.Fatalf("restrike started %v, ended %v; want C4 struck again softly", started, ended)
+	}
+	if s.Retrigger {
+		t.Fatal("Restrike left the sequencer retriggering")
+	}
+}
+
+func TestSequencerArticulation(t *testing.T) {
+	s := NewSequencer()
+	s.Articulations = map[int]Articulation{0: Staccato, 2: Tenuto, 3: Legato}
+	started, _ := s.Play(0, 1, []Key{39})
+	released := s.Release()
+	want := []NoteEvent{{Pitch: 60, Velocity: 96, Start: 0, Duration: 36, Channel: 1}}
+	if len(started) != 1 || !slices.Equal(released, want) {
+		t.Fatalf("staccato started %v, released %v, want %v", started, released, want)
+	}
+	// A staccato key struck again is struck afresh
+	if started, _ := s.Play(1, 1, []Key{39}); len(started) != 1 || started[0].Start != 120 {
+		t.Fatalf("staccato step 1 started %v, want the key again", started)
+	}
+	s.Release()
+
+	s.Play(0, 2, []Key{39})
+	if released := s.Release(); len(released) != 1 || released[0].Duration != 120 {
+		t.Errorf("tenuto released %v, want a whole step", released)
+	}
+	s.Play(0, 3, []Key{39})
+	if released := s.Release(); released != nil {
+		t.Errorf("legato released %v, want the note held", released)
+	}
+	if started, _ := s.Play(1, 3, []Key{39}); started != nil {
+		t.Errorf("legato step 1 started %v, want the note still held", started)
+	}
+}
+
+func TestParseArticulation(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want Articulation
+	}{
+		{"legato", Legato}, {"Staccato", Staccato}, {"tenuto", Tenuto}, {"60%", 0.6},
+	} {
+		got, err := ParseArticulation(tc.s)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseArticulation(%q) = %v, %v, want %v", tc.s, got, err, tc.want)
+		}
+	}
+	if got := Articulation(0.6).String(); got != "60%" {
+		t.Errorf("String() = %q, want 60%%", got)
+	}
+	for _, s := range []string{"0%", "120%", "marcato", "0.5"} {
+		if _, err := ParseArticulation(s); err == nil {
+			t.Errorf("ParseArticulation accepted %q", s)
+		}
+	}
+}
+
+func TestSequencerRitardando(t *testing.T) {
+	s := NewSequencer()
+	s.Ritardando(2, 4)
+	var gaps []int64
+	for step := 1; step <= 8; step++ {
+		gaps = append(gaps, s.Tick(step)-s.Tick(step-1))
+	}
+	want := []int64{120, 120, 135, 150, 165, 180, 120, 120}
+	if !slices.Equal(gaps, want) {
+		t.Errorf("steps %v apart, want %v", gaps, want)
+	}
+}
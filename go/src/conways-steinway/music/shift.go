@@ -0,0 +1,58 @@
+package music
+
+import "slices"
+
+// Transpose returns keys moved up by semitones, or down when it is negative,
+// dropping any that would fall off the keyboard
+func Transpose(keys []Key, semitones int) []Key {
+	var out []Key
+	for _, k := range keys {
+		if t := k + Key(semitones); t >= 0 && t < Keys {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// The comfortable register, C2 to C7, that ShiftClusters moves clusters into
+const (
+	LowestComfortable  Key = 15
+	HighestComfortable Key = 75
+)
+
+// ShiftClusters moves each cluster among keys, five or more keys each within
+// a tone of the next, that reaches below C2 or above C7 by whole octaves
+// towards the middle of the keyboard, where a dense run of notes sounds less
+// muddy or shrill. Keys must be lowest first; the result is too, without
+// repeats. A cluster that no whole number of octaves brings inside the
+// register is left where it is.
+func ShiftClusters(keys []Key) []Key {
+	runs := clusters(keys)
+	if len(runs) == 0 {
+		return keys
+	}
+	out := make([]Key, 0, len(keys))
+	next := 0
+	for _, r := range runs {
+		out = append(out, keys[next:r[0]]...)
+		run := keys[r[0]:r[1]]
+		low, high := run[0], run[len(run)-1]
+		shift := Key(0)
+		for low+shift < LowestComfortable {
+			shift += 12
+		}
+		for high+shift > HighestComfortable {
+			shift -= 12
+		}
+		if low+shift < LowestComfortable {
+			shift = 0
+		}
+		for _, k := range run {
+			out = append(out, k+shift)
+		}
+		next = r[1]
+	}
+	out = append(out, keys[next:]...)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
@@ -0,0 +1,46 @@
+package music
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTranspose(t *testing.T) {
+	keys := []Key{0, 39, 86}
+	if got, want := Transpose(keys, 2), []Key{2, 41}; !slices.Equal(got, want) {
+		t.Errorf("up a tone = %v, want %v", got, want)
+	}
+	if got, want := Transpose(keys, -12), []Key{27, 74}; !slices.Equal(got, want) {
+		t.Errorf("down an octave = %v, want %v", got, want)
+	}
+}
+
+func TestShiftClusters(t *testing.T) {
+	for _, tc := range []struct {
+		keys, want []Key
+	}{
+		// A0 to C#1 rises two octaves to A2 to C#3, and the lone key stays put
+		{[]Key{0, 1, 2, 3, 4, 50}, []Key{24, 25, 26, 27, 28, 50}},
+		// A cluster at the top falls an octave at a time until it fits
+		{[]Key{80, 82, 84, 85, 87}, []Key{68, 70, 72, 73, 75}},
+		{[]Key{3, 5, 7, 9, 11, 27}, []Key{15, 17, 19, 21, 23, 27}},
+		// Shifting can land a cluster on a key already struck
+		{[]Key{3, 5, 7, 9, 11, 19}, []Key{15, 17, 19, 21, 23}},
+		// Four keys are not a cluster, and a cluster in the register stays
+		{[]Key{0, 1, 2, 3}, []Key{0, 1, 2, 3}},
+		{[]Key{40, 41, 42, 43, 44}, []Key{40, 41, 42, 43, 44}},
+	} {
+		if got := ShiftClusters(tc.keys); !slices.Equal(got, tc.want) {
+			t.Errorf("ShiftClusters(%v) = %v, want %v", tc.keys, got, tc.want)
+		}
+	}
+
+	// Every second key from A0 to C8 spans more than the register
+	var wide []Key
+	for k := Key(0); k < Keys; k += 2 {
+		wide = append(wide, k)
+	}
+	if got := ShiftClusters(wide); !slices.Equal(got, wide) {
+		t.Errorf("a cluster wider than the register moved to %v", got)
+	}
+}
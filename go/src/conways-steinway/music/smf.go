@@ -0,0 +1,155 @@
+package music
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+)
+
+// Track is one track of a Standard MIDI File
+type Track struct {
+	Name     string
+	Channel  int // MIDI channel Program is selected on
+	Program  int // General MIDI program, 1 to 128, selected at the start; 0 selects none
+	Notes    []NoteEvent
+	Controls []Control
+	Changes  []ProgramChange // programs selected later on
+}
+
+// ProgramChange selects a General MIDI program on a channel partway through
+// a piece
+type ProgramChange struct {
+	Program int   // 1 to 128
+	Tick    int64 // tick the program is selected on
+	Channel int   // MIDI channel, 1 to 16
+}
+
+// WriteSMF writes a Type-1 Standard MIDI File: a first track holding the
+// piece's name and the clock's tempo and time signature, then one track for
+// each of tracks. Ticks are TicksPerQuarter to the quarter note, and every
+// track ends with an end-of-track event after its last note.
+func WriteSMF(w io.Writer, name string, clock Clock, tracks []Track) error {
+	if err := clock.Validate(); err != nil {
+		return fmt.Errorf("smf: %w", err)
+	}
+	bw := bufio.NewWriter(w)
+	header := []byte("MThd\x00\x00\x00\x06")
+	header = binary.BigEndian.AppendUint16(header, 1)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(tracks)+1))
+	header = binary.BigEndian.AppendUint16(header, TicksPerQuarter)
+	bw.Write(header)
+
+	var conductor trackWriter
+	conductor.meta(0, 0x03, []byte(name))
+	perQuarter := min(clock.QuarterNote().Microseconds(), 1<<24-1)
+	conductor.meta(0, 0x51, []byte{byte(perQuarter >> 16), byte(perQuarter >> 8), byte(perQuarter)})
+	// The unit is written as a power of two, with a metronome click, counted
+	// in MIDI clocks of 24 to the quarter note, every beat
+	unit := byte(bits.Len(uint(clock.Meter.Unit)) - 1)
+	conductor.meta(0, 0x58, []byte{byte(clock.Meter.Beats), unit, byte(96 / clock.Meter.Unit), 8})
+	conductor.meta(0, 0x2f, nil)
+	conductor.writeTo(bw)
+
+	for _, t := range tracks {
+		var tw trackWriter
+		tw.meta(0, 0x03, []byte(t.Name))
+		if t.Program > 0 {
+			tw.event(0, 0xc0|byte(t.Channel-1)&0x0f, byte(t.Program-1)&0x7f)
+		}
+		end := int64(0)
+		for _, m := range messages(t.Notes, t.Controls, t.Changes) {
+			tw.event(m.tick, m.data...)
+			end = max(end, m.tick)
+		}
+		tw.meta(end, 0x2f, nil)
+		tw.writeTo(bw)
+	}
+	return bw.Flush()
+}
+
+// message is one channel message of a track
+type message struct {
+	tick int64
+	data []byte
+}
+
+// messages turns notes into note-on and note-off messages, controls into
+// control changes and changes into program changes, in time order. On the
+// same tick note-offs come first, so that a note struck again straight away
+// is not cut short, then control changes, so that a pedal pressed with a
+// chord holds it and a bank is selected before its program, then program
+// changes and note-ons, each kind lowest pitch or controller first.
+func messages(notes []NoteEvent, controls []Control, changes []ProgramChange) []message {
+	msgs := make([]message, 0, 2*len(notes)+len(controls)+len(changes))
+	for _, n := range notes {
+		ch := byte(n.Channel-1) & 0x0f
+		msgs = append(msgs,
+			message{n.Start, []byte{0x90 | ch, byte(n.Pitch), byte(n.Velocity)}},
+			message{n.End(), []byte{0x80 | ch, byte(n.Pitch), 0}})
+	}
+	for _, c := range controls {
+		msgs = append(msgs, message{c.Tick, []byte{0xb0 | byte(c.Channel-1)&0x0f, byte(c.Controller), byte(c.Value)}})
+	}
+	for _, c := range changes {
+		msgs = append(msgs, message{c.Tick, []byte{0xc0 | byte(c.Channel-1)&0x0f, byte(c.Program-1) & 0x7f}})
+	}
+	order := map[byte]int{0x80: 0, 0xb0: 1, 0xc0: 2, 0x90: 3}
+	sort.SliceStable(msgs, func(i, j int) bool {
+		if msgs[i].tick != msgs[j].tick {
+			return msgs[i].tick < msgs[j].tick
+		}
+		if kind, other := order[msgs[i].data[0]&0xf0], order[msgs[j].data[0]&0xf0]; kind != other {
+			return kind < other
+		}
+		return msgs[i].data[1] < msgs[j].data[1]
+	})
+	return msgs
+}
+
+// trackWriter builds the events of one MTrk chunk
+type trackWriter struct {
+	buf  bytes.Buffer
+	tick int64 // tick of the last event written
+}
+
+// event appends an event at tick, which must not be before the last one
+func (t *trackWriter) event(tick int64, data ...byte) {
+	t.delta(tick - t.tick)
+	t.tick = tick
+	t.buf.Write(data)
+}
+
+// meta appends a meta event of the given type
+func (t *trackWriter) meta(tick int64, kind byte, data []byte) {
+	t.delta(tick - t.tick)
+	t.tick = tick
+	t.buf.Write([]byte{0xff, kind})
+	t.quantity(uint32(len(data)))
+	t.buf.Write(data)
+}
+
+func (t *trackWriter) delta(d int64) { t.quantity(uint32(d)) }
+
+// quantity appends n as a MIDI variable-length quantity: seven bits to a
+// byte, most significant first, with the top bit set on all but the last
+func (t *trackWriter) quantity(n uint32) {
+	var b [5]byte
+	i := len(b) - 1
+	b[i] = byte(n & 0x7f)
+	for n >>= 7; n > 0; n >>= 7 {
+		i--
+		b[i] = byte(n&0x7f) | 0x80
+	}
+	t.buf.Write(b[i:])
+}
+
+// writeTo writes the track as an MTrk chunk
+func (t *trackWriter) writeTo(w io.Writer) {
+	header := binary.BigEndian.AppendUint32([]byte("MTrk"), uint32(t.buf.Len()))
+	w.Write(header)
+	w.Write(t.buf.Bytes())
+}
@@ -0,0 +1,99 @@
+package music
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteSMF(t *testing.T) {
+	tracks := []Track{{Name: "Piano", Notes: []NoteEvent{
+		{Pitch: 64, Velocity: 96, Start: 120, Duration: 240, Channel: 2},
+		{Pitch: 60, Velocity: 96, Start: 0, Duration: 120, Channel: 2},
+	}}}
+	var buf bytes.Buffer
+	if err := WriteSMF(&buf, "Song", Clock{BPM: 120, GenerationsPerBeat: 4, Meter: TimeSignature{4, 4}}, tracks); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		'M', 'T', 'h', 'd', 0, 0, 0, 6, 0, 1, 0, 2, 0x01, 0xe0,
+		'M', 'T', 'r', 'k', 0, 0, 0, 27,
+		0, 0xff, 0x03, 4, 'S', 'o', 'n', 'g',
+		0, 0xff, 0x51, 3, 0x07, 0xa1, 0x20, // 500000 microseconds a quarter
+		0, 0xff, 0x58, 4, 4, 2, 24, 8,
+		0, 0xff, 0x2f, 0,
+		'M', 'T', 'r', 'k', 0, 0, 0, 30,
+		0, 0xff, 0x03, 5, 'P', 'i', 'a', 'n', 'o',
+		0, 0x91, 60, 96,
+		0x78, 0x81, 60, 0, // the note-off comes before the note-on at tick 120
+		0, 0x91, 64, 96,
+		0x81, 0x70, 0x81, 64, 0, // 240 ticks later
+		0, 0xff, 0x2f, 0,
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, want) {
+		t.Fatalf("got\n% x\nwant\n% x", got, want)
+	}
+}
+
+func TestWriteSMFRejectsTempo(t *testing.T) {
+	if err := WriteSMF(&bytes.Buffer{}, "", Clock{GenerationsPerBeat: 1, Meter: TimeSignature{4, 4}}, nil); err == nil {
+		t.Fatal("a tempo of 0 bpm should be rejected")
+	}
+}
+
+func TestVariableLengthQuantity(t *testing.T) {
+	for _, tc := range []struct {
+		n    uint32
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7f, []byte{0x7f}},
+		{0x80, []byte{0x81, 0x00}},
+		{0x2000, []byte{0xc0, 0x00}},
+		{0x3fff, []byte{0xff, 0x7f}},
+		{0x200000, []byte{0x81, 0x80, 0x80, 0x00}},
+		{0x0fffffff, []byte{0xff, 0xff, 0xff, 0x7f}},
+	} {
+		var tw trackWriter
+		tw.quantity(tc.n)
+		if got := tw.buf.Bytes(); !bytes.Equal(got, tc.want) {
+			t.Errorf("quantity(%#x) = % x, want % x", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestWriteSMFTimeSignature(t *testing.T) {
+	var buf bytes.Buffer
+	clock := Clock{BPM: 90, GenerationsPerBeat: 3, Meter: TimeSignature{6, 8}}
+	if err := WriteSMF(&buf, "", clock, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 90 eighth notes a minute is a quarter note every 1333333 microseconds
+	want := []byte{0, 0xff, 0x51, 3, 0x14, 0x58, 0x55, 0, 0xff, 0x58, 4, 6, 3, 12, 8}
+	if !bytes.Contains(buf.Bytes(), want) {
+		t.Fatalf("got\n% x\nwant it to contain\n% x", buf.Bytes(), want)
+	}
+}
+
+func TestMessagesOrderControls(t *testing.T) {
+	notes := []NoteEvent{{Pitch: 60, Velocity: 90, Start: 0, Duration: 120, Channel: 1}, {Pitch: 62, Velocity: 90, Start: 120, Duration: 120, Channel: 1}}
+	controls := []Control{{Controller: SustainPedal, Value: 127, Tick: 120, Channel: 1}}
+	changes := []ProgramChange{{Program: 12, Tick: 120, Channel: 1}}
+	var kinds []byte
+	for _, m := range messages(notes, controls, changes) {
+		kinds = append(kinds, m.data[0])
+	}
+	if want := []byte{0x90, 0x80, 0xb0, 0xc0, 0x90, 0x80}; !bytes.Equal(kinds, want) {
+		t.Fatalf("message kinds % x, want % x", kinds, want)
+	}
+}
+
+func TestWriteSMFProgram(t *testing.T) {
+	var buf bytes.Buffer
+	track := Track{Name: "Strings", Channel: 2, Program: 49, Notes: []NoteEvent{{Pitch: 60, Velocity: 90, Duration: 120, Channel: 2}}}
+	if err := WriteSMF(&buf, "", NewClock(), []Track{track}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("Strings\x00\xc1\x30\x00\x91\x3c")) {
+		t.Fatalf("no program change before the first note in % x", buf.Bytes())
+	}
+}
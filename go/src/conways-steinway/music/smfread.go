@@ -0,0 +1,160 @@
+package music
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// ReadSMF reads the notes of every track of a Standard MIDI File, of format
+// 0 or 1, in order of the tick they start on, and returns them with the
+// file's ticks to the quarter note. Notes left sounding at the end of their
+// track end there. Files timed in SMPTE frames are not supported.
+func ReadSMF(r io.Reader) ([]NoteEvent, int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(data) < 14 || !bytes.Equal(data[:4], []byte("MThd")) {
+		return nil, 0, errors.New("smf: not a Standard MIDI File")
+	}
+	size := int(binary.BigEndian.Uint32(data[4:8]))
+	if size < 6 || 8+size > len(data) {
+		return nil, 0, errors.New("smf: truncated header")
+	}
+	division := int(binary.BigEndian.Uint16(data[12:14]))
+	if division&0x8000 != 0 || division == 0 {
+		return nil, 0, errors.New("smf: SMPTE timing is not supported")
+	}
+	var notes []NoteEvent
+	for rest := data[8+size:]; len(rest) >= 8; {
+		kind, size := string(rest[:4]), int(binary.BigEndian.Uint32(rest[4:8]))
+		if 8+size > len(rest) {
+			return nil, 0, fmt.Errorf("smf: truncated %s chunk", kind)
+		}
+		if kind == "MTrk" {
+			track, err := readTrack(rest[8 : 8+size])
+			if err != nil {
+				return nil, 0, err
+			}
+			notes = append(notes, track...)
+		}
+		rest = rest[8+size:]
+	}
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].Start < notes[j].Start })
+	return notes, division, nil
+}
+
+// readTrack reads the notes of the events of one track chunk
+func readTrack(data []byte) ([]NoteEvent, error) {
+	var notes []NoteEvent
+	sounding := make(map[[2]int][]int) // indexes into notes of the notes held on a channel and pitch
+	var tick int64
+	var status byte
+	for i := 0; i < len(data); {
+		delta, n := readVarLen(data[i:])
+		if n == 0 {
+			return nil, errors.New("smf: truncated delta time")
+		}
+		tick += int64(delta)
+		i += n
+		if i >= len(data) {
+			return nil, errors.New("smf: truncated event")
+		}
+		if data[i]&0x80 != 0 {
+			status = data[i]
+			i++
+		} else if status == 0 {
+			return nil, errors.New("smf: running status without a status byte")
+		}
+		switch {
+		case status == 0xff || status == 0xf0 || status == 0xf7:
+			if status == 0xff {
+				i++ // meta event type
+			}
+			length, n := readVarLen(data[min(i, len(data)):])
+			if n == 0 || i+n+int(length) > len(data) {
+				return nil, errors.New("smf: truncated meta or system exclusive event")
+			}
+			i += n + int(length)
+			status = 0 // running status does not carry over
+			continue
+		case status >= 0xf0:
+			return nil, fmt.Errorf("smf: unexpected status %#x in a track", status)
+		}
+		length := 2
+		if kind := status & 0xf0; kind == 0xc0 || kind == 0xd0 {
+			length = 1
+		}
+		if i+length > len(data) {
+			return nil, errors.New("smf: truncated channel message")
+		}
+		msg := data[i : i+length]
+		i += length
+		channel := int(status&0x0f) + 1
+		switch kind := status & 0xf0; {
+		case kind == 0x90 && msg[1] > 0:
+			v := [2]int{channel, int(msg[0])}
+			sounding[v] = append(sounding[v], len(notes))
+			notes = append(notes, NoteEvent{Pitch: int(msg[0]), Velocity: int(msg[1]), Start: tick, Channel: channel})
+		case kind == 0x80 || kind == 0x90:
+			v := [2]int{channel, int(msg[0])}
+			if held := sounding[v]; len(held) > 0 {
+				notes[held[0]].Duration = tick - notes[held[0]].Start
+				sounding[v] = held[1:]
+			}
+		}
+	}
+	for _, held := range sounding {
+		for _, j := range held {
+			notes[j].Duration = tick - notes[j].Start
+		}
+	}
+	return notes, nil
+}
+
+// readVarLen reads a variable-length quantity, returning it and the bytes it
+// took, or 0 bytes when data ends first
+func readVarLen(data []byte) (uint32, int) {
+	var v uint32
+	for i := 0; i < len(data) && i < 4; i++ {
+		v = v<<7 | uint32(data[i]&0x7f)
+		if data[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// NotePattern turns notes into a pattern of cells, each note bringing to life
+// the cell in its key's column on the row of its onset, counted from the
+// first note's at ticksPerRow ticks to the row. Notes off the piano's keys or
+// on the drums' channel, and rows beyond the first rows, are left out. The
+// pattern spans all 88 columns, so each key's cells stay in its column.
+func NotePattern(notes []NoteEvent, ticksPerRow int64, rows int) *life.Pattern {
+	p := &life.Pattern{Width: Keys}
+	first := int64(-1)
+	seen := make(map[life.Coord]bool)
+	for _, n := range notes {
+		k := Key(n.Pitch - LowestNote)
+		if k < 0 || k >= Keys || n.Channel == DrumChannel {
+			continue
+		}
+		if first < 0 {
+			first = n.Start
+		}
+		c := life.Coord{X: int(k), Y: int((n.Start - first) / max(ticksPerRow, 1))}
+		if c.Y >= rows || seen[c] {
+			continue
+		}
+		seen[c] = true
+		p.Cells = append(p.Cells, c)
+		p.Height = max(p.Height, c.Y+1)
+	}
+	return p
+}
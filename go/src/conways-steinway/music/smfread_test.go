@@ -0,0 +1,82 @@
+package music
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+func TestReadSMF(t *testing.T) {
+	written := []NoteEvent{
+		{Pitch: 60, Velocity: 90, Start: 0, Duration: 480, Channel: 1},
+		{Pitch: 64, Velocity: 70, Start: 480, Duration: 240, Channel: 1},
+		{Pitch: 36, Velocity: 100, Start: 240, Duration: 120, Channel: DrumChannel},
+	}
+	var buf bytes.Buffer
+	tracks := []Track{{Channel: 1, Program: 1, Notes: written[:2]}, {Channel: DrumChannel, Notes: written[2:]}}
+	if err := WriteSMF(&buf, "test", NewClock(), tracks); err != nil {
+		t.Fatal(err)
+	}
+	notes, perQuarter, err := ReadSMF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perQuarter != TicksPerQuarter {
+		t.Errorf("ticks to the quarter note = %d, want %d", perQuarter, TicksPerQuarter)
+	}
+	want := []NoteEvent{written[0], written[2], written[1]}
+	if len(notes) != len(want) {
+		t.Fatalf("read %v, want %v", notes, want)
+	}
+	for i := range want {
+		if notes[i] != want[i] {
+			t.Errorf("note %d = %+v, want %+v", i, notes[i], want[i])
+		}
+	}
+
+	if _, _, err := ReadSMF(bytes.NewReader([]byte("RIFF0000WAVE"))); err == nil {
+		t.Error("ReadSMF accepted a file that is not MIDI")
+	}
+}
+
+func TestReadSMFRunningStatus(t *testing.T) {
+	track := []byte{
+		0x00, 0x90, 60, 100, // note-on
+		0x00, 62, 80, // running status
+		0x60, 60, 0, // a note-on at velocity 0 ends the first
+		0x00, 0xff, 0x2f, 0x00,
+	}
+	file := []byte("MThd\x00\x00\x00\x06\x00\x00\x00\x01\x00\x60MTrk\x00\x00\x00")
+	file = append(file, byte(len(track)))
+	file = append(file, track...)
+	notes, _, err := ReadSMF(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []NoteEvent{{Pitch: 60, Velocity: 100, Duration: 0x60, Channel: 1}, {Pitch: 62, Velocity: 80, Duration: 0x60, Channel: 1}}
+	if len(notes) != 2 || notes[0] != want[0] || notes[1] != want[1] {
+		t.Errorf("read %+v, want %+v, the second ended with its track", notes, want)
+	}
+}
+
+func TestNotePattern(t *testing.T) {
+	notes := []NoteEvent{
+		{Pitch: 60, Start: 480, Channel: 1},
+		{Pitch: 64, Start: 480, Channel: 1},
+		{Pitch: 67, Start: 600, Channel: 1},
+		{Pitch: 36, Start: 600, Channel: DrumChannel},
+		{Pitch: 10, Start: 600, Channel: 1},
+		{Pitch: 72, Start: 480 * 3, Channel: 1},
+	}
+	p := NotePattern(notes, 120, 4)
+	want := []life.Coord{{X: 39, Y: 0}, {X: 43, Y: 0}, {X: 46, Y: 1}}
+	if p.Width != Keys || p.Height != 2 || len(p.Cells) != len(want) {
+		t.Fatalf("pattern %dx%d %v, want 88x2 %v", p.Width, p.Height, p.Cells, want)
+	}
+	for i, c := range want {
+		if p.Cells[i] != c {
+			t.Errorf("cell %d = %v, want %v", i, p.Cells[i], c)
+		}
+	}
+}
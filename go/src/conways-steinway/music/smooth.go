@@ -0,0 +1,108 @@
+package music
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Smoother conditions the keys a generation strikes on the keys struck
+// before them, as a first-order Markov model over the interval from the
+// nearest of those keys: steps and repeats are likely, wider leaps less so
+// and leaps beyond MaxLeap never taken. A key the model turns down is moved
+// by octaves nearer the keys before if that makes it likely enough, and left
+// out if not, but the board always strikes at least one key, so it keeps
+// choosing the notes. Its random numbers come from a seeded source, so a
+// seed plays the same performance every time.
+type Smoother struct {
+	Strength float64 // 0 leaves the keys alone, 1 follows the model fully
+	MaxLeap  int     // widest interval, in semitones, from the keys before
+
+	rng *rand.Rand
+}
+
+// NewSmoother returns a smoother that takes its random numbers from rng
+func NewSmoother(rng *rand.Rand, strength float64, maxLeap int) *Smoother {
+	return &Smoother{Strength: strength, MaxLeap: maxLeap, rng: rng}
+}
+
+// stepwise is the widest interval, in semitones, counted as a step
+const stepwise = 2
+
+// leapFalloff is the semitones over which a leap beyond a step grows half as likely
+const leapFalloff = 3
+
+// Transition returns how likely the model moves by interval semitones, from
+// 1 for a step or repeat, halving every leapFalloff semitones wider and 0
+// beyond MaxLeap
+func (s *Smoother) Transition(interval int) float64 {
+	interval = max(interval, -interval)
+	if interval > s.MaxLeap {
+		return 0
+	}
+	if interval <= stepwise {
+		return 1
+	}
+	return math.Pow(0.5, float64(interval-stepwise)/leapFalloff)
+}
+
+// nearest returns the interval from k to the nearest of previous
+func nearest(k Key, previous []Key) int {
+	best := Keys
+	for _, p := range previous {
+		best = min(best, max(int(k-p), int(p-k)))
+	}
+	return best
+}
+
+// folded returns k moved by octaves, within the keyboard, as near as it can
+// be to the nearest of previous
+func folded(k Key, previous []Key) Key {
+	best := k
+	for f := k % 12; f < Keys; f += 12 {
+		if nearest(f, previous) < nearest(best, previous) {
+			best = f
+		}
+	}
+	return best
+}
+
+// Smooth returns the keys of keys the model lets follow previous, each with
+// its velocity, in order from the lowest. With no previous keys, or no
+// Strength, it returns keys as they are.
+func (s *Smoother) Smooth(previous, keys []Key, velocities []int) ([]Key, []int) {
+	if len(previous) == 0 || len(keys) == 0 || s.Strength <= 0 {
+		return keys, velocities
+	}
+	chosen := make(map[Key]int)
+	likeliest, likeliestVelocity, best := Key(0), 0, -1.0
+	for i, k := range keys {
+		v := velocities[i]
+		p := s.Transition(nearest(k, previous))
+		if s.rng.Float64() >= s.Strength*(1-p) {
+			chosen[k] = max(chosen[k], v)
+			continue
+		}
+		f := folded(k, previous)
+		q := s.Transition(nearest(f, previous))
+		if q > p && s.rng.Float64() >= s.Strength*(1-q) {
+			chosen[f] = max(chosen[f], v)
+		}
+		if q > best {
+			likeliest, likeliestVelocity, best = f, v, q
+		}
+	}
+	if len(chosen) == 0 {
+		chosen[likeliest] = likeliestVelocity
+	}
+	smoothed := make([]Key, 0, len(chosen))
+	for k := range chosen {
+		smoothed = append(smoothed, k)
+	}
+	sort.Slice(smoothed, func(i, j int) bool { return smoothed[i] < smoothed[j] })
+	v := make([]int, len(smoothed))
+	for i, k := range smoothed {
+		v[i] = chosen[k]
+	}
+	return smoothed, v
+}
@@ -0,0 +1,46 @@
+package music
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestSmootherTransition(t *testing.T) {
+	s := NewSmoother(rand.New(rand.NewSource(1)), 1, 12)
+	for _, tc := range []struct {
+		interval int
+		want     float64
+	}{
+		{0, 1}, {-2, 1}, {5, 0.5}, {11, 0.125}, {13, 0},
+	} {
+		if got := s.Transition(tc.interval); got != tc.want {
+			t.Errorf("Transition(%d) = %g, want %g", tc.interval, got, tc.want)
+		}
+	}
+}
+
+func TestSmooth(t *testing.T) {
+	s := NewSmoother(rand.New(rand.NewSource(1)), 1, 5)
+	previous := []Key{39} // C4
+	// D4 steps, C5 folds back onto C4, F#2 folds to F#3 but is still too
+	// far for a full strength model, which never leaps beyond a fourth
+	keys, velocities := s.Smooth(previous, []Key{21, 41, 51}, []int{60, 70, 80})
+	if want := []Key{39, 41}; !slices.Equal(keys, want) {
+		t.Fatalf("smoothed keys %v, want %v", keys, want)
+	}
+	if want := []int{80, 70}; !slices.Equal(velocities, want) {
+		t.Errorf("smoothed velocities %v, want %v", velocities, want)
+	}
+
+	if keys, _ := s.Smooth(previous, []Key{80}, []int{90}); len(keys) != 1 {
+		t.Errorf("smoothing away every key left %v, want the likeliest kept", keys)
+	}
+	if keys, _ := s.Smooth(nil, []Key{0, 87}, []int{1, 2}); !slices.Equal(keys, []Key{0, 87}) {
+		t.Errorf("smoothing the first keys struck gave %v, want them as they are", keys)
+	}
+	off := NewSmoother(rand.New(rand.NewSource(1)), 0, 0)
+	if keys, _ := off.Smooth(previous, []Key{0, 87}, []int{1, 2}); !slices.Equal(keys, []Key{0, 87}) {
+		t.Errorf("smoothing at no strength gave %v", keys)
+	}
+}
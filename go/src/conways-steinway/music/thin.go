@@ -0,0 +1,67 @@
+package music
+
+import (
+	"math/rand"
+	"slices"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// Thinner leaves out some of the keys a generation strikes afresh, at
+// random, so that dense passages play fewer notes while the board plays on
+// as before. Each key is struck with Probability; by age, older cells are
+// likelier to be heard, up to always once they are FullAge. Keys already
+// held are never left out, so thinning drops whole notes rather than
+// chopping them up. Its random numbers come from a seeded source, so a seed
+// plays the same performance every time.
+type Thinner struct {
+	Probability float64 // of striking a newborn cell's key, 0 to 1
+	ByAge       bool    // raise the probability with the cell's age
+	FullAge     int     // age from which a cell's key is always struck, by age
+
+	rng *rand.Rand
+}
+
+// NewThinner returns a thinner that takes its random numbers from rng,
+// counting cells fully grown as NewDynamics does
+func NewThinner(rng *rand.Rand, probability float64, byAge bool) *Thinner {
+	return &Thinner{Probability: probability, ByAge: byAge, FullAge: NewDynamics().FullAge, rng: rng}
+}
+
+// Chance returns the probability of striking the key of a cell that has
+// survived age generations
+func (t *Thinner) Chance(age int) float64 {
+	p := min(max(t.Probability, 0), 1)
+	if t.ByAge {
+		grown := 1.0
+		if t.FullAge > 0 {
+			grown = float64(min(max(age, 0), t.FullAge)) / float64(t.FullAge)
+		}
+		p += (1 - p) * grown
+	}
+	return p
+}
+
+// Thin returns the keys of keys struck, each with its velocity, the ages
+// giving how long the cell of each has lived. Keys among held are kept.
+func (t *Thinner) Thin(held, keys []Key, velocities, ages []int) ([]Key, []int) {
+	var kept []Key
+	var v []int
+	for i, k := range keys {
+		if !slices.Contains(held, k) && t.rng.Float64() >= t.Chance(ages[i]) {
+			continue
+		}
+		kept = append(kept, k)
+		v = append(v, velocities[i])
+	}
+	return kept, v
+}
+
+// Ages returns how long the cell of each key struck by row y of b has lived,
+// as Played numbers the rows. Cells of boards that do not track age are
+// newborn.
+func Ages(b life.Board, y int) map[Key]int {
+	a := make(map[Key]int)
+	eachCell(b, y, func(k Key, _, age int) { a[k] = age })
+	return a
+}
@@ -0,0 +1,47 @@
+package music
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestThinnerChance(t *testing.T) {
+	th := NewThinner(rand.New(rand.NewSource(1)), 0.5, false)
+	if got := th.Chance(100); got != 0.5 {
+		t.Errorf("Chance(100) = %g, want 0.5 without age", got)
+	}
+	th.ByAge = true
+	for _, tc := range []struct {
+		age  int
+		want float64
+	}{
+		{0, 0.5}, {8, 0.75}, {16, 1}, {40, 1},
+	} {
+		if got := th.Chance(tc.age); got != tc.want {
+			t.Errorf("Chance(%d) = %g by age, want %g", tc.age, got, tc.want)
+		}
+	}
+}
+
+func TestThin(t *testing.T) {
+	th := NewThinner(rand.New(rand.NewSource(1)), 0, false)
+	keys, velocities := th.Thin([]Key{40}, []Key{39, 40, 41}, []int{10, 20, 30}, []int{0, 0, 0})
+	if !slices.Equal(keys, []Key{40}) || !slices.Equal(velocities, []int{20}) {
+		t.Errorf("Thin at probability 0 = %v, %v, want only the held key", keys, velocities)
+	}
+	th.ByAge = true
+	if keys, _ := th.Thin(nil, []Key{39, 41}, []int{10, 30}, []int{0, 16}); !slices.Equal(keys, []Key{41}) {
+		t.Errorf("Thin by age = %v, want the grown cell's key", keys)
+	}
+
+	th = NewThinner(rand.New(rand.NewSource(1)), 0.25, false)
+	struck := 0
+	for range 1000 {
+		keys, _ := th.Thin(nil, []Key{0}, []int{1}, []int{0})
+		struck += len(keys)
+	}
+	if struck < 200 || struck > 300 {
+		t.Errorf("struck %d of 1000 at probability 0.25", struck)
+	}
+}
@@ -0,0 +1,253 @@
+package music
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Tuning is a scale read from a Scala .scl file, laid out over the MIDI
+// notes by a keyboard mapping, so that the board's columns can play in
+// tunings other than twelve-tone equal temperament
+type Tuning struct {
+	Description string
+	Map         KeyboardMap
+	cents       []float64 // of each degree above the first, the last being the period
+}
+
+// KeyboardMap lays a scale out over the MIDI notes, as a Scala .kbm file
+// does: Middle plays the scale's first degree, each note above it the next
+// degree Degrees gives, repeating every len(Degrees) notes a period of the
+// scale higher, and Reference sounds at Frequency. Notes outside First to
+// Last, and those whose degree is -1, are not retuned.
+type KeyboardMap struct {
+	First, Last int
+	Middle      int
+	Reference   int
+	Frequency   float64 // Hz
+	Octave      int     // degree of the period the mapping repeats at; 0 is the scale's own
+	Degrees     []int   // degree each note plays, from Middle; nil plays every degree in turn
+}
+
+// NewKeyboardMap returns the linear mapping Scala uses without a .kbm: every
+// degree in turn from middle C, which keeps its equal-tempered pitch
+func NewKeyboardMap() KeyboardMap {
+	return KeyboardMap{First: 0, Last: 127, Middle: 60, Reference: 60, Frequency: 440 * math.Pow(2, -9.0/12)}
+}
+
+// LoadTuning reads the .scl file at scl and, unless kbm is empty, the .kbm
+// file mapping it onto the keys
+func LoadTuning(scl, kbm string) (*Tuning, error) {
+	f, err := os.Open(scl)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	t, err := ParseScala(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", scl, err)
+	}
+	if kbm == "" {
+		return t, nil
+	}
+	g, err := os.Open(kbm)
+	if err != nil {
+		return nil, err
+	}
+	defer g.Close()
+	if t.Map, err = ParseKeyboardMap(g); err != nil {
+		return nil, fmt.Errorf("%s: %w", kbm, err)
+	}
+	return t, nil
+}
+
+// scalaLines returns the lines of a Scala file that are not comments, which
+// start with "!"
+func scalaLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if text := scanner.Text(); !strings.HasPrefix(text, "!") {
+			lines = append(lines, strings.TrimSpace(text))
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// ParseScala reads a Scala .scl file: a description, the number of degrees
+// and the pitch of each above the first, the last being the period, in
+// cents if it has a decimal point and as a ratio such as 3/2 or 2 if not. It
+// is mapped onto the keys by NewKeyboardMap.
+func ParseScala(r io.Reader) (*Tuning, error) {
+	lines, err := scalaLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("no description and number of notes")
+	}
+	n, err := strconv.Atoi(firstField(lines[1]))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid number of notes %q", lines[1])
+	}
+	if len(lines)-2 < n {
+		return nil, fmt.Errorf("%d notes, want %d", len(lines)-2, n)
+	}
+	t := &Tuning{Description: lines[0], Map: NewKeyboardMap(), cents: make([]float64, n)}
+	for i, line := range lines[2 : 2+n] {
+		if t.cents[i], err = parsePitch(firstField(line)); err != nil {
+			return nil, err
+		}
+	}
+	if t.cents[n-1] <= 0 {
+		return nil, fmt.Errorf("period %g cents is not above the first note", t.cents[n-1])
+	}
+	return t, nil
+}
+
+func firstField(s string) string {
+	if f := strings.Fields(s); len(f) > 0 {
+		return f[0]
+	}
+	return ""
+}
+
+// parsePitch converts a pitch of a .scl file into cents
+func parsePitch(s string) (float64, error) {
+	if strings.Contains(s, ".") {
+		c, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid pitch %q", s)
+		}
+		return c, nil
+	}
+	num, den, ok := strings.Cut(s, "/")
+	if !ok {
+		den = "1"
+	}
+	a, err1 := strconv.ParseFloat(num, 64)
+	b, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || a <= 0 || b <= 0 {
+		return 0, fmt.Errorf("invalid pitch %q", s)
+	}
+	return 1200 * math.Log2(a/b), nil
+}
+
+// ParseKeyboardMap reads a Scala .kbm file: the size of the mapping, the
+// first and last notes retuned, the middle and reference notes, the
+// reference frequency, the degree of the period and the degree of each note
+// of the mapping, "x" for those not retuned. A size of 0 maps every degree in
+// turn.
+func ParseKeyboardMap(r io.Reader) (KeyboardMap, error) {
+	var m KeyboardMap
+	lines, err := scalaLines(r)
+	if err != nil {
+		return m, err
+	}
+	if len(lines) < 7 {
+		return m, fmt.Errorf("%d of the 7 header lines", len(lines))
+	}
+	var header [7]int
+	for i, line := range lines[:7] {
+		field := firstField(line)
+		if i == 5 {
+			if m.Frequency, err = strconv.ParseFloat(field, 64); err != nil || m.Frequency <= 0 {
+				return m, fmt.Errorf("invalid reference frequency %q", line)
+			}
+			continue
+		}
+		if header[i], err = strconv.Atoi(field); err != nil || header[i] < 0 {
+			return m, fmt.Errorf("invalid value %q on line %d", line, i+1)
+		}
+	}
+	size := header[0]
+	m.First, m.Last, m.Middle, m.Reference, m.Octave = header[1], header[2], header[3], header[4], header[6]
+	if size == 0 {
+		return m, nil
+	}
+	m.Degrees = make([]int, size)
+	for i := range m.Degrees {
+		m.Degrees[i] = -1
+		if 7+i >= len(lines) {
+			continue // missing entries are not retuned
+		}
+		field := firstField(lines[7+i])
+		if field == "x" || field == "X" {
+			continue
+		}
+		if m.Degrees[i], err = strconv.Atoi(field); err != nil || m.Degrees[i] < 0 {
+			return m, fmt.Errorf("invalid degree %q", lines[7+i])
+		}
+	}
+	return m, nil
+}
+
+// degreeCents returns the pitch of degree d of the scale, in cents above its
+// first, continuing through the periods above and below
+func (t *Tuning) degreeCents(d int) float64 {
+	n := len(t.cents)
+	periods, i := d/n, d%n
+	if i < 0 {
+		periods, i = periods-1, i+n
+	}
+	c := float64(periods) * t.cents[n-1]
+	if i > 0 {
+		c += t.cents[i-1]
+	}
+	return c
+}
+
+// noteCents returns the pitch of note, in cents above Middle, or false if the
+// mapping does not retune it
+func (t *Tuning) noteCents(note int) (float64, bool) {
+	m := t.Map
+	if note < m.First || note > m.Last {
+		return 0, false
+	}
+	i := note - m.Middle
+	if len(m.Degrees) == 0 {
+		return t.degreeCents(i), true
+	}
+	size := len(m.Degrees)
+	periods, j := i/size, i%size
+	if j < 0 {
+		periods, j = periods-1, j+size
+	}
+	if m.Degrees[j] < 0 {
+		return 0, false
+	}
+	period := t.cents[len(t.cents)-1]
+	if m.Octave > 0 {
+		period = t.degreeCents(m.Octave)
+	}
+	return float64(periods)*period + t.degreeCents(m.Degrees[j]), true
+}
+
+// Frequency returns the frequency note sounds at, in Hz, or false if the
+// mapping does not retune it
+func (t *Tuning) Frequency(note int) (float64, bool) {
+	c, ok := t.noteCents(note)
+	if !ok {
+		return 0, false
+	}
+	ref, ok := t.noteCents(t.Map.Reference)
+	if !ok {
+		ref = 0 // a reference the mapping leaves out tunes the middle note
+	}
+	return t.Map.Frequency * math.Pow(2, (c-ref)/1200), true
+}
+
+// Pitch returns the pitch note sounds at as a fractional MIDI note number in
+// equal temperament, so 69.5 is a quarter tone above A4. Notes the mapping
+// does not retune keep their own.
+func (t *Tuning) Pitch(note int) float64 {
+	f, ok := t.Frequency(note)
+	if !ok {
+		return float64(note)
+	}
+	return 69 + 12*math.Log2(f/440)
+}
@@ -0,0 +1,99 @@
+package music
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// edo returns a .scl file dividing the octave into n equal steps
+func edo(n int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "! %d-edo.scl\n!\n%d equal divisions of the octave\n %d\n!\n", n, n, n)
+	for i := 1; i < n; i++ {
+		fmt.Fprintf(&b, " %.5f\n", 1200*float64(i)/float64(n))
+	}
+	b.WriteString(" 2/1\n")
+	return b.String()
+}
+
+func TestParseScala(t *testing.T) {
+	twelve, err := ParseScala(strings.NewReader(edo(12)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if twelve.Description != "12 equal divisions of the octave" {
+		t.Errorf("description %q", twelve.Description)
+	}
+	for _, n := range []int{21, 60, 69, 108} {
+		if p := twelve.Pitch(n); math.Abs(p-float64(n)) > 1e-6 {
+			t.Errorf("12-edo plays %d at %g", n, p)
+		}
+	}
+
+	nineteen, err := ParseScala(strings.NewReader(edo(19)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Middle C keeps its pitch and 19 notes up is the octave above
+	if p := nineteen.Pitch(60); math.Abs(p-60) > 1e-6 {
+		t.Errorf("19-edo plays middle C at %g", p)
+	}
+	if p := nineteen.Pitch(79); math.Abs(p-72) > 1e-6 {
+		t.Errorf("19-edo plays 79 at %g, want 72", p)
+	}
+	if p := nineteen.Pitch(61); math.Abs(p-60-12.0/19) > 1e-6 {
+		t.Errorf("19-edo plays 61 at %g", p)
+	}
+
+	just, err := ParseScala(strings.NewReader("Just major\n7\n9/8\n5/4\n4/3\n3/2\n5/3\n15/8\n2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := just.degreeCents(4); math.Abs(c-701.955) > 1e-3 {
+		t.Errorf("a just fifth is %g cents", c)
+	}
+	if c := just.degreeCents(-1); math.Abs(c-(1200*math.Log2(15.0/8)-1200)) > 1e-9 {
+		t.Errorf("the degree below the first is %g cents", c)
+	}
+
+	for _, bad := range []string{"", "x\n2\n3/2\n", "x\nn\n", "x\n1\n0/1\n", "x\n1\nabc\n"} {
+		if _, err := ParseScala(strings.NewReader(bad)); err == nil {
+			t.Errorf("parsed %q", bad)
+		}
+	}
+}
+
+func TestParseKeyboardMap(t *testing.T) {
+	just, err := ParseScala(strings.NewReader("Just major\n7\n9/8\n5/4\n4/3\n3/2\n5/3\n15/8\n2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The major scale on the white keys, the black ones left alone, with A4
+	// at 440 Hz
+	kbm := "! white.kbm\n12\n0\n127\n60\n69\n440.0\n7\n0\nx\n1\nx\n2\n3\nx\n4\nx\n5\nx\n6\n"
+	if just.Map, err = ParseKeyboardMap(strings.NewReader(kbm)); err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := just.Frequency(69); !ok || math.Abs(f-440) > 1e-9 {
+		t.Errorf("A4 at %g Hz, %v", f, ok)
+	}
+	// E4 is a just major third above C4, which is a just sixth below A4
+	c4 := 440 * 3.0 / 5
+	if f, _ := just.Frequency(64); math.Abs(f-c4*5/4) > 1e-9 {
+		t.Errorf("E4 at %g Hz, want %g", f, c4*5/4)
+	}
+	if f, _ := just.Frequency(72); math.Abs(f-2*c4) > 1e-9 {
+		t.Errorf("C5 at %g Hz, want %g", f, 2*c4)
+	}
+	if _, ok := just.Frequency(61); ok {
+		t.Error("C#4 retuned")
+	}
+	if p := just.Pitch(61); p != 61 {
+		t.Errorf("C#4 plays at %g, want its own pitch", p)
+	}
+	if _, err := ParseKeyboardMap(strings.NewReader("12\n0\n127\n60\n69\nfast\n12\n")); err == nil {
+		t.Error("parsed a bad reference frequency")
+	}
+}
@@ -0,0 +1,114 @@
+package music
+
+import (
+	"math"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// Dynamics maps the cells that strike keys to MIDI velocities, so that a
+// lonely newborn cell plays quietly and an old cell in a dense cluster loudly.
+// Neighbours and age count equally towards a loudness from 0 to 1, which is
+// raised to the power Curve and scaled to the range Min to Max.
+type Dynamics struct {
+	Min, Max int     // velocities of the quietest and loudest cells, 1 to 127
+	Curve    float64 // 1 is linear; above 1 keeps most notes quiet, below 1 loud
+	FullAge  int     // age from which a cell counts as fully grown
+}
+
+// NewDynamics returns dynamics from pianissimo to fortissimo with a linear
+// curve, counting cells fully grown after 16 generations
+func NewDynamics() Dynamics {
+	return Dynamics{Min: 32, Max: 112, Curve: 1, FullAge: 16}
+}
+
+// Velocity returns the velocity of a cell with neighbours of its eight
+// neighbours alive that has survived age generations
+func (d Dynamics) Velocity(neighbours, age int) int {
+	grown := 1.0
+	if d.FullAge > 0 {
+		grown = float64(min(max(age, 0), d.FullAge)) / float64(d.FullAge)
+	}
+	return d.Loudness((float64(min(max(neighbours, 0), 8))/8 + grown) / 2)
+}
+
+// Loudness returns the velocity of a loudness from 0 to 1, raised to the
+// power Curve and scaled to the range Min to Max
+func (d Dynamics) Loudness(loudness float64) int {
+	lo, hi := clampVelocity(d.Min), clampVelocity(d.Max)
+	if hi < lo {
+		lo, hi = hi, lo
+	}
+	loudness = min(max(loudness, 0), 1)
+	if d.Curve > 0 {
+		loudness = math.Pow(loudness, d.Curve)
+	}
+	return lo + int(math.Round(float64(hi-lo)*loudness))
+}
+
+func clampVelocity(v int) int { return min(max(v, 1), 127) }
+
+// Velocities returns the velocity of each key struck by row y of b, as
+// Played numbers the rows. Cells of boards that do not track age count as
+// newborn.
+func (d Dynamics) Velocities(b life.Board, y int) map[Key]int {
+	v := make(map[Key]int)
+	eachCell(b, y, func(k Key, neighbours, age int) { v[k] = d.Velocity(neighbours, age) })
+	return v
+}
+
+// eachCell calls f with each key struck by row y of b, as Played numbers the
+// rows, and how many of its cell's neighbours are alive and how long it has
+// lived. Cells of boards that do not track age are newborn.
+func eachCell(b life.Board, y int, f func(k Key, neighbours, age int)) {
+	_, height := b.Size()
+	if y < 0 {
+		y += height
+	}
+	ager, _ := b.(life.Ager)
+	for _, k := range Played(b, y) {
+		x, n, age := int(k), 0, 0
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if (dx != 0 || dy != 0) && b.Alive(x+dx, y+dy) {
+					n++
+				}
+			}
+		}
+		if ager != nil {
+			age = ager.Age(x, y)
+		}
+		f(k, n, age)
+	}
+}
+
+// Follow returns the velocity of each of keys, which are the keys struck
+// after transposing them by semitones and moving them by octaves, as pitch
+// shifting and chord voicings do. Each takes the velocity of the nearest
+// struck key of the same pitch class, or fallback if there is none.
+func Follow(velocities map[Key]int, keys []Key, semitones, fallback int) []int {
+	return follow(velocities, keys, semitones, fallback, func(v, w int) bool { return v > w })
+}
+
+// follow is Follow for any value of the struck keys, preferring louder ones
+// as louder says when two are as near
+func follow[V any](struck map[Key]V, keys []Key, semitones int, fallback V, louder func(v, w V) bool) []V {
+	out := make([]V, len(keys))
+	for i, k := range keys {
+		out[i] = fallback
+		best := -1
+		for s, v := range struck {
+			d := int(k) - semitones - int(s)
+			if d%12 != 0 {
+				continue
+			}
+			if d < 0 {
+				d = -d
+			}
+			if best < 0 || d < best || d == best && louder(v, out[i]) {
+				best, out[i] = d, v
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package music
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+func TestVelocity(t *testing.T) {
+	d := NewDynamics()
+	for _, tc := range []struct {
+		neighbours, age, want int
+	}{
+		{0, 0, 32},
+		{8, 16, 112},
+		{8, 100, 112},
+		{4, 0, 52},
+		{0, 8, 52},
+		{4, 8, 72},
+	} {
+		if got := d.Velocity(tc.neighbours, tc.age); got != tc.want {
+			t.Errorf("Velocity(%d, %d) = %d, want %d", tc.neighbours, tc.age, got, tc.want)
+		}
+	}
+	d.Curve = 2
+	if got := d.Velocity(4, 8); got != 52 {
+		t.Errorf("squared Velocity(4, 8) = %d, want 52", got)
+	}
+	d.Min, d.Max = 200, -5
+	if lo, hi := d.Velocity(0, 0), d.Velocity(8, 16); lo != 1 || hi != 127 {
+		t.Errorf("out of range limits gave %d to %d, want 1 to 127", lo, hi)
+	}
+}
+
+func TestVelocities(t *testing.T) {
+	g := life.NewEmptyGrid(Keys, 3)
+	// A lone cell at A0 and a block, one of whose cells is on the note row
+	g.SetAlive(0, 2, true)
+	for _, c := range []life.Coord{{X: 10, Y: 1}, {X: 11, Y: 1}, {X: 10, Y: 2}, {X: 11, Y: 2}} {
+		g.SetAlive(c.X, c.Y, true)
+	}
+	g.Step()
+	d := NewDynamics()
+	got := d.Velocities(g, -1)
+	want := map[Key]int{10: d.Velocity(3, 1), 11: d.Velocity(3, 1)}
+	if len(got) != len(want) || got[10] != want[10] || got[11] != want[11] {
+		t.Errorf("Velocities = %v, want %v", got, want)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	struck := map[Key]int{0: 40, 12: 90, 39: 70}
+	// Transposed up a tone, 26 comes from 0 or 12 moved by octaves, and the
+	// nearer wins; 6 comes from no struck pitch class and falls back
+	got := Follow(struck, []Key{26, 2, 41, 6}, 2, 64)
+	if want := []int{90, 40, 70, 64}; !slices.Equal(got, want) {
+		t.Errorf("Follow = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,200 @@
+// Package osc streams a performance as Open Sound Control messages over UDP,
+// so that SuperCollider, Max/MSP, TouchDesigner and the like can play or
+// visualise it. Messages are encoded as OSC 1.0 describes them.
+package osc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"time"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// Message is an OSC message: an address and its arguments, each an int32,
+// float32 or string
+type Message struct {
+	Address string
+	Args    []any
+}
+
+// AppendBinary appends the encoding of the message to b
+func (m Message) AppendBinary(b []byte) ([]byte, error) {
+	b = appendString(b, m.Address)
+	tags := []byte{','}
+	for _, a := range m.Args {
+		switch a.(type) {
+		case int32:
+			tags = append(tags, 'i')
+		case float32:
+			tags = append(tags, 'f')
+		case string:
+			tags = append(tags, 's')
+		default:
+			return nil, fmt.Errorf("osc: %s: unsupported argument %T", m.Address, a)
+		}
+	}
+	b = appendString(b, string(tags))
+	for _, a := range m.Args {
+		switch a := a.(type) {
+		case int32:
+			b = binary.BigEndian.AppendUint32(b, uint32(a))
+		case float32:
+			b = binary.BigEndian.AppendUint32(b, math.Float32bits(a))
+		case string:
+			b = appendString(b, a)
+		}
+	}
+	return b, nil
+}
+
+// appendString appends s nul-terminated and padded with nuls to a multiple
+// of four bytes
+func appendString(b []byte, s string) []byte {
+	b = append(b, s...)
+	return append(b, make([]byte, 4-len(s)%4)...)
+}
+
+// AppendBundle appends the encoding of a bundle of msgs to be acted on at
+// the given time
+func AppendBundle(b []byte, at time.Time, msgs ...Message) ([]byte, error) {
+	b = appendString(b, "#bundle")
+	b = binary.BigEndian.AppendUint64(b, timetag(at))
+	for _, m := range msgs {
+		enc, err := m.AppendBinary(nil)
+		if err != nil {
+			return nil, err
+		}
+		b = binary.BigEndian.AppendUint32(b, uint32(len(enc)))
+		b = append(b, enc...)
+	}
+	return b, nil
+}
+
+// ntpEpoch is the Unix time of the start of 1900, from which OSC time tags
+// count, as NTP timestamps do
+const ntpEpoch = -2208988800
+
+// timetag returns t as an NTP timestamp: seconds in the top 32 bits and
+// fractions of a second in the bottom 32
+func timetag(t time.Time) uint64 {
+	secs := uint64(t.Unix() - ntpEpoch)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+// Output sends the events of a performance to an OSC server as they are
+// published:
+//
+//	/note  channel pitch velocity              as each note starts, and with a
+//	                                           velocity of 0 as it stops
+//	/chord layer channel root quality pitch…   for each chord struck, with the
+//	                                           MIDI pitches of its keys
+//	/stats layer generation population births deaths density
+//	                                           once a generation
+//
+// Every argument is an int32 but the root and quality, which are names such
+// as "F#" and "minor 7", and the density, a float32 from 0 to 1. With
+// KeepTime each /note is sent in a bundle time-tagged with when it sounds.
+type Output struct {
+	conn  net.PacketConn
+	addr  net.Addr
+	clock *music.Clock
+	start time.Time
+	err   error // first failed send
+}
+
+// Dial returns an output sending to the server at addr, a host and UDP port
+func Dial(addr string) (*Output, error) {
+	to, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("OSC address %q: %w", addr, err)
+	}
+	// An unconnected socket keeps sending when nothing listens yet, so the
+	// server can be started after the run
+	conn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return nil, fmt.Errorf("OSC address %q: %w", addr, err)
+	}
+	return &Output{conn: conn, addr: to}, nil
+}
+
+// Addr returns the address messages are sent to
+func (o *Output) Addr() string { return o.addr.String() }
+
+// KeepTime makes the output tag each note with the time its tick on clock
+// falls, counting from start, so that a server scheduling bundles plays
+// notes the sequencer spreads across a step as it spreads them. Called again
+// it re-times the notes handled from then on.
+func (o *Output) KeepTime(clock music.Clock, start time.Time) {
+	o.clock, o.start = &clock, start
+}
+
+// Handle sends NoteOn, NoteOff, Chord and Generation events to the server. A
+// failed send is kept for Close to return and stops any more being sent.
+func (o *Output) Handle(e events.Event) {
+	switch e := e.(type) {
+	case events.NoteOn:
+		n := e.Note
+		o.note(n.Start, Message{"/note", []any{int32(n.Channel), int32(n.Pitch), int32(n.Velocity)}})
+	case events.NoteOff:
+		n := e.Note
+		o.note(n.End(), Message{"/note", []any{int32(n.Channel), int32(n.Pitch), int32(0)}})
+	case events.Chord:
+		c := e.Chord
+		args := []any{int32(e.Layer), int32(e.Channel), music.PitchClass(c.Root).String(), c.Quality.String()}
+		for _, k := range c.Keys {
+			args = append(args, int32(k.Note()))
+		}
+		o.send(Message{"/chord", args})
+	case events.Generation:
+		s := e.Stats
+		o.send(Message{"/stats", []any{int32(e.Layer), int32(e.Generation),
+			int32(s.Population), int32(s.Births), int32(s.Deaths), float32(s.Density)}})
+	}
+}
+
+// note sends a /note message, bundled with the time of tick with KeepTime
+func (o *Output) note(tick int64, m Message) {
+	if o.clock == nil {
+		o.send(m)
+		return
+	}
+	if o.err != nil {
+		return
+	}
+	b, err := AppendBundle(nil, o.start.Add(o.clock.Time(tick)), m)
+	if err == nil {
+		err = o.write(b)
+	}
+	o.err = err
+}
+
+func (o *Output) send(m Message) {
+	if o.err != nil {
+		return
+	}
+	b, err := m.AppendBinary(nil)
+	if err == nil {
+		err = o.write(b)
+	}
+	o.err = err
+}
+
+func (o *Output) write(b []byte) error {
+	if _, err := o.conn.WriteTo(b, o.addr); err != nil {
+		return fmt.Errorf("OSC address %s: %w", o.addr, err)
+	}
+	return nil
+}
+
+// Close closes the socket and returns the first error sending from it
+func (o *Output) Close() error {
+	if err := o.conn.Close(); err != nil && o.err == nil {
+		o.err = err
+	}
+	return o.err
+}
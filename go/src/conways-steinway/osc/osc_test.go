@@ -0,0 +1,102 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+func TestMessageAppendBinary(t *testing.T) {
+	// The example from the OSC 1.0 specification
+	got, err := Message{"/oscillator/4/frequency", []any{float32(440)}}.AppendBinary(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]byte("/oscillator/4/frequency\x00,f\x00\x00"), 0x43, 0xdc, 0x00, 0x00)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got % x\nwant % x", got, want)
+	}
+	got, _ = Message{"/chord", []any{int32(1), "Cm"}}.AppendBinary(nil)
+	want = []byte("/chord\x00\x00,is\x00\x00\x00\x00\x01Cm\x00\x00")
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := (Message{"/bad", []any{1.5}}).AppendBinary(nil); err == nil {
+		t.Error("float64 argument encoded, want an error")
+	}
+}
+
+func TestTimetag(t *testing.T) {
+	at := time.Unix(1, int64(time.Second/4))
+	if got, want := timetag(at), uint64(2208988801)<<32|1<<30; got != want {
+		t.Errorf("timetag = %#x, want %#x", got, want)
+	}
+}
+
+// listen returns a UDP socket on the loopback interface and a receive
+// function returning each datagram sent to it
+func listen(t *testing.T) (net.PacketConn, func() []byte) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, func() []byte {
+		buf := make([]byte, 1024)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf[:n]
+	}
+}
+
+func TestOutputSends(t *testing.T) {
+	server, receive := listen(t)
+	o, err := Dial(server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c4 := music.NoteEvent{Pitch: 60, Velocity: 96, Start: 0, Duration: 480, Channel: 2}
+	o.Handle(events.NoteOn{Note: c4})
+	o.Handle(events.Generation{Layer: 1, Generation: 7, Stats: life.Stats{Population: 12, Births: 3, Deaths: 2, Density: 0.25}})
+	chord := music.Chord{Root: 9, Quality: music.Minor, Keys: []music.Key{48, 51, 55}}
+	o.Handle(events.Chord{Layer: 0, Channel: 1, Chord: chord})
+
+	for _, want := range []Message{
+		{"/note", []any{int32(2), int32(60), int32(96)}},
+		{"/stats", []any{int32(1), int32(7), int32(12), int32(3), int32(2), float32(0.25)}},
+		{"/chord", []any{int32(0), int32(1), "A", "minor", int32(69), int32(72), int32(76)}},
+	} {
+		enc, _ := want.AppendBinary(nil)
+		if got := receive(); !bytes.Equal(got, enc) {
+			t.Errorf("received %q, want %q", got, enc)
+		}
+	}
+
+	start := time.Unix(1000, 0)
+	o.KeepTime(music.NewClock(), start)
+	o.Handle(events.NoteOff{Note: c4})
+	got := receive()
+	if !bytes.HasPrefix(got, []byte("#bundle\x00")) {
+		t.Fatalf("received %q, want a bundle", got)
+	}
+	// C4 stops a quarter note, half a second at 120 bpm, after the start
+	if tag, want := binary.BigEndian.Uint64(got[8:]), timetag(start.Add(time.Second/2)); tag != want {
+		t.Errorf("bundle time tag %#x, want %#x", tag, want)
+	}
+	off, _ := Message{"/note", []any{int32(2), int32(60), int32(0)}}.AppendBinary(nil)
+	if !bytes.Equal(got[20:], off) || binary.BigEndian.Uint32(got[16:]) != uint32(len(off)) {
+		t.Errorf("bundle holds %q, want %q", got[16:], off)
+	}
+	if err := o.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/config"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/live"
+)
+
+// ports runs the "ports" subcommand: it lists the MIDI output ports
+// --midi-port can choose from and the input ports --midi-in can, with the
+// index that selects each one
+func ports(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("ports: unexpected arguments %v", args)
+	}
+	names, err := live.Ports()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Println("No MIDI output ports")
+	} else {
+		fmt.Println("Output ports:")
+	}
+	for i, name := range names {
+		fmt.Printf("%d: %s\n", i, name)
+	}
+	ins, err := live.InPorts()
+	if err != nil {
+		return err
+	}
+	if len(ins) == 0 {
+		fmt.Println("No MIDI input ports")
+		return nil
+	}
+	fmt.Println("Input ports:")
+	for i, name := range ins {
+		fmt.Printf("%d: %s\n", i, name)
+	}
+	return nil
+}
+
+// checkPorts reports the ports --midi-port and --midi-in choose that cannot
+// be found, so that they are reported with any other problems before the
+// performance starts
+func checkPorts(cfg *config.Config) config.Problems {
+	var problems config.Problems
+	if cfg.MIDIPort != "" && !cfg.Silent {
+		if _, err := live.Find(cfg.MIDIPort); err != nil {
+			problems = append(problems, cfg.Problem("midi.port", "%w", err))
+		}
+	}
+	if cfg.MIDIIn != "" {
+		if _, err := live.FindInput(cfg.MIDIIn); err != nil {
+			problems = append(problems, cfg.Problem("midi.in", "%w", err))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2/drivers"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/config"
+)
+
+// fakeOut is an output port of fakeDriver
+type fakeOut struct {
+	name string
+	open bool
+}
+
+func (o *fakeOut) Open() error             { o.open = true; return nil }
+func (o *fakeOut) Close() error            { o.open = false; return nil }
+func (o *fakeOut) IsOpen() bool            { return o.open }
+func (o *fakeOut) Number() int             { return -1 }
+func (o *fakeOut) String() string          { return o.name }
+func (o *fakeOut) Underlying() interface{} { return nil }
+func (o *fakeOut) Send(data []byte) error  { return nil }
+
+// fakeDriver stands in for the RtMidi driver of a -tags rtmidi build
+type fakeDriver struct{ outs []drivers.Out }
+
+func (d *fakeDriver) Ins() ([]drivers.In, error)   { return nil, nil }
+func (d *fakeDriver) Outs() ([]drivers.Out, error) { return d.outs, nil }
+func (d *fakeDriver) String() string               { return "fake" }
+func (d *fakeDriver) Close() error                 { return nil }
+
+func TestMIDIPort(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cfg, err := config.Parse("conways-steinway", []string{"--midi-port", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := checkPorts(cfg); len(problems) != 1 || !strings.Contains(problems.Error(), "-tags rtmidi") {
+		t.Fatalf("without a driver --midi-port reported %v, want the build tag that adds one", problems)
+	}
+
+	through, disklavier := &fakeOut{name: "Midi Through Port-0"}, &fakeOut{name: "Disklavier MIDI 1"}
+	d := &fakeDriver{outs: []drivers.Out{through, disklavier}}
+	drivers.Register(d)
+	defer delete(drivers.REGISTRY, d.String())
+
+	for _, selector := range []string{"1", "Disklavier MIDI 1", "disklavier"} {
+		cfg, err := config.Parse("conways-steinway", []string{"--midi-port", selector})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if problems := checkPorts(cfg); len(problems) > 0 {
+			t.Fatalf("--midi-port %s: %v", selector, problems)
+		}
+		outputs, err := openPorts(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(outputs) != 1 || outputs[0].Name() != disklavier.name || !disklavier.open || through.open {
+			t.Errorf("--midi-port %s opened %v, want the Disklavier alone", selector, outputs)
+		}
+		for _, o := range outputs {
+			o.Close()
+		}
+	}
+
+	cfg, err = config.Parse("conways-steinway", []string{"--midi-port", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := checkPorts(cfg); len(problems) != 1 {
+		t.Errorf("--midi-port 2 of 2 ports reported %v, want a problem", problems)
+	}
+}
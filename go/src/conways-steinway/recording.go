@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// recording collects the notes of a run for the file outputs, one track for
+// each MIDI channel the layers play on
+type recording struct {
+	notes    map[int][]music.NoteEvent     // finished notes by channel
+	controls map[int][]music.Control       // control changes by channel
+	programs map[int]int                   // program selected on each channel at the start
+	changes  map[int][]music.ProgramChange // programs selected later, by channel
+}
+
+func (f *recording) handle(e events.Event) {
+	switch e := e.(type) {
+	case events.NoteOff:
+		if f.notes == nil {
+			f.notes = make(map[int][]music.NoteEvent)
+		}
+		f.notes[e.Note.Channel] = append(f.notes[e.Note.Channel], e.Note)
+	case events.Control:
+		if f.controls == nil {
+			f.controls = make(map[int][]music.Control)
+		}
+		f.controls[e.Control.Channel] = append(f.controls[e.Control.Channel], e.Control)
+	case events.Program:
+		if e.Start > 0 {
+			if f.changes == nil {
+				f.changes = make(map[int][]music.ProgramChange)
+			}
+			f.changes[e.Channel] = append(f.changes[e.Channel], music.ProgramChange{Program: e.Program, Tick: e.Start, Channel: e.Channel})
+			return
+		}
+		if f.programs == nil {
+			f.programs = make(map[int]int)
+		}
+		f.programs[e.Channel] = e.Program
+	}
+}
+
+// count returns the number of notes collected
+func (f *recording) count() int {
+	n := 0
+	for _, notes := range f.notes {
+		n += len(notes)
+	}
+	return n
+}
+
+// save writes the notes to path with write, one of the file formats such as
+// WriteSMF, naming each track after the layer or board row playing on its
+// channel
+func (f *recording) save(path string, clock music.Clock, layers []*layer, write writer) error {
+	var tracks []music.Track
+	seen := make(map[int]bool)
+	for _, l := range layers {
+		for _, p := range l.parts {
+			if seen[p.channel] {
+				continue
+			}
+			seen[p.channel] = true
+			name := fmt.Sprintf("Channel %d", p.channel)
+			switch {
+			case p.drums:
+				name = fmt.Sprintf("Drums (channel %d)", p.channel)
+			case len(layers) > 1:
+				name = fmt.Sprintf("Layer %d (channel %d)", l.index+1, p.channel)
+			case len(l.cfg.Channels) > 1:
+				name = fmt.Sprintf("Row %d (channel %d)", p.row, p.channel)
+			}
+			tracks = append(tracks, music.Track{
+				Name: name, Channel: p.channel, Program: f.programs[p.channel],
+				Notes: f.notes[p.channel], Controls: f.controls[p.channel], Changes: f.changes[p.channel],
+			})
+		}
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(out, "Conway's Steinway", clock, tracks); err != nil {
+		out.Close()
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return out.Close()
+}
+
+// writer writes tracks to a file format
+type writer func(w io.Writer, title string, clock music.Clock, tracks []music.Track) error
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/config"
+)
+
+// renderFormats are the file outputs the "render" subcommand writes, with the
+// configured path each writes to by default
+var renderFormats = map[string]struct {
+	output config.Output
+	path   func(cfg *config.Config) *string
+}{
+	"midi":     {config.OutputMIDIFile, func(cfg *config.Config) *string { return &cfg.MIDIPath }},
+	"musicxml": {config.OutputMusicXML, func(cfg *config.Config) *string { return &cfg.MusicXMLPath }},
+	"lilypond": {config.OutputLilyPond, func(cfg *config.Config) *string { return &cfg.LilyPondPath }},
+	"abc":      {config.OutputABC, func(cfg *config.Config) *string { return &cfg.ABCPath }},
+	"wav":      {config.OutputWAV, func(cfg *config.Config) *string { return &cfg.WAVPath }},
+}
+
+// render runs the "render" subcommand: it plays the configured performance
+// straight to a file, as --output does, in the format given by -format
+func render(cfg *config.Config, args []string) error {
+	fset := flag.NewFlagSet("render", flag.ContinueOnError)
+	format := fset.String("format", "midi", "file format: midi, musicxml, lilypond, abc or wav")
+	out := fset.String("out", "", "file written (default the format's --midi-path, --musicxml-path, --lilypond-path, --abc-path or --wav-path)")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() > 0 {
+		return fmt.Errorf("render: unexpected arguments %v", fset.Args())
+	}
+	f, ok := renderFormats[*format]
+	if !ok {
+		return fmt.Errorf("render: invalid format %q (want midi, musicxml, lilypond, abc or wav)", *format)
+	}
+	renderCfg := *cfg
+	renderCfg.Output = f.output
+	if *out != "" {
+		*f.path(&renderCfg) = *out
+	}
+	return perform(&renderCfg)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+)
+
+// rewindHelp says how to rewind, for input that is not a rewind command
+const rewindHelp = `Type "r" and Enter to rewind every generation kept by --history, or "r 16" for the last 16`
+
+// readRewinds reads rewind commands typed on r while the performance plays
+// live, sending how many generations each asks to go back: "r" or "rewind"
+// alone for every generation kept, which is sent as 0, or followed by a
+// count. Commands typed while one is waiting to be played are dropped.
+func readRewinds(r io.Reader) <-chan int {
+	rewinds := make(chan int, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 0 || len(fields) > 2 || fields[0] != "r" && fields[0] != "rewind" {
+				fmt.Fprintln(status, rewindHelp)
+				continue
+			}
+			n := 0
+			if len(fields) == 2 {
+				var err error
+				if n, err = strconv.Atoi(fields[1]); err != nil || n < 1 {
+					fmt.Fprintln(status, rewindHelp)
+					continue
+				}
+			}
+			select {
+			case rewinds <- n:
+			default:
+			}
+		}
+	}()
+	return rewinds
+}
+
+// rewind steps the layer's board back over up to n of the generations it
+// has played, or every one its history keeps when n is 0, so that they are
+// played again from the next tick, and publishes how far it went. Boards
+// without a history are left alone.
+func (l *layer) rewind(bus *events.Bus, n int) {
+	b, ok := l.board.(interface{ StepBack() bool })
+	if !ok {
+		return
+	}
+	back := 0
+	for (n == 0 || back < n) && l.generation > 0 && b.StepBack() {
+		back++
+		l.generation--
+	}
+	if back == 0 {
+		return
+	}
+	// The generations played again are not a cycle of the board
+	l.cycles.Reset()
+	l.watchdog.Reset()
+	bus.Publish(events.Rewind{Layer: l.index, Generation: l.generation + 1, Back: back})
+}
@@ -0,0 +1,35 @@
+package rle
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+//go:embed patterns/*.rle
+var builtins embed.FS
+
+// Builtins returns the names of the patterns shipped with the program
+func Builtins() []string {
+	entries, _ := fs.ReadDir(builtins, "patterns")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".rle"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Builtin returns the shipped pattern with the given name, e.g. "gosper-gun"
+func Builtin(name string) (*life.Pattern, error) {
+	f, err := builtins.Open("patterns/" + strings.ToLower(strings.TrimSpace(name)) + ".rle")
+	if err != nil {
+		return nil, fmt.Errorf("no built-in pattern %q (have %s)", name, strings.Join(Builtins(), ", "))
+	}
+	defer f.Close()
+	return Parse(f)
+}
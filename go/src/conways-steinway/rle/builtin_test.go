@@ -0,0 +1,57 @@
+package rle
+
+import (
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+func TestBuiltinsParse(t *testing.T) {
+	names := Builtins()
+	if len(names) < 6 {
+		t.Fatalf("Builtins() = %v, want the six classic patterns", names)
+	}
+	for _, name := range names {
+		p, err := Builtin(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		for _, c := range p.Cells {
+			if c.X >= p.Width || c.Y >= p.Height {
+				t.Errorf("%s: cell %v outside its %dx%d header", name, c, p.Width, p.Height)
+			}
+		}
+	}
+	if _, err := Builtin("no-such-pattern"); err == nil {
+		t.Fatal("Builtin accepted an unknown name")
+	}
+}
+
+func TestBuiltinPopulations(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		generations int
+		population  int
+	}{
+		{"glider", 4, 5},
+		{"lwss", 4, 9},
+		{"pulsar", 3, 48},
+		{"gosper-gun", 30, 36 + 5},
+		{"R-Pentomino", 1103, 116},
+	} {
+		p, err := Builtin(tc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := life.NewHashLife(life.Conway)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Place(h, 0, 0)
+		h.Advance(tc.generations)
+		if got := h.Population(); got != tc.population {
+			t.Errorf("%s: population after %d generations = %d, want %d", tc.name, tc.generations, got, tc.population)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package rle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// ParseCells reads the plaintext .cells format used on the LifeWiki: one line
+// per row with 'O' for a live cell and '.' for a dead one, and lines starting
+// with '!' as comments. Rows may be shorter than the widest one.
+func ParseCells(r io.Reader) (*life.Pattern, error) {
+	p := &life.Pattern{}
+	scanner := bufio.NewScanner(r)
+	line, y := 0, 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(text, "!") {
+			continue
+		}
+		for x, ch := range text {
+			switch ch {
+			case 'O', 'o', '*':
+				p.Cells = append(p.Cells, life.Coord{X: x, Y: y})
+			case '.':
+			default:
+				return nil, fmt.Errorf("cells: line %d: unsupported cell %q", line, ch)
+			}
+		}
+		p.Width = max(p.Width, len(text))
+		y++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// Trailing blank lines are not rows of dead cells
+	for y > 0 && (len(p.Cells) == 0 || p.Cells[len(p.Cells)-1].Y < y-1) {
+		y--
+	}
+	p.Height = y
+	return p, nil
+}
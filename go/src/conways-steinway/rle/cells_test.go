@@ -0,0 +1,38 @@
+package rle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+func TestParseCells(t *testing.T) {
+	p, err := Read(strings.NewReader(`!Name: Glider
+!The smallest spaceship.
+.O
+..O
+OOO
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCells(t, p, 3, 3, gliderCells)
+	if p.Rule != nil {
+		t.Fatalf("Rule = %v, want none", p.Rule)
+	}
+}
+
+func TestParseCellsWithoutComments(t *testing.T) {
+	p, err := Read(strings.NewReader("OO\n\n..O\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCells(t, p, 3, 3, []life.Coord{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 2}})
+}
+
+func TestParseCellsErrors(t *testing.T) {
+	if _, err := ParseCells(strings.NewReader("!comment\n.O.\n.X.\n")); err == nil {
+		t.Fatal("ParseCells accepted an unknown cell")
+	}
+}
@@ -0,0 +1,23 @@
+package rle
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// parseGrid reads a whole board saved in life.Grid's JSON form. The pattern
+// is the size of the board, with its cells where they were on it, and takes
+// the board's rule.
+func parseGrid(data []byte) (*life.Pattern, error) {
+	var g life.Grid
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	p := &life.Pattern{Width: g.Width, Height: g.Height, Rule: &g.Rule}
+	for x, y := range g.LiveCells() {
+		p.Cells = append(p.Cells, life.Coord{X: x, Y: y})
+	}
+	return p, nil
+}
@@ -0,0 +1,122 @@
+package rle
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// Read reads a pattern in any of the supported formats, chosen by the file's
+// first line: "#Life 1.05" and "#Life 1.06" headers select those formats, a
+// '!' comment or a row of '.' and 'O' cells selects plaintext, a '{' starts a
+// whole board saved as JSON, and anything else is read as RLE.
+func Read(r io.Reader) (*life.Pattern, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	first, _, _ := bytes.Cut(bytes.TrimLeft(data, " \t\r\n"), []byte("\n"))
+	first = bytes.TrimSpace(first)
+	switch {
+	case bytes.HasPrefix(first, []byte("#Life 1.05")):
+		return parseLife105(bytes.NewReader(data))
+	case bytes.HasPrefix(first, []byte("#Life 1.06")):
+		return parseLife106(bytes.NewReader(data))
+	case bytes.HasPrefix(first, []byte("!")), len(first) > 0 && len(bytes.Trim(first, ".O")) == 0:
+		return ParseCells(bytes.NewReader(data))
+	case bytes.HasPrefix(first, []byte("{")):
+		return parseGrid(data)
+	}
+	return Parse(bytes.NewReader(data))
+}
+
+// parseLife105 reads the Life 1.05 format: #P lines giving the offset of a
+// block of rows drawn with '*' (alive) and '.' (dead), and an optional #R
+// rule in S/B notation, #N selecting standard Life
+func parseLife105(r io.Reader) (*life.Pattern, error) {
+	var rule *life.Rule
+	var cells []life.Coord
+	x0, y := 0, 0
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "":
+			continue
+		case strings.HasPrefix(text, "#P"):
+			fields := strings.Fields(text[2:])
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("life 1.05: line %d: #P needs an x and a y", line)
+			}
+			x, errX := strconv.Atoi(fields[0])
+			yy, errY := strconv.Atoi(fields[1])
+			if errX != nil || errY != nil {
+				return nil, fmt.Errorf("life 1.05: line %d: bad #P offset %q", line, text)
+			}
+			x0, y = x, yy
+		case strings.HasPrefix(text, "#N"):
+			conway := life.Conway
+			rule = &conway
+		case strings.HasPrefix(text, "#R"):
+			r, err := life.ParseRule(strings.TrimSpace(text[2:]))
+			if err != nil {
+				return nil, fmt.Errorf("life 1.05: line %d: %w", line, err)
+			}
+			rule = &r
+		case strings.HasPrefix(text, "#"):
+			continue
+		default:
+			for i, ch := range text {
+				switch ch {
+				case '*':
+					cells = append(cells, life.Coord{X: x0 + i, Y: y})
+				case '.':
+				default:
+					return nil, fmt.Errorf("life 1.05: line %d: unsupported cell %q", line, ch)
+				}
+			}
+			y++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	pattern := life.NewPattern(cells)
+	pattern.Rule = rule
+	return pattern, nil
+}
+
+// parseLife106 reads the Life 1.06 format: one "x y" pair per live cell
+func parseLife106(r io.Reader) (*life.Pattern, error) {
+	var cells []life.Coord
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("life 1.06: line %d: want \"x y\", got %q", line, text)
+		}
+		x, errX := strconv.Atoi(fields[0])
+		y, errY := strconv.Atoi(fields[1])
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("life 1.06: line %d: bad coordinates %q", line, text)
+		}
+		cells = append(cells, life.Coord{X: x, Y: y})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return life.NewPattern(cells), nil
+}
@@ -0,0 +1,96 @@
+package rle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// gliderCells is the glider of the RLE tests, in reading order
+var gliderCells = []life.Coord{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}}
+
+func assertCells(t *testing.T, p *life.Pattern, width, height int, want []life.Coord) {
+	t.Helper()
+	if p.Width != width || p.Height != height {
+		t.Fatalf("size = %dx%d, want %dx%d", p.Width, p.Height, width, height)
+	}
+	if len(p.Cells) != len(want) {
+		t.Fatalf("Cells = %v, want %v", p.Cells, want)
+	}
+	for i := range want {
+		if p.Cells[i] != want[i] {
+			t.Fatalf("Cells = %v, want %v", p.Cells, want)
+		}
+	}
+}
+
+func TestReadLife105(t *testing.T) {
+	p, err := Read(strings.NewReader(`#Life 1.05
+#D Glider
+#R 23/3
+#P -1 -1
+.*.
+..*
+***
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCells(t, p, 3, 3, gliderCells)
+	if p.Rule == nil || *p.Rule != life.Conway {
+		t.Fatalf("Rule = %v, want B3/S23", p.Rule)
+	}
+}
+
+func TestReadLife105Blocks(t *testing.T) {
+	p, err := Read(strings.NewReader("#Life 1.05\n#P 0 0\n*\n#P 4 2\n**\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCells(t, p, 6, 3, []life.Coord{{X: 0, Y: 0}, {X: 4, Y: 2}, {X: 5, Y: 2}})
+	if p.Rule != nil {
+		t.Fatalf("Rule = %v, want none", p.Rule)
+	}
+}
+
+func TestReadLife106(t *testing.T) {
+	p, err := Read(strings.NewReader("#Life 1.06\n0 -1\n1 0\n-1 1\n0 1\n1 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCells(t, p, 3, 3, gliderCells)
+}
+
+func TestReadDetectsRLE(t *testing.T) {
+	p, err := Read(strings.NewReader(glider))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCells(t, p, 3, 3, gliderCells)
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, text := range []string{
+		"#Life 1.05\n#P 1\n*\n",
+		"#Life 1.05\n*x*\n",
+		"#Life 1.06\n1 2 3\n",
+		"#Life 1.06\na b\n",
+	} {
+		if _, err := Read(strings.NewReader(text)); err == nil {
+			t.Errorf("Read(%q) succeeded, want an error", text)
+		}
+	}
+}
+
+func TestReadJSONBoard(t *testing.T) {
+	p, err := Read(strings.NewReader(`{"width":5,"height":4,"rule":"B36/S23","cells":[[1,0],[2,1],[0,2],[1,2],[2,2]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The board keeps its size rather than being shrunk to the glider
+	assertCells(t, p, 5, 4, gliderCells)
+	if p.Rule == nil || p.Rule.String() != "B36/S23" {
+		t.Fatalf("Rule = %v, want B36/S23", p.Rule)
+	}
+}
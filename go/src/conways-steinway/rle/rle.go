@@ -0,0 +1,123 @@
+// Package rle reads patterns in the Run Length Encoded format used by most
+// Life software and pattern collections, in the older Life 1.05 and 1.06
+// formats still found in pattern archives, and in the LifeWiki's plaintext
+// .cells format.
+package rle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// Parse reads an RLE pattern: optional # comment lines, a header line such as
+// "x = 3, y = 3, rule = B3/S23", then runs of b (dead) and o (alive) cells
+// with $ ending each row and ! ending the pattern.
+func Parse(r io.Reader) (*life.Pattern, error) {
+	var p *life.Pattern
+	x, y := 0, 0
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if p == nil {
+			var err error
+			if p, err = parseHeader(text); err != nil {
+				return nil, fmt.Errorf("rle: line %d: %w", line, err)
+			}
+			continue
+		}
+		run := 0
+		for _, ch := range text {
+			switch {
+			case ch >= '0' && ch <= '9':
+				run = run*10 + int(ch-'0')
+				continue
+			case ch == ' ' || ch == '\t':
+				continue
+			}
+			n := max(run, 1)
+			run = 0
+			switch ch {
+			case 'b', '.':
+				x += n
+			case 'o', 'A':
+				for i := 0; i < n; i++ {
+					p.Cells = append(p.Cells, life.Coord{X: x + i, Y: y})
+				}
+				x += n
+			case '$':
+				x, y = 0, y+n
+			case '!':
+				return p, nil
+			default:
+				return nil, fmt.Errorf("rle: line %d: unsupported cell %q", line, ch)
+			}
+		}
+		if run != 0 {
+			return nil, fmt.Errorf("rle: line %d: run count not followed by a cell", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, fmt.Errorf("rle: missing header line")
+	}
+	return p, nil
+}
+
+// parseHeader parses the "x = m, y = n, rule = abc" line
+func parseHeader(text string) (*life.Pattern, error) {
+	p := &life.Pattern{}
+	seen := map[string]bool{}
+	// The rule always comes last and may itself contain commas, so it takes
+	// the rest of the line
+	fields := strings.Split(text, ",")
+	for i, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("header field %q is not key = value", strings.TrimSpace(field))
+		}
+		if strings.TrimSpace(key) == "rule" {
+			value = strings.Join(append([]string{value}, fields[i+1:]...), ",")
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		seen[key] = true
+		switch key {
+		case "x", "y":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("header %s = %q is not a size", key, value)
+			}
+			if key == "x" {
+				p.Width = n
+			} else {
+				p.Height = n
+			}
+		case "rule":
+			// Golly appends the bounded grid it was saved from, e.g. "B3/S23:T88,40"
+			value, _, _ = strings.Cut(value, ":")
+			rule, err := life.ParseRule(value)
+			if err != nil {
+				return nil, err
+			}
+			p.Rule = &rule
+		}
+		if key == "rule" {
+			break
+		}
+	}
+	if !seen["x"] || !seen["y"] {
+		return nil, fmt.Errorf("header %q needs both x and y", text)
+	}
+	return p, nil
+}
@@ -0,0 +1,92 @@
+package rle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+const glider = `#N Glider
+#C The smallest spaceship.
+x = 3, y = 3, rule = B3/S23
+bob$2bo$3o!
+`
+
+const gosper = `#N Gosper glider gun
+x = 36, y = 9, rule = B3/S23
+24bo$22bobo$12b2o6b2o12b2o$11bo3bo4b2o12b2o$2o8bo5bo3b2o$2o8bo3bob2o4b
+obo$10bo5bo7bo$11bo3bo$12b2o!
+`
+
+func TestParseGlider(t *testing.T) {
+	p, err := Parse(strings.NewReader(glider))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Width != 3 || p.Height != 3 {
+		t.Fatalf("size = %dx%d, want 3x3", p.Width, p.Height)
+	}
+	if p.Rule == nil || *p.Rule != life.Conway {
+		t.Fatalf("Rule = %v, want B3/S23", p.Rule)
+	}
+	want := []life.Coord{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}}
+	if len(p.Cells) != len(want) {
+		t.Fatalf("Cells = %v, want %v", p.Cells, want)
+	}
+	for i := range want {
+		if p.Cells[i] != want[i] {
+			t.Fatalf("Cells = %v, want %v", p.Cells, want)
+		}
+	}
+}
+
+func TestParseGosperGunAcrossLines(t *testing.T) {
+	p, err := Parse(strings.NewReader(gosper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Cells) != 36 {
+		t.Fatalf("population = %d, want 36", len(p.Cells))
+	}
+
+	g := life.NewEmptyGrid(40, 20)
+	p.Place(g, 2, 2)
+	life.Advance(g, 30)
+	if s := life.StatsOf(g); s.Population != 36+5 {
+		t.Fatalf("population after one period = %d, want the gun plus a glider", s.Population)
+	}
+}
+
+func TestParseHeaderVariants(t *testing.T) {
+	p, err := Parse(strings.NewReader("x=2,y=1,rule=23/36:T88,40\n2o!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Rule == nil || p.Rule.String() != "B36/S23" {
+		t.Fatalf("Rule = %v, want B36/S23", p.Rule)
+	}
+
+	p, err = Parse(strings.NewReader("x = 1, y = 1\no!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Rule != nil {
+		t.Fatalf("Rule = %v, want none", p.Rule)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"#C only a comment\n",
+		"y = 3\nbo!",
+		"x = 3, y = 3, rule = Q\nbo!",
+		"x = 3, y = 3\nbzo!",
+		"x = 3, y = 3\nbo3",
+	} {
+		if _, err := Parse(strings.NewReader(text)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", text)
+		}
+	}
+}
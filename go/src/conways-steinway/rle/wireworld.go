@@ -0,0 +1,50 @@
+package rle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// ParseWireworld reads a Wireworld circuit drawn one line per row: '#' for a
+// conductor, '@' for an electron head, '~' for a tail and '.' or a space for
+// an empty cell. Lines starting with '!' are comments, and rows may be
+// shorter than the widest one.
+func ParseWireworld(r io.Reader) (*life.Wireworld, error) {
+	var rows [][]life.WireState
+	width := 0
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(text, "!") {
+			continue
+		}
+		row := make([]life.WireState, 0, len(text))
+		for _, ch := range text {
+			s, ok := life.ParseWireState(ch)
+			if !ok {
+				return nil, fmt.Errorf("wireworld: line %d: unsupported cell %q", line, ch)
+			}
+			row = append(row, s)
+		}
+		rows = append(rows, row)
+		width = max(width, len(row))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// Trailing blank lines are not rows of empty cells
+	for len(rows) > 0 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	w := life.NewWireworld(width, len(rows))
+	for y, row := range rows {
+		copy(w.Cells[y*width:], row)
+	}
+	return w, nil
+}
@@ -0,0 +1,36 @@
+package rle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+func TestParseWireworld(t *testing.T) {
+	w, err := ParseWireworld(strings.NewReader("!A clock\n.~@.\n#  #\n.##\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Width != 4 || w.Height != 3 {
+		t.Fatalf("size = %dx%d, want 4x3", w.Width, w.Height)
+	}
+	want := map[life.Coord]life.WireState{
+		{X: 1, Y: 0}: life.WireTail, {X: 2, Y: 0}: life.WireHead,
+		{X: 0, Y: 1}: life.WireConductor, {X: 3, Y: 1}: life.WireConductor,
+		{X: 1, Y: 2}: life.WireConductor, {X: 2, Y: 2}: life.WireConductor,
+	}
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if got := w.State(x, y); got != want[life.Coord{X: x, Y: y}] {
+				t.Fatalf("state at %d,%d = %d, want %d", x, y, got, want[life.Coord{X: x, Y: y}])
+			}
+		}
+	}
+}
+
+func TestParseWireworldErrors(t *testing.T) {
+	if _, err := ParseWireworld(strings.NewReader("##\n#X\n")); err == nil {
+		t.Fatal("ParseWireworld accepted an unknown cell")
+	}
+}
@@ -0,0 +1,107 @@
+package ruletable
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// neighbourOffsets lists the neighbours in the order Rule.Next takes them
+var neighbourOffsets = map[int][]life.Coord{
+	8: {{X: 0, Y: -1}, {X: 1, Y: -1}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}, {X: -1, Y: 1}, {X: -1, Y: 0}, {X: -1, Y: -1}},
+	4: {{X: 0, Y: -1}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: -1, Y: 0}},
+}
+
+// Grid is a fixed-size board stepped by a Rule. Every state but 0 counts as
+// alive for the Board interface.
+type Grid struct {
+	Width, Height int
+	Rule          *Rule
+	Wrap          bool    // join opposite borders; otherwise cells beyond them are in state 0
+	States        []uint8 // cell states in reading order
+	Generation    int
+
+	next []uint8
+}
+
+// NewGrid returns a width by height grid of state 0 cells run by rule
+func NewGrid(rule *Rule, width, height int) *Grid {
+	return &Grid{
+		Width:  width,
+		Height: height,
+		Rule:   rule,
+		States: make([]uint8, width*height),
+		next:   make([]uint8, width*height),
+	}
+}
+
+// Size returns the width and height of the grid
+func (g *Grid) Size() (width, height int) { return g.Width, g.Height }
+
+// State returns the state of the cell at (x, y); cells off the grid are in
+// state 0
+func (g *Grid) State(x, y int) uint8 {
+	if x < 0 || x >= g.Width || y < 0 || y >= g.Height {
+		return 0
+	}
+	return g.States[x+y*g.Width]
+}
+
+// SetState sets the state of the cell at (x, y); coordinates off the grid and
+// states the rule does not have are ignored
+func (g *Grid) SetState(x, y int, s uint8) {
+	if x >= 0 && x < g.Width && y >= 0 && y < g.Height && int(s) < g.Rule.States {
+		g.States[x+y*g.Width] = s
+	}
+}
+
+// Alive reports whether the cell at (x, y) is in any state but 0
+func (g *Grid) Alive(x, y int) bool { return g.State(x, y) != 0 }
+
+// SetAlive puts the cell at (x, y) in state 1, or 0
+func (g *Grid) SetAlive(x, y int, alive bool) {
+	var s uint8
+	if alive {
+		s = 1
+	}
+	g.SetState(x, y, s)
+}
+
+// Step advances the grid by one generation
+func (g *Grid) Step() {
+	offsets := neighbourOffsets[g.Rule.Neighbours]
+	n := make([]uint8, len(offsets))
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			for i, o := range offsets {
+				nx, ny := x+o.X, y+o.Y
+				if g.Wrap {
+					nx, ny = (nx+g.Width)%g.Width, (ny+g.Height)%g.Height
+				}
+				n[i] = g.State(nx, ny)
+			}
+			g.next[x+y*g.Width] = g.Rule.Next(g.States[x+y*g.Width], n)
+		}
+	}
+	g.States, g.next = g.next, g.States
+	g.Generation++
+}
+
+// stateChars draws the states: '.' for 0, '#' for 1, then digits and letters
+const stateChars = ".#23456789abcdefghijklmnopqrstuvwxyz"
+
+// Print writes the grid to w, one character per cell
+func (g *Grid) Print(w io.Writer) {
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			s := int(g.States[x+y*g.Width])
+			if s < len(stateChars) {
+				fmt.Fprintf(w, "%c", stateChars[s])
+			} else {
+				fmt.Fprint(w, "?")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
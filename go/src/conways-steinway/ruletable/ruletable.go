@@ -0,0 +1,116 @@
+// Package ruletable runs cellular automata described by Golly .rule files,
+// whose @TABLE or @TREE section gives the next state of a cell for every
+// combination of its own and its neighbours' states.
+package ruletable
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Rule is a transition function read from a .rule file
+type Rule struct {
+	Name       string
+	States     int // number of cell states, 0 being dead
+	Neighbours int // 8 for the Moore neighbourhood, 4 for von Neumann
+
+	next  func(c uint8, n []uint8) uint8
+	cache map[[9]uint8]uint8
+}
+
+// Next returns the next state of a cell in state c whose neighbours have the
+// states n, in the order N, NE, E, SE, S, SW, W, NW (or N, E, S, W)
+func (r *Rule) Next(c uint8, n []uint8) uint8 {
+	var key [9]uint8
+	key[0] = c
+	copy(key[1:], n)
+	if s, ok := r.cache[key]; ok {
+		return s
+	}
+	s := r.next(c, n)
+	r.cache[key] = s
+	return s
+}
+
+// Load reads the .rule file at path
+func Load(path string) (*Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Parse reads a .rule file: an @RULE line naming the rule followed by an
+// @TABLE or @TREE section. Other sections, such as @COLORS and @ICONS, are
+// skipped.
+func Parse(r io.Reader) (*Rule, error) {
+	var name, kind, section string
+	var body []line
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "@") {
+			fields := strings.Fields(text)
+			section = fields[0]
+			switch section {
+			case "@RULE":
+				if len(fields) > 1 {
+					name = fields[1]
+				}
+			case "@TABLE", "@TREE":
+				if kind != "" {
+					return nil, fmt.Errorf("line %d: %s after %s", n, section, kind)
+				}
+				kind = section
+			}
+			continue
+		}
+		if section == kind && kind != "" {
+			body = append(body, line{n, text})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var rule *Rule
+	var err error
+	switch kind {
+	case "@TABLE":
+		rule, err = parseTable(body)
+	case "@TREE":
+		rule, err = parseTree(body)
+	default:
+		return nil, fmt.Errorf("no @TABLE or @TREE section")
+	}
+	if err != nil {
+		return nil, err
+	}
+	rule.Name = name
+	rule.cache = make(map[[9]uint8]uint8)
+	return rule, nil
+}
+
+// line is a non-blank line of a section, comments removed
+type line struct {
+	n    int
+	text string
+}
@@ -0,0 +1,156 @@
+package ruletable
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+const lifeTable = `@RULE LifeTable
+# Conway's Life as a rule table
+@TABLE
+n_states:2
+neighborhood:Moore
+symmetries:permute
+var a={0,1}
+0,1,1,1,0,0,0,0,0,1
+1,1,1,0,0,0,0,0,0,1
+1,1,1,1,0,0,0,0,0,1
+1,a,a,a,a,a,a,a,a,0
+
+@COLORS
+1 255 255 255
+`
+
+// lifeTree builds Conway's Life as a rule tree: each node below the root
+// only depends on how many live neighbours have been seen so far
+func lifeTree() string {
+	var nodes []string
+	index := map[[2]int]int{}
+	var node func(level, count int) int
+	node = func(level, count int) int {
+		if i, ok := index[[2]int{level, count}]; ok {
+			return i
+		}
+		var line string
+		if level == 1 {
+			line = fmt.Sprintf("1 %d %d", btoi(count == 3), btoi(count == 2 || count == 3))
+		} else {
+			line = fmt.Sprintf("%d %d %d", level, node(level-1, count), node(level-1, count+1))
+		}
+		nodes = append(nodes, line)
+		index[[2]int{level, count}] = len(nodes) - 1
+		return len(nodes) - 1
+	}
+	node(9, 0)
+	return fmt.Sprintf("@RULE LifeTree\n@TREE\nnum_states=2\nnum_neighbors=8\nnum_nodes=%d\n%s\n",
+		len(nodes), strings.Join(nodes, "\n"))
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestLifeAsTableAndTree(t *testing.T) {
+	for name, text := range map[string]string{"table": lifeTable, "tree": lifeTree()} {
+		t.Run(name, func(t *testing.T) {
+			rule, err := Parse(strings.NewReader(text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rule.States != 2 || rule.Neighbours != 8 {
+				t.Fatalf("rule %s has %d states and %d neighbours", rule.Name, rule.States, rule.Neighbours)
+			}
+			want := life.NewGrid(30, 20, rand.New(rand.NewSource(2)), 0.4)
+			want.Edge = life.EdgeWrap
+			got := NewGrid(rule, want.Width, want.Height)
+			got.Wrap = true
+			for y := 0; y < want.Height; y++ {
+				for x := 0; x < want.Width; x++ {
+					got.SetAlive(x, y, want.Alive(x, y))
+				}
+			}
+			for gen := 1; gen <= 30; gen++ {
+				want.Step()
+				got.Step()
+				if life.Hash(got) != want.Hash() {
+					t.Fatalf("generation %d differs from Life", gen)
+				}
+			}
+		})
+	}
+}
+
+func TestTableRotate4(t *testing.T) {
+	rule, err := Parse(strings.NewReader(`@RULE Spread
+@TABLE
+n_states:2
+neighborhood:vonNeumann
+symmetries:rotate4
+0,1,0,0,0,1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGrid(rule, 5, 5)
+	g.SetAlive(2, 2, true)
+	g.Step()
+	var b strings.Builder
+	g.Print(&b)
+	if want := ".....\n..#..\n.###.\n..#..\n.....\n"; b.String() != want {
+		t.Fatalf("after one step:\n%swant\n%s", b.String(), want)
+	}
+}
+
+func TestTableBindsVariables(t *testing.T) {
+	rule, err := Parse(strings.NewReader(`@RULE Bind
+@TABLE
+n_states:3
+neighborhood:Moore
+symmetries:none
+var a={1,2}
+var b={1,2}
+0,a,0,0,0,a,0,0,0,2
+0,0,0,b,0,0,0,b,0,b
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		n    []uint8
+		want uint8
+	}{
+		{[]uint8{1, 0, 0, 0, 1, 0, 0, 0}, 2}, // N and S agree
+		{[]uint8{1, 0, 0, 0, 2, 0, 0, 0}, 0}, // N and S differ
+		{[]uint8{0, 0, 1, 0, 0, 0, 1, 0}, 1}, // E and W agree: their state
+		{[]uint8{0, 0, 2, 0, 0, 0, 2, 0}, 2},
+		{[]uint8{0, 0, 2, 0, 0, 0, 1, 0}, 0},
+	} {
+		if got := rule.Next(0, tc.n); got != tc.want {
+			t.Errorf("Next(0, %v) = %d, want %d", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{
+		"@RULE Empty\n",
+		"@TABLE\nneighborhood:Moore\n0,1,1,1,0,0,0,0,0,1\n",
+		"@TABLE\nn_states:2\n0,1,1,1,0,0,0,0,1\n",
+		"@TABLE\nn_states:2\n0,1,1,1,0,0,0,0,0,2\n",
+		"@TABLE\nn_states:2\nneighborhood:hexagonal\n",
+		"@TABLE\nn_states:2\nsymmetries:sideways\n",
+		"@TABLE\nn_states:2\nvar a={0,1}\n0,0,0,0,0,0,0,0,0,a\n",
+		"@TREE\nnum_states=2\nnum_neighbors=8\nnum_nodes=1\n1 0 1\n",
+	} {
+		if _, err := Parse(strings.NewReader(text)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", text)
+		}
+	}
+}
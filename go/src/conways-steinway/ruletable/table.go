@@ -0,0 +1,299 @@
+package ruletable
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// transition is one line of a rule table: the states allowed at the centre
+// and at each neighbour, and the state that results
+type transition struct {
+	inputs [][]uint8 // allowed states of the centre, then each neighbour
+	vars   []string  // variable bound at each input, "" for a literal
+	output uint8
+	outVar string // variable whose bound value is the output, if any
+}
+
+// symmetries maps each "symmetries:" name to the neighbour orders it makes
+// equivalent, for Moore (8) and von Neumann (4) neighbourhoods
+func symmetries(name string, neighbours int) ([][]int, bool, error) {
+	rotate := func(p []int, by int) []int {
+		q := make([]int, len(p))
+		for i := range p {
+			q[i] = p[(i+by)%len(p)]
+		}
+		return q
+	}
+	identity := make([]int, neighbours)
+	for i := range identity {
+		identity[i] = i
+	}
+	// Mirror left to right: N and S stay, the rest swap sides
+	reflect := []int{0, 7, 6, 5, 4, 3, 2, 1}
+	step := 2 // a quarter turn moves a Moore neighbour two places
+	if neighbours == 4 {
+		reflect = []int{0, 3, 2, 1}
+		step = 1
+	}
+	rotations := func(by int) [][]int {
+		var ps [][]int
+		for i := 0; i < neighbours; i += by {
+			ps = append(ps, rotate(identity, i))
+		}
+		return ps
+	}
+	withReflections := func(ps [][]int) [][]int {
+		for _, p := range ps[:len(ps):len(ps)] {
+			q := make([]int, len(p))
+			for i := range p {
+				q[i] = p[reflect[i]]
+			}
+			ps = append(ps, q)
+		}
+		return ps
+	}
+	switch name {
+	case "none":
+		return [][]int{identity}, false, nil
+	case "rotate4":
+		return rotations(step), false, nil
+	case "rotate8":
+		if neighbours != 8 {
+			break
+		}
+		return rotations(1), false, nil
+	case "reflect_horizontal":
+		return withReflections([][]int{identity}), false, nil
+	case "rotate4reflect":
+		return withReflections(rotations(step)), false, nil
+	case "rotate8reflect":
+		if neighbours != 8 {
+			break
+		}
+		return withReflections(rotations(1)), false, nil
+	case "permute":
+		return nil, true, nil
+	}
+	return nil, false, fmt.Errorf("unsupported symmetries %q", name)
+}
+
+// parseTable reads the body of an @TABLE section
+func parseTable(body []line) (*Rule, error) {
+	rule := &Rule{Neighbours: 8}
+	symmetry := "none"
+	vars := make(map[string][]uint8)
+	var rows []transition
+
+	for _, l := range body {
+		key, value, isSetting := strings.Cut(l.text, ":")
+		switch {
+		case isSetting:
+			value = strings.TrimSpace(value)
+			switch strings.TrimSpace(key) {
+			case "n_states":
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 2 || n > 256 {
+					return nil, fmt.Errorf("line %d: n_states %q must be 2-256", l.n, value)
+				}
+				rule.States = n
+			case "neighborhood", "neighbourhood":
+				switch value {
+				case "Moore":
+					rule.Neighbours = 8
+				case "vonNeumann":
+					rule.Neighbours = 4
+				default:
+					return nil, fmt.Errorf("line %d: unsupported neighborhood %q", l.n, value)
+				}
+			case "symmetries":
+				symmetry = value
+			default:
+				return nil, fmt.Errorf("line %d: unknown setting %q", l.n, key)
+			}
+		case strings.HasPrefix(l.text, "var "):
+			name, values, err := parseVar(l.text[4:], vars, rule.States)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", l.n, err)
+			}
+			vars[name] = values
+		default:
+			t, err := parseTransition(l.text, vars, rule)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", l.n, err)
+			}
+			rows = append(rows, t)
+		}
+	}
+	if rule.States == 0 {
+		return nil, fmt.Errorf("missing n_states")
+	}
+	perms, permute, err := symmetries(symmetry, rule.Neighbours)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range rows {
+		if permute && t.outVar != "" {
+			return nil, fmt.Errorf("output variable %q cannot be used with permute symmetry", t.outVar)
+		}
+	}
+
+	rule.next = func(c uint8, n []uint8) uint8 {
+		for _, t := range rows {
+			if permute {
+				if t.matchPermuted(c, n) {
+					return t.output
+				}
+				continue
+			}
+			for _, p := range perms {
+				if out, ok := t.match(c, n, p); ok {
+					return out
+				}
+			}
+		}
+		return c
+	}
+	return rule, nil
+}
+
+// parseVar reads "name={a,b,...}", where each item is a state or an earlier
+// variable
+func parseVar(text string, vars map[string][]uint8, states int) (string, []uint8, error) {
+	name, set, ok := strings.Cut(text, "=")
+	name, set = strings.TrimSpace(name), strings.TrimSpace(set)
+	if !ok || name == "" || !strings.HasPrefix(set, "{") || !strings.HasSuffix(set, "}") {
+		return "", nil, fmt.Errorf("variable %q is not name={values}", text)
+	}
+	var values []uint8
+	for _, item := range strings.FieldsFunc(set[1:len(set)-1], func(r rune) bool { return r == ',' || r == ' ' }) {
+		if v, ok := vars[item]; ok {
+			values = append(values, v...)
+			continue
+		}
+		s, err := parseState(item, states)
+		if err != nil {
+			return "", nil, err
+		}
+		values = append(values, s)
+	}
+	return name, values, nil
+}
+
+func parseState(s string, states int) (uint8, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n >= states {
+		return 0, fmt.Errorf("state %q is not between 0 and %d", s, states-1)
+	}
+	return uint8(n), nil
+}
+
+// parseTransition reads a line listing the centre, each neighbour and the
+// result, separated by commas or spaces, or run together when every state is
+// a single digit
+func parseTransition(text string, vars map[string][]uint8, rule *Rule) (transition, error) {
+	want := rule.Neighbours + 2
+	tokens := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	if len(tokens) == 1 && len(text) == want {
+		tokens = strings.Split(text, "")
+	}
+	if len(tokens) != want {
+		return transition{}, fmt.Errorf("transition %q has %d states, want %d", text, len(tokens), want)
+	}
+	t := transition{vars: make([]string, want-1)}
+	for i, tok := range tokens[:want-1] {
+		if v, ok := vars[tok]; ok {
+			t.inputs = append(t.inputs, v)
+			t.vars[i] = tok
+			continue
+		}
+		s, err := parseState(tok, rule.States)
+		if err != nil {
+			return t, err
+		}
+		t.inputs = append(t.inputs, []uint8{s})
+	}
+	out := tokens[want-1]
+	if _, ok := vars[out]; ok {
+		bound := false
+		for _, v := range t.vars {
+			bound = bound || v == out
+		}
+		if !bound {
+			return t, fmt.Errorf("output variable %q is not used as an input", out)
+		}
+		t.outVar = out
+		return t, nil
+	}
+	s, err := parseState(out, rule.States)
+	t.output = s
+	return t, err
+}
+
+// match reports whether the transition applies to centre c with neighbours
+// n taken in the order p, and returns its result
+func (t *transition) match(c uint8, n []uint8, p []int) (uint8, bool) {
+	var bound map[string]uint8
+	check := func(i int, s uint8) bool {
+		if !contains(t.inputs[i], s) {
+			return false
+		}
+		if v := t.vars[i]; v != "" {
+			if bound == nil {
+				bound = make(map[string]uint8, 4)
+			}
+			if b, ok := bound[v]; ok && b != s {
+				return false
+			}
+			bound[v] = s
+		}
+		return true
+	}
+	if !check(0, c) {
+		return 0, false
+	}
+	for i, j := range p {
+		if !check(i+1, n[j]) {
+			return 0, false
+		}
+	}
+	if t.outVar != "" {
+		return bound[t.outVar], true
+	}
+	return t.output, true
+}
+
+// matchPermuted reports whether some ordering of n matches the transition.
+// Variables are not bound under permute symmetry.
+func (t *transition) matchPermuted(c uint8, n []uint8) bool {
+	if !contains(t.inputs[0], c) {
+		return false
+	}
+	used := make([]bool, len(n))
+	var try func(i int) bool
+	try = func(i int) bool {
+		if i == len(n) {
+			return true
+		}
+		for j, s := range n {
+			if !used[j] && contains(t.inputs[i+1], s) {
+				used[j] = true
+				if try(i + 1) {
+					return true
+				}
+				used[j] = false
+			}
+		}
+		return false
+	}
+	return try(0)
+}
+
+func contains(values []uint8, s uint8) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
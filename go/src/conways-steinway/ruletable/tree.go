@@ -0,0 +1,87 @@
+package ruletable
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// treeOrder maps Golly's rule tree variable order (NW, NE, SW, SE, N, W, E,
+// S for Moore; N, W, E, S for von Neumann) onto Next's neighbour order
+var treeOrder = map[int][]int{
+	8: {7, 1, 5, 3, 0, 6, 2, 4},
+	4: {0, 3, 1, 2},
+}
+
+// parseTree reads the body of an @TREE section: num_states, num_neighbors and
+// num_nodes settings followed by the nodes, each a level and, for every
+// state, the index of a lower node or, at level 1, the resulting state. The
+// last node is the root.
+func parseTree(body []line) (*Rule, error) {
+	rule := &Rule{}
+	nodes := 0
+	var tree [][]int
+	var levels []int
+	for _, l := range body {
+		if key, value, ok := strings.Cut(l.text, "="); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s is not a number", l.n, strings.TrimSpace(key))
+			}
+			switch strings.TrimSpace(key) {
+			case "num_states":
+				rule.States = n
+			case "num_neighbors", "num_neighbours":
+				rule.Neighbours = n
+			case "num_nodes":
+				nodes = n
+			default:
+				return nil, fmt.Errorf("line %d: unknown setting %q", l.n, key)
+			}
+			continue
+		}
+		fields := strings.Fields(l.text)
+		values := make([]int, len(fields))
+		for i, f := range fields {
+			v, err := strconv.Atoi(f)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %q is not a number", l.n, f)
+			}
+			values[i] = v
+		}
+		if len(values) != rule.States+1 {
+			return nil, fmt.Errorf("line %d: node has %d entries, want a level and %d", l.n, len(values), rule.States)
+		}
+		level, children := values[0], values[1:]
+		for _, c := range children {
+			if level == 1 && (c < 0 || c >= rule.States) || level > 1 && (c < 0 || c >= len(tree) || levels[c] != level-1) {
+				return nil, fmt.Errorf("line %d: bad entry %d for a level %d node", l.n, c, level)
+			}
+		}
+		tree = append(tree, children)
+		levels = append(levels, level)
+	}
+	if rule.States < 2 || rule.States > 256 {
+		return nil, fmt.Errorf("num_states must be 2-256")
+	}
+	order, ok := treeOrder[rule.Neighbours]
+	if !ok {
+		return nil, fmt.Errorf("num_neighbors must be 4 or 8")
+	}
+	if len(tree) == 0 || len(tree) != nodes {
+		return nil, fmt.Errorf("tree has %d nodes, num_nodes says %d", len(tree), nodes)
+	}
+	root := len(tree) - 1
+	if levels[root] != rule.Neighbours+1 {
+		return nil, fmt.Errorf("root node is level %d, want %d", levels[root], rule.Neighbours+1)
+	}
+
+	rule.next = func(c uint8, n []uint8) uint8 {
+		node := root
+		for _, i := range order {
+			node = tree[node][n[i]]
+		}
+		return uint8(tree[node][c])
+	}
+	return rule, nil
+}
@@ -0,0 +1,940 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math/rand"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/config"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/jsonl"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/live"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/osc"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/synth"
+)
+
+// stream is an output the performance streams to as it is played, such as
+// OSC or JSON Lines
+type stream interface {
+	timekeeper
+	Handle(e events.Event)
+	Close() error
+}
+
+// status is where the run reports what it is doing: standard output, unless
+// the performance streams there
+var status io.Writer = os.Stdout
+
+// run plays the layers' boards generation by generation, stepping each one
+// every so many ticks of a shared clock. The terminal output pauses between
+// ticks so the boards animate, as the osc output does to stream them; the
+// file outputs render them as fast as they can and write the file when the
+// run ends. Notes are humanized and arpeggiated with random numbers from
+// seed.
+func run(cfg *config.Config, layers []*layer, seed int64) error {
+	clock := cfg.Clock()
+	if err := clock.Validate(); err != nil {
+		return err
+	}
+	bus := &events.Bus{}
+	seq := music.NewSequencer()
+	seq.TicksPerStep = clock.TicksPerStep()
+	seq.Swing = clock.Swing
+	seq.Grid = cfg.Quantize
+	rng := rand.New(rand.NewSource(seed))
+	if cfg.HumanizeTiming > 0 || cfg.HumanizeVelocity > 0 {
+		timing := clock.Ticks(time.Duration(cfg.HumanizeTiming) * time.Millisecond)
+		seq.Humanize = music.NewHumanizer(rng, timing, cfg.HumanizeVelocity)
+	}
+	if cfg.ArpeggiateAbove > 0 {
+		seq.Arpeggio = music.NewArpeggiator(rng, cfg.ArpeggiateAbove, cfg.ArpeggioOrder)
+	}
+	seq.Polyphony, seq.Steal = cfg.Polyphony, cfg.Steal
+	seq.Scale, seq.Root = cfg.Scale, (playingKey(cfg, 1)+music.PitchClass(cfg.Transpose%12+12))%12
+	seq.Retrigger = cfg.Retrigger
+	seq.Articulations = cfg.Articulations.Map()
+	bus.Subscribe((&performer{bus: bus, seq: seq, transpose: cfg.Transpose, velocities: cfg.VelocityMap}).handle)
+	loop, err := newLooper(cfg, clock, bus, seq)
+	if err != nil {
+		return err
+	}
+	if loop != nil {
+		bus.Subscribe(loop.handle)
+	}
+	if cfg.Transport && cfg.MIDIIn == "" {
+		return fmt.Errorf("--midi-transport follows the transport on the MIDI input, so it needs --midi-in")
+	}
+	if cfg.Transport && cfg.Link {
+		return fmt.Errorf("--midi-transport and --link both start the performance, so they cannot be combined")
+	}
+	var file *recording
+	var path string
+	var write writer
+	switch cfg.Output {
+	case config.OutputMIDIFile:
+		path, write = cfg.MIDIPath, music.WriteSMF
+	case config.OutputMusicXML:
+		path, write = cfg.MusicXMLPath, music.WriteMusicXML
+	case config.OutputLilyPond:
+		path, write = cfg.LilyPondPath, music.WriteLilyPond
+	case config.OutputABC:
+		path, write = cfg.ABCPath, music.WriteABC
+	case config.OutputWAV:
+		if cfg.SoundFont == "" {
+			return fmt.Errorf("--output wav needs a --soundfont to play the notes on")
+		}
+		font, err := synth.Load(cfg.SoundFont)
+		if err != nil {
+			return err
+		}
+		path = cfg.WAVPath
+		write = func(w io.Writer, _ string, clock music.Clock, tracks []music.Track) error {
+			return synth.Render(w, font, clock, tracks)
+		}
+	}
+	var sender stream
+	switch {
+	case write != nil:
+		file = &recording{}
+		bus.Subscribe(file.handle)
+	case cfg.Output == config.OutputOSC:
+		o, err := osc.Dial(cfg.OSCAddr)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(status, "Sending OSC to %s\n", o.Addr())
+		sender = o
+		bus.Subscribe(sender.Handle)
+	case cfg.Output == config.OutputJSONL:
+		o, err := jsonl.Create(cfg.JSONLPath)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(status, "Writing JSON Lines to %s\n", o.Name())
+		sender = o
+		bus.Subscribe(sender.Handle)
+	default:
+		bus.Subscribe((&terminal{w: os.Stdout, layered: len(layers) > 1, parts: len(cfg.Channels) > 1 || cfg.Drums}).handle)
+	}
+	var tuning *music.Tuning
+	if cfg.Scala != "" {
+		var err error
+		if tuning, err = music.LoadTuning(cfg.Scala, cfg.KeyboardMap); err != nil {
+			if sender != nil {
+				sender.Close()
+			}
+			return err
+		}
+	} else if cfg.KeyboardMap != "" {
+		if sender != nil {
+			sender.Close()
+		}
+		return fmt.Errorf("--kbm maps a tuning onto the keys, but there is no --scl")
+	}
+	var input *live.Input
+	if cfg.MIDIIn != "" {
+		var err error
+		if input, err = live.OpenInput(cfg.MIDIIn); err != nil {
+			if sender != nil {
+				sender.Close()
+			}
+			return err
+		}
+		fmt.Fprintf(status, "Planting the notes played on MIDI port %s\n", input.Name())
+		defer input.Close()
+	}
+	ports, err := openPorts(cfg)
+	if err != nil {
+		if sender != nil {
+			sender.Close()
+		}
+		return err
+	}
+	for _, p := range ports {
+		fmt.Fprintf(status, "Playing on MIDI port %s\n", p.Name())
+		if cfg.MPE {
+			p.UseMPE()
+		}
+		if tuning != nil {
+			p.Retune(tuning, cfg.TuningMode == config.TuningBend)
+		}
+		bus.Subscribe(p.Handle)
+	}
+	audio, err := openAudio(cfg, tuning, file == nil && sender == nil)
+	if err != nil {
+		for _, p := range ports {
+			p.Close()
+		}
+		if sender != nil {
+			sender.Close()
+		}
+		return err
+	}
+	if audio != nil {
+		fmt.Fprintf(status, "Playing on the built-in %v synth\n", cfg.AudioWave)
+		bus.Subscribe(audio.Handle)
+	}
+	for _, c := range cfg.Channels {
+		if c.Program > 0 {
+			bus.Publish(events.Program{Channel: c.Channel, Program: c.Program})
+		}
+	}
+
+	var pace *music.Clock
+	var follow *follower
+	var hold *transport
+	if cfg.Transport && file == nil {
+		fmt.Fprintf(status, "Waiting for MIDI port %s to start\n", input.Name())
+		<-input.Rolled()
+	}
+	start := time.Now()
+	if file == nil {
+		pace = &clock
+		if cfg.Link {
+			if follow, start, err = joinLink(&clock); err != nil {
+				for _, p := range ports {
+					p.Close()
+				}
+				if sender != nil {
+					sender.Close()
+				}
+				if audio != nil {
+					audio.Close()
+				}
+				return err
+			}
+		}
+		var outputs []timekeeper
+		for _, p := range ports {
+			if cfg.MIDIClock {
+				p.SendClock()
+			}
+			if cfg.MMC {
+				p.SendMMC()
+			}
+			outputs = append(outputs, p)
+		}
+		if sender != nil {
+			outputs = append(outputs, sender)
+		}
+		if audio != nil {
+			outputs = append(outputs, audio)
+		}
+		for _, o := range outputs {
+			o.KeepTime(clock, start)
+		}
+		if cfg.Transport {
+			hold = &transport{input: input, ports: ports, outputs: outputs[len(ports):]}
+		}
+		if follow != nil {
+			follow.outputs = outputs
+			defer follow.session.Close()
+		}
+	}
+	var rewinds <-chan int
+	if cfg.History > 0 && file == nil {
+		fmt.Fprintln(status, rewindHelp)
+		rewinds = readRewinds(os.Stdin)
+	}
+	tick := playAll(bus, seq, layers, cfg.Generations, pace, start, time.Duration(cfg.Lookahead)*time.Millisecond, follow, hold, input, loop, rewinds)
+	if loop != nil {
+		loop.close(tick)
+	}
+	bus.Publish(events.End{Tick: tick})
+	if sender != nil {
+		if err := sender.Close(); err != nil {
+			return err
+		}
+	}
+	for _, p := range ports {
+		if err := p.Close(); err != nil {
+			return err
+		}
+	}
+	if audio != nil {
+		if err := audio.Close(); err != nil {
+			return err
+		}
+	}
+	if seq.Dropped > 0 {
+		fmt.Fprintf(status, "Dropped %d notes to keep within a polyphony of %d\n", seq.Dropped, cfg.Polyphony)
+	}
+	if file == nil {
+		return nil
+	}
+	if err := file.save(path, clock, layers, write); err != nil {
+		return err
+	}
+	fmt.Fprintf(status, "Wrote %d notes to %s\n", file.count(), path)
+	return nil
+}
+
+// openPorts opens the MIDI outputs the notes are played on live: the port
+// chosen by --midi-port and the port --virtual-port creates, unless --silent
+// plays no sound
+func openPorts(cfg *config.Config) ([]*live.Output, error) {
+	var ports []*live.Output
+	if cfg.Silent {
+		return nil, nil
+	}
+	if cfg.MIDIPort != "" {
+		p, err := live.Open(cfg.MIDIPort)
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, p)
+	}
+	if cfg.VirtualPort {
+		p, err := live.OpenVirtual()
+		if err != nil {
+			for _, p := range ports {
+				p.Close()
+			}
+			return nil, err
+		}
+		ports = append(ports, p)
+	}
+	return ports, nil
+}
+
+// openAudio starts the built-in synth playing through the sound output, in
+// tuning unless it is nil, or returns nil with --silent or --audio=false.
+// Audio is on by default, as in the Python and Rust players, but then only
+// when the terminal plays the performance live, not when it is streamed over
+// OSC or JSON Lines or written to a file, and a computer that cannot play it
+// plays silently with a note of why; only an --audio asked for fails the run.
+func openAudio(cfg *config.Config, tuning *music.Tuning, playing bool) (*synth.Audio, error) {
+	asked := cfg.Source("audio").Kind != config.FromDefault
+	if !cfg.Audio || cfg.Silent || !playing && !asked {
+		return nil, nil
+	}
+	s := synth.NewSynth()
+	s.Wave, s.Tuning = cfg.AudioWave, tuning
+	ms := func(n int) time.Duration { return time.Duration(max(n, 0)) * time.Millisecond }
+	s.Envelope = synth.ADSR{Attack: ms(cfg.AudioAttack), Decay: ms(cfg.AudioDecay), Sustain: cfg.AudioSustain, Release: ms(cfg.AudioRelease)}
+	audio, err := synth.OpenAudio(s)
+	if err != nil && !asked {
+		fmt.Fprintf(status, "Playing silently, as the sound output cannot be opened: %v\n", err)
+		return nil, nil
+	}
+	return audio, err
+}
+
+// playAll plays ticks of the shared clock until generations have passed, or
+// forever when generations is 0, and returns the tick it stopped on. With a
+// pace each tick is played lookahead before the clock says the sequencer's
+// tick for it falls, held back by any ritardando, so the boards animate and
+// live outputs, which send each note at its time, have it queued before
+// then; without one the ticks are played at once. The clock counts from
+// start, and with a follower both it and the start follow an Ableton Link
+// session, while with a transport the start is put off for as long as it is
+// stopped. The notes played on input, if any, are planted on the boards
+// before each tick, and its controllers work the looper, if any, which plays
+// its loop's notes for the tick. Each count received on rewinds steps the
+// boards back over that many generations, or every one kept for 0, before
+// the next tick; the generations played again count towards generations.
+func playAll(bus *events.Bus, seq *music.Sequencer, layers []*layer, generations int, pace *music.Clock, start time.Time, lookahead time.Duration, follow *follower, hold *transport, input *live.Input, loop *looper, rewinds <-chan int) int {
+	tick := 0
+	for ; generations <= 0 || tick < generations; tick++ {
+		if follow != nil {
+			*pace, start = follow.follow(*pace, start)
+		}
+		if hold != nil {
+			start = hold.hold(*pace, start)
+		}
+		if pace != nil {
+			time.Sleep(time.Until(start.Add(pace.Time(seq.Tick(tick)) - lookahead)))
+		}
+		if input != nil {
+			notes := input.Take()
+			for _, l := range layers {
+				l.plant(notes)
+			}
+			if loop != nil {
+				loop.step(tick, input.TakeControls())
+			}
+		}
+		select {
+		case n := <-rewinds:
+			for _, l := range layers {
+				l.rewind(bus, n)
+			}
+		default:
+		}
+		for _, l := range layers {
+			if tick%l.every != 0 {
+				continue
+			}
+			if !l.play(bus, tick) {
+				return tick
+			}
+		}
+	}
+	if pace != nil {
+		time.Sleep(time.Until(start.Add(pace.Time(seq.Tick(tick)))))
+	}
+	return tick
+}
+
+// performer turns the keys each layer strikes into NoteOn and NoteOff events,
+// pairing them with a sequencer shared by every layer
+type performer struct {
+	bus *events.Bus
+	seq *music.Sequencer
+
+	pedals     map[int]bool      // channels whose sustain pedal is down
+	transpose  int               // semitones the music is moved beyond its key
+	velocities music.VelocityMap // reshapes the velocities the notes are played at
+}
+
+func (p *performer) handle(e events.Event) {
+	var tick int
+	var started, ended, released []music.NoteEvent
+	var expressions []music.Expression
+	switch e := e.(type) {
+	case events.Notes:
+		tick = e.Tick
+		if e.Restrike {
+			started, ended = p.seq.Restrike(e.Tick, e.Channel, e.Keys, e.Velocities)
+		} else {
+			started, ended = p.seq.Strike(e.Tick, e.Channel, e.Keys, e.Velocities)
+		}
+		released = p.seq.Release()
+		for i, x := range e.Expressions {
+			x.Pitch, x.Channel, x.Tick = e.Keys[i].Note(), e.Channel, p.seq.Tick(e.Tick)
+			expressions = append(expressions, x)
+		}
+	case events.Pedal:
+		p.pedal(e.Tick, e.Channel, e.Down)
+		return
+	case events.Modulation:
+		c := music.Control{Controller: e.Controller, Value: e.Value, Tick: p.seq.Tick(e.Tick), Channel: e.Channel}
+		p.bus.Publish(events.Control{Tick: e.Tick, Control: c})
+		return
+	case events.Section:
+		at := p.seq.Tick(e.Tick)
+		if e.Bank >= 0 {
+			for _, c := range []music.Control{{Controller: music.BankSelect, Value: e.Bank >> 7 & 0x7f}, {Controller: music.BankSelectLSB, Value: e.Bank & 0x7f}} {
+				c.Tick, c.Channel = at, e.Channel
+				p.bus.Publish(events.Control{Tick: e.Tick, Control: c})
+			}
+		}
+		p.bus.Publish(events.Program{Tick: e.Tick, Channel: e.Channel, Program: e.Program, Start: at})
+		return
+	case events.Phrase:
+		if e.Cadence > 0 {
+			p.seq.Ritardando(e.Tick, e.Cadence)
+		}
+		return
+	case events.KeyChange:
+		// Voice stealing keeps the notes of the scale in the new key
+		p.seq.Root = (e.To + music.PitchClass(p.transpose%12+12)) % 12
+		return
+	case events.End:
+		tick = e.Tick
+		ended = p.seq.Flush(e.Tick)
+		for _, channel := range slices.Sorted(maps.Keys(p.pedals)) {
+			p.pedal(tick, channel, false)
+		}
+	default:
+		return
+	}
+	for _, n := range ended {
+		n.Velocity = p.velocities.Apply(n.Velocity)
+		p.bus.Publish(events.NoteOff{Tick: tick, Note: n})
+	}
+	for _, n := range started {
+		n.Velocity = p.velocities.Apply(n.Velocity)
+		p.bus.Publish(events.NoteOn{Tick: tick, Note: n})
+	}
+	for _, n := range released {
+		n.Velocity = p.velocities.Apply(n.Velocity)
+		p.bus.Publish(events.NoteOff{Tick: tick, Note: n})
+	}
+	for _, x := range expressions {
+		p.bus.Publish(events.Expression{Tick: tick, Expression: x})
+	}
+}
+
+// pedal publishes the sustain pedal on channel going down or up at tick, if
+// it is not already
+func (p *performer) pedal(tick, channel int, down bool) {
+	if p.pedals[channel] == down {
+		return
+	}
+	if p.pedals == nil {
+		p.pedals = make(map[int]bool)
+	}
+	value := 0
+	if down {
+		p.pedals[channel] = true
+		value = 127
+	} else {
+		delete(p.pedals, channel)
+	}
+	c := music.Control{Controller: music.SustainPedal, Value: value, Tick: p.seq.Tick(tick), Channel: channel}
+	p.bus.Publish(events.Control{Tick: tick, Control: c})
+}
+
+// play shows the layer's current generation, publishes what happened to it
+// and steps it. It reports false when the run should stop.
+func (l *layer) play(bus *events.Bus, tick int) bool {
+	cfg := l.cfg
+	board := l.board
+	previous := l.generation
+	if cfg.Rest == config.RestSkip {
+		l.skipRests(tick)
+	}
+	l.generation++
+	generation := l.generation
+	bus.Publish(events.Board{Layer: l.index, Generation: generation, Channel: l.channel, Board: board})
+	if cfg.ModulateEvery > 0 && previous > 0 {
+		if from, to := playingKey(cfg, previous), playingKey(cfg, generation); from != to {
+			bus.Publish(events.KeyChange{Layer: l.index, Generation: generation, From: from, To: to})
+		}
+	}
+	stats := life.StatsOf(board)
+	loudness := l.arc.Update(stats.Population) * cfg.Volume
+	anyChord := false
+	for i, p := range l.parts {
+		if p.drums {
+			drums, velocities := dynamics(cfg).Drums(board, p.row)
+			keys := make([]music.Key, len(drums))
+			for i, d := range drums {
+				keys[i] = d.Key()
+			}
+			velocities = music.ScaleVelocities(velocities, loudness)
+			if !cfg.Gates.Open(p.channel, tick) {
+				keys, velocities = nil, nil
+			}
+			bus.Publish(events.Notes{Layer: l.index, Generation: generation, Tick: tick, Channel: p.channel, Keys: keys, Velocities: velocities})
+			continue
+		}
+		if !cfg.Gates.Open(p.channel, tick) {
+			// Closed steps of the --gate rhythm are silent, not rests
+			bus.Publish(events.Notes{Layer: l.index, Generation: generation, Tick: tick, Channel: p.channel})
+			continue
+		}
+		if l.cadence == 1 {
+			l.resolve(bus, p, generation, tick, loudness)
+			anyChord = anyChord || !l.bass
+			continue
+		}
+		v := l.voiced(i, p, generation)
+		keys, velocities, chord, isChord := v.keys, music.ScaleVelocities(v.velocities, loudness), v.chord, v.isChord
+		rest := len(keys) == 0
+		var expressions []music.Expression
+		if rest {
+			keys, velocities = l.rest(p, generation)
+		} else {
+			l.last[p.channel] = struck{keys, velocities}
+			if cfg.MPE {
+				expressions = express(cfg, board, p.row, generation, keys)
+			}
+		}
+		bus.Publish(events.Notes{Layer: l.index, Generation: generation, Tick: tick, Channel: p.channel, Keys: keys, Velocities: velocities, Restrike: rest, Expressions: expressions})
+		if isChord {
+			bus.Publish(events.Chord{Layer: l.index, Generation: generation, Tick: tick, Channel: p.channel, Chord: chord})
+		}
+		anyChord = anyChord || isChord
+	}
+	if down, changed := l.pedal.Update(stats.Density, anyChord); changed {
+		for _, p := range l.parts {
+			if p.drums {
+				continue
+			}
+			bus.Publish(events.Pedal{Layer: l.index, Generation: generation, Tick: tick, Channel: p.channel, Down: down})
+		}
+	}
+	for _, m := range cfg.Modulations {
+		value := m.Value(board, stats)
+		if last, ok := l.modulated[m.Controller]; ok && last == value {
+			continue
+		}
+		l.modulated[m.Controller] = value
+		for _, p := range l.parts {
+			if p.drums {
+				continue
+			}
+			bus.Publish(events.Modulation{Layer: l.index, Generation: generation, Tick: tick, Channel: p.channel, Controller: m.Controller, Value: value})
+		}
+	}
+	bus.Publish(events.Generation{Layer: l.index, Generation: generation, Stats: stats})
+	happened := make(map[config.SectionTrigger]bool)
+	if stats.Population < l.peak/4 {
+		happened[config.OnCollapse] = true
+		l.peak = stats.Population
+	}
+	l.peak = max(l.peak, stats.Population)
+	if period, ok := l.cycles.Observe(generation, board); ok {
+		happened[config.OnCycle] = true
+		bus.Publish(events.Cycle{Layer: l.index, Generation: generation, Period: period})
+		switch cfg.OnCycle {
+		case config.CycleStop:
+			return false
+		case config.CycleReseed:
+			bus.Publish(events.Reseed{Layer: l.index, Generation: generation, Reason: "board entered a cycle"})
+			reseed(l.rng, board, config.ReseedRandom, cfg)
+			l.cycles.Reset()
+			l.watchdog.Reset()
+			happened[config.OnReseed] = true
+		}
+	}
+	if cfg.ReseedThreshold >= 0 && l.watchdog.Check(board) {
+		bus.Publish(events.Reseed{Layer: l.index, Generation: generation, Reason: "board is empty or static"})
+		reseed(l.rng, board, cfg.ReseedStrategy, cfg)
+		l.cycles.Reset()
+		l.watchdog.Reset()
+		happened[config.OnReseed] = true
+	}
+	l.section(bus, generation, tick, happened)
+	l.phrase(bus, generation, tick, stats.Population, happened[config.OnCycle])
+	if s, ok := board.(life.RuleSetter); ok && cfg.MutateEvery > 0 && generation%cfg.MutateEvery == 0 {
+		from := cfg.Rule
+		cfg.Rule = life.MutateRule(l.rng, from, cfg.Neighbourhood.Size())
+		s.SetRule(cfg.Rule)
+		l.cycles.Reset()
+		bus.Publish(events.RuleChange{Layer: l.index, Generation: generation, From: from, To: cfg.Rule})
+	}
+	if cfg.Noise > 0 && generation%max(cfg.NoiseEvery, 1) == 0 {
+		life.Perturb(l.rng, board, cfg.Noise)
+	}
+	board.Step()
+	return true
+}
+
+// phrase publishes the end of the layer's phrase when generation, whose
+// population is given, ends one, starting the --cadence that closes it. The
+// performance's last phrase is closed by a cadence ending with its last
+// generation.
+func (l *layer) phrase(bus *events.Bus, generation, tick, population int, cycle bool) {
+	cfg := l.cfg
+	reason, ended := l.phrases.End(population, cycle)
+	if l.cadence > 0 {
+		l.cadence--
+		return
+	}
+	length := 0
+	if cfg.Cadence > 0 {
+		bar := cfg.Clock().Meter.Beats * cfg.GenerationsPerBeat
+		length = max(cfg.Cadence*bar/l.every, 1)
+		if last := (cfg.Generations + l.every - 1) / l.every; cfg.Generations > 0 && generation == last-length {
+			reason, ended = "the performance is ending", true
+		}
+	}
+	if !ended {
+		return
+	}
+	l.cadence = length
+	bus.Publish(events.Phrase{Layer: l.index, Generation: generation, Tick: tick, Reason: reason, Cadence: length * l.every})
+}
+
+// resolve strikes the tonic chord of the playing key that ends a cadence on
+// the channel of p, or its root alone in a bass line
+func (l *layer) resolve(bus *events.Bus, p part, generation, tick int, loudness float64) {
+	cfg := l.cfg
+	root := (playingKey(cfg, generation) + music.PitchClass(cfg.Transpose%12+12)) % 12
+	chord := music.TonicChord(root, cfg.Scale)
+	keys := chord.Keys
+	if l.bass {
+		keys = []music.Key{keys[0] % music.BassKeys}
+	}
+	velocities := make([]int, len(keys))
+	for i := range velocities {
+		velocities[i] = dynamics(cfg).Loudness(0.5)
+	}
+	velocities = music.ScaleVelocities(velocities, loudness)
+	l.last[p.channel] = struck{keys, velocities}
+	bus.Publish(events.Notes{Layer: l.index, Generation: generation, Tick: tick, Channel: p.channel, Keys: keys, Velocities: velocities, Restrike: true})
+	if !l.bass {
+		bus.Publish(events.Chord{Layer: l.index, Generation: generation, Tick: tick, Channel: p.channel, Chord: chord})
+	}
+}
+
+// sectionReasons say what started a section on each trigger
+var sectionReasons = map[config.SectionTrigger]string{
+	config.OnCycle:    "board entered a cycle",
+	config.OnCollapse: "population collapsed",
+	config.OnReseed:   "board reseeded",
+}
+
+// section moves the layer's channels on to the instruments --section selects
+// in generation, given what happened to the board in it. Each takes effect
+// from the layer's next generation.
+func (l *layer) section(bus *events.Bus, generation, tick int, happened map[config.SectionTrigger]bool) {
+	for i, s := range l.cfg.Sections {
+		var reason string
+		switch {
+		case s.At > 0 && generation == s.At:
+			reason = fmt.Sprintf("generation %d", generation)
+		case s.Every > 0 && generation%s.Every == 0:
+			reason = fmt.Sprintf("every %d generations", s.Every)
+		case s.On != "" && happened[s.On]:
+			reason = sectionReasons[s.On]
+		default:
+			continue
+		}
+		program := s.Programs[l.sections[i]%len(s.Programs)]
+		l.sections[i]++
+		for _, p := range l.parts {
+			if s.Channel == 0 && p.drums || s.Channel != 0 && s.Channel != p.channel {
+				continue
+			}
+			bus.Publish(events.Section{Layer: l.index, Generation: generation, Tick: tick + l.every, Channel: p.channel, Program: program, Bank: s.Bank, Reason: reason})
+		}
+	}
+}
+
+// plant brings to life a cell in the column of each note's key, on the row
+// --inject-row says, for the notes played on the MIDI input. Boards whose
+// cells cannot be set are left alone.
+func (l *layer) plant(notes []music.NoteEvent) {
+	s, ok := l.board.(life.Setter)
+	if !ok {
+		return
+	}
+	width, height := l.board.Size()
+	for _, n := range notes {
+		x := n.Pitch - music.LowestNote
+		if x < 0 || x >= min(width, music.Keys) {
+			continue
+		}
+		var y int
+		switch l.cfg.InjectRow {
+		case config.InjectColumn:
+			for y := 0; y < height; y++ {
+				s.SetAlive(x, y, true)
+			}
+			continue
+		case config.InjectTop:
+			y = 0
+		case config.InjectVelocity:
+			y = (height - 1) - (height-1)*n.Velocity/127
+		case config.InjectRandom:
+			y = l.rng.Intn(height)
+		default:
+			if y = l.cfg.NoteRow; y < 0 {
+				y += height
+			}
+		}
+		s.SetAlive(x, y, true)
+	}
+}
+
+// struck is the keys a part of a layer struck and their velocities
+type struck struct {
+	keys       []music.Key
+	velocities []int
+}
+
+// restSoftness is how loud the repeat rest policy plays the last keys struck
+const restSoftness = 0.5
+
+// maxRestSkip is the most generations the skip rest policy steps over at once
+const maxRestSkip = 256
+
+// rest returns the keys the part plays in a generation that strikes none,
+// and their velocities, as --rest-policy says
+func (l *layer) rest(p part, generation int) ([]music.Key, []int) {
+	switch l.cfg.Rest {
+	case config.RestRepeat:
+		last, ok := l.last[p.channel]
+		if !ok {
+			return nil, nil
+		}
+		return last.keys, music.ScaleVelocities(last.velocities, restSoftness)
+	case config.RestPedalTone:
+		root := (playingKey(l.cfg, generation) + music.PitchClass(l.cfg.Transpose%12+12)) % 12
+		return []music.Key{music.LowestComfortable + music.Key(root)}, []int{dynamics(l.cfg).Min}
+	}
+	return nil, nil
+}
+
+// skipRests steps the board on, unheard, while none of the layer's rows
+// strike a key on tick, for the skip rest policy
+func (l *layer) skipRests(tick int) {
+	for i := 0; i < maxRestSkip && l.silent(tick); i++ {
+		l.board.Step()
+		l.generation++
+	}
+}
+
+// silent reports whether none of the layer's rows whose --gate is open on
+// tick strike a key in the next generation, once kept to the scale and
+// thinned as play would, keeping what each strikes for play. Rows whose gate
+// is closed cannot sound on tick whatever the generation, so a tick with
+// none open, like a cadence's last, is not skipped.
+func (l *layer) silent(tick int) bool {
+	generation := l.generation + 1
+	l.ahead, l.aheadGeneration = l.ahead[:0], generation
+	sounding := l.cadence == 1
+	open := false
+	for _, p := range l.parts {
+		var v voicing
+		if l.cfg.Gates.Open(p.channel, tick) {
+			open = true
+			if p.drums {
+				drums, _ := dynamics(l.cfg).Drums(l.board, p.row)
+				sounding = sounding || len(drums) > 0
+			} else {
+				v = l.voice(p, generation)
+				sounding = sounding || len(v.keys) > 0
+			}
+		}
+		l.ahead = append(l.ahead, v)
+	}
+	return open && !sounding
+}
+
+// voicing is what a part of a layer strikes in a generation, with the
+// velocities before the dynamics are applied
+type voicing struct {
+	keys       []music.Key
+	velocities []int
+	chord      music.Chord
+	isChord    bool
+}
+
+// voice returns what part p strikes in generation: the keys strike() maps
+// and keeps to the scale, as a bass line in a bass layer, smoothed by
+// --smooth and thinned by --note-probability
+func (l *layer) voice(p part, generation int) voicing {
+	cfg := l.cfg
+	keys, velocities, chord, isChord := strike(cfg, l.board, p.row, generation)
+	if l.bass && len(keys) > 0 {
+		k, v := music.BassLine(keys, velocities)
+		keys, velocities, isChord = []music.Key{k}, []int{v}, false
+	}
+	if l.smoother != nil && len(keys) > 0 {
+		keys, velocities = l.smoother.Smooth(l.last[p.channel].keys, keys, velocities)
+		if cfg.DetectChords {
+			chord, isChord = music.DetectChord(keys)
+		}
+	}
+	if l.thinner != nil && len(keys) > 0 {
+		keys, velocities = l.thinner.Thin(l.last[p.channel].keys, keys, velocities, ages(cfg, l.board, p.row, generation, keys))
+	}
+	return voicing{keys, velocities, chord, isChord}
+}
+
+// voiced returns what the layer's ith part, p, strikes in generation: what
+// silent worked out for it ahead, which a random thinning or smoothing may
+// not repeat, or else its voice
+func (l *layer) voiced(i int, p part, generation int) voicing {
+	if l.aheadGeneration == generation && i < len(l.ahead) {
+		return l.ahead[i]
+	}
+	return l.voice(p, generation)
+}
+
+// playingKey returns the key generation is played in: --key, or the scale's
+// root, moved up a fifth for every --modulate-every generations played before
+func playingKey(cfg *config.Config, generation int) music.PitchClass {
+	key, ok := cfg.Key.PitchClass()
+	if !ok {
+		key = cfg.Root
+	}
+	if cfg.ModulateEvery > 0 {
+		key += music.PitchClass(7 * ((generation - 1) / cfg.ModulateEvery))
+	}
+	return key % 12
+}
+
+// strike returns the keys a row of the board plays in generation, as the
+// --mapper maps them, and their velocities, kept to the scale, moved into the playing key, pitch shifted and
+// voiced as configured, and the chord they make if chords are detected
+func strike(cfg *config.Config, board life.Board, row, generation int) (keys []music.Key, velocities []int, chord music.Chord, isChord bool) {
+	snap := cfg.ScaleFit == config.FitSnap
+
+	// A key fitted to the scale is as loud as the loudest cell moved onto it
+	mapped := cfg.Mapper.Mapper().Strike(board, row, generation, dynamics(cfg))
+	struck := make(map[music.Key]int)
+	for k, v := range mapped {
+		if q, ok := cfg.Scale.Fit(k, cfg.Root, snap); ok {
+			struck[q] = max(struck[q], v)
+		}
+	}
+	keys = cfg.Scale.Quantize(music.StruckKeys(mapped), cfg.Root, snap)
+
+	transpose := music.Interval(cfg.Root, playingKey(cfg, generation)) + cfg.Transpose
+	keys = music.Transpose(keys, transpose)
+	if cfg.PitchShift {
+		keys = music.ShiftClusters(keys)
+	}
+	if cfg.DetectChords {
+		if chord, isChord = music.DetectChord(keys); isChord {
+			keys = chord.Revoice(keys, cfg.Voicing)
+			if cfg.AvoidSemitones {
+				keys = music.AvoidSemitones(keys)
+			}
+		}
+	}
+	return keys, music.Follow(struck, keys, transpose, dynamics(cfg).Velocity(0, 0)), chord, isChord
+}
+
+// express returns the expression of each of keys, which strike() returned
+// for row, for MPE: the expression of the cells fitted to the scale onto its
+// key, followed as the velocities are through transposing and voicing
+func express(cfg *config.Config, board life.Board, row, generation int, keys []music.Key) []music.Expression {
+	snap := cfg.ScaleFit == config.FitSnap
+	fitted := make(map[music.Key]music.Expression)
+	for k, x := range music.Expressions(board, row, cfg.MPEBend) {
+		if q, ok := cfg.Scale.Fit(k, cfg.Root, snap); ok {
+			if y, ok := fitted[q]; !ok || x.Pressure > y.Pressure {
+				fitted[q] = x
+			}
+		}
+	}
+	transpose := music.Interval(cfg.Root, playingKey(cfg, generation)) + cfg.Transpose
+	return music.FollowExpressions(fitted, keys, transpose)
+}
+
+// ages returns how long the cell of each of keys, which strike() returned
+// for row, has lived, followed as the velocities are through fitting to the
+// scale, transposing and voicing
+func ages(cfg *config.Config, board life.Board, row, generation int, keys []music.Key) []int {
+	snap := cfg.ScaleFit == config.FitSnap
+	fitted := make(map[music.Key]int)
+	for k, a := range music.Ages(board, row) {
+		if q, ok := cfg.Scale.Fit(k, cfg.Root, snap); ok {
+			fitted[q] = max(fitted[q], a)
+		}
+	}
+	transpose := music.Interval(cfg.Root, playingKey(cfg, generation)) + cfg.Transpose
+	return music.Follow(fitted, keys, transpose, 0)
+}
+
+// dynamics returns the velocities cells are played at, as configured
+func dynamics(cfg *config.Config) music.Dynamics {
+	d := music.NewDynamics()
+	d.Min, d.Max, d.Curve = cfg.VelocityMin, cfg.VelocityMax, cfg.VelocityCurve
+	return d
+}
+
+// injectSize is the side of the square of random cells the inject strategy adds
+const injectSize = 16
+
+// reseed refills the visible board with random cells, either replacing it
+// entirely or injecting a patch into it
+func reseed(rng *rand.Rand, board life.Board, strategy config.ReseedStrategy, cfg *config.Config) {
+	s, ok := board.(life.Setter)
+	if !ok {
+		return
+	}
+	width, height := board.Size()
+	if strategy == config.ReseedInject {
+		w, h := min(injectSize, width), min(injectSize, height)
+		life.Randomize(rng, s, rng.Intn(width-w+1), rng.Intn(height-h+1), w, h, cfg.Density)
+		return
+	}
+	life.RandomizeSymmetric(rng, s, 0, 0, width, height, cfg.Density, cfg.Symmetry)
+	if grid, ok := board.(*life.Grid); ok {
+		grid.RandomColours(rng)
+	}
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/config"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+func init() { status = io.Discard }
+
+// testLayer builds the single layer of cfg
+func testLayer(t *testing.T, cfg *config.Config) *layer {
+	t.Helper()
+	layers, err := newLayers(cfg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return layers[0]
+}
+
+// record subscribes to bus and returns the events published on it
+func record(bus *events.Bus) *[]events.Event {
+	var got []events.Event
+	bus.Subscribe(func(e events.Event) { got = append(got, e) })
+	return &got
+}
+
+// struckKeys returns the keys of the Notes events in got, by generation
+func struckKeys(got []events.Event) map[int][]music.Key {
+	keys := make(map[int][]music.Key)
+	for _, e := range got {
+		if n, ok := e.(events.Notes); ok {
+			keys[n.Generation] = append(keys[n.Generation], n.Keys...)
+		}
+	}
+	return keys
+}
+
+// notesOf returns the Notes events in got
+func notesOf(got []events.Event) []events.Notes {
+	var notes []events.Notes
+	for _, e := range got {
+		if n, ok := e.(events.Notes); ok {
+			notes = append(notes, n)
+		}
+	}
+	return notes
+}
+
+// blinker puts a vertical blinker in column x with its foot on the bottom
+// row of a 40-row board, or lying on the row above it when flat, so that
+// the note row has a cell in every other generation
+func blinker(s life.Setter, x int, flat bool) {
+	if flat {
+		s.SetAlive(x-1, 38, true)
+		s.SetAlive(x, 38, true)
+		s.SetAlive(x+1, 38, true)
+		return
+	}
+	s.SetAlive(x, 37, true)
+	s.SetAlive(x, 38, true)
+	s.SetAlive(x, 39, true)
+}
+
+func TestRestPolicies(t *testing.T) {
+	major, err := music.ParseScale("major")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		rest  config.RestPolicy
+		drop  bool                // keep to C major, dropping the keys outside it
+		place func(s life.Setter) // the cells on the empty board
+		check func(t *testing.T, notes []events.Notes)
+	}{
+		{
+			name:  "silence",
+			rest:  config.RestSilence,
+			place: func(s life.Setter) { s.SetAlive(39, 39, true) },
+			check: func(t *testing.T, notes []events.Notes) {
+				if len(notes[0].Keys) != 1 || len(notes[1].Keys) != 0 {
+					t.Errorf("struck %v then %v, want a key then silence", notes[0].Keys, notes[1].Keys)
+				}
+			},
+		},
+		{
+			name:  "repeat",
+			rest:  config.RestRepeat,
+			place: func(s life.Setter) { s.SetAlive(39, 39, true) },
+			check: func(t *testing.T, notes []events.Notes) {
+				soft := music.ScaleVelocities(notes[0].Velocities, restSoftness)
+				if !slices.Equal(notes[1].Keys, notes[0].Keys) || !slices.Equal(notes[1].Velocities, soft) || !notes[1].Restrike {
+					t.Errorf("rest struck %v at %v, want %v restruck at %v", notes[1].Keys, notes[1].Velocities, notes[0].Keys, soft)
+				}
+			},
+		},
+		{
+			name:  "pedal tone",
+			rest:  config.RestPedalTone,
+			place: func(s life.Setter) { s.SetAlive(39, 39, true) },
+			check: func(t *testing.T, notes []events.Notes) {
+				want := []music.Key{music.LowestComfortable}
+				if !slices.Equal(notes[1].Keys, want) || notes[1].Velocities[0] != dynamics(config.Default()).Min {
+					t.Errorf("rest struck %v at %v, want the low C %v softly", notes[1].Keys, notes[1].Velocities, want)
+				}
+			},
+		},
+		{
+			name:  "skip",
+			rest:  config.RestSkip,
+			place: func(s life.Setter) { blinker(s, 40, false) },
+			check: func(t *testing.T, notes []events.Notes) {
+				for i, n := range notes {
+					if n.Generation != 2*i+1 || len(n.Keys) == 0 {
+						t.Errorf("played generation %d with %v, want generation %d with a key", n.Generation, n.Keys, 2*i+1)
+					}
+				}
+			},
+		},
+		{
+			// Column 25 is A#2, outside the scale, so the generations only
+			// its blinker reaches the note row in are rests too
+			name:  "skip past keys left out of the scale",
+			rest:  config.RestSkip,
+			drop:  true,
+			place: func(s life.Setter) { blinker(s, 25, false); blinker(s, 60, true) },
+			check: func(t *testing.T, notes []events.Notes) {
+				for i, n := range notes {
+					if n.Generation != 2*i+2 || len(n.Keys) != 1 {
+						t.Errorf("played generation %d with %v, want generation %d with the A5 alone", n.Generation, n.Keys, 2*i+2)
+					}
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Density, cfg.Rest = 0, tt.rest
+			if tt.drop {
+				cfg.Scale, cfg.ScaleFit = major, config.FitDrop
+			}
+			l := testLayer(t, cfg)
+			tt.place(l.board.(life.Setter))
+			var bus events.Bus
+			got := record(&bus)
+			for tick := 0; tick < 3; tick++ {
+				l.play(&bus, tick)
+			}
+			notes := notesOf(*got)
+			if len(notes) != 3 {
+				t.Fatalf("got %d Notes events, want one a generation played", len(notes))
+			}
+			tt.check(t, notes)
+		})
+	}
+}
+
+func TestRewind(t *testing.T) {
+	cfg := config.Default()
+	cfg.Density, cfg.History = 0.3, 8
+	l := testLayer(t, cfg)
+	var bus events.Bus
+	got := record(&bus)
+	for tick := 0; tick < 4; tick++ {
+		l.play(&bus, tick)
+	}
+	first := struckKeys(*got)
+	if len(first[3]) == 0 || len(first[4]) == 0 {
+		t.Fatal("no keys struck to compare the replay with")
+	}
+
+	*got = nil
+	l.rewind(&bus, 2)
+	if len(*got) != 1 || (*got)[0] != (events.Rewind{Layer: 0, Generation: 3, Back: 2}) {
+		t.Fatalf("rewinding published %v, want a Rewind back 2 to generation 3", *got)
+	}
+	for tick := 4; tick < 6; tick++ {
+		l.play(&bus, tick)
+	}
+	again := struckKeys(*got)
+	for g := 3; g <= 4; g++ {
+		if !slices.Equal(again[g], first[g]) {
+			t.Errorf("generation %d replayed %v, first played %v", g, again[g], first[g])
+		}
+	}
+
+	// Rewinding further than the history keeps goes back as far as it can
+	*got = nil
+	l.rewind(&bus, 100)
+	if r, ok := (*got)[0].(events.Rewind); !ok || r.Back != 4 || r.Generation != 1 {
+		t.Errorf("rewinding past the history published %v, want back 4 to generation 1", *got)
+	}
+}
+
+func TestReadRewinds(t *testing.T) {
+	for input, want := range map[string]int{"r\n": 0, "rewind 16\n": 16, "nonsense\nr 0\nr 3\n": 3} {
+		if got := <-readRewinds(strings.NewReader(input)); got != want {
+			t.Errorf("%q rewinds %d, want %d", input, got, want)
+		}
+	}
+}
+
+func TestHistoryNeedsGrid(t *testing.T) {
+	cfg := config.Default()
+	cfg.History, cfg.Engine = 8, config.EngineBitPacked
+	if _, err := newLayers(cfg, 1); err == nil {
+		t.Error("--history was accepted for the bitpacked engine")
+	}
+}
+
+func TestPlant(t *testing.T) {
+	tests := []struct {
+		row      config.InjectRow
+		velocity int
+		want     func(x, y int) bool // whether the cell at (x, y) is planted
+	}{
+		{config.InjectPlayed, 100, func(x, y int) bool { return y == 39 }},
+		{config.InjectTop, 100, func(x, y int) bool { return y == 0 }},
+		{config.InjectVelocity, 127, func(x, y int) bool { return y == 0 }},
+		{config.InjectVelocity, 64, func(x, y int) bool { return y == 20 }},
+		{config.InjectColumn, 100, func(x, y int) bool { return true }},
+	}
+	for _, tt := range tests {
+		cfg := config.Default()
+		cfg.Density, cfg.InjectRow = 0, tt.row
+		l := testLayer(t, cfg)
+		// Pitch 20 is below the lowest key, so it plants nothing
+		l.plant([]music.NoteEvent{{Pitch: 60, Velocity: tt.velocity}, {Pitch: 20, Velocity: tt.velocity}})
+		for x := range 88 {
+			for y := range 40 {
+				if want := x == 39 && tt.want(x, y); l.board.Alive(x, y) != want {
+					t.Errorf("%s at velocity %d: cell (%d, %d) alive is %v, want %v", tt.row, tt.velocity, x, y, !want, want)
+				}
+			}
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Density, cfg.InjectRow = 0, config.InjectRandom
+	l := testLayer(t, cfg)
+	l.plant([]music.NoteEvent{{Pitch: 60, Velocity: 100}})
+	cells := 0
+	for x := range life.LiveCells(l.board) {
+		if x != 39 {
+			t.Errorf("random planted a cell in column %d, want column 39", x)
+		}
+		cells++
+	}
+	if cells != 1 {
+		t.Errorf("random planted %d cells, want 1", cells)
+	}
+}
+
+func TestPlantedReseed(t *testing.T) {
+	cfg := config.Default()
+	cfg.Density, cfg.ReseedThreshold = 0, 1
+	l := testLayer(t, cfg)
+	// The board starts empty, but reseeds at the usual density
+	l.cfg.Density = 0.3
+	var bus events.Bus
+	got := record(&bus)
+
+	// The lone cell planted is struck and dies, and the board stays empty
+	// for one generation more than the threshold before it is reseeded
+	l.plant([]music.NoteEvent{{Pitch: 60, Velocity: 100}})
+	for tick := 0; tick < 4; tick++ {
+		l.play(&bus, tick)
+	}
+	keys := struckKeys(*got)
+	if !slices.Equal(keys[1], []music.Key{39}) || len(keys[2]) != 0 || len(keys[3]) != 0 {
+		t.Errorf("struck %v, %v and %v, want the planted key and then silence", keys[1], keys[2], keys[3])
+	}
+	var reseeds []events.Reseed
+	population := 0
+	for _, e := range *got {
+		switch e := e.(type) {
+		case events.Reseed:
+			reseeds = append(reseeds, e)
+		case events.Generation:
+			if e.Generation == 4 {
+				population = e.Stats.Population
+			}
+		}
+	}
+	want := []events.Reseed{{Layer: 0, Generation: 3, Reason: "board is empty or static"}}
+	if !slices.Equal(reseeds, want) {
+		t.Errorf("published %v, want %v", reseeds, want)
+	}
+	if population == 0 {
+		t.Error("the board was still empty after it was reseeded")
+	}
+}
+
+func TestSections(t *testing.T) {
+	cfg := config.Default()
+	// With nothing to reseed it with, the empty board is reseeded in every
+	// generation
+	cfg.Density, cfg.ReseedThreshold = 0, 0
+	if err := cfg.Sections.Set("at=2,program=49;every=3,program=12/89;on=reseed,program=1/2"); err != nil {
+		t.Fatal(err)
+	}
+	l := testLayer(t, cfg)
+	var bus events.Bus
+	got := record(&bus)
+	for generation := 1; generation <= 6; generation++ {
+		l.play(&bus, 10+generation)
+	}
+
+	var sections []events.Section
+	for _, e := range *got {
+		if s, ok := e.(events.Section); ok {
+			sections = append(sections, s)
+		}
+	}
+	// Each section is played from the tick after the generation starting it
+	section := func(generation, program int, reason string) events.Section {
+		return events.Section{Generation: generation, Tick: 11 + generation, Channel: 1, Program: program, Bank: -1, Reason: reason}
+	}
+	want := []events.Section{
+		section(1, 1, "board reseeded"),
+		section(2, 49, "generation 2"),
+		section(2, 2, "board reseeded"),
+		section(3, 12, "every 3 generations"),
+		section(3, 1, "board reseeded"),
+		section(4, 2, "board reseeded"),
+		section(5, 1, "board reseeded"),
+		section(6, 89, "every 3 generations"),
+		section(6, 2, "board reseeded"),
+	}
+	if !slices.Equal(sections, want) {
+		t.Errorf("published sections\n%v\nwant\n%v", sections, want)
+	}
+}
+
+func TestAudioOnlyInTheTerminal(t *testing.T) {
+	// Streamed over OSC or JSON Lines, or written to a file, the performance
+	// only plays on the synth when --audio asks for it
+	if audio, err := openAudio(config.Default(), nil, false); audio != nil || err != nil {
+		t.Errorf("openAudio of a stream = %v, %v, want no synth", audio, err)
+	}
+}
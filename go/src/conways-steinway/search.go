@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/config"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/life"
+)
+
+// soupResult is the outcome of one searched seed
+type soupResult struct {
+	seed int64
+	life.Soup
+}
+
+// search runs the "search" subcommand: it plays many random soups without
+// showing them and writes the seeds of the highest-scoring ones, each of which
+// can be replayed with --seed
+func search(cfg *config.Config, args []string) error {
+	fset := flag.NewFlagSet("search", flag.ContinueOnError)
+	soups := fset.Int("soups", 1000, "number of random boards to try")
+	generations := fset.Int("generations", 2000, "generations each board may run for")
+	keep := fset.Int("keep", 10, "number of seeds to keep")
+	out := fset.String("out", "seeds.txt", "file the best seeds are written to, or - for standard output")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if *soups < 1 {
+		return fmt.Errorf("search: -soups must be at least 1, not %d", *soups)
+	}
+	if *generations < 1 {
+		return fmt.Errorf("search: -generations must be at least 1, not %d", *generations)
+	}
+	if *keep < 1 {
+		return fmt.Errorf("search: -keep must be at least 1, not %d", *keep)
+	}
+
+	base := cfg.Seed
+	if base == 0 {
+		base = time.Now().UnixNano()
+	}
+	soupCfg := *cfg
+	soupCfg.Workers, soupCfg.History = 1, 0
+
+	results := make([]soupResult, *soups)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	var failed error
+	var mu sync.Mutex
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				seed := base + int64(i)
+				board, err := newBoard(&soupCfg, randomPopulate(&soupCfg, rand.New(rand.NewSource(seed))))
+				if err != nil {
+					mu.Lock()
+					failed = err
+					mu.Unlock()
+					continue
+				}
+				results[i] = soupResult{seed: seed, Soup: life.RunSoup(board, *generations, cfg.CycleWindow)}
+			}
+		}()
+	}
+	for i := range results {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if failed != nil {
+		return failed
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score() > results[j].Score() })
+	results = results[:min(*keep, len(results))]
+
+	var f *os.File
+	if *out != "-" {
+		var err error
+		if f, err = os.Create(*out); err != nil {
+			return err
+		}
+	}
+	w := bufio.NewWriter(os.Stdout)
+	if f != nil {
+		w = bufio.NewWriter(f)
+	}
+	fmt.Fprintf(w, "# %d soups of %d generations, rule %v\n", *soups, *generations, cfg.Rule)
+	fmt.Fprintln(w, "# seed score lifespan max-population period oscillators")
+	for _, r := range results {
+		fmt.Fprintf(w, "%d %.1f %d %d %d %d\n", r.seed, r.Score(), r.Lifespan, r.MaxPopulation, r.Period, r.Oscillators)
+	}
+	err := w.Flush()
+	if f != nil {
+		// A full disk may only show when the file is closed
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if f != nil {
+		fmt.Printf("Wrote the best %d of %d seeds to %s\n", len(results), *soups, *out)
+	}
+	return nil
+}
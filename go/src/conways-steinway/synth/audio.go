@@ -0,0 +1,35 @@
+package synth
+
+import (
+	"io"
+	"time"
+)
+
+// openDevice starts the computer's sound output playing the 16-bit stereo
+// frames read from r at rate; the oto driver sets it, and tests replace it
+var openDevice func(rate int, r io.Reader) (io.Closer, error)
+
+// Audio plays a Synth through the computer's sound output
+type Audio struct {
+	*Synth
+	device io.Closer
+}
+
+// OpenAudio starts s playing on the sound output
+func OpenAudio(s *Synth) (*Audio, error) {
+	d, err := openDevice(s.Rate, s)
+	if err != nil {
+		return nil, err
+	}
+	return &Audio{s, d}, nil
+}
+
+// Close waits for the notes still sounding to fade out, but no longer than
+// their release and a second more, then stops the sound output
+func (a *Audio) Close() error {
+	deadline := time.Now().Add(a.Envelope.Release + time.Second)
+	for !a.Idle() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return a.device.Close()
+}
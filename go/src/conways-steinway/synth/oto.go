@@ -0,0 +1,27 @@
+package synth
+
+import (
+	"io"
+
+	"github.com/ebitengine/oto/v3"
+)
+
+// The oto driver plays through PulseAudio or ALSA on Linux, CoreAudio on
+// macOS and WASAPI on Windows, loading them without cgo, so every build has
+// sound
+func init() {
+	openDevice = func(rate int, r io.Reader) (io.Closer, error) {
+		ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
+			SampleRate:   rate,
+			ChannelCount: 2,
+			Format:       oto.FormatSignedInt16LE,
+		})
+		if err != nil {
+			return nil, err
+		}
+		<-ready
+		p := ctx.NewPlayer(r)
+		p.Play()
+		return p, nil
+	}
+}
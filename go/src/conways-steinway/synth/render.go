@@ -0,0 +1,179 @@
+package synth
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// SampleRate is the rate, in frames a second, audio is rendered at
+const SampleRate = 44100
+
+// drumBank is the bank General MIDI SoundFonts keep their drum kits in
+const drumBank = 128
+
+// Render plays tracks on font and writes them to w as a 16-bit stereo WAV
+// file at SampleRate. Each track plays the preset its Program selects, or
+// the first, from the drum bank on DrumChannel, with its notes held on by
+// the sustain pedal while it is down. Samples are played with their tuning,
+// loops, pan, attenuation and volume envelope; the fonts' filters,
+// modulation envelopes, LFOs and effects are not. The mix is scaled down if
+// it would clip.
+func Render(w io.Writer, font *SoundFont, clock music.Clock, tracks []music.Track) error {
+	if err := clock.Validate(); err != nil {
+		return fmt.Errorf("wav: %w", err)
+	}
+	var mix []float32
+	for _, t := range tracks {
+		bank := 0
+		if t.Channel == music.DrumChannel {
+			bank = drumBank
+		}
+		p := font.find(bank, max(t.Program-1, 0))
+		pedal := pedalSpans(t.Controls)
+		for _, n := range t.Notes {
+			at := frames(clock, n.Start)
+			held := max(frames(clock, sustained(n.End(), pedal))-at, 0)
+			gain := math.Pow(float64(n.Velocity)/127, 2) * masterGain
+			for _, v := range font.voices(p, n.Pitch, n.Velocity) {
+				mix = font.play(mix, v, at, held, gain)
+			}
+		}
+	}
+	peak := float32(0)
+	for _, s := range mix {
+		peak = max(peak, s, -s)
+	}
+	if peak > 1 {
+		for i := range mix {
+			mix[i] /= peak
+		}
+	}
+	return WriteWAV(w, SampleRate, mix)
+}
+
+// masterGain leaves headroom for several loud notes at once
+const masterGain = 0.3
+
+// frames returns the frame tick falls on
+func frames(clock music.Clock, tick int64) int {
+	return int(math.Round(clock.Time(tick).Seconds() * SampleRate))
+}
+
+// pedalSpans returns the ticks the sustain pedal goes down and comes up
+// again, in pairs, from a track's control changes; a pedal that never comes
+// up ends its span at math.MaxInt64 and holds no notes on
+func pedalSpans(controls []music.Control) [][2]int64 {
+	cs := append([]music.Control(nil), controls...)
+	sort.SliceStable(cs, func(i, j int) bool { return cs[i].Tick < cs[j].Tick })
+	var spans [][2]int64
+	down := false
+	for _, c := range cs {
+		if c.Controller != music.SustainPedal || (c.Value >= 64) == down {
+			continue
+		}
+		if down = !down; down {
+			spans = append(spans, [2]int64{c.Tick, math.MaxInt64})
+		} else {
+			spans[len(spans)-1][1] = c.Tick
+		}
+	}
+	return spans
+}
+
+// sustained returns the tick a note released at end stops being held: end,
+// or when the pedal comes up if it is down then
+func sustained(end int64, spans [][2]int64) int64 {
+	for _, s := range spans {
+		if s[0] <= end && end < s[1] {
+			if s[1] == math.MaxInt64 {
+				return end
+			}
+			return s[1]
+		}
+	}
+	return end
+}
+
+// play mixes voice v into the interleaved stereo frames of mix, from frame
+// at, held for held frames then released, growing mix as it needs to
+func (f *SoundFont) play(mix []float32, v voice, at, held int, gain float64) []float32 {
+	step := math.Pow(2, (v.pitch-v.root)/12) * float64(v.rate) / SampleRate
+	left := float32(gain * v.gain * math.Cos((v.pan+1)*math.Pi/4))
+	right := float32(gain * v.gain * math.Sin((v.pan+1)*math.Pi/4))
+	env := envelope{v: v, held: float64(held) / SampleRate}
+	pos := float64(v.start)
+	for i := 0; ; i++ {
+		amp, playing := env.at(float64(i) / SampleRate)
+		if !playing {
+			break
+		}
+		if v.loop {
+			for pos >= float64(v.loopEnd) {
+				pos -= float64(v.loopEnd - v.loopStart)
+			}
+		} else if pos >= float64(v.end-1) {
+			break
+		}
+		j := int(pos)
+		frac := float32(pos - float64(j))
+		s := f.data[j]
+		if j+1 < len(f.data) {
+			s += (f.data[j+1] - s) * frac
+		}
+		s *= float32(amp)
+		k := 2 * (at + i)
+		if k+1 >= len(mix) {
+			mix = append(mix, make([]float32, k+2-len(mix))...)
+		}
+		mix[k] += s * left
+		mix[k+1] += s * right
+		pos += step
+	}
+	return mix
+}
+
+// envelope is a voice's volume envelope: silent for the delay, rising
+// linearly over the attack, full for the hold, then falling in decibels
+// towards the sustain level over the decay, which is the time a fall to
+// silence would take. From the release the level falls on towards silence,
+// at the rate that would take the release time from full.
+type envelope struct {
+	v    voice
+	held float64 // seconds from the start to the release
+}
+
+// at returns the envelope's gain t seconds after the start, and whether the
+// voice is still sounding
+func (e envelope) at(t float64) (float64, bool) {
+	if t < e.held {
+		return e.holding(t), true
+	}
+	level := e.holding(e.held)
+	if level <= 0 {
+		return 0, false
+	}
+	cb := -200*math.Log10(level) + silence*(t-e.held)/e.v.release
+	if cb >= silence {
+		return 0, false
+	}
+	return centibels(cb), true
+}
+
+// holding returns the envelope's gain t seconds after the start while the
+// note is held
+func (e envelope) holding(t float64) float64 {
+	v := e.v
+	switch {
+	case t < v.delay:
+		return 0
+	case t < v.delay+v.attack:
+		return (t - v.delay) / v.attack
+	case t < v.delay+v.attack+v.hold:
+		return 1
+	}
+	return centibels(min(silence*(t-v.delay-v.attack-v.hold)/v.decay, v.sustain))
+}
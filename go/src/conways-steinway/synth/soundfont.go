@@ -0,0 +1,399 @@
+// Package synth turns a performance into sound without any MIDI gear, by
+// playing its notes on the samples of a SoundFont.
+package synth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+// SoundFont is the part of a SoundFont 2 file the synth plays: its presets,
+// their instruments and the samples they are built from
+type SoundFont struct {
+	Name    string
+	presets []preset
+	insts   []instrument
+	samples []sample
+	data    []float32 // every sample's frames, from -1 to 1
+}
+
+// preset is an instrument General MIDI selects by bank and program
+type preset struct {
+	name          string
+	bank, program int
+	global        gens // applies to every zone
+	zones         []zone
+}
+
+// instrument is a set of sample zones
+type instrument struct {
+	name   string
+	global gens
+	zones  []zone
+}
+
+// zone is a region of keys and velocities a preset plays with an instrument,
+// or an instrument with a sample, with the generators that sound it
+type zone struct {
+	gens gens
+	link int // the instrument or sample played
+}
+
+// sample is where a sample's frames lie in the data, and how it is tuned
+type sample struct {
+	start, end, loopStart, loopEnd int
+	rate                           int
+	key                            int // the key the sample sounds at its own rate
+	correction                     int // cents to add to its tuning
+}
+
+// Generators, numbered as SoundFont 2.01 numbers them
+const (
+	genStartOffset       = 0
+	genEndOffset         = 1
+	genLoopStartOffset   = 2
+	genLoopEndOffset     = 3
+	genStartCoarseOffset = 4
+	genEndCoarseOffset   = 12
+	genPan               = 17
+	genDelayVolEnv       = 33
+	genAttackVolEnv      = 34
+	genHoldVolEnv        = 35
+	genDecayVolEnv       = 36
+	genSustainVolEnv     = 37
+	genReleaseVolEnv     = 38
+	genInstrument        = 41
+	genKeyRange          = 43
+	genVelRange          = 44
+	genLoopStartCoarse   = 45
+	genKeynum            = 46
+	genVelocity          = 47
+	genAttenuation       = 48
+	genLoopEndCoarse     = 50
+	genCoarseTune        = 51
+	genFineTune          = 52
+	genSampleID          = 53
+	genSampleModes       = 54
+	genScaleTuning       = 56
+	genRootKey           = 58
+	genCount             = 61
+)
+
+// gens holds a zone's generators; set marks those it gives
+type gens struct {
+	amount [genCount]int16
+	set    [genCount]bool
+}
+
+// get returns the amount of generator g, or def if it is not set
+func (g *gens) get(op int, def int) int {
+	if g.set[op] {
+		return int(g.amount[op])
+	}
+	return def
+}
+
+// span returns the low and high bytes of a range generator, 0 to 127 if it
+// is not set
+func (g *gens) span(op int) (lo, hi int) {
+	if !g.set[op] {
+		return 0, 127
+	}
+	return int(uint16(g.amount[op]) & 0xff), int(uint16(g.amount[op]) >> 8)
+}
+
+// covers reports whether the zone's key and velocity ranges take in key and
+// velocity
+func (g *gens) covers(key, velocity int) bool {
+	klo, khi := g.span(genKeyRange)
+	vlo, vhi := g.span(genVelRange)
+	return key >= klo && key <= khi && velocity >= vlo && velocity <= vhi
+}
+
+// over returns the generators of g overridden by those z sets
+func (g gens) over(z gens) gens {
+	for op := range z.set {
+		if z.set[op] {
+			g.amount[op], g.set[op] = z.amount[op], true
+		}
+	}
+	return g
+}
+
+// Load reads the SoundFont 2 file at path
+func Load(path string) (*SoundFont, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// errFormat is returned for data that is not a SoundFont 2 file
+var errFormat = errors.New("not a SoundFont 2 file")
+
+// Parse decodes a SoundFont 2 file. Only 16-bit samples are read; the
+// 24-bit extension is ignored.
+func Parse(data []byte) (*SoundFont, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "sfbk" {
+		return nil, errFormat
+	}
+	lists := make(map[string]map[string][]byte)
+	err := chunks(data[12:], func(id string, body []byte) error {
+		if id != "LIST" || len(body) < 4 {
+			return nil
+		}
+		sub := make(map[string][]byte)
+		lists[string(body[:4])] = sub
+		return chunks(body[4:], func(id string, body []byte) error {
+			sub[id] = body
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	info, sdta, pdta := lists["INFO"], lists["sdta"], lists["pdta"]
+	if sdta == nil || pdta == nil {
+		return nil, errFormat
+	}
+	f := &SoundFont{Name: cString(info["INAM"])}
+	smpl := sdta["smpl"]
+	f.data = make([]float32, len(smpl)/2)
+	for i := range f.data {
+		f.data[i] = float32(int16(binary.LittleEndian.Uint16(smpl[2*i:]))) / 32768
+	}
+	if err := f.parseHydra(pdta); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// chunks calls fn with the id and body of each RIFF chunk in data
+func chunks(data []byte, fn func(id string, body []byte) error) error {
+	for len(data) >= 8 {
+		id, size := string(data[:4]), int(binary.LittleEndian.Uint32(data[4:]))
+		if size > len(data)-8 {
+			return fmt.Errorf("%w: %q chunk runs past the end of the file", errFormat, id)
+		}
+		if err := fn(id, data[8:8+size]); err != nil {
+			return err
+		}
+		data = data[8+size+size%2:]
+	}
+	return nil
+}
+
+// parseHydra decodes the presets, instruments and samples of the pdta list
+func (f *SoundFont) parseHydra(pdta map[string][]byte) error {
+	records := func(id string, size int) ([][]byte, error) {
+		data := pdta[id]
+		if len(data)%size != 0 || len(data) < 2*size {
+			return nil, fmt.Errorf("%w: bad %s chunk", errFormat, id)
+		}
+		out := make([][]byte, len(data)/size)
+		for i := range out {
+			out[i] = data[i*size : (i+1)*size]
+		}
+		return out, nil
+	}
+	var phdr, pbag, pgen, inst, ibag, igen, shdr [][]byte
+	for _, c := range []struct {
+		out  *[][]byte
+		id   string
+		size int
+	}{
+		{&phdr, "phdr", 38}, {&pbag, "pbag", 4}, {&pgen, "pgen", 4},
+		{&inst, "inst", 22}, {&ibag, "ibag", 4}, {&igen, "igen", 4}, {&shdr, "shdr", 46},
+	} {
+		r, err := records(c.id, c.size)
+		if err != nil {
+			return err
+		}
+		*c.out = r
+	}
+	u16 := func(b []byte, at int) int { return int(binary.LittleEndian.Uint16(b[at:])) }
+	u32 := func(b []byte, at int) int { return int(binary.LittleEndian.Uint32(b[at:])) }
+
+	// zones reads the bags first to last of a preset or instrument, each of
+	// which ends with the generator naming what it plays, except that a first
+	// bag without one holds the global generators
+	zones := func(bags, gen [][]byte, first, last, linkOp int) (global gens, out []zone, err error) {
+		if first > last || last >= len(bags) {
+			return global, nil, fmt.Errorf("%w: bad zone index", errFormat)
+		}
+		for b := first; b < last; b++ {
+			from, to := u16(bags[b], 0), u16(bags[b+1], 0)
+			if from > to || to >= len(gen) {
+				return global, nil, fmt.Errorf("%w: bad generator index", errFormat)
+			}
+			var g gens
+			link := -1
+			for _, rec := range gen[from:to] {
+				op := u16(rec, 0)
+				if op == linkOp {
+					link = u16(rec, 2)
+				} else if op < genCount {
+					g.amount[op], g.set[op] = int16(u16(rec, 2)), true
+				}
+			}
+			switch {
+			case link >= 0:
+				out = append(out, zone{g, link})
+			case b == first:
+				global = g
+			}
+		}
+		return global, out, nil
+	}
+
+	for i := 0; i+1 < len(shdr); i++ {
+		r := shdr[i]
+		s := sample{
+			start: u32(r, 20), end: u32(r, 24), loopStart: u32(r, 28), loopEnd: u32(r, 32),
+			rate: u32(r, 36), key: int(r[40]), correction: int(int8(r[41])),
+		}
+		if s.end > len(f.data) || s.start > s.end || s.rate == 0 {
+			return fmt.Errorf("%w: sample %q lies outside the sample data", errFormat, cString(r[:20]))
+		}
+		f.samples = append(f.samples, s)
+	}
+	for i := 0; i+1 < len(inst); i++ {
+		global, zs, err := zones(ibag, igen, u16(inst[i], 20), u16(inst[i+1], 20), genSampleID)
+		if err != nil {
+			return err
+		}
+		for _, z := range zs {
+			if z.link >= len(f.samples) {
+				return fmt.Errorf("%w: instrument %q plays a missing sample", errFormat, cString(inst[i][:20]))
+			}
+		}
+		f.insts = append(f.insts, instrument{cString(inst[i][:20]), global, zs})
+	}
+	for i := 0; i+1 < len(phdr); i++ {
+		r := phdr[i]
+		global, zs, err := zones(pbag, pgen, u16(r, 24), u16(phdr[i+1], 24), genInstrument)
+		if err != nil {
+			return err
+		}
+		for _, z := range zs {
+			if z.link >= len(f.insts) {
+				return fmt.Errorf("%w: preset %q plays a missing instrument", errFormat, cString(r[:20]))
+			}
+		}
+		f.presets = append(f.presets, preset{cString(r[:20]), u16(r, 22), u16(r, 20), global, zs})
+	}
+	if len(f.presets) == 0 {
+		return fmt.Errorf("%w: no presets", errFormat)
+	}
+	return nil
+}
+
+// cString returns the text of a nul-padded name
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// find returns the preset at bank and program, 0-based, or failing that the
+// same program in bank 0, or the font's first preset
+func (f *SoundFont) find(bank, program int) *preset {
+	for _, b := range []int{bank, 0} {
+		for i := range f.presets {
+			if p := &f.presets[i]; p.bank == b && p.program == program {
+				return p
+			}
+		}
+	}
+	return &f.presets[0]
+}
+
+// voice is how one sample sounds a note: the zone generators of preset and
+// instrument resolved into the values the renderer needs
+type voice struct {
+	sample
+	loop        bool    // the sample loops while the note is held
+	pitch       float64 // the key the note sounds at, in semitones, with tuning
+	root        float64 // the key the sample sounds at its own rate, with its correction
+	gain        float64 // linear gain from attenuation, before velocity
+	pan         float64 // -1 left to 1 right
+	delay, hold float64 // seconds of the volume envelope's stages
+	attack      float64
+	decay       float64
+	sustain     float64 // centibels of attenuation while the note is held; see envelope
+	release     float64
+}
+
+// voices returns the voices of preset p that play key at velocity, one for
+// each instrument zone in each preset zone that covers them
+func (f *SoundFont) voices(p *preset, key, velocity int) []voice {
+	var out []voice
+	for _, pz := range p.zones {
+		pg := p.global.over(pz.gens)
+		if !pg.covers(key, velocity) {
+			continue
+		}
+		in := &f.insts[pz.link]
+		for _, iz := range in.zones {
+			ig := in.global.over(iz.gens)
+			if !ig.covers(key, velocity) {
+				continue
+			}
+			out = append(out, f.voice(&pg, &ig, iz.link, key))
+		}
+	}
+	return out
+}
+
+// voice resolves a voice from preset generators pg, which add to the
+// instrument's, and instrument generators ig playing sample s
+func (f *SoundFont) voice(pg, ig *gens, s, key int) voice {
+	sum := func(op, def int) int { return ig.get(op, def) + pg.get(op, 0) }
+	v := voice{sample: f.samples[s]}
+	offset := func(fine, coarse int) int { return ig.get(fine, 0) + 32768*ig.get(coarse, 0) }
+	v.start += offset(genStartOffset, genStartCoarseOffset)
+	v.end += offset(genEndOffset, genEndCoarseOffset)
+	v.loopStart += offset(genLoopStartOffset, genLoopStartCoarse)
+	v.loopEnd += offset(genLoopEndOffset, genLoopEndCoarse)
+	v.start = min(max(v.start, 0), len(f.data))
+	v.end = min(max(v.end, v.start), len(f.data))
+	mode := ig.get(genSampleModes, 0)
+	v.loop = (mode == 1 || mode == 3) && v.loopStart >= v.start && v.loopEnd <= v.end && v.loopEnd > v.loopStart
+
+	root := ig.get(genRootKey, -1)
+	if root < 0 {
+		root = v.key
+	}
+	if k := ig.get(genKeynum, -1); k >= 0 {
+		key = k
+	}
+	scale := float64(sum(genScaleTuning, 100)) / 100
+	tune := float64(sum(genCoarseTune, 0)) + float64(sum(genFineTune, 0))/100
+	v.pitch = float64(root) + float64(key-root)*scale + tune
+	v.root = float64(root) - float64(v.correction)/100
+
+	v.gain = centibels(float64(max(sum(genAttenuation, 0), 0)))
+	v.pan = float64(min(max(sum(genPan, 0), -500), 500)) / 500
+	seconds := func(op int) float64 { return math.Pow(2, float64(sum(op, -12000))/1200) }
+	v.delay, v.attack, v.hold = seconds(genDelayVolEnv), seconds(genAttackVolEnv), seconds(genHoldVolEnv)
+	v.decay, v.release = seconds(genDecayVolEnv), seconds(genReleaseVolEnv)
+	v.sustain = float64(min(max(sum(genSustainVolEnv, 0), 0), silence))
+	return v
+}
+
+// silence is the attenuation, in centibels, at which a voice is inaudible
+// and the volume envelope's decay and release count as complete
+const silence = 960
+
+// centibels returns the linear gain of an attenuation in centibels
+func centibels(cb float64) float64 { return math.Pow(10, -cb/200) }
@@ -0,0 +1,147 @@
+package synth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// testFont builds a SoundFont with one preset playing one instrument, whose
+// sample is a looped sine wave of period frames at 44100 frames a second
+// sounding A4, with a tenth of a second's release
+func testFont(period int) []byte {
+	chunk := func(id string, body []byte) []byte {
+		b := binary.LittleEndian.AppendUint32([]byte(id), uint32(len(body)))
+		b = append(b, body...)
+		if len(body)%2 != 0 {
+			b = append(b, 0)
+		}
+		return b
+	}
+	list := func(kind string, subs ...[]byte) []byte {
+		return chunk("LIST", append([]byte(kind), bytes.Join(subs, nil)...))
+	}
+	name := func(s string, n int) []byte { return append([]byte(s), make([]byte, n-len(s))...) }
+	le16 := binary.LittleEndian.AppendUint16
+	le32 := binary.LittleEndian.AppendUint32
+
+	var smpl []byte
+	frames := 10 * period
+	for i := 0; i < frames+46; i++ {
+		s := 0.0
+		if i < frames {
+			s = math.Sin(2 * math.Pi * float64(i) / float64(period))
+		}
+		smpl = le16(smpl, uint16(int16(s*30000)))
+	}
+	gen := func(b []byte, op int, amount int16) []byte { return le16(le16(b, uint16(op)), uint16(amount)) }
+	phdr := le32(le32(le32(le16(le16(le16(name("Sine", 20), 0), 0), 0), 0), 0), 0)
+	phdr = le32(le32(le32(le16(le16(le16(append(phdr, name("EOP", 20)...), 0), 0), 1), 0), 0), 0)
+	pbag := le16(le16(le16(le16(nil, 0), 0), 1), 0)
+	pgen := gen(gen(nil, genInstrument, 0), 0, 0)
+	inst := le16(append(le16(name("Sine", 20), 0), name("EOI", 20)...), 1)
+	ibag := le16(le16(le16(le16(nil, 0), 0), 3), 0)
+	igen := gen(gen(gen(gen(nil, genSampleModes, 1), genReleaseVolEnv, -3986), genSampleID, 0), 0, 0)
+	shdr := le32(le32(le32(le32(le32(name("sine", 20), 0), uint32(frames)), 0), uint32(frames)), SampleRate)
+	shdr = le16(le16(append(shdr, 69, 0), 0), 1)
+	shdr = append(shdr, make([]byte, 46)...)
+
+	body := list("INFO", chunk("ifil", le16(le16(nil, 2), 1)), chunk("INAM", []byte("Test\x00")))
+	body = append(body, list("sdta", chunk("smpl", smpl))...)
+	body = append(body, list("pdta", chunk("phdr", phdr), chunk("pbag", pbag), chunk("pmod", make([]byte, 10)),
+		chunk("pgen", pgen), chunk("inst", inst), chunk("ibag", ibag), chunk("imod", make([]byte, 10)),
+		chunk("igen", igen), chunk("shdr", shdr))...)
+	return chunk("RIFF", append([]byte("sfbk"), body...))
+}
+
+func TestParse(t *testing.T) {
+	f, err := Parse(testFont(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "Test" || len(f.presets) != 1 || len(f.insts) != 1 || len(f.samples) != 1 {
+		t.Fatalf("parsed %q with %d presets, %d instruments and %d samples", f.Name, len(f.presets), len(f.insts), len(f.samples))
+	}
+	vs := f.voices(f.find(drumBank, 5), 81, 100)
+	if len(vs) != 1 || !vs[0].loop || vs[0].pitch != 81 || vs[0].root != 69 {
+		t.Fatalf("voices = %+v, want one looped voice an octave above the sample", vs)
+	}
+	if r := vs[0].release; math.Abs(r-0.1) > 0.001 {
+		t.Errorf("release %gs, want 0.1s", r)
+	}
+	if _, err := Parse([]byte("RIFF\x04\x00\x00\x00WAVE")); err == nil {
+		t.Error("parsed a WAV file as a SoundFont")
+	}
+}
+
+func TestRender(t *testing.T) {
+	f, err := Parse(testFont(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A5 for a quarter note, half a second at 120 bpm, then held a quarter
+	// note more by the pedal
+	track := music.Track{Channel: 1, Notes: []music.NoteEvent{
+		{Pitch: 81, Velocity: 127, Start: 0, Duration: music.TicksPerQuarter, Channel: 1},
+	}, Controls: []music.Control{
+		{Controller: music.SustainPedal, Value: 127, Tick: 0, Channel: 1},
+		{Controller: music.SustainPedal, Value: 0, Tick: 2 * music.TicksPerQuarter, Channel: 1},
+	}}
+	var buf bytes.Buffer
+	if err := Render(&buf, f, music.NewClock(), []music.Track{track}); err != nil {
+		t.Fatal(err)
+	}
+	wav := buf.Bytes()
+	if string(wav[:4]) != "RIFF" || string(wav[8:16]) != "WAVEfmt " || binary.LittleEndian.Uint32(wav[24:]) != SampleRate {
+		t.Fatalf("bad WAV header % x", wav[:44])
+	}
+	left := func(frame int) int16 { return int16(binary.LittleEndian.Uint16(wav[44+4*frame:])) }
+	frames := (len(wav) - 44) / 4
+
+	// The sample's 441 Hz an octave up crosses zero 882 times a second
+	crossings := 0
+	for i := 1; i < SampleRate/2; i++ {
+		if (left(i-1) < 0) != (left(i) < 0) {
+			crossings++
+		}
+	}
+	if crossings < 875 || crossings > 890 {
+		t.Errorf("%d zero crossings in the first half second, want 882 for A5", crossings)
+	}
+	loudest := func(from, to int) int16 {
+		peak := int16(0)
+		for i := from; i < min(to, frames); i++ {
+			peak = max(peak, left(i))
+		}
+		return peak
+	}
+	// Still sounding at full level until the pedal comes up at one second,
+	// then silent by the end of its release
+	if held, full := loudest(SampleRate*9/10, SampleRate), loudest(0, SampleRate/10); held < full*9/10 {
+		t.Errorf("peak %d while the pedal holds the note, want near %d", held, full)
+	}
+	if frames < SampleRate*105/100 || frames > SampleRate*125/100 {
+		t.Errorf("%d frames, want the second held and about a tenth of release", frames)
+	}
+}
+
+func TestPedalSpans(t *testing.T) {
+	spans := pedalSpans([]music.Control{
+		{Controller: music.SustainPedal, Value: 0, Tick: 0},
+		{Controller: music.SustainPedal, Value: 100, Tick: 10},
+		{Controller: 7, Value: 0, Tick: 15},
+		{Controller: music.SustainPedal, Value: 127, Tick: 20},
+		{Controller: music.SustainPedal, Value: 10, Tick: 30},
+	})
+	if len(spans) != 1 || spans[0] != [2]int64{10, 30} {
+		t.Fatalf("spans = %v, want [[10 30]]", spans)
+	}
+	for end, want := range map[int64]int64{5: 5, 10: 30, 29: 30, 30: 30} {
+		if got := sustained(end, spans); got != want {
+			t.Errorf("sustained(%d) = %d, want %d", end, got, want)
+		}
+	}
+}
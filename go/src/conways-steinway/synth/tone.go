@@ -0,0 +1,275 @@
+package synth
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// Waveform is the shape of the wave a Synth's voices play
+type Waveform int
+
+const (
+	Sine     Waveform = iota // pure and soft
+	Triangle                 // brighter, with odd harmonics
+)
+
+var waveformNames = [...]string{
+	Sine:     "sine",
+	Triangle: "triangle",
+}
+
+func (w Waveform) String() string {
+	if w < 0 || int(w) >= len(waveformNames) {
+		return fmt.Sprintf("Waveform(%d)", int(w))
+	}
+	return waveformNames[w]
+}
+
+// ParseWaveform converts a name such as "triangle" into a Waveform
+func ParseWaveform(s string) (Waveform, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for w, n := range waveformNames {
+		if n == name {
+			return Waveform(w), nil
+		}
+	}
+	return Sine, fmt.Errorf("invalid waveform %q (want %s)", s, strings.Join(waveformNames[:], ", "))
+}
+
+// Set implements flag.Value
+func (w *Waveform) Set(s string) error {
+	p, err := ParseWaveform(s)
+	if err != nil {
+		return err
+	}
+	*w = p
+	return nil
+}
+
+// at returns the wave's level at phase, from 0 to 1 through one cycle
+func (w Waveform) at(phase float64) float64 {
+	if w == Triangle {
+		return 1 - 4*math.Abs(phase-0.5)
+	}
+	return math.Sin(2 * math.Pi * phase)
+}
+
+// ADSR is a linear envelope: the level rises to full over Attack, falls to
+// Sustain, from 0 to 1, over Decay while the note is held, and to silence
+// over Release once it is let go
+type ADSR struct {
+	Attack, Decay time.Duration
+	Sustain       float64
+	Release       time.Duration
+}
+
+// NewADSR returns a piano-like envelope: a quick strike fading to a softer
+// held tone and a short release
+func NewADSR() ADSR {
+	return ADSR{Attack: 5 * time.Millisecond, Decay: 400 * time.Millisecond, Sustain: 0.4, Release: 300 * time.Millisecond}
+}
+
+// MaxVoices is how many notes a Synth sounds at once; a note beyond it
+// takes the place of the one struck longest ago
+const MaxVoices = 96
+
+// toneGain is each voice's level at full velocity, leaving headroom for
+// chords before the mix is limited
+const toneGain = 0.12
+
+// Synth is a polyphonic synthesizer that plays the notes of a performance
+// as they are published, on every channel but DrumChannel, whose notes it
+// leaves out. It is read as a stream of 16-bit stereo frames at Rate, which
+// an audio device plays. With KeepTime each note starts and stops on the
+// frame its tick falls on; otherwise it does on the next frame read.
+type Synth struct {
+	Rate     int
+	Wave     Waveform
+	Envelope ADSR
+	Tuning   *music.Tuning // tuning the notes are played in; nil is equal temperament
+
+	mu     sync.Mutex
+	frame  int64 // frames read so far
+	voices []*tone
+	pedals map[int]bool
+	clock  *music.Clock
+	origin int64 // the frame tick 0 falls on
+	queue  []due // events waiting for their frame, soonest first
+}
+
+// due is an event handled when the stream reaches its frame
+type due struct {
+	frame int64
+	event events.Event
+}
+
+// tone is one sounding note
+type tone struct {
+	channel, pitch int
+	step           float64 // phase advance a frame
+	phase          float64
+	gain           float64
+	level          float64 // the envelope's level
+	attacking      bool
+	released       bool
+	releasing      float64 // level lost a frame since the release
+	held           bool    // let go while the pedal was down
+}
+
+// NewSynth returns a synth at SampleRate playing sine waves with NewADSR
+func NewSynth() *Synth {
+	return &Synth{Rate: SampleRate, Wave: Sine, Envelope: NewADSR()}
+}
+
+// KeepTime makes the synth start and stop each note on the frame its tick
+// on clock falls on, counting from start, taken to be the next frame read if
+// it is now. Called again it re-times the notes handled from then on.
+func (s *Synth) KeepTime(clock music.Clock, start time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = &clock
+	s.origin = s.frame + int64(math.Round(time.Until(start).Seconds()*float64(s.Rate)))
+}
+
+// Handle plays NoteOn and NoteOff events and sustain pedal Control events
+func (s *Synth) Handle(e events.Event) {
+	var tick int64
+	switch e := e.(type) {
+	case events.NoteOn:
+		tick = e.Note.Start
+	case events.NoteOff:
+		tick = e.Note.End()
+	case events.Control:
+		tick = e.Control.Tick
+	default:
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	frame := s.frame
+	if s.clock != nil {
+		frame = s.origin + int64(math.Round(s.clock.Time(tick).Seconds()*float64(s.Rate)))
+	}
+	i := sort.Search(len(s.queue), func(i int) bool { return s.queue[i].frame > frame })
+	s.queue = slices.Insert(s.queue, i, due{frame, e})
+}
+
+// Idle reports whether no notes are sounding or waiting to be played
+func (s *Synth) Idle() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.voices) == 0 && len(s.queue) == 0
+}
+
+// Read fills p with as many whole frames as fit, each a pair of 16-bit
+// little-endian samples, left then right. It never runs dry: with nothing
+// sounding it reads silence.
+func (s *Synth) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(p) / 4
+	for i := 0; i < n; i++ {
+		for len(s.queue) > 0 && s.queue[0].frame <= s.frame {
+			s.play(s.queue[0].event)
+			s.queue = s.queue[1:]
+		}
+		v := pcm16(float32(math.Tanh(s.next())))
+		binary.LittleEndian.PutUint16(p[4*i:], uint16(v))
+		binary.LittleEndian.PutUint16(p[4*i+2:], uint16(v))
+		s.frame++
+	}
+	return 4 * n, nil
+}
+
+// play acts on an event that has fallen due
+func (s *Synth) play(e events.Event) {
+	switch e := e.(type) {
+	case events.NoteOn:
+		n := e.Note
+		if n.Channel == music.DrumChannel {
+			return
+		}
+		if len(s.voices) >= MaxVoices {
+			s.voices = s.voices[1:]
+		}
+		hz := 440 * math.Pow(2, float64(n.Pitch-69)/12)
+		if s.Tuning != nil {
+			hz = 440 * math.Pow(2, (s.Tuning.Pitch(n.Pitch)-69)/12)
+		}
+		v := float64(n.Velocity) / 127
+		s.voices = append(s.voices, &tone{channel: n.Channel, pitch: n.Pitch, step: hz / float64(s.Rate), gain: v * v * toneGain, attacking: true})
+	case events.NoteOff:
+		for _, t := range s.voices {
+			if t.channel == e.Note.Channel && t.pitch == e.Note.Pitch && !t.released && !t.held {
+				if s.pedals[t.channel] {
+					t.held = true
+				} else {
+					s.release(t)
+				}
+				break
+			}
+		}
+	case events.Control:
+		c := e.Control
+		if c.Controller != music.SustainPedal {
+			return
+		}
+		if s.pedals == nil {
+			s.pedals = make(map[int]bool)
+		}
+		s.pedals[c.Channel] = c.Value >= 64
+		if !s.pedals[c.Channel] {
+			for _, t := range s.voices {
+				if t.channel == c.Channel && t.held {
+					s.release(t)
+				}
+			}
+		}
+	}
+}
+
+// release lets a tone go, to fade from its level over the release time
+func (s *Synth) release(t *tone) {
+	t.released, t.held = true, false
+	t.releasing = t.level / max(s.Envelope.Release.Seconds()*float64(s.Rate), 1)
+}
+
+// next returns the mix of the voices' next frame, advancing their
+// envelopes and dropping those that have faded out
+func (s *Synth) next() float64 {
+	env := s.Envelope
+	attack := 1 / max(env.Attack.Seconds()*float64(s.Rate), 1)
+	decay := (1 - env.Sustain) / max(env.Decay.Seconds()*float64(s.Rate), 1)
+	mix := 0.0
+	kept := s.voices[:0]
+	for _, t := range s.voices {
+		mix += s.Wave.at(t.phase) * t.gain * t.level
+		if t.phase += t.step; t.phase >= 1 {
+			t.phase--
+		}
+		switch {
+		case t.released:
+			t.level -= t.releasing
+		case t.attacking:
+			t.level = min(t.level+attack, 1)
+			t.attacking = t.level < 1
+		default:
+			t.level = max(t.level-decay, env.Sustain)
+		}
+		if t.level > 0 || !t.released {
+			kept = append(kept, t)
+		}
+	}
+	clear(s.voices[len(kept):])
+	s.voices = kept
+	return mix
+}
@@ -0,0 +1,114 @@
+package synth
+
+import (
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// read returns the left samples of the next frames the synth plays
+func read(s *Synth, frames int) []int16 {
+	p := make([]byte, 4*frames)
+	s.Read(p)
+	out := make([]int16, frames)
+	for i := range out {
+		out[i] = int16(binary.LittleEndian.Uint16(p[4*i:]))
+	}
+	return out
+}
+
+func peak(samples []int16) int16 {
+	p := int16(0)
+	for _, s := range samples {
+		p = max(p, s, -s)
+	}
+	return p
+}
+
+func TestSynthKeepsTime(t *testing.T) {
+	s := NewSynth()
+	read(s, 100) // frames before KeepTime do not count
+	s.KeepTime(music.NewClock(), time.Now())
+	// A4 from half a second for half a second, at 120 bpm
+	a4 := music.NoteEvent{Pitch: 69, Velocity: 127, Start: music.TicksPerQuarter, Duration: music.TicksPerQuarter, Channel: 1}
+	s.Handle(events.NoteOn{Note: a4})
+	s.Handle(events.NoteOff{Note: a4})
+	s.Handle(events.NoteOn{Note: music.NoteEvent{Pitch: 36, Velocity: 127, Start: 0, Duration: 10, Channel: music.DrumChannel}})
+
+	if p := peak(read(s, SampleRate/2)); p != 0 {
+		t.Errorf("peak %d before the note, want silence (and no drums)", p)
+	}
+	sounding := read(s, SampleRate/2)
+	crossings := 0
+	for i := 1; i < len(sounding); i++ {
+		if (sounding[i-1] < 0) != (sounding[i] < 0) {
+			crossings++
+		}
+	}
+	if crossings < 435 || crossings > 445 {
+		t.Errorf("%d zero crossings in half a second, want 440 for A4", crossings)
+	}
+	release := read(s, SampleRate*3/10)
+	if peak(release[:SampleRate/100]) == 0 || peak(release[len(release)-SampleRate/100:]) > peak(release[:SampleRate/100])/10 {
+		t.Error("note does not fade out over the release")
+	}
+	read(s, 10)
+	if !s.Idle() {
+		t.Error("synth not idle once the note has faded out")
+	}
+}
+
+func TestSynthPedal(t *testing.T) {
+	s := NewSynth()
+	c4 := music.NoteEvent{Pitch: 60, Velocity: 100, Channel: 1}
+	s.Handle(events.Control{Control: music.Control{Controller: music.SustainPedal, Value: 127, Channel: 1}})
+	s.Handle(events.NoteOn{Note: c4})
+	s.Handle(events.NoteOff{Note: c4})
+	read(s, SampleRate)
+	if p := peak(read(s, SampleRate/10)); p == 0 {
+		t.Fatal("note let go while the pedal is down is silent")
+	}
+	s.Handle(events.Control{Control: music.Control{Controller: music.SustainPedal, Value: 0, Channel: 1}})
+	read(s, SampleRate*3/10+10)
+	if !s.Idle() {
+		t.Error("note still sounding after the pedal came up and its release passed")
+	}
+}
+
+func TestParseWaveform(t *testing.T) {
+	if w, err := ParseWaveform(" Triangle "); err != nil || w != Triangle {
+		t.Errorf("ParseWaveform(triangle) = %v, %v", w, err)
+	}
+	if _, err := ParseWaveform("square"); err == nil {
+		t.Error("ParseWaveform(square) succeeded")
+	}
+}
+
+// device is a sound output that records the rate it was opened at
+type device struct{ rate int }
+
+func (d *device) Close() error { return nil }
+
+func TestOpenAudio(t *testing.T) {
+	defer func(open func(int, io.Reader) (io.Closer, error)) { openDevice = open }(openDevice)
+	d := &device{}
+	openDevice = func(rate int, r io.Reader) (io.Closer, error) {
+		d.rate = rate
+		return d, nil
+	}
+	s := NewSynth()
+	a, err := OpenAudio(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.rate != s.Rate {
+		t.Errorf("opened the sound output at %d Hz, want %d", d.rate, s.Rate)
+	}
+	if err := a.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,41 @@
+package synth
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// WriteWAV writes interleaved stereo frames, from -1 to 1, as a 16-bit PCM
+// WAV file at rate frames a second
+func WriteWAV(w io.Writer, rate int, frames []float32) error {
+	const channels, bytesPerSample = 2, 2
+	size := len(frames) / channels * channels * bytesPerSample
+	header := []byte("RIFF")
+	header = binary.LittleEndian.AppendUint32(header, uint32(36+size))
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16)
+	header = binary.LittleEndian.AppendUint16(header, 1) // PCM
+	header = binary.LittleEndian.AppendUint16(header, channels)
+	header = binary.LittleEndian.AppendUint32(header, uint32(rate))
+	header = binary.LittleEndian.AppendUint32(header, uint32(rate*channels*bytesPerSample))
+	header = binary.LittleEndian.AppendUint16(header, channels*bytesPerSample)
+	header = binary.LittleEndian.AppendUint16(header, 8*bytesPerSample)
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(size))
+
+	bw := bufio.NewWriter(w)
+	bw.Write(header)
+	var b [2]byte
+	for _, s := range frames[:size/bytesPerSample] {
+		binary.LittleEndian.PutUint16(b[:], uint16(pcm16(s)))
+		bw.Write(b[:])
+	}
+	return bw.Flush()
+}
+
+// pcm16 converts a sample from -1 to 1 into a 16-bit one, clipping it
+func pcm16(s float32) int16 {
+	return int16(math.Round(float64(min(max(s, -1), 1)) * 32767))
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/live"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// transport starts and stops the performance with the transport of a DAW or
+// sequencer sending to the MIDI input, with --midi-transport
+type transport struct {
+	input   *live.Input
+	ports   []*live.Output // paused while the performance is stopped
+	outputs []timekeeper   // the other live outputs, re-timed when it goes on
+}
+
+// hold waits while the input's transport is stopped, pausing the MIDI ports
+// meanwhile, and returns start put off by the time it waited, re-timing the
+// other outputs on clock to count from it
+func (t *transport) hold(clock music.Clock, start time.Time) time.Time {
+	if t.input.Rolling() {
+		return start
+	}
+	stopped := time.Now()
+	for _, p := range t.ports {
+		p.Pause()
+	}
+	fmt.Fprintf(status, "Stopped by MIDI port %s\n", t.input.Name())
+	<-t.input.Rolled()
+	for _, p := range t.ports {
+		p.Resume()
+	}
+	start = start.Add(time.Since(stopped))
+	for _, o := range t.outputs {
+		o.KeepTime(clock, start)
+	}
+	return start
+}
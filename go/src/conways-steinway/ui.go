@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/events"
+	"github.com/Jeff-Lowrey/conways-steinway/go/conways-steinway/music"
+)
+
+// graphWidth is the number of recent generations shown in the population graph
+const graphWidth = 32
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// terminal prints each board followed by a status line, with a population
+// graph, for each generation
+type terminal struct {
+	w       io.Writer
+	layered bool          // label each board with its layer
+	parts   bool          // label each row's notes with their channel
+	history map[int][]int // recent populations of each layer
+}
+
+func (t *terminal) handle(e events.Event) {
+	switch e := e.(type) {
+	case events.Board:
+		if t.layered {
+			fmt.Fprintf(t.w, "Layer %d (channel %d)\n", e.Layer+1, e.Channel)
+		}
+		e.Board.Print(t.w)
+	case events.Notes:
+		if len(e.Keys) > 0 && e.Channel == music.DrumChannel && t.parts {
+			names := make([]string, len(e.Keys))
+			for i, k := range e.Keys {
+				names[i] = music.Drum(k.Note()).String()
+			}
+			fmt.Fprintf(t.w, "Drums %s\n", strings.Join(names, ", "))
+		} else if len(e.Keys) > 0 {
+			names := make([]string, len(e.Keys))
+			for i, k := range e.Keys {
+				names[i] = k.String()
+			}
+			if t.parts {
+				fmt.Fprintf(t.w, "Channel %d notes %s\n", e.Channel, strings.Join(names, " "))
+			} else {
+				fmt.Fprintf(t.w, "Notes %s\n", strings.Join(names, " "))
+			}
+		}
+	case events.Chord:
+		fmt.Fprintf(t.w, "Chord %v\n", e.Chord)
+	case events.Pedal:
+		if e.Down {
+			fmt.Fprintf(t.w, "%sPedal down\n", t.label(e.Layer))
+		} else {
+			fmt.Fprintf(t.w, "%sPedal up\n", t.label(e.Layer))
+		}
+	case events.Generation:
+		if t.history == nil {
+			t.history = make(map[int][]int)
+		}
+		history := append(t.history[e.Layer], e.Stats.Population)
+		if len(history) > graphWidth {
+			history = history[1:]
+		}
+		t.history[e.Layer] = history
+		fmt.Fprintf(t.w, "Generation %d  population %d (+%d -%d, %.1f%%)  %s\n",
+			e.Generation, e.Stats.Population, e.Stats.Births, e.Stats.Deaths, e.Stats.Density*100, graph(history))
+	case events.Cycle:
+		fmt.Fprintf(t.w, "%sBoard entered a cycle of period %d\n", t.label(e.Layer), e.Period)
+	case events.Reseed:
+		fmt.Fprintf(t.w, "%sReseeding: %s\n", t.label(e.Layer), e.Reason)
+	case events.Rewind:
+		fmt.Fprintf(t.w, "%sRewound %d generations to generation %d\n", t.label(e.Layer), e.Back, e.Generation)
+	case events.Section:
+		fmt.Fprintf(t.w, "%sProgram %d on channel %d: %s\n", t.label(e.Layer), e.Program, e.Channel, e.Reason)
+	case events.Phrase:
+		fmt.Fprintf(t.w, "%sPhrase ended: %s\n", t.label(e.Layer), e.Reason)
+	case events.Loop:
+		fmt.Fprintf(t.w, "Loop %s, now %d notes\n", e.Action, e.Notes)
+	case events.RuleChange:
+		fmt.Fprintf(t.w, "%sRule changed from %v to %v\n", t.label(e.Layer), e.From, e.To)
+	case events.KeyChange:
+		fmt.Fprintf(t.w, "%sKey changed from %v to %v\n", t.label(e.Layer), e.From, e.To)
+	}
+}
+
+// label prefixes a message about one layer when there are several
+func (t *terminal) label(layer int) string {
+	if !t.layered {
+		return ""
+	}
+	return fmt.Sprintf("Layer %d: ", layer+1)
+}
+
+// graph renders the recent populations as a sparkline
+func graph(history []int) string {
+	peak := 0
+	for _, n := range history {
+		peak = max(peak, n)
+	}
+	var b strings.Builder
+	for _, n := range history {
+		i := 0
+		if peak > 0 {
+			i = n * (len(sparks) - 1) / peak
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
@@ -1,3 +1,14 @@
 module github.com/Jeff-Lowrey/conways-steinway/go
 
-go 1.24.2
+go 1.25.0
+
+require (
+	github.com/ebitengine/oto/v3 v3.5.1
+	gitlab.com/gomidi/midi/v2 v2.3.24
+)
+
+require (
+	github.com/ebitengine/purego v0.11.0 // indirect
+	github.com/jfreymuth/pulse v0.1.3 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+)